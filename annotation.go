@@ -0,0 +1,100 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Bounds of annotations per experiment and of their text.
+const (
+	maxAnnotations    = 1000
+	maxAnnotationText = 1024
+)
+
+// Annotation is a note on an experiment or on one of its variations, such as
+// "variation 2 deploy fixed a bug", which explains discontinuities in its
+// metrics. Tag is blank for notes on the whole experiment.
+type Annotation struct {
+	Time time.Time `json:"time"`
+	Tag  string    `json:"tag,omitempty"`
+	Text string    `json:"text"`
+}
+
+// Annotate adds a note at time `at` to the variation tagged `tag`, or to the
+// whole experiment if the tag is blank. Annotations are saved with the state
+// of the experiment, see SaveExperiments.
+func (e *Experiment) Annotate(tag, text string, at time.Time) error {
+	if e.annotations == nil {
+		return fmt.Errorf("%s cannot be annotated", e.Name)
+	}
+
+	if tag != "" {
+		if _, err := e.GetTaggedVariation(tag); err != nil {
+			return err
+		}
+	}
+
+	if text == "" || len(text) > maxAnnotationText {
+		return fmt.Errorf("annotation must have 1 to %d bytes", maxAnnotationText)
+	}
+
+	e.annotations.add(Annotation{Time: at, Tag: tag, Text: text})
+	return nil
+}
+
+// Annotations returns the notes on the experiment and its variations, oldest
+// first.
+func (e *Experiment) Annotations() []Annotation {
+	return e.annotations.list()
+}
+
+// annotations is a bounded, time ordered list of annotations, safe for
+// concurrent use.
+type annotations struct {
+	sync.Mutex
+	notes []Annotation
+}
+
+// add inserts `a` in time order, dropping the oldest note if full.
+func (n *annotations) add(a Annotation) {
+	n.Lock()
+	defer n.Unlock()
+
+	i := sort.Search(len(n.notes), func(i int) bool { return n.notes[i].Time.After(a.Time) })
+	n.notes = append(n.notes, Annotation{})
+	copy(n.notes[i+1:], n.notes[i:])
+	n.notes[i] = a
+
+	if len(n.notes) > maxAnnotations {
+		n.notes = n.notes[len(n.notes)-maxAnnotations:]
+	}
+}
+
+// list copies the notes. It is empty for the nil list.
+func (n *annotations) list() []Annotation {
+	if n == nil {
+		return []Annotation{}
+	}
+
+	n.Lock()
+	defer n.Unlock()
+
+	return append([]Annotation{}, n.notes...)
+}
+
+// restore adds stored notes. Notes on variations which no longer exist are
+// kept, since they still explain past metrics.
+func (n *annotations) restore(stored []Annotation) {
+	if n == nil {
+		return
+	}
+
+	for _, a := range stored {
+		n.add(a)
+	}
+}
@@ -0,0 +1,68 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnnotate(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	now := time.Now()
+	if err := e.Annotate("shape-20130822:2", "deploy fixed rendering bug", now); err != nil {
+		t.Fatalf("while annotating: %s", err.Error())
+	}
+
+	if err := e.Annotate("", "traffic spike from campaign", now.Add(-time.Hour)); err != nil {
+		t.Fatalf("while annotating: %s", err.Error())
+	}
+
+	got := e.Annotations()
+	if len(got) != 2 || got[0].Tag != "" || got[1].Tag != "shape-20130822:2" {
+		t.Fatalf("expected annotations in time order, got %v", got)
+	}
+
+	if err := e.Annotate("shape-20130822:9", "unknown", now); err == nil {
+		t.Fatalf("expected unknown tag to fail")
+	}
+
+	if err := e.Annotate("", "", now); err == nil {
+		t.Fatalf("expected empty text to fail")
+	}
+
+	// annotations are saved and restored with the state
+	store := mapStore{}
+	if err := SaveExperiments(store, es); err != nil {
+		t.Fatalf("while saving: %s", err.Error())
+	}
+
+	restored, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	if _, err := RestoreExperiments(store, restored, RestoreFail); err != nil {
+		t.Fatalf("while restoring: %s", err.Error())
+	}
+
+	if got := restored.index["shape-20130822"].Annotations(); len(got) != 2 || got[1].Text != "deploy fixed rendering bug" {
+		t.Fatalf("expected restored annotations, got %v", got)
+	}
+}
+
+func TestAnnotationsBounded(t *testing.T) {
+	n := &annotations{}
+	start := time.Now()
+	for i := 0; i < maxAnnotations+10; i++ {
+		n.add(Annotation{Time: start.Add(time.Duration(i) * time.Second), Text: "note"})
+	}
+
+	got := n.list()
+	if len(got) != maxAnnotations || !got[0].Time.Equal(start.Add(10*time.Second)) {
+		t.Fatalf("expected the oldest annotations to be dropped")
+	}
+}
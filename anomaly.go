@@ -0,0 +1,142 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// AnomalyConfig parameterizes the EWMA control chart kept for each arm.
+type AnomalyConfig struct {
+	Lambda     float64 // smoothing factor of the moving average, in (0, 1]
+	Limit      float64 // control limit in standard deviations, e.g. 3
+	Warmup     int     // rewards per arm observed before anything is flagged
+	Quarantine bool    // drop anomalous rewards instead of only flagging them
+}
+
+// NewAnomalyDetector wraps a strategy with an online anomaly detector on the
+// reward stream of each arm. Rewards outside of the control limits of the
+// arm's EWMA chart are reported to `n`; if configured, they are quarantined and
+// never reach the wrapped strategy.
+func NewAnomalyDetector(s Strategy, name string, arms int, c AnomalyConfig, n Notifier) (Strategy, error) {
+	if !(c.Lambda > 0 && c.Lambda <= 1) {
+		return &anomalyDetector{}, fmt.Errorf("λ not in (0, 1]")
+	}
+
+	if !(c.Limit > 0) {
+		return &anomalyDetector{}, fmt.Errorf("control limit not in (0, ∞)")
+	}
+
+	return &anomalyDetector{
+		wrapper:  wrapper{strategy: s},
+		name:     name,
+		config:   c,
+		notifier: n,
+		charts:   make([]ewmaChart, arms),
+	}, nil
+}
+
+// anomalyDetector keeps an exponentially weighted moving average and variance
+// per arm. A reward is anomalous if it deviates from the average by more than
+// `Limit` standard deviations.
+type anomalyDetector struct {
+	wrapper
+	name     string
+	config   AnomalyConfig
+	notifier Notifier
+	charts   []ewmaChart
+}
+
+// ewmaChart is the control chart of a single arm.
+type ewmaChart struct {
+	n        int     // observed rewards
+	mean     float64 // exponentially weighted mean
+	variance float64 // exponentially weighted variance
+	burst    int     // number of consecutive anomalous rewards
+}
+
+// observe adds reward x to the chart and returns true if it was anomalous.
+// Anomalous rewards are kept out of the chart. A burst longer than the warmup
+// restarts the chart, accepting a sustained shift as the new normal.
+func (c *ewmaChart) observe(x float64, cfg AnomalyConfig) bool {
+	diff := x - c.mean
+	if c.n > cfg.Warmup && math.Abs(diff) > cfg.Limit*math.Sqrt(c.variance) {
+		c.burst++
+		if c.burst > cfg.Warmup {
+			*c = ewmaChart{}
+		}
+
+		return true
+	}
+
+	c.burst = 0
+	c.n++
+	if c.n == 1 {
+		c.mean = x
+		return false
+	}
+
+	incr := cfg.Lambda * diff
+	c.mean = c.mean + incr
+	c.variance = (1 - cfg.Lambda) * (c.variance + diff*incr)
+
+	return false
+}
+
+// Update checks the reward against the arm's control chart before passing it
+// on. The first reward of an anomalous burst emits an EventAnomaly.
+func (a *anomalyDetector) Update(arm int, reward float64) {
+	if a.check(arm, reward) && a.config.Quarantine {
+		return
+	}
+
+	a.strategy.Update(arm, reward)
+}
+
+// UpdateBatch checks each reward like Update and passes the rewards which are
+// not quarantined on at once.
+func (a *anomalyDetector) UpdateBatch(arm int, rewards []float64) {
+	accepted := make([]float64, 0, len(rewards))
+	for _, reward := range rewards {
+		if !a.check(arm, reward) || !a.config.Quarantine {
+			accepted = append(accepted, reward)
+		}
+	}
+
+	UpdateBatch(a.strategy, arm, accepted)
+}
+
+// check observes the reward on the arm's control chart and returns true if it
+// was anomalous.
+func (a *anomalyDetector) check(arm int, reward float64) bool {
+	a.Lock()
+	chart := &a.charts[arm-1]
+	anomalous := chart.observe(reward, a.config)
+	starting := anomalous && chart.burst == 1
+	a.Unlock()
+
+	if starting && a.notifier != nil {
+		a.notifier.Notify(Event{
+			Time:       time.Now(),
+			Kind:       EventAnomaly,
+			Experiment: a.name,
+			Arm:        arm,
+			Message:    fmt.Sprintf("reward %f outside of control limits", reward),
+		})
+	}
+
+	return anomalous
+}
+
+// Reset resets the wrapped strategy and all control charts.
+func (a *anomalyDetector) Reset() {
+	a.ResetWithArchive()
+}
+
+// String returns information on this strategy.
+func (a *anomalyDetector) String() string {
+	return fmt.Sprintf("AnomalyDetector(%s)", a.strategy)
+}
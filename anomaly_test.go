@@ -0,0 +1,60 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	inner, err := NewEpsilonGreedy(2, 0.1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	counters := &inner.(*epsilonGreedy).Counters
+
+	var events []Event
+	notifier := NotifierFunc(func(e Event) { events = append(events, e) })
+	config := AnomalyConfig{Lambda: 0.2, Limit: 3, Warmup: 10, Quarantine: true}
+	d, err := NewAnomalyDetector(inner, "shape-20130822", 2, config, notifier)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for i := 0; i < 50; i++ {
+		counters.counts[0]++
+		d.Update(1, 0.5+0.01*float64(i%3))
+	}
+
+	if got := len(events); got != 0 {
+		t.Fatalf("expected no anomalies on steady rewards, got %d", got)
+	}
+
+	before := counters.values[0]
+	for i := 0; i < 3; i++ {
+		counters.counts[0]++
+		d.Update(1, 100.0)
+	}
+
+	if got := len(events); got != 1 {
+		t.Fatalf("expected a single event for the burst, got %d", got)
+	}
+
+	if got := events[0].Arm; got != 1 {
+		t.Fatalf("expected anomaly on arm 1, got %d", got)
+	}
+
+	if got := counters.values[0]; got != before {
+		t.Fatalf("expected quarantined rewards to be dropped, value moved to %f", got)
+	}
+}
+
+func TestAnomalyDetectorParameters(t *testing.T) {
+	inner := NewUCB1(2)
+	if _, err := NewAnomalyDetector(inner, "e", 2, AnomalyConfig{Lambda: 0, Limit: 3}, nil); err == nil {
+		t.Fatalf("expected error on λ = 0")
+	}
+
+	if _, err := NewAnomalyDetector(inner, "e", 2, AnomalyConfig{Lambda: 0.1, Limit: 0}, nil); err == nil {
+		t.Fatalf("expected error on limit = 0")
+	}
+}
@@ -4,18 +4,64 @@
 package main
 
 import (
+	"embed"
+	"encoding/base64"
 	"flag"
+	"fmt"
 	"github.com/bmizerany/pat"
 	"github.com/purzelrakete/bandit"
 	bhttp "github.com/purzelrakete/bandit/http"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
+// defaults are the experiments shipped with the binary. Experiments read at
+// runtime override defaults of the same name.
+//
+//go:embed defaults.json
+var defaults embed.FS
+
 var (
 	apiExperiments = flag.String("experiments", "experiments.json", "local file or http endpoint")
-	apiBind        = flag.String("port", ":8080", "interface / port to bind to")
+	apiBind        = flag.String("port", ":8080", "interface / port or unix:/path/to/socket to bind to")
+	apiReadTimeout = flag.Duration("read-timeout", 10*time.Second, "max duration of reading a request. 0 disables")
+	apiRespTimeout = flag.Duration("write-timeout", 10*time.Second, "max duration of writing a response, except for /replication. 0 disables")
+	apiIdleTimeout = flag.Duration("idle-timeout", 2*time.Minute, "max duration of idle keep alive connections. 0 disables")
+	apiMaxBody     = flag.Int64("max-body-bytes", 1<<20, "max size of request bodies. 0 disables")
 	apiPinTTL      = flag.Duration("pin-ttl", 0, "ttl life of a pinned variation")
+	apiDryRun      = flag.String("dry-run", "", "report changes this experiments file makes to the running instance and exit")
+	apiDryRunAdmin = flag.String("dry-run-admin", "http://localhost:8081", "admin url of the running instance -dry-run reports against")
+	apiCacheTTL    = flag.Duration("cache-ttl", 0, "ttl of cached selections per uid. 0 disables")
+	apiPrimary     = flag.String("replicate", "", "url of the primary's admin /replication endpoint")
+	apiDeltas      = flag.Duration("replication-interval", time.Second, "fq of deltas sent to replicas")
+	apiClickHouse  = flag.String("clickhouse", "", "url of a ClickHouse HTTP interface to export events to")
+	apiExportTable = flag.String("export-table", "bandit_events", "table to export events to")
+	apiExportCodec = flag.String("export-compression", "", "compression of exported events ∈ {,gzip}")
+	apiEventLog    = flag.String("event-log", "", "file to append selection and reward lines to")
+	apiEventCodec  = flag.String("event-log-compression", "", "compression of the event log ∈ {,gzip}")
+	apiProtobufLog = flag.String("protobuf-log", "", "file to append length delimited protobuf events to, see events.proto")
+	apiStore       = flag.String("store", "", "embedded store file to persist strategy state in")
+	apiStoreCodec  = flag.String("store-codec", "json", "serialization of stored state ∈ {json,gob,protobuf}")
+	apiStoreEvery  = flag.Duration("store-interval", time.Minute, "fq of state persistence")
+	apiReadThrough = flag.Duration("store-read-through", 0, "serve state cached from the store, refreshed at this staleness, without saving")
+	apiRestore     = flag.String("restore-policy", bandit.RestoreFail, "on stored state not matching variations ∈ {fail,reset,map-by-tag}")
+	apiKeyFile     = flag.String("key-file", "", "file with a base64 aes key to decrypt experiment values with")
+	apiHistory     = flag.Duration("history-interval", time.Minute, "fq of arm estimates recorded for stats")
+	apiClone       = flag.String("clone", "", "comma separated from:to experiment names to clone with fresh state")
+	apiAdminBind   = flag.String("admin-port", "", "interface / port or unix:/path/to/socket to bind admin endpoints to. blank disables")
+	apiExposure    = flag.Duration("exposure-interval", time.Hour, "fq of checking min-selections-per-hour")
+	apiStrictTags  = flag.Bool("strict-tags", false, "refuse experiments reusing a stored tag for a different url")
+	apiStrictNames = flag.Bool("strict-names", false, "refuse experiments reusing the name of a retired experiment, instead of warning")
+	apiSampleEvery = flag.Int("log-exploitation-every", 1, "log one in n exploitation decisions per arm, and all exploration decisions")
+	apiRewards     = flag.Bool("online-rewards", false, "apply rewards posted to /feedback directly, e.g. for soak tests")
+	apiKilled      = flag.Bool("killed", false, "start with the kill switch on, serving preferred variations only")
 )
 
 func init() {
@@ -23,15 +69,320 @@ func init() {
 }
 
 func main() {
-	es, err := bandit.NewExperiments(bandit.NewOpener(*apiExperiments))
+	// the running instance decrypts and diffs against its live state
+	if *apiDryRun != "" {
+		changes, err := dryRun(*apiDryRunAdmin, *apiDryRun)
+		if err != nil {
+			log.Fatalf("could not dry run: %s", err.Error())
+		}
+
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+
+		if *apiStrictTags {
+			if err := bandit.CheckChanges(changes); err != nil {
+				log.Fatalf("strict tags: %s", err.Error())
+			}
+		}
+
+		return
+	}
+
+	var decrypter bandit.Decrypter
+	if *apiKeyFile != "" {
+		encoded, err := ioutil.ReadFile(*apiKeyFile)
+		if err != nil {
+			log.Fatalf("could not read key file: %s", err.Error())
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil {
+			log.Fatalf("could not decode key: %s", err.Error())
+		}
+
+		decrypter, err = bandit.NewAESKey(key)
+		if err != nil {
+			log.Fatalf("could not initialize key: %s", err.Error())
+		}
+	}
+
+	es, err := bandit.ParseExperimentsFS(defaults, "defaults.json")
+	if err != nil {
+		log.Fatalf("could not read default experiments: %s", err.Error())
+	}
+
+	overrides, err := bandit.NewDecryptedExperiments(bandit.NewOpener(*apiExperiments), decrypter)
 	if err != nil {
 		log.Fatalf("could not initialize experiments: %s", err.Error())
 	}
 
+	es.Override(overrides)
+
+	// iterate on experiments without copying their config by hand
+	if *apiClone != "" {
+		for _, pair := range strings.Split(*apiClone, ",") {
+			names := strings.SplitN(pair, ":", 2)
+			if len(names) != 2 {
+				log.Fatalf("could not clone '%s': expected from:to", pair)
+			}
+
+			if _, err := es.Clone(names[0], names[1]); err != nil {
+				log.Fatalf("could not clone: %s", err.Error())
+			}
+		}
+	}
+
+	var cache *bandit.SelectionCache
+	if *apiCacheTTL > 0 {
+		cache = bandit.NewSelectionCache(*apiCacheTTL)
+	}
+
+	var sampler *bandit.DecisionSampler
+	if *apiSampleEvery > 1 {
+		if sampler, err = bandit.NewDecisionSampler(*apiSampleEvery); err != nil {
+			log.Fatalf("could not sample decisions: %s", err.Error())
+		}
+	}
+
+	// restore and periodically persist strategy state
+	if *apiStore != "" {
+		embedded, err := bandit.NewEmbeddedStore(*apiStore)
+		if err != nil {
+			log.Fatalf("could not open store: %s", err.Error())
+		}
+
+		store, err := bandit.NewCodedStore(embedded, *apiStoreCodec)
+		if err != nil {
+			log.Fatalf("could not open store: %s", err.Error())
+		}
+
+		// analyses keyed by name would mix a retired experiment with a new
+		// one. applied experiments are checked and recorded as well.
+		es.Registry = &bandit.Registry{
+			Store:       store,
+			StrictTags:  *apiStrictTags,
+			StrictNames: *apiStrictNames,
+		}
+
+		reused, err := es.Registry.Check(es)
+		if err != nil {
+			log.Fatalf("could not check experiments: %s", err.Error())
+		}
+
+		for _, change := range reused {
+			log.Printf("experiment name reused: %s", change)
+		}
+
+		if _, err := bandit.RecordNames(store, es, time.Now()); err != nil {
+			log.Fatalf("could not record names: %s", err.Error())
+		}
+
+		if *apiReadThrough > 0 {
+			for name, e := range es.All() {
+				s, err := bandit.NewReadThrough(e.Strategy, store, name, e.Tags(), *apiReadThrough)
+				if err != nil {
+					log.Fatalf("could not read through %s: %s", name, err.Error())
+				}
+
+				e.Strategy = s
+			}
+		} else {
+			changes, err := bandit.RestoreExperiments(store, es, *apiRestore)
+			if err != nil {
+				log.Fatalf("could not restore experiments: %s", err.Error())
+			}
+
+			for _, change := range changes {
+				log.Printf("restored with change: %s", change)
+			}
+
+			go func() {
+				for _ = range time.Tick(*apiStoreEvery) {
+					if err := bandit.SaveExperiments(store, es); err != nil {
+						log.Printf("could not save experiments: %s", err.Error())
+					}
+				}
+			}()
+		}
+	}
+
+	// stream selection and reward lines to an event log and to analytics
+	outputs := []io.Writer{os.Stderr}
+	if *apiEventLog != "" {
+		file, err := os.OpenFile(*apiEventLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			log.Fatalf("could not open event log: %s", err.Error())
+		}
+
+		eventLog, err := bandit.NewCompressedLog(file, *apiEventCodec, 10*time.Second)
+		if err != nil {
+			log.Fatalf("could not compress event log: %s", err.Error())
+		}
+
+		outputs = append(outputs, eventLog)
+	}
+
+	if *apiProtobufLog != "" {
+		file, err := os.OpenFile(*apiProtobufLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			log.Fatalf("could not open protobuf log: %s", err.Error())
+		}
+
+		outputs = append(outputs, bandit.NewExporter(bandit.NewProtobufSink(file), 1000, 10*time.Second))
+	}
+
+	if *apiClickHouse != "" {
+		sink, err := bandit.NewCompressedClickHouseSink(*apiClickHouse, *apiExportTable, *apiExportCodec)
+		if err != nil {
+			log.Fatalf("could not export: %s", err.Error())
+		}
+
+		outputs = append(outputs, bandit.NewExporter(sink, 1000, 10*time.Second))
+	}
+
+	log.SetOutput(io.MultiWriter(outputs...))
+
+	// notify about experiments which stopped receiving traffic
+	go bandit.NewExposure(es, bandit.NewLogNotifier()).Run(*apiExposure)
+
+	// stop all experiments at once during incidents: SIGUSR1 kills, SIGUSR2 revives
+	if *apiKilled {
+		bandit.Kill("started with -killed", bandit.NewLogNotifier())
+	}
+
+	go killOnSignal(bandit.NewLogNotifier())
+
+	// record arm estimates over time
+	go bandit.Track(es, *apiHistory)
+
+	// replicas serve selections from the primary's state
+	if *apiPrimary != "" {
+		go bandit.Replicate(es, bandit.NewOpener(*apiPrimary), *apiDeltas)
+	}
+
+	handlers := bhttp.HandlerOptions{
+		TTL:     *apiPinTTL,
+		Cache:   cache,
+		Sampler: sampler,
+	}
+
 	m := pat.New()
-	m.Get("/experiments/:name", http.HandlerFunc(bhttp.SelectionHandler(es, *apiPinTTL)))
-	http.Handle("/", m)
+	m.Get("/experiments/:name", http.HandlerFunc(bhttp.NewSelectionHandler(es, handlers)))
+	m.Get("/stats/:name", http.HandlerFunc(bhttp.StatsHandler(es)))
+	m.Get("/buckets/:name", http.HandlerFunc(bhttp.BucketsHandler(es)))
+	m.Get("/preview/:name", http.HandlerFunc(bhttp.PreviewHandler(es)))
+	m.Get("/openapi.json", http.HandlerFunc(bhttp.OpenAPIHandler()))
+
+	// rewards are usually aggregated from logs by bandit-job
+	if *apiRewards {
+		m.Post("/feedback", http.HandlerFunc(bhttp.NewRewardHandler(es, handlers)))
+	}
+
+	options := bhttp.ServerOptions{
+		ReadTimeout:  *apiReadTimeout,
+		WriteTimeout: *apiRespTimeout,
+		IdleTimeout:  *apiIdleTimeout,
+		MaxBodyBytes: *apiMaxBody,
+	}
+
+	// admin endpoints are bound separately, so they can be kept private
+	if *apiAdminBind != "" {
+		admin := pat.New()
+		admin.Post("/experiments/:name/restart", http.HandlerFunc(bhttp.RestartHandler(es)))
+		admin.Get("/experiments/:name", http.HandlerFunc(bhttp.PageHandler(es)))
+		admin.Post("/experiments/:name/annotations", http.HandlerFunc(bhttp.AnnotateHandler(es)))
+		admin.Get("/experiments/:name/annotations", http.HandlerFunc(bhttp.AnnotationsHandler(es)))
+		admin.Post("/experiments/:name/pause", http.HandlerFunc(bhttp.PauseHandler(es)))
+		admin.Post("/experiments/:name/resume", http.HandlerFunc(bhttp.ResumeHandler(es)))
+		admin.Post("/kill", http.HandlerFunc(bhttp.KillHandler(bandit.NewLogNotifier())))
+		admin.Post("/revive", http.HandlerFunc(bhttp.ReviveHandler(bandit.NewLogNotifier())))
+		admin.Get("/experiments", http.HandlerFunc(bhttp.ExperimentsHandler(es)))
+		admin.Get("/config", http.HandlerFunc(bhttp.ConfigHandler(es)))
+		admin.Get("/stats/:name", http.HandlerFunc(bhttp.StatsHandler(es)))
+		admin.Get("/replication", http.HandlerFunc(bhttp.ReplicationHandler(es, *apiDeltas)))
+
+		// deletion requests remove what this instance keeps per uid
+		var erasers []bandit.Eraser
+		if cache != nil {
+			erasers = append(erasers, cache)
+		}
+
+		admin.Post("/users/:uid/erase", http.HandlerFunc(bhttp.EraseHandler(erasers...)))
+
+		// applied experiments would not be read through the store
+		if *apiReadThrough == 0 {
+			admin.Post("/experiments/import", http.HandlerFunc(bhttp.ImportHandler(es, decrypter, *apiRestore)))
+			admin.Post("/experiments", http.HandlerFunc(bhttp.ApplyHandler(es, decrypter, *apiRestore)))
+		}
+
+		go func() {
+			log.Fatal(serve(*apiAdminBind, admin, options))
+		}()
+	}
 
 	// serve
-	log.Fatal(http.ListenAndServe(*apiBind, nil))
+	log.Fatal(serve(*apiBind, m, options))
+}
+
+// killOnSignal turns the kill switch on at SIGUSR1 and off at SIGUSR2.
+// dryRun posts the experiments config at `config` to the admin endpoint
+// `admin` of a running instance with `dry-run=true`, and returns the changes
+// it reports against its live state. Nothing is applied.
+func dryRun(admin, config string) ([]bandit.Change, error) {
+	r, err := bandit.NewOpener(config).Open()
+	if err != nil {
+		return []bandit.Change{}, fmt.Errorf("could not open %s: %s", config, err.Error())
+	}
+
+	defer r.Close()
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(strings.TrimRight(admin, "/")+"/experiments?dry-run=true", "application/json", r)
+	if err != nil {
+		return []bandit.Change{}, err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []bandit.Change{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return []bandit.Change{}, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var changes []bandit.Change
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		changes = append(changes, bandit.Change{Experiment: fields[0], Kind: fields[1], Detail: fields[2]})
+	}
+
+	return changes, nil
+}
+
+func killOnSignal(n bandit.Notifier) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	for sig := range signals {
+		if sig == syscall.SIGUSR1 {
+			bandit.Kill("received SIGUSR1", n)
+		} else {
+			bandit.Revive(n)
+		}
+	}
+}
+
+// serve serves `h` on `addr` with the limits in `o`.
+func serve(addr string, h http.Handler, o bhttp.ServerOptions) error {
+	l, err := bhttp.Listen(addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %s", addr, err.Error())
+	}
+
+	return bhttp.NewServer(h, o).Serve(l)
 }
@@ -0,0 +1,227 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Reconcile carries the strategy state and annotations of the running
+// experiments over to the experiments of the same name in `next`, as if
+// `next` was restored from a store with `policy`. Experiments configured with
+// seed-pulls are seeded from their running version instead, see Seed. With a
+// Registry, the names and tags of `next` are checked first, see
+// Registry.Check. The running experiments are not changed. Returns the changes
+// from the running experiments to `next`, or an error listing every
+// experiment which cannot be carried over.
+func (e *Experiments) Reconcile(next *Experiments, policy string) ([]Change, error) {
+	if policy != RestoreFail && policy != RestoreReset && policy != RestoreByTag {
+		return []Change{}, fmt.Errorf("unknown restore policy '%s'", policy)
+	}
+
+	var reused []Change
+	if e.Registry != nil {
+		checked, err := e.Registry.Check(next)
+		if err != nil {
+			return []Change{}, err
+		}
+
+		reused = checked
+	}
+
+	running, upcoming, names := e.All(), next.All(), []string{}
+	for name := range upcoming {
+		if _, ok := running[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	changes, failures := append(Diff(e, next), reused...), []string{}
+	for _, name := range names {
+		before, after := running[name], upcoming[name]
+		state := before.state()
+
+		// new variations start from similar ones, whatever the policy
+		var c *Counters
+		var reconciled []Change
+		var err error
+		if after.SeedPulls > 0 {
+			c, reconciled = after.seed(state, after.SeedPulls)
+		} else {
+			c, reconciled, err = reconcile(after, state, policy)
+		}
+
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		changes = append(changes, reconciled...)
+		if err := after.Strategy.Init(c); err != nil {
+			failures = append(failures, fmt.Sprintf("could not carry over %s: %s", name, err.Error()))
+			continue
+		}
+
+		if len(reconciled) == 0 {
+			if err := unmarshalState(after.Strategy, state.Strategy); err != nil {
+				failures = append(failures, fmt.Sprintf("could not carry over %s: %s", name, err.Error()))
+				continue
+			}
+		}
+
+		after.annotations.restore(state.Annotations)
+	}
+
+	if len(failures) > 0 {
+		return []Change{}, fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+
+	return changes, nil
+}
+
+// Apply reconciles `next` with the running experiments, see Reconcile, and
+// replaces them with it. Nothing is replaced if any experiment cannot be
+// reconciled, and concurrent applies are serialized. The index is replaced
+// under a lock, so that Get and All see either the running or the next
+// experiments. Buffered rewards are flushed before reconciling and the
+// replaced experiments are closed, but rewards applied to the running
+// experiments during Apply may be lost. As on a restart, disabled variations,
+// archiving and pausing are not carried over. With a Registry, the names of
+// the applied experiments are recorded, see RecordNames.
+func (e *Experiments) Apply(next *Experiments, policy string) ([]Change, error) {
+	e.importing.Lock()
+	defer e.importing.Unlock()
+
+	running := e.All()
+	for _, before := range running {
+		flush(before.Strategy)
+	}
+
+	changes, err := e.Reconcile(next, policy)
+	if err != nil {
+		return []Change{}, err
+	}
+
+	applied := next.All()
+	e.Lock()
+	e.index = applied
+	e.Unlock()
+
+	if e.Registry != nil {
+		e.Registry.record(e)
+	}
+
+	for name, before := range running {
+		if err := before.close(); err != nil {
+			log.Printf("could not close replaced %s: %s", name, err.Error())
+		}
+	}
+
+	return changes, nil
+}
+
+// Config returns the configs of all experiments as a json array, ordered by
+// name, which can be applied again to roll an Apply back. Encrypted values
+// stay encrypted. Fails if an experiment was not read from a config.
+func (e *Experiments) Config() ([]byte, error) {
+	all, names := e.All(), []string{}
+	for name := range all {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	configs := []json.RawMessage{}
+	for _, name := range names {
+		if all[name].config == nil {
+			return []byte{}, fmt.Errorf("%s was not read from a config", name)
+		}
+
+		configs = append(configs, all[name].config)
+	}
+
+	return json.Marshal(configs)
+}
+
+// ImportReport summarizes the changes of an import by experiment name.
+// Experiments are created, updated with at least one change, retired, or
+// else unchanged.
+type ImportReport struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Retired   []string `json:"retired"`
+	Unchanged []string `json:"unchanged"`
+	Changes   []Change `json:"changes"`
+}
+
+// NewImportReport summarizes `changes` from the running experiments to
+// `next`, as returned by Reconcile.
+func NewImportReport(next *Experiments, changes []Change) ImportReport {
+	report := ImportReport{
+		Created:   []string{},
+		Updated:   []string{},
+		Retired:   []string{},
+		Unchanged: []string{},
+		Changes:   changes,
+	}
+
+	if report.Changes == nil {
+		report.Changes = []Change{}
+	}
+
+	changed := make(map[string]bool)
+	for _, change := range changes {
+		switch {
+		case change.Kind == ChangeExperimentAdded:
+			report.Created = append(report.Created, change.Experiment)
+		case change.Kind == ChangeExperimentRemoved:
+			report.Retired = append(report.Retired, change.Experiment)
+		case !changed[change.Experiment]:
+			report.Updated = append(report.Updated, change.Experiment)
+		}
+
+		changed[change.Experiment] = true
+	}
+
+	for name := range next.All() {
+		if !changed[name] {
+			report.Unchanged = append(report.Unchanged, name)
+		}
+	}
+
+	sort.Strings(report.Updated)
+	sort.Strings(report.Unchanged)
+	return report
+}
+
+// Import validates and reconciles all experiments in `next` first, see
+// Reconcile, and then replaces the running experiments with them, all or
+// nothing. With `dryRun`, nothing is replaced. Experiments in `next` which
+// are not applied are closed. Returns a report of the created, updated and
+// retired experiments.
+func (e *Experiments) Import(next *Experiments, policy string, dryRun bool) (ImportReport, error) {
+	var changes []Change
+	var err error
+	if dryRun {
+		changes, err = e.Reconcile(next, policy)
+	} else {
+		changes, err = e.Apply(next, policy)
+	}
+
+	if dryRun || err != nil {
+		next.Close()
+	}
+
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	return NewImportReport(next, changes), nil
+}
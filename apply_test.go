@@ -0,0 +1,211 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApply(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	running := es.index["shape-20130822"]
+	running.Strategy.SelectArm()
+	running.Strategy.Update(1, 1)
+	running.Annotate("", "launch", time.Now())
+
+	// the same experiment, with one variation less
+	config := []byte(`[{"experiment_name": "shape-20130822", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "variations": [
+		{"ordinal": 1, "url": "http://localhost:8080/widget?shape=circle"}]}]`)
+
+	next, err := NewExperiments(NewBytesOpener(config))
+	if err != nil {
+		t.Fatalf("could not read new config: %s", err.Error())
+	}
+
+	if _, err := es.Apply(next, RestoreFail); err == nil {
+		t.Fatalf("expected mismatching state to fail")
+	}
+
+	if es.index["shape-20130822"] != running {
+		t.Fatalf("expected failed apply to keep running experiments")
+	}
+
+	// square is gone. circle keeps its tag.
+	next, _ = NewExperiments(NewBytesOpener(config))
+	changes, err := es.Apply(next, RestoreByTag)
+	if err != nil {
+		t.Fatalf("could not apply: %s", err.Error())
+	}
+
+	if len(changes) == 0 {
+		t.Fatalf("expected changes")
+	}
+
+	applied := es.index["shape-20130822"]
+	if applied == running || applied.Arms() != 1 {
+		t.Fatalf("expected applied experiment with 1 arm")
+	}
+
+	if got := applied.Strategy.Snapshot().values[0]; got != 1 {
+		t.Fatalf("expected value of circle to be carried over, got %f", got)
+	}
+
+	if got := applied.Annotations(); len(got) != 1 || got[0].Text != "launch" {
+		t.Fatalf("expected annotations to be carried over, got %v", got)
+	}
+}
+
+func TestImport(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	running := es.index["shape-20130822"]
+	running.Strategy.SelectArm()
+
+	// shape loses a variation, color is new.
+	config := []byte(`[{"experiment_name": "shape-20130822", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "variations": [
+		{"ordinal": 1, "url": "http://localhost:8080/widget?shape=square"}]},
+		{"experiment_name": "color-20130901", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "variations": [
+		{"ordinal": 1, "url": "http://localhost:8080/widget?color=red"}]}]`)
+
+	next, _ := NewExperiments(NewBytesOpener(config))
+	if _, err := es.Import(next, RestoreFail, false); err == nil {
+		t.Fatalf("expected mismatching state to fail")
+	}
+
+	if es.Len() != 1 || es.index["shape-20130822"] != running {
+		t.Fatalf("expected failed import to keep running experiments")
+	}
+
+	next, _ = NewExperiments(NewBytesOpener(config))
+	report, err := es.Import(next, RestoreByTag, true)
+	if err != nil {
+		t.Fatalf("could not check import: %s", err.Error())
+	}
+
+	if es.Len() != 1 {
+		t.Fatalf("expected dry run to keep running experiments")
+	}
+
+	if len(report.Created) != 1 || report.Created[0] != "color-20130901" {
+		t.Fatalf("expected color to be created, got %v", report.Created)
+	}
+
+	if len(report.Updated) != 1 || report.Updated[0] != "shape-20130822" {
+		t.Fatalf("expected shape to be updated, got %v", report.Updated)
+	}
+
+	// importing only color retires shape.
+	config = []byte(`[{"experiment_name": "color-20130901", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "variations": [
+		{"ordinal": 1, "url": "http://localhost:8080/widget?color=red"}]}]`)
+
+	next, _ = NewExperiments(NewBytesOpener(config))
+	report, err = es.Import(next, RestoreFail, false)
+	if err != nil {
+		t.Fatalf("could not import: %s", err.Error())
+	}
+
+	if _, ok := es.index["color-20130901"]; !ok || es.Len() != 1 {
+		t.Fatalf("expected color to be imported")
+	}
+
+	if len(report.Retired) != 1 || report.Retired[0] != "shape-20130822" {
+		t.Fatalf("expected shape to be retired, got %v", report.Retired)
+	}
+
+	next, _ = NewExperiments(NewBytesOpener(config))
+	if report, _ = es.Import(next, RestoreFail, true); len(report.Unchanged) != 1 {
+		t.Fatalf("expected color to be unchanged, got %v", report)
+	}
+}
+
+func TestApplyWhileServing(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if e, ok := es.Get("shape-20130822"); ok {
+				e.Update(1, 1)
+			}
+
+			for range es.All() {
+			}
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		next, err := NewExperiments(NewFileOpener("experiments.json"))
+		if err != nil {
+			t.Fatalf("could not read new config: %s", err.Error())
+		}
+
+		if _, err := es.Apply(next, RestoreFail); err != nil {
+			t.Fatalf("could not apply: %s", err.Error())
+		}
+	}
+
+	<-done
+	if _, ok := es.Get("shape-20130822"); !ok {
+		t.Fatalf("expected applied experiment")
+	}
+}
+
+func TestApplyConfigRollback(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	if _, err := es.Clone("shape-20130822", "shape-20130823"); err != nil {
+		t.Fatalf("could not clone: %s", err.Error())
+	}
+
+	previous, err := es.Config()
+	if err != nil {
+		t.Fatalf("could not get config: %s", err.Error())
+	}
+
+	config := []byte(`[{"experiment_name": "shape-20130822", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "variations": [
+		{"ordinal": 1, "url": "http://localhost:8080/widget?shape=circle"}]}]`)
+
+	next, _ := NewExperiments(NewBytesOpener(config))
+	if _, err := es.Apply(next, RestoreByTag); err != nil {
+		t.Fatalf("could not apply: %s", err.Error())
+	}
+
+	next, err = NewExperiments(NewBytesOpener(previous))
+	if err != nil {
+		t.Fatalf("could not read previous config: %s", err.Error())
+	}
+
+	if _, err := es.Apply(next, RestoreByTag); err != nil {
+		t.Fatalf("could not roll back: %s", err.Error())
+	}
+
+	clone, ok := es.Get("shape-20130823")
+	if !ok || clone.Arms() != 2 || es.index["shape-20130822"].Arms() != 2 {
+		t.Fatalf("expected both experiments with 2 arms after rolling back")
+	}
+
+	if rolledBack, _ := es.Config(); string(rolledBack) != string(previous) {
+		t.Fatalf("expected config %s, got %s", previous, rolledBack)
+	}
+}
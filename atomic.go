@@ -0,0 +1,330 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"hash"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NewAtomicEpsilonGreedy constructs an epsilon greedy strategy for reward
+// firehoses. Update only uses atomic operations, so it can be called from
+// thousands of goroutines without contending on a mutex. Selections only lock
+// their random draw. It does not support priorities, costs or discounting.
+func NewAtomicEpsilonGreedy(arms int, epsilon float64) (Strategy, error) {
+	if !(epsilon >= 0 && epsilon <= 1) {
+		return &atomicEpsilonGreedy{}, fmt.Errorf("epsilon not in [0, 1]")
+	}
+
+	e := &atomicEpsilonGreedy{
+		arms:    arms,
+		epsilon: epsilon,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	e.state.Store(newAtomicCounters(arms, 0))
+	return e, nil
+}
+
+// atomicEpsilonGreedy keeps its counters in an atomically replaced state, so
+// that Init and Reset never block rewards.
+type atomicEpsilonGreedy struct {
+	sync.Mutex // guards rand and ties
+
+	arms    int
+	epsilon float64
+	rand    *rand.Rand
+	ties    string
+	state   atomic.Value // *atomicCounters
+	named   nameCache    // String of the strategy, see Fingerprint
+}
+
+// atomicCounters are the counters of all arms. The revision of the state is
+// base plus the number of rewards applied to it.
+type atomicCounters struct {
+	base uint64
+	arms []atomicArm
+}
+
+// atomicArm is the state of a single arm, accessed atomically. It is padded to
+// a cache line, so that rewards of different arms do not contend.
+type atomicArm struct {
+	pulls   int64  // selections
+	rewards int64  // applied rewards
+	sum     uint64 // bits of the float64 sum of rewards
+	_       [40]byte
+}
+
+// newAtomicCounters returns zero counters for `arms` at revision `base`.
+func newAtomicCounters(arms int, base uint64) *atomicCounters {
+	return &atomicCounters{base: base, arms: make([]atomicArm, arms)}
+}
+
+// count returns the pulls of the arm. A reward for an arm which was never
+// selected counts as its first pull, as with Counters.
+func (a *atomicArm) count() int64 {
+	pulls, rewards := atomic.LoadInt64(&a.pulls), atomic.LoadInt64(&a.rewards)
+	if rewards > pulls {
+		return rewards
+	}
+
+	return pulls
+}
+
+// value returns the average reward per pull, where pulls which were not
+// rewarded yet count as 0.
+func (a *atomicArm) value() float64 {
+	count := a.count()
+	if count == 0 {
+		return 0
+	}
+
+	return math.Float64frombits(atomic.LoadUint64(&a.sum)) / float64(count)
+}
+
+// add adds `n` rewards summing to `sum`.
+func (a *atomicArm) add(n int, sum float64) {
+	for {
+		old := atomic.LoadUint64(&a.sum)
+		next := math.Float64bits(math.Float64frombits(old) + sum)
+		if atomic.CompareAndSwapUint64(&a.sum, old, next) {
+			break
+		}
+	}
+
+	atomic.AddInt64(&a.rewards, int64(n))
+}
+
+// counters returns the current state.
+func (e *atomicEpsilonGreedy) counters() *atomicCounters {
+	return e.state.Load().(*atomicCounters)
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (e *atomicEpsilonGreedy) SelectArm() int {
+	c := e.counters()
+	values := make([]float64, e.arms)
+	for i := range c.arms {
+		values[i] = c.arms[i].value()
+	}
+
+	e.Lock()
+	var arm int
+	switch {
+	case e.rand.Float64() < e.epsilon:
+		arm = e.rand.Intn(e.arms)
+	case e.ties == TiesOrdinal:
+		_, best := bmath.Max(values)
+		arm = best[0]
+	default:
+		arm = bmath.ArgMax(values, e.rand)
+	}
+	e.Unlock()
+
+	atomic.AddInt64(&c.arms[arm].pulls, 1)
+	return arm + 1
+}
+
+// Update adds the reward of the 1 indexed arm.
+func (e *atomicEpsilonGreedy) Update(arm int, reward float64) {
+	e.counters().arms[arm-1].add(1, reward)
+}
+
+// UpdateBatch adds `rewards` of the 1 indexed arm at once.
+func (e *atomicEpsilonGreedy) UpdateBatch(arm int, rewards []float64) {
+	sum := 0.0
+	for _, reward := range rewards {
+		sum += reward
+	}
+
+	e.counters().arms[arm-1].add(len(rewards), sum)
+}
+
+// Init replaces the state with `snapshot`.
+func (e *atomicEpsilonGreedy) Init(snapshot *Counters) error {
+	if e.arms != snapshot.arms {
+		return fmt.Errorf("cannot %d arms with %d arms", e.arms, snapshot.arms)
+	}
+
+	if snapshot.arms == 0 {
+		return fmt.Errorf("need at least 1 arm")
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	c := newAtomicCounters(e.arms, e.revision()+1)
+	for i := range c.arms {
+		count := int64(snapshot.counts[i])
+		c.arms[i] = atomicArm{
+			pulls:   count,
+			rewards: count,
+			sum:     math.Float64bits(snapshot.values[i] * float64(count)),
+		}
+	}
+
+	e.state.Store(c)
+	return nil
+}
+
+// Snapshot returns the current counters. Arms are read one by one, so rewards
+// applied while reading may be in the snapshot for some arms only.
+func (e *atomicEpsilonGreedy) Snapshot() *Counters {
+	return e.counters().snapshot()
+}
+
+// snapshot converts `c` into Counters.
+func (c *atomicCounters) snapshot() *Counters {
+	snapshot := &Counters{
+		arms:   len(c.arms),
+		counts: make([]int, len(c.arms)),
+		values: make([]float64, len(c.arms)),
+	}
+
+	for i := range c.arms {
+		snapshot.counts[i] = int(c.arms[i].count())
+		snapshot.values[i] = c.arms[i].value()
+	}
+
+	snapshot.revision = c.revision()
+	return snapshot
+}
+
+// revision returns the base plus all rewards applied to `c`.
+func (c *atomicCounters) revision() uint64 {
+	revision := c.base
+	for i := range c.arms {
+		revision += uint64(atomic.LoadInt64(&c.arms[i].rewards))
+	}
+
+	return revision
+}
+
+// revision returns the revision of the current state.
+func (e *atomicEpsilonGreedy) revision() uint64 {
+	return e.counters().revision()
+}
+
+// policy leaves `h` unchanged, as there are no priorities or costs, and
+// returns the revision.
+func (e *atomicEpsilonGreedy) policy(h hash.Hash32) uint64 {
+	return e.revision()
+}
+
+// names returns the name cache of the strategy.
+func (e *atomicEpsilonGreedy) names() *nameCache {
+	return &e.named
+}
+
+// leading is true if 0 indexed `arm` has the highest value.
+func (e *atomicEpsilonGreedy) leading(arm int) bool {
+	c := e.counters()
+	value := c.arms[arm].value()
+	for i := range c.arms {
+		if c.arms[i].value() > value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reset the strategy to initial state.
+func (e *atomicEpsilonGreedy) Reset() {
+	e.ResetWithArchive()
+}
+
+// ResetWithArchive swaps in fresh counters and returns the replaced ones.
+// Rewards which loaded the replaced state just before the reset may be
+// missing from the archive.
+func (e *atomicEpsilonGreedy) ResetWithArchive() *Counters {
+	e.Lock()
+	defer e.Unlock()
+
+	replaced := e.counters()
+	archive := replaced.snapshot()
+	e.state.Store(newAtomicCounters(e.arms, archive.revision+1))
+	return archive
+}
+
+// Distribution returns the probability of selecting each arm.
+func (e *atomicEpsilonGreedy) Distribution() []float64 {
+	c := e.counters()
+	values := make([]float64, e.arms)
+	for i := range c.arms {
+		values[i] = c.arms[i].value()
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	_, best := bmath.Max(values)
+	if e.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	distribution := make([]float64, e.arms)
+	for i := range distribution {
+		distribution[i] = e.epsilon / float64(e.arms)
+	}
+
+	for _, arm := range best {
+		distribution[arm] += (1 - e.epsilon) / float64(len(best))
+	}
+
+	return distribution
+}
+
+// BreakTies sets how equally good arms are chosen, TiesRandom or TiesOrdinal.
+func (e *atomicEpsilonGreedy) BreakTies(mode string) error {
+	if mode != TiesRandom && mode != TiesOrdinal {
+		return fmt.Errorf("unknown tie breaking '%s'", mode)
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	e.ties = mode
+	return nil
+}
+
+// UseSource replaces the random source of selections.
+func (e *atomicEpsilonGreedy) UseSource(src rand.Source) error {
+	e.Lock()
+	defer e.Unlock()
+
+	e.rand = rand.New(src)
+	return nil
+}
+
+// release undoes the pulls of 1 indexed `arms` which were selected but never
+// served.
+func (e *atomicEpsilonGreedy) release(arms []int) {
+	c := e.counters()
+	for _, arm := range arms {
+		pulls := &c.arms[arm-1].pulls
+		for {
+			old := atomic.LoadInt64(pulls)
+			if old == 0 || atomic.CompareAndSwapInt64(pulls, old, old-1) {
+				break
+			}
+		}
+	}
+}
+
+// pull counts a selection of the 1 indexed `arm`.
+func (e *atomicEpsilonGreedy) pull(arm int) {
+	atomic.AddInt64(&e.counters().arms[arm-1].pulls, 1)
+}
+
+// String returns information on this strategy.
+func (e *atomicEpsilonGreedy) String() string {
+	return fmt.Sprintf("AtomicEpsilonGreedy(epsilon=%.2f)", e.epsilon)
+}
@@ -0,0 +1,97 @@
+package bandit
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestAtomicEpsilonGreedy(t *testing.T) {
+	s, err := NewAtomicEpsilonGreedy(2, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// 1000 goroutines reward arm 1 with 1 and arm 2 with 0.5, 10 times each
+	var wg sync.WaitGroup
+	for g := 0; g < 1000; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				s.Update(1, 1)
+				s.Update(2, 0.5)
+			}
+		}()
+	}
+
+	wg.Wait()
+	snapshot := s.Snapshot()
+	for arm, expected := range []float64{1, 0.5} {
+		if snapshot.counts[arm] != 10000 || math.Abs(snapshot.values[arm]-expected) > 1e-9 {
+			t.Fatalf("expected 10000 pulls at %f for arm %d, got %d at %f", expected, arm+1, snapshot.counts[arm], snapshot.values[arm])
+		}
+	}
+
+	if snapshot.revision != 20000 {
+		t.Fatalf("expected revision 20000, got %d", snapshot.revision)
+	}
+
+	if arm := s.SelectArm(); arm != 1 {
+		t.Fatalf("expected greedy selection of arm 1, got %d", arm)
+	}
+
+	archive := s.(Archiver).ResetWithArchive()
+	if archive.counts[0] != 10000 || s.Snapshot().counts[0] != 0 {
+		t.Fatalf("expected reset to archive 10000 pulls, got %d", archive.counts[0])
+	}
+
+	if err := s.Init(archive); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	restored := s.Snapshot()
+	if restored.counts[1] != 10000 || math.Abs(restored.values[1]-0.5) > 1e-9 {
+		t.Fatalf("expected restored counters, got %v %v", restored.counts, restored.values)
+	}
+
+	if restored.revision <= archive.revision {
+		t.Fatalf("expected revision after %d, got %d", archive.revision, restored.revision)
+	}
+}
+
+func TestAtomicEpsilonGreedyUnrewarded(t *testing.T) {
+	s, err := NewAtomicEpsilonGreedy(1, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// unrewarded pulls count as 0 until their rewards arrive
+	s.SelectArm()
+	s.SelectArm()
+	s.Update(1, 1)
+	if got := s.Snapshot().values[0]; math.Abs(got-0.5) > 1e-9 {
+		t.Fatalf("expected value 0.5, got %f", got)
+	}
+}
+
+func BenchmarkUpdateParallelEpsilonGreedy(b *testing.B) {
+	s, _ := NewEpsilonGreedy(10, 0.1)
+	benchmarkUpdateParallel(b, s)
+}
+
+func BenchmarkUpdateParallelAtomicEpsilonGreedy(b *testing.B) {
+	s, _ := NewAtomicEpsilonGreedy(10, 0.1)
+	benchmarkUpdateParallel(b, s)
+}
+
+// benchmarkUpdateParallel rewards all arms of `s` from GOMAXPROCS goroutines.
+func benchmarkUpdateParallel(b *testing.B, s Strategy) {
+	b.RunParallel(func(pb *testing.PB) {
+		arm := 0
+		for pb.Next() {
+			s.Update(arm%10+1, 1)
+			arm++
+		}
+	})
+}
@@ -17,12 +17,14 @@ import (
 	"time"
 )
 
-// Strategy can select arm or update information
+// Strategy can select arm or update information. Built-in strategies are safe
+// for concurrent use.
 type Strategy interface {
 	SelectArm() int
 	Update(arm int, reward float64)
 	Init(*Counters) error
 	Reset()
+	Snapshot() *Counters
 }
 
 // New returns an initialized stragtegy given a name like 'softmax'.
@@ -34,30 +36,140 @@ func New(arms int, name string, params []float64) (Strategy, error) {
 		}
 
 		return NewEpsilonGreedy(arms, params[0])
+	case "atomicEpsilonGreedy":
+		if len(params) != 1 {
+			return &atomicEpsilonGreedy{}, fmt.Errorf("missing ε")
+		}
+
+		return NewAtomicEpsilonGreedy(arms, params[0])
+	case "optimisticEpsilonGreedy":
+		if len(params) != arms+1 {
+			return &epsilonGreedy{}, fmt.Errorf("missing ε and an initial value per arm")
+		}
+
+		return NewOptimisticEpsilonGreedy(arms, params[0], params[1:])
 	case "uniform":
 		if len(params) != 0 {
 			return &epsilonGreedy{}, fmt.Errorf("uniform has no parameters")
 		}
 
 		return NewEpsilonGreedy(arms, 1)
+	case "annealingEpsilonGreedy":
+		if len(params) != 2 {
+			return &epsilonGreedy{}, fmt.Errorf("missing ε and decay")
+		}
+
+		return NewAnnealingEpsilonGreedy(arms, params[0], params[1])
+	case "epsilonFirst":
+		if len(params) != 1 {
+			return &epsilonGreedy{}, fmt.Errorf("missing number of exploring pulls")
+		}
+
+		return NewEpsilonFirst(arms, int(params[0]))
 	case "softmax":
 		if len(params) != 1 {
 			return &softmax{}, fmt.Errorf("missing τ")
 		}
 
 		return NewSoftmax(arms, params[0])
+	case "annealingSoftmax":
+		if len(params) != 0 {
+			return &softmax{}, fmt.Errorf("annealing softmax has no parameters")
+		}
+
+		return NewAnnealingSoftmax(arms), nil
+	case "gradient":
+		if len(params) != 1 {
+			return &gradient{}, fmt.Errorf("missing α")
+		}
+
+		return NewGradient(arms, params[0])
+	case "moss":
+		if len(params) != 1 {
+			return &moss{}, fmt.Errorf("missing horizon")
+		}
+
+		return NewMOSS(arms, int(params[0]))
 	case "ucb1":
 		if len(params) != 0 {
 			return &softmax{}, fmt.Errorf("UCB1 has no parameters")
 		}
 
 		return NewUCB1(arms), nil
+	case "osub":
+		if len(params) != 0 {
+			return &osub{}, fmt.Errorf("OSUB has no parameters")
+		}
+
+		return NewOSUB(arms), nil
+	case "ucbTuned":
+		if len(params) != 0 {
+			return &uCBTuned{}, fmt.Errorf("UCB-Tuned has no parameters")
+		}
+
+		return NewUCBTuned(arms), nil
+	case "meanVariance":
+		if len(params) != 1 {
+			return &meanVariance{}, fmt.Errorf("missing λ")
+		}
+
+		return NewMeanVariance(arms, params[0])
+	case "klUcb":
+		if len(params) > 1 {
+			return &klUCB{}, fmt.Errorf("KL-UCB has at most a precision")
+		}
+
+		precision := klucbPrecision
+		if len(params) == 1 {
+			precision = params[0]
+		}
+
+		return NewKLUCB(arms, precision)
+	case "windowedUcb1":
+		if len(params) != 1 {
+			return &uCB1{}, fmt.Errorf("missing window")
+		}
+
+		return NewWindowedUCB1(arms, int(params[0]))
 	case "thompson":
 		if len(params) != 1 {
 			return &thompson{}, fmt.Errorf("missing α")
 		}
 
 		return NewThompson(arms, params[0])
+	case "gaussianThompson":
+		switch len(params) {
+		case 0:
+			return NewGaussianThompson(arms, UninformativeGaussianPrior)
+		case 4:
+			return NewGaussianThompson(arms, GaussianPrior{params[0], params[1], params[2], params[3]})
+		}
+
+		return &gaussianThompson{}, fmt.Errorf("Gaussian Thompson has no parameters or a prior μ, κ, α and β")
+	case "bootstrapThompson":
+		if len(params) != 1 {
+			return &bootstrapThompson{}, fmt.Errorf("missing number of replicates")
+		}
+
+		return NewBootstrapThompson(arms, int(params[0]))
+	case "bayesUcb":
+		if len(params) != 1 {
+			return &bayesUCB{}, fmt.Errorf("missing α")
+		}
+
+		return NewBayesUCB(arms, params[0])
+	case "successiveElimination":
+		if len(params) != 2 {
+			return &SuccessiveElimination{}, fmt.Errorf("missing ε and δ")
+		}
+
+		return NewSuccessiveElimination(arms, params[0], params[1])
+	case "budgetedUcb":
+		if len(params) != arms+1 {
+			return &BudgetedUCB{}, fmt.Errorf("missing budget and a cost per arm")
+		}
+
+		return NewBudgetedUCB(params[1:], params[0])
 	}
 
 	return &epsilonGreedy{}, fmt.Errorf("'%s' unknown strategy", name)
@@ -75,31 +187,191 @@ func NewEpsilonGreedy(arms int, epsilon float64) (Strategy, error) {
 	}, nil
 }
 
+// NewOptimisticEpsilonGreedy constructs an epsilon greedy strategy whose arms
+// are valued at `initial` until they are first selected. Optimistic initial
+// values, such as the highest possible reward, make it try every arm early,
+// instead of exploiting the first arm rewarded until ε explores the others.
+func NewOptimisticEpsilonGreedy(arms int, epsilon float64, initial []float64) (Strategy, error) {
+	if len(initial) != arms {
+		return &epsilonGreedy{}, fmt.Errorf("need %d initial values, got %d", arms, len(initial))
+	}
+
+	s, err := NewEpsilonGreedy(arms, epsilon)
+	if err != nil {
+		return s, err
+	}
+
+	s.(*epsilonGreedy).initial = append([]float64{}, initial...)
+	return s, nil
+}
+
+// NewAnnealingEpsilonGreedy constructs an epsilon greedy strategy whose ε
+// decays with the total number of pulls t as ε / (1 + t / k), so that long
+// running experiments stop spending traffic on exploration.
+func NewAnnealingEpsilonGreedy(arms int, epsilon, k float64) (Strategy, error) {
+	if !(epsilon >= 0 && epsilon <= 1) {
+		return &epsilonGreedy{}, fmt.Errorf("epsilon not in [0, 1]")
+	}
+
+	if !(k > 0) {
+		return &epsilonGreedy{}, fmt.Errorf("decay not in (0, ∞)")
+	}
+
+	return &epsilonGreedy{
+		Counters: NewCounters(arms),
+		epsilon:  epsilon,
+		decay:    k,
+	}, nil
+}
+
+// NewEpsilonFirst constructs a strategy which explores uniformly at random for
+// the first `n` pulls, and then always exploits the best known arm.
+func NewEpsilonFirst(arms, n int) (Strategy, error) {
+	if n < 1 {
+		return &epsilonGreedy{}, fmt.Errorf("exploring pulls not in [1, ∞)")
+	}
+
+	return &epsilonGreedy{
+		Counters: NewCounters(arms),
+		first:    n,
+	}, nil
+}
+
 // epsilonGreedy randomly selects arms with a probability of ε. The rest of
 // the time, epsilonGreedy selects the currently best known arm.
 type epsilonGreedy struct {
 	Counters
-	epsilon float64 // epsilon value for this strategy
+	epsilon float64   // epsilon value for this strategy
+	decay   float64   // pulls halving epsilon. 0 does not anneal.
+	first   int       // pulls exploring before only exploiting. 0 uses epsilon.
+	initial []float64 // values of arms never selected. nil values them at 0.
+	maxima  []int     // equally best arms at revision maxAt. nil until found.
+	maxAt   uint64
 }
 
 // SelectArm returns 1 indexed arm to be tried next.
 func (e *epsilonGreedy) SelectArm() int {
-	arm := 0
-	if z := e.rand.Float64(); z > e.epsilon {
-		_, imax := bmath.Max(e.values)
-		// best arm. randomly pick because there may be equally best arms.
-		arm = imax[e.rand.Intn(len(imax))]
-	} else {
-		// random arm
-		arm = e.rand.Intn(e.arms)
-	}
+	e.Lock()
+	defer e.Unlock()
 
+	arm := e.draw(nil)
 	e.counts[arm]++
 	return arm + 1
 }
 
+// draw returns a 0 indexed arm. `best` are the equally best arms, or nil if
+// they still have to be found.
+func (e *epsilonGreedy) draw(best []int) int {
+	if z := e.rand.Float64(); z < e.exploration() {
+		// random arm
+		return e.rand.Intn(e.arms)
+	}
+
+	// best arm. there may be equally best arms.
+	if best == nil {
+		best = e.best()
+	}
+
+	return e.pick(best)
+}
+
+// best returns the equally best arms. They only change with the revision,
+// priorities and costs, so they are found once per revision instead of on
+// every selection. With initial values, they also change with the first
+// selection of an arm, and are found every time.
+func (e *epsilonGreedy) best() []int {
+	if e.initial != nil {
+		_, best := bmath.Max(e.greedyEstimates())
+		return best
+	}
+
+	if e.maxima == nil || e.maxAt != e.revision {
+		_, e.maxima = bmath.Max(e.estimates())
+		e.maxAt = e.revision
+	}
+
+	return e.maxima
+}
+
+// Prioritize weights the estimates of arms, see Counters.Prioritize, and
+// finds the best arms again.
+func (e *epsilonGreedy) Prioritize(priorities []float64) error {
+	if err := e.Counters.Prioritize(priorities); err != nil {
+		return err
+	}
+
+	e.Lock()
+	e.maxima = nil
+	e.Unlock()
+	return nil
+}
+
+// Charge subtracts costs from the estimates of arms, see Counters.Charge, and
+// finds the best arms again.
+func (e *epsilonGreedy) Charge(costs []float64) error {
+	if err := e.Counters.Charge(costs); err != nil {
+		return err
+	}
+
+	e.Lock()
+	e.maxima = nil
+	e.Unlock()
+	return nil
+}
+
+// greedyEstimates returns the estimates to exploit, where arms which were
+// never selected have their initial values.
+func (e *epsilonGreedy) greedyEstimates() []float64 {
+	if e.initial == nil {
+		return e.estimates()
+	}
+
+	estimates := append([]float64{}, e.estimates()...)
+	for i, count := range e.counts {
+		if count == 0 {
+			estimates[i] = e.estimate(i, e.initial[i])
+		}
+	}
+
+	return estimates
+}
+
+// exploration returns the current ε.
+func (e *epsilonGreedy) exploration() float64 {
+	if e.decay == 0 && e.first == 0 {
+		return e.epsilon
+	}
+
+	t := 0
+	for _, count := range e.counts {
+		t += count
+	}
+
+	if e.first > 0 {
+		if t < e.first {
+			return 1
+		}
+
+		return 0
+	}
+
+	return e.epsilon / (1 + float64(t)/e.decay)
+}
+
 // String returns information on this strategy
 func (e *epsilonGreedy) String() string {
+	if e.first > 0 {
+		return fmt.Sprintf("EpsilonFirst(n=%d)", e.first)
+	}
+
+	if e.decay > 0 {
+		return fmt.Sprintf("AnnealingEpsilonGreedy(epsilon=%.2f, k=%.0f)", e.epsilon, e.decay)
+	}
+
+	if e.initial != nil {
+		return fmt.Sprintf("OptimisticEpsilonGreedy(epsilon=%.2f, initial=%v)", e.epsilon, e.initial)
+	}
+
 	return fmt.Sprintf("EpsilonGreedy(epsilon=%.2f)", e.epsilon)
 }
 
@@ -116,41 +388,140 @@ func NewSoftmax(arms int, τ float64) (Strategy, error) {
 	}, nil
 }
 
+// NewAnnealingSoftmax constructs a softmax strategy without a fixed τ. τ
+// decays with the total number of pulls t as 1 / log(t + 1), so the strategy
+// explores uniformly at first and exploits more and more over time.
+func NewAnnealingSoftmax(arms int) Strategy {
+	return &softmax{
+		Counters: NewCounters(arms),
+		anneal:   true,
+	}
+}
+
 // softmax selects proportially to success
 type softmax struct {
 	Counters
-	tau float64 // tau value for this Strategy
+	tau     float64      // tau value for this Strategy
+	anneal  bool         // derive tau from the total number of pulls
+	weights []float64    // preallocated unnormalized weights. len(weights) == arms.
+	alias   *bmath.Alias // sampling table of the distribution. nil if stale.
+	aliased uint64       // revision the alias table was built at
+	pulled  int          // pulls the alias table was built at, if annealing
 }
 
 // SelectArm returns 1 indexed arm to be tried next.
 func (s *softmax) SelectArm() int {
-	max, _ := bmath.Max(s.values)
+	s.Lock()
+	defer s.Unlock()
+
+	s.rebuild()
+	draw := s.alias.Sample(s.rand)
+	s.counts[draw]++
+	return draw + 1
+}
 
-	normalizer := 0.0
-	for _, value := range s.values {
-		normalizer += math.Exp((value - max) / s.tau)
+// rebuild brings the alias table up to date, so that arms are sampled in
+// O(1). It is rebuilt after rewards changed the state, and when annealing
+// after every pull, since τ follows the number of pulls. Annealing therefore
+// costs O(arms) per selection. Rebuilds reuse the table and do not allocate.
+func (s *softmax) rebuild() {
+	pulls := 0
+	if s.anneal {
+		for _, count := range s.counts {
+			pulls += count
+		}
+	}
+
+	if s.alias != nil && s.aliased == s.revision && s.pulled == pulls {
+		return
 	}
 
-	if math.IsInf(normalizer, 0) {
-		panic("normalizer in softmax too large")
+	s.distribution()
+	if s.alias == nil {
+		s.alias = bmath.NewAlias(s.weights)
+	} else {
+		s.alias.Rebuild(s.weights)
 	}
 
-	cumulativeProb := 0.0
-	draw := len(s.values) - 1
-	z := s.rand.Float64()
-	for i, value := range s.values {
-		cumulativeProb = cumulativeProb + math.Exp((value-max)/s.tau)/normalizer
-		if cumulativeProb > z {
-			draw = i
-			break
+	s.aliased, s.pulled = s.revision, pulls
+}
+
+// Prioritize sets business priorities and rebuilds the distribution.
+func (s *softmax) Prioritize(priorities []float64) error {
+	if err := s.Counters.Prioritize(priorities); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.alias = nil
+	return nil
+}
+
+// Charge sets serving costs and rebuilds the distribution.
+func (s *softmax) Charge(costs []float64) error {
+	if err := s.Counters.Charge(costs); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.alias = nil
+	return nil
+}
+
+// distribution fills the unnormalized weights of all arms. Exponents are
+// shifted by the highest estimate, the log-sum-exp trick, so weights are in
+// (0, 1] and cannot overflow. τ = 0 is the greedy limit, weighting equally
+// best arms equally.
+func (s *softmax) distribution() {
+	if len(s.weights) != s.arms {
+		s.weights = make([]float64, s.arms)
+	}
+
+	tau := s.temperature()
+	estimates := s.estimates()
+	max := math.Inf(-1)
+	for _, value := range estimates {
+		if value > max {
+			max = value
+		}
+	}
+
+	for i, value := range estimates {
+		switch {
+		case tau > 0:
+			s.weights[i] = math.Exp((value - max) / tau)
+		case value == max:
+			s.weights[i] = 1
+		default:
+			s.weights[i] = 0
 		}
 	}
-	s.counts[draw]++
-	return draw + 1
+}
+
+// temperature returns τ. Annealing starts out at t = 1 to stay finite.
+func (s *softmax) temperature() float64 {
+	if !s.anneal {
+		return s.tau
+	}
+
+	t := 1
+	for _, count := range s.counts {
+		t += count
+	}
+
+	return 1 / math.Log(float64(t)+1)
 }
 
 // String returns information on this Strategy
 func (s *softmax) String() string {
+	if s.anneal {
+		return "AnnealingSoftmax"
+	}
+
 	return fmt.Sprintf("Softmax(tau=%.2f)", s.tau)
 }
 
@@ -166,34 +537,63 @@ type uCB1 struct {
 	Counters
 }
 
-// SelectArm returns 1 indexed arm to be tried next.
+// SelectArm returns 1 indexed arm to be tried next. Every pull changes the
+// exploration bonus of all arms, so each selection is a pass over all arms.
 func (u *uCB1) SelectArm() int {
-	for i, count := range u.counts {
-		if count == 0 {
-			u.counts[i]++
-			return i + 1
-		}
-	}
+	u.Lock()
+	defer u.Unlock()
 
 	var totalCounts int
 	for _, count := range u.counts {
 		totalCounts += count
 	}
 
-	ucbValues := make([]float64, u.arms)
+	// best arm in a single pass. arms which were never pulled are best. there
+	// may be equally best arms.
+	logTotal := 2 * math.Log(float64(totalCounts))
+	estimates := u.estimates()
+	max, arm, ties := math.Inf(-1), 0, 0
 	for i := 0; i < u.arms; i++ {
-		bonus := math.Sqrt((2 * math.Log(float64(totalCounts))) / float64(u.counts[i]))
-		ucbValues[i] = u.values[i] + bonus
-	}
+		ucb := math.Inf(1)
+		if u.counts[i] > 0 {
+			ucb = estimates[i] + math.Sqrt(logTotal/float64(u.counts[i]))
+		}
 
-	_, imax := bmath.Max(ucbValues)
-	// best arm. randomly pick because there may be equally best arms.
-	arm := imax[u.rand.Intn(len(imax))]
+		if ucb > max {
+			max, arm, ties = ucb, i, 1
+		} else if ucb == max && u.ties != TiesOrdinal {
+			ties++
+			if u.rand.Intn(ties) == 0 {
+				arm = i
+			}
+		}
+	}
 
 	u.counts[arm]++
 	return arm + 1
 }
 
+// bounds returns the upper confidence bound of each arm. Arms which were
+// never pulled are best.
+func (u *uCB1) bounds() []float64 {
+	total := 0
+	for _, count := range u.counts {
+		total += count
+	}
+
+	logTotal := 2 * math.Log(float64(total))
+	ucbs := append([]float64{}, u.estimates()...)
+	for i := range ucbs {
+		if u.counts[i] == 0 {
+			ucbs[i] = math.Inf(1)
+		} else {
+			ucbs[i] += math.Sqrt(logTotal / float64(u.counts[i]))
+		}
+	}
+
+	return ucbs
+}
+
 // String returns information on this Strategy
 func (u *uCB1) String() string {
 	return fmt.Sprintf("UCB1")
@@ -221,8 +621,8 @@ func NewDelayed(s Strategy, o Opener, poll time.Duration) (Strategy, error) {
 	}()
 
 	strategy := delayedStrategy{
-		strategy: s,
-		updates:  c,
+		wrapper: wrapper{strategy: s},
+		updates: c,
 	}
 
 	go func() {
@@ -238,32 +638,21 @@ func NewDelayed(s Strategy, o Opener, poll time.Duration) (Strategy, error) {
 // configured source file, which is pooled at `poll` interval. The retrieved
 // Snapshot replaces the strategy's internal counters.
 type delayedStrategy struct {
-	Counters
-	updates  chan Counters
-	strategy Strategy
-}
-
-// SelectArm delegates to the wrapped strategy
-func (b *delayedStrategy) SelectArm() int {
-	return b.strategy.SelectArm()
+	wrapper
+	updates chan Counters
 }
 
 // String gives information about delayed strategy + the wrapped strategy.
 func (b *delayedStrategy) String() string {
-	return fmt.Sprintf("Delayed(%b)", b.strategy)
-}
-
-// DelayedUpdate updates the internal counters of a strategy with the provided
-// counters.
-func (b *delayedStrategy) Init(c *Counters) error {
-	b.Lock()
-	defer b.Unlock()
-	return b.strategy.Init(c)
+	return fmt.Sprintf("Delayed(%s)", b.strategy)
 }
 
 // Update is a NOP. Delayed strategy is updated with Reset(counter) instead
 func (b *delayedStrategy) Update(arm int, reward float64) {}
 
+// UpdateBatch is a NOP, like Update.
+func (b *delayedStrategy) UpdateBatch(arm int, rewards []float64) {}
+
 // NewThompson constructs a thompson sampling strategy.
 func NewThompson(arms int, α float64) (Strategy, error) {
 	if !(α > 0.0) {
@@ -287,21 +676,30 @@ type thompson struct {
 
 // SelectArm returns 1 indexed arm to be tried next.
 func (t *thompson) SelectArm() int {
+	t.Lock()
+	defer t.Unlock()
+
 	var thetas = make([]float64, t.arms)
 	for i := 0; i < t.arms; i++ {
-		si := t.values[i] * float64(t.counts[i])
-		fi := float64(t.counts[i]) - si
-		thetas[i] = t.betaRand.NextBeta(si+t.alpha, fi+t.alpha)
+		α, β := t.posterior(i, t.alpha)
+		thetas[i] = t.estimate(i, t.betaRand.NextBeta(α, β))
 	}
 
 	_, imax := bmath.Max(thetas)
-	// best arm. randomly pick because there may be equally best arms.
-	arm := imax[t.rand.Intn(len(imax))]
+	// best arm. there may be equally best arms.
+	arm := t.pick(imax)
 
 	t.counts[arm]++
 	return arm + 1
 }
 
+// posterior returns the parameters of the Beta posterior of arm `i` under a
+// Beta(α, α) prior, counting rewards as Bernoulli successes.
+func (c *Counters) posterior(i int, α float64) (float64, float64) {
+	successes := c.values[i] * float64(c.counts[i])
+	return successes + α, float64(c.counts[i]) - successes + α
+}
+
 // String returns information on this strategy
 func (t *thompson) String() string {
 	return fmt.Sprintf("Thompson(alpha=%.2f)", t.alpha)
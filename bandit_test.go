@@ -7,6 +7,7 @@ import (
 	bmath "github.com/purzelrakete/bandit/math"
 	"github.com/purzelrakete/bandit/sim"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -269,3 +270,290 @@ func TestThompson(t *testing.T) {
 		t.Fatalf("cumulative performance should be > %f. is %f", expectedCumulative, got)
 	}
 }
+
+func TestFingerprint(t *testing.T) {
+	a, _ := NewEpsilonGreedy(2, 0.1)
+	b, _ := NewEpsilonGreedy(2, 0.2)
+
+	before := Fingerprint(a)
+	if before == Fingerprint(b) {
+		t.Fatalf("expected different parameters to have different fingerprints")
+	}
+
+	a.Update(1, 1.0)
+	after := Fingerprint(a)
+	if before == after {
+		t.Fatalf("expected update to change fingerprint %s", after)
+	}
+
+	if !strings.HasSuffix(after, "-1") {
+		t.Fatalf("expected revision 1 in fingerprint %s", after)
+	}
+
+	e := Experiment{Name: "e", Strategy: a}
+	line := SelectionLine(e, Variation{Ordinal: 1, Tag: "e:1"})
+	events, err := ParseLog(strings.NewReader(line))
+	if err != nil {
+		t.Fatalf("could not parse selection line: %s", err.Error())
+	}
+
+	if got := events[0].Policy; got != after {
+		t.Fatalf("expected policy %s, got %s", after, got)
+	}
+
+	// wrappers name the wrapped strategy
+	d := NewSimulatedDelayedStrategy(a, 2, 1)
+	if got := strategyName(d); got != "Delayed(EpsilonGreedy(epsilon=0.10))" {
+		t.Fatalf("expected name of the wrapped strategy, got %s", got)
+	}
+}
+
+func TestFingerprintWrapped(t *testing.T) {
+	s, _ := NewEpsilonGreedy(2, 0.1)
+	floored, err := NewFloored(s, 2, 0.1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	before := Fingerprint(floored)
+	if err := charge(floored, []float64{0, 0.5}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if after := Fingerprint(floored); after == before {
+		t.Fatalf("expected costs to change fingerprint %s", after)
+	}
+
+	floored.Update(1, 1)
+	if got := Fingerprint(floored); !strings.HasSuffix(got, "-1") {
+		t.Fatalf("expected revision of wrapped strategy in fingerprint %s", got)
+	}
+
+	// the race detector allocates on its own
+	if raceEnabled {
+		return
+	}
+
+	// the name is formatted once. its bytes, the hash, the costs and the
+	// fingerprint are allocated
+	if allocs := testing.AllocsPerRun(100, func() { Fingerprint(s) }); allocs > 4 {
+		t.Fatalf("expected at most 4 allocations per fingerprint, got %.0f", allocs)
+	}
+}
+
+func TestEpsilonGreedyDelayedRewards(t *testing.T) {
+	sims := 1000
+	trials := 300
+	bestArmIndex := 4 // Bernoulli(bestArm)
+	arms := []sim.Arm{
+		bmath.BernRand(0.1),
+		bmath.BernRand(0.3),
+		bmath.BernRand(0.2),
+		bmath.BernRand(0.8),
+	}
+
+	strategy, err := NewEpsilonGreedy(len(arms), 0.1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	feedback := sim.Feedback{Delay: bmath.ExpRand(0.1), Loss: 0.2}
+	s, err := sim.MonteCarloFeedback(sims, trials, arms, strategy, feedback)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if lost := float64(s.Lost) / float64(sims*trials); math.Abs(lost-0.2) > 0.01 {
+		t.Fatalf("expected to lose 20%% of rewards, lost %f", lost)
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.8 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+
+	if _, err := sim.MonteCarloFeedback(1, 1, arms, strategy, sim.Feedback{Loss: 2}); err == nil {
+		t.Fatalf("expected error on loss outside of [0, 1]")
+	}
+}
+
+func TestAnnealingSoftmax(t *testing.T) {
+	sims := 500
+	trials := 1000
+	bestArmIndex := 4 // Bernoulli(bestArm)
+	arms := []sim.Arm{
+		bmath.BernRand(0.1),
+		bmath.BernRand(0.3),
+		bmath.BernRand(0.2),
+		bmath.BernRand(0.8),
+	}
+
+	strategy := NewAnnealingSoftmax(len(arms))
+	if got := strategy.(*softmax).temperature(); got < 1 {
+		t.Fatalf("expected high initial temperature, got %f", got)
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	first, last := accuracies[0], accuracies[len(accuracies)-1]
+	if last < 0.5 || last < first {
+		t.Fatalf("accuracy should grow from %f, is only %f", first, last)
+	}
+
+	strategy.Init(&Counters{arms: 4, counts: []int{1000, 0, 0, 0}, values: make([]float64, 4)})
+	if got := strategy.(*softmax).temperature(); got > 0.2 {
+		t.Fatalf("expected temperature to decay, got %f", got)
+	}
+
+	// τ anneals with pulls, also while no rewards arrive
+	strategy = NewAnnealingSoftmax(2)
+	strategy.Init(&Counters{arms: 2, counts: []int{1, 1}, values: []float64{1, 0}})
+	for i := 0; i < 10000; i++ {
+		strategy.SelectArm()
+	}
+
+	best := 0
+	for i := 0; i < 1000; i++ {
+		if strategy.SelectArm() == 1 {
+			best++
+		}
+	}
+
+	if best < 990 {
+		t.Fatalf("expected annealed selections of the best arm, got %d of 1000", best)
+	}
+}
+
+func TestSoftmaxStable(t *testing.T) {
+	// huge values would overflow exp without shifting by the maximum
+	strategy, err := NewSoftmax(3, 1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	c := NewCounters(3)
+	c.counts, c.values = []int{1, 1, 1}, []float64{1e6, 1e6 - 1, 1}
+	strategy.Init(&c)
+	p := strategy.(Previewer).Distribution()
+	e := math.E
+	if math.Abs(p[0]-e/(e+1)) > 1e-9 || math.Abs(p[1]-1/(e+1)) > 1e-9 || p[2] != 0 {
+		t.Fatalf("expected weights relative to the best arm, got %v", p)
+	}
+
+	// τ = 0 only selects the best arms
+	greedy, err := NewSoftmax(3, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	c.values = []float64{0.5, 0.2, 0.5}
+	greedy.Init(&c)
+	if p := greedy.(Previewer).Distribution(); p[0] != 0.5 || p[1] != 0 || p[2] != 0.5 {
+		t.Fatalf("expected τ = 0 to be greedy, got %v", p)
+	}
+
+	// selections and rebuilds after rewards reuse the alias table
+	selectAndUpdate := func() { strategy.Update(strategy.SelectArm(), 1) }
+	if allocs := testing.AllocsPerRun(100, selectAndUpdate); allocs != 0 {
+		t.Fatalf("expected softmax not to allocate, got %.0f allocations", allocs)
+	}
+}
+
+func TestAnnealingEpsilonGreedy(t *testing.T) {
+	strategy, err := NewAnnealingEpsilonGreedy(2, 0.2, 100)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e := strategy.(*epsilonGreedy)
+	if got := e.exploration(); got != 0.2 {
+		t.Fatalf("expected initial ε of 0.2, got %f", got)
+	}
+
+	e.Init(&Counters{arms: 2, counts: []int{50, 50}, values: []float64{0.1, 0.2}})
+	if got := e.exploration(); got != 0.1 {
+		t.Fatalf("expected ε to halve after k pulls, got %f", got)
+	}
+
+	if got := e.Distribution(); math.Abs(got[0]-0.05) > 1e-9 || math.Abs(got[1]-0.95) > 1e-9 {
+		t.Fatalf("expected decayed distribution, got %v", got)
+	}
+
+	if _, err := NewAnnealingEpsilonGreedy(2, 0.2, 0); err == nil {
+		t.Fatalf("expected zero decay to fail")
+	}
+}
+
+func TestEpsilonFirst(t *testing.T) {
+	strategy, err := NewEpsilonFirst(3, 300)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e := strategy.(*epsilonGreedy)
+	e.values = []float64{0.1, 0.9, 0.2}
+
+	// uniform while exploring
+	for i := 0; i < 300; i++ {
+		strategy.SelectArm()
+	}
+
+	for arm, count := range e.counts {
+		if count < 50 {
+			t.Fatalf("expected arm %d to be explored, got %d pulls", arm+1, count)
+		}
+	}
+
+	// then greedy
+	for i := 0; i < 100; i++ {
+		if got := strategy.SelectArm(); got != 2 {
+			t.Fatalf("expected best arm 2 after exploring, got %d", got)
+		}
+	}
+
+	if _, err := New(3, "epsilonFirst", []float64{0}); err == nil {
+		t.Fatalf("expected no exploring pulls to fail")
+	}
+}
+
+func TestOptimisticEpsilonGreedy(t *testing.T) {
+	rewards := []float64{0.1, 0.9, 0.2}
+
+	// greedy without exploration locks onto the first arm rewarded
+	greedy, err := NewEpsilonGreedy(3, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	greedy.(*epsilonGreedy).BreakTies(TiesOrdinal)
+	for i := 0; i < 10; i++ {
+		arm := greedy.SelectArm()
+		greedy.Update(arm, rewards[arm-1])
+	}
+
+	if counts := greedy.Snapshot().counts; counts[0] != 10 {
+		t.Fatalf("expected greedy to lock onto arm 1, got %v", counts)
+	}
+
+	strategy, err := New(3, "optimisticEpsilonGreedy", []float64{0, 1, 1, 1})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for i := 0; i < 10; i++ {
+		arm := strategy.SelectArm()
+		strategy.Update(arm, rewards[arm-1])
+	}
+
+	if counts := strategy.Snapshot().counts; counts[1] != 8 {
+		t.Fatalf("expected every arm to be tried, then arm 2, got %v", counts)
+	}
+
+	if _, err := New(3, "optimisticEpsilonGreedy", []float64{0, 1}); err == nil {
+		t.Fatalf("expected missing initial values to fail")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+// BatchSelector is implemented by strategies which can select many arms with
+// a single pass over their arms. This matters for experiments with thousands
+// of arms, such as content items.
+type BatchSelector interface {
+	SelectArms(arms []int)
+}
+
+// SelectArms fills `arms` with 1 indexed arms selected by `s`. All selections
+// in a batch see the state of the strategy at the start of the batch.
+func SelectArms(s Strategy, arms []int) {
+	if b, ok := s.(BatchSelector); ok {
+		b.SelectArms(arms)
+		return
+	}
+
+	for i := range arms {
+		arms[i] = s.SelectArm()
+	}
+}
+
+// SelectArms fills `arms` with 1 indexed arms, finding the best arms once.
+func (e *epsilonGreedy) SelectArms(arms []int) {
+	e.Lock()
+	defer e.Unlock()
+
+	best := e.best()
+	for i := range arms {
+		arm := e.draw(best)
+		e.counts[arm]++
+		arms[i] = arm + 1
+	}
+}
+
+// SelectArms fills `arms` with 1 indexed arms from the alias table of the
+// distribution, which is rebuilt at most once, also when annealing.
+func (s *softmax) SelectArms(arms []int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.rebuild()
+	for i := range arms {
+		draw := s.alias.Sample(s.rand)
+		s.counts[draw]++
+		arms[i] = draw + 1
+	}
+}
@@ -0,0 +1,161 @@
+package bandit
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestSelectArms(t *testing.T) {
+	strategy, err := NewSoftmax(3, 0.1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	counters := &strategy.(*softmax).Counters
+	counters.values = []float64{0.1, 0.9, 0.1}
+
+	arms := make([]int, 1000)
+	SelectArms(strategy, arms)
+
+	best := 0
+	for _, arm := range arms {
+		if arm < 1 || arm > 3 {
+			t.Fatalf("selected impossible arm %d", arm)
+		}
+
+		if arm == 2 {
+			best++
+		}
+	}
+
+	if best < 900 {
+		t.Fatalf("expected best arm to dominate, got %d of %d", best, len(arms))
+	}
+
+	if got := counters.counts[0] + counters.counts[1] + counters.counts[2]; got != len(arms) {
+		t.Fatalf("expected %d pulls, got %d", len(arms), got)
+	}
+}
+
+func TestEpsilonGreedyMaxima(t *testing.T) {
+	s, err := NewEpsilonGreedy(3, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s.Update(2, 1)
+	if got := s.SelectArm(); got != 2 {
+		t.Fatalf("expected arm 2, got %d", got)
+	}
+
+	s.Update(3, 2)
+	if got := s.SelectArm(); got != 3 {
+		t.Fatalf("expected rewarded arm 3, got %d", got)
+	}
+
+	if err := prioritize(s, []float64{1, 1, 0.1}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := s.SelectArm(); got != 2 {
+		t.Fatalf("expected prioritized arm 2, got %d", got)
+	}
+
+	if err := charge(s, []float64{0, 1, 0}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := s.SelectArm(); got != 3 {
+		t.Fatalf("expected arm 3 after charging arm 2, got %d", got)
+	}
+}
+
+// benchmarkSelect selects with a strategy over 10k arms, in batches of `batch`.
+func benchmarkSelect(b *testing.B, name string, params []float64, batch int) {
+	arms := 10000
+	strategy, err := New(arms, name, params)
+	if err != nil {
+		b.Fatalf(err.Error())
+	}
+
+	for arm := 1; arm <= arms; arm++ {
+		strategy.Update(arm, float64(arm%100)/100)
+	}
+
+	selected := make([]int, batch)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += batch {
+		SelectArms(strategy, selected)
+	}
+}
+
+func BenchmarkEpsilonGreedy10k(b *testing.B) {
+	benchmarkSelect(b, "epsilonGreedy", []float64{0.1}, 1)
+}
+
+func BenchmarkEpsilonGreedy10kBatch(b *testing.B) {
+	benchmarkSelect(b, "epsilonGreedy", []float64{0.1}, 1000)
+}
+
+func BenchmarkSoftmax10k(b *testing.B) {
+	benchmarkSelect(b, "softmax", []float64{0.1}, 1)
+}
+
+func BenchmarkSoftmax10kBatch(b *testing.B) {
+	benchmarkSelect(b, "softmax", []float64{0.1}, 1000)
+}
+
+// BenchmarkSoftmax10kLinearScan selects by building the cumulative weights and
+// searching them for every selection, as before alias tables.
+func BenchmarkSoftmax10kLinearScan(b *testing.B) {
+	arms := 10000
+	strategy, err := NewSoftmax(arms, 0.1)
+	if err != nil {
+		b.Fatalf(err.Error())
+	}
+
+	for arm := 1; arm <= arms; arm++ {
+		strategy.Update(arm, float64(arm%100)/100)
+	}
+
+	s, cdf := strategy.(*softmax), make([]float64, arms)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.distribution()
+		total := 0.0
+		for arm, weight := range s.weights {
+			total += weight
+			cdf[arm] = total
+		}
+
+		z := s.rand.Float64() * total
+		draw := sort.Search(arms, func(i int) bool { return cdf[i] > z })
+		if draw == arms {
+			draw = arms - 1
+		}
+
+		s.counts[draw]++
+	}
+}
+
+// BenchmarkSoftmax10kRewarded rewards after every 100 selections, which
+// rebuilds the alias table.
+func BenchmarkSoftmax10kRewarded(b *testing.B) {
+	arms := 10000
+	strategy, err := NewSoftmax(arms, 0.1)
+	if err != nil {
+		b.Fatalf(err.Error())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		arm := strategy.SelectArm()
+		if i%100 == 0 {
+			strategy.Update(arm, 1)
+		}
+	}
+}
+
+func BenchmarkUCB110k(b *testing.B) {
+	benchmarkSelect(b, "ucb1", []float64{}, 1)
+}
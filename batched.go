@@ -0,0 +1,168 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"time"
+)
+
+// BatchUpdater is implemented by strategies which can apply many rewards of
+// an arm at once. All strategies in this package do.
+type BatchUpdater interface {
+	UpdateBatch(arm int, rewards []float64)
+}
+
+// UpdateBatch applies `rewards` of the 1 indexed arm to `s` in order, e.g. a
+// day of rewards aggregated by a job. BatchUpdaters apply them at once, other
+// strategies get an Update per reward.
+func UpdateBatch(s Strategy, arm int, rewards []float64) {
+	if u, ok := s.(BatchUpdater); ok {
+		u.UpdateBatch(arm, rewards)
+		return
+	}
+
+	for _, reward := range rewards {
+		s.Update(arm, reward)
+	}
+}
+
+// NewBatched wraps a strategy so that rewards are buffered and applied to it
+// every `size` rewards or every `interval`, whichever comes first. A zero
+// interval only flushes by size. This reduces lock contention on high
+// throughput experiments, typically with Thompson sampling, at the cost of
+// selecting on slightly stale posteriors.
+func NewBatched(s Strategy, size int, interval time.Duration) (Strategy, error) {
+	if size < 1 {
+		return &batchedStrategy{}, fmt.Errorf("batch size not in [1, ∞)")
+	}
+
+	if interval < 0 {
+		return &batchedStrategy{}, fmt.Errorf("batch interval not in [0, ∞)")
+	}
+
+	b := &batchedStrategy{
+		wrapper:  wrapper{strategy: s},
+		size:     size,
+		interval: interval,
+		pending:  make(map[int][]float64),
+	}
+
+	if interval > 0 {
+		done := make(chan bool)
+		b.done = done
+		go func() {
+			t := time.NewTicker(interval)
+			defer t.Stop()
+			for {
+				select {
+				case <-t.C:
+					b.Flush()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	return b, nil
+}
+
+// flusher is implemented by strategies which buffer rewards, see NewBatched.
+type flusher interface {
+	Flush()
+}
+
+// flush applies the buffered rewards of `s`, if it buffers any.
+func flush(s Strategy) {
+	if f, ok := s.(flusher); ok {
+		f.Flush()
+	}
+}
+
+// batchedStrategy buffers rewards per arm. Rewards are applied in the order
+// they were received, so a flush has the same result as unbatched updates.
+type batchedStrategy struct {
+	wrapper
+	size     int
+	interval time.Duration
+	pending  map[int][]float64 // 1 indexed arm to buffered rewards
+	buffered int
+	done     chan bool // closed to stop flushing every interval. nil if stopped.
+}
+
+// Update buffers the reward, flushing once the batch is full.
+func (b *batchedStrategy) Update(arm int, reward float64) {
+	b.Lock()
+	b.pending[arm] = append(b.pending[arm], reward)
+	b.buffered++
+	full := b.buffered >= b.size
+	b.Unlock()
+
+	if full {
+		b.Flush()
+	}
+}
+
+// UpdateBatch buffers all rewards, flushing once the batch is full.
+func (b *batchedStrategy) UpdateBatch(arm int, rewards []float64) {
+	b.Lock()
+	b.pending[arm] = append(b.pending[arm], rewards...)
+	b.buffered += len(rewards)
+	full := b.buffered >= b.size
+	b.Unlock()
+
+	if full {
+		b.Flush()
+	}
+}
+
+// Flush applies all buffered rewards to the wrapped strategy. The lock is held
+// while applying, so that a reset cannot interleave with the flush.
+func (b *batchedStrategy) Flush() {
+	b.Lock()
+	defer b.Unlock()
+
+	pending := b.pending
+	b.pending = make(map[int][]float64)
+	b.buffered = 0
+	for arm, rewards := range pending {
+		UpdateBatch(b.strategy, arm, rewards)
+	}
+}
+
+// Close stops flushing every interval, applies all buffered rewards and
+// closes the wrapped strategy.
+func (b *batchedStrategy) Close() error {
+	b.Lock()
+	if b.done != nil {
+		close(b.done)
+		b.done = nil
+	}
+	b.Unlock()
+
+	b.Flush()
+	return closeStrategy(b.strategy)
+}
+
+// Init drops buffered rewards, which belong to the replaced state, and
+// delegates to the wrapped strategy.
+func (b *batchedStrategy) Init(c *Counters) error {
+	b.Lock()
+	b.pending = make(map[int][]float64)
+	b.buffered = 0
+	b.Unlock()
+
+	return b.strategy.Init(c)
+}
+
+// Reset drops buffered rewards and resets the wrapped strategy.
+func (b *batchedStrategy) Reset() {
+	b.ResetWithArchive()
+}
+
+// String returns information on this strategy.
+func (b *batchedStrategy) String() string {
+	return fmt.Sprintf("Batched(%s, size=%d, interval=%s)", b.strategy, b.size, b.interval)
+}
@@ -0,0 +1,159 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatched(t *testing.T) {
+	plain, _ := NewThompson(2, 1)
+	inner, _ := NewThompson(2, 1)
+	b, err := NewBatched(inner, 3, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	rewards := []struct {
+		arm    int
+		reward float64
+	}{{1, 1}, {2, 0}, {1, 0}, {1, 1}}
+
+	for i, r := range rewards[:2] {
+		plain.Update(r.arm, r.reward)
+		b.Update(r.arm, r.reward)
+		if got := b.Snapshot(); got.revision != 0 {
+			t.Fatalf("expected reward %d to be buffered, got revision %d", i, got.revision)
+		}
+	}
+
+	for _, r := range rewards[2:] {
+		plain.Update(r.arm, r.reward)
+		b.Update(r.arm, r.reward)
+	}
+
+	b.(*batchedStrategy).Flush()
+	got, want := b.Snapshot(), plain.Snapshot()
+	for i := range want.values {
+		if got.values[i] != want.values[i] || got.counts[i] != want.counts[i] {
+			t.Fatalf("expected batched %v %v, got %v %v", want.counts, want.values, got.counts, got.values)
+		}
+	}
+}
+
+func TestBatchedInterval(t *testing.T) {
+	inner, _ := NewThompson(2, 1)
+	b, err := NewBatched(inner, 100, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	b.Update(1, 1)
+	time.Sleep(50 * time.Millisecond)
+	if got := b.Snapshot(); got.values[0] != 1 {
+		t.Fatalf("expected reward to be flushed after interval, got %v", got.values)
+	}
+
+	if _, err := NewBatched(inner, 0, 0); err == nil {
+		t.Fatalf("expected batch size 0 to be rejected")
+	}
+}
+
+func TestBatchedClose(t *testing.T) {
+	inner, _ := NewThompson(2, 1)
+	b, err := NewBatched(inner, 100, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	b.Update(1, 1)
+	if err := closeStrategy(b); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := inner.Snapshot(); got.values[0] != 1 {
+		t.Fatalf("expected close to flush, got %v", got.values)
+	}
+
+	// no more flushes after close
+	b.Update(2, 1)
+	time.Sleep(50 * time.Millisecond)
+	if got := inner.Snapshot(); got.values[1] != 0 {
+		t.Fatalf("expected no flush after close, got %v", got.values)
+	}
+
+	if err := closeStrategy(b); err != nil {
+		t.Fatalf("expected closing twice to succeed: %s", err.Error())
+	}
+}
+
+func TestBatchedApply(t *testing.T) {
+	config := []byte(`[{"experiment_name": "shape-20130822", "strategy": "thompson",
+		"parameters": [1], "preferred": 1, "batch-updates": {"size": 100, "seconds": 60}, "variations": [
+		{"ordinal": 1, "url": "http://localhost/circle"},
+		{"ordinal": 2, "url": "http://localhost/square"}]}]`)
+
+	es, err := NewExperiments(NewBytesOpener(config))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	running, _ := es.Get("shape-20130822")
+	running.Strategy.Update(2, 1)
+
+	next, _ := NewExperiments(NewBytesOpener(config))
+	if _, err := es.Apply(next, RestoreFail); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	applied, _ := es.Get("shape-20130822")
+	if got := applied.Strategy.Snapshot(); got.values[1] != 1 {
+		t.Fatalf("expected buffered reward to be carried over, got %v", got.values)
+	}
+
+	replaced := running.Strategy.(*batchedStrategy)
+	replaced.Lock()
+	defer replaced.Unlock()
+	if replaced.done != nil {
+		t.Fatalf("expected replaced strategy to be closed")
+	}
+}
+
+func TestUpdateBatch(t *testing.T) {
+	rewards := []float64{1, 0, 1, 1}
+	wrap := map[string]func(Strategy) (Strategy, error){
+		"anomaly": func(s Strategy) (Strategy, error) {
+			return NewAnomalyDetector(s, "shape-20130822", 2, AnomalyConfig{Lambda: 0.1, Limit: 3, Warmup: 10}, nil)
+		},
+		"batched":  func(s Strategy) (Strategy, error) { return NewBatched(s, 4, 0) },
+		"floored":  func(s Strategy) (Strategy, error) { return NewFloored(s, 2, 0.1) },
+		"prefetch": func(s Strategy) (Strategy, error) { return NewPrefetcher(s), nil },
+	}
+
+	plain := NewUCB1(2)
+	for _, reward := range rewards {
+		plain.Update(1, reward)
+	}
+
+	want := plain.Snapshot()
+	for name, w := range wrap {
+		s, err := w(NewUCB1(2))
+		if err != nil {
+			t.Fatalf("could not wrap %s: %s", name, err.Error())
+		}
+
+		UpdateBatch(s, 1, rewards)
+		if got := s.Snapshot(); got.values[0] != want.values[0] || got.counts[0] != want.counts[0] {
+			t.Fatalf("expected %s to apply the batch, got %v %v", name, got.counts, got.values)
+		}
+	}
+
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.UpdateBatch(2, rewards)
+	if got := e.Strategy.Snapshot(); got.counts[1] != want.counts[0] || got.values[1] != want.values[0] {
+		t.Fatalf("expected experiment to apply the batch, got %v %v", got.counts, got.values)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+)
+
+// NewBayesUCB constructs a Bayes-UCB strategy for Bernoulli rewards, see
+// Kaufmann, Cappé & Garivier, 2012, "On Bayesian Upper Confidence Bounds for
+// Bandit Problems". Posteriors are the same as in Thompson sampling with prior
+// strength `α`, but selections are deterministic.
+func NewBayesUCB(arms int, α float64) (Strategy, error) {
+	if !(α > 0.0) {
+		return &bayesUCB{}, fmt.Errorf("α not in (0, ∞]")
+	}
+
+	return &bayesUCB{
+		Counters: NewCounters(arms),
+		alpha:    α,
+	}, nil
+}
+
+// bayesUCB selects the arm with the highest 1 - 1/t quantile of its Beta
+// posterior, where t is the number of the current pull.
+type bayesUCB struct {
+	Counters
+	alpha float64 // strength of the beta prior, as in thompson
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (b *bayesUCB) SelectArm() int {
+	b.Lock()
+	defer b.Unlock()
+
+	var arm int
+	if b.ties == TiesOrdinal {
+		_, best := bmath.Max(b.quantiles())
+		arm = best[0]
+	} else {
+		arm = bmath.ArgMax(b.quantiles(), b.rand)
+	}
+
+	b.counts[arm]++
+	return arm + 1
+}
+
+// quantiles returns the prioritized posterior quantile of each arm.
+func (b *bayesUCB) quantiles() []float64 {
+	t := 1
+	for _, count := range b.counts {
+		t += count
+	}
+
+	p := 1 - 1/float64(t)
+	quantiles := make([]float64, b.arms)
+	for i := range quantiles {
+		α, β := b.posterior(i, b.alpha)
+		quantiles[i] = b.estimate(i, bmath.BetaQuantile(p, α, β))
+	}
+
+	return quantiles
+}
+
+// Distribution returns the probability of selecting each arm.
+func (b *bayesUCB) Distribution() []float64 {
+	b.Lock()
+	defer b.Unlock()
+
+	_, best := bmath.Max(b.quantiles())
+	if b.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	distribution := make([]float64, b.arms)
+	for _, arm := range best {
+		distribution[arm] = 1 / float64(len(best))
+	}
+
+	return distribution
+}
+
+// String returns information on this strategy
+func (b *bayesUCB) String() string {
+	return fmt.Sprintf("BayesUCB(alpha=%.2f)", b.alpha)
+}
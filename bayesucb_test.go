@@ -0,0 +1,41 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"testing"
+)
+
+func TestBayesUCB(t *testing.T) {
+	sims := 100
+	trials := 1000
+	bestArmIndex := 4 // Bernoulli(bestArm)
+	arms := []sim.Arm{
+		bmath.BernRand(0.1),
+		bmath.BernRand(0.3),
+		bmath.BernRand(0.2),
+		bmath.BernRand(0.8),
+	}
+
+	strategy, err := New(len(arms), "bayesUcb", []float64{1})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.9 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+
+	if _, err := NewBayesUCB(2, 0); err == nil {
+		t.Fatalf("expected α of 0 to fail")
+	}
+}
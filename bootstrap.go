@@ -0,0 +1,263 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+	"math/bits"
+)
+
+// NewBootstrapThompson constructs a Thompson sampler which needs no
+// parametric posterior, see Eckles & Kaptein, 2014, "Thompson Sampling with
+// the Online Bootstrap". It keeps `replicates` bootstrap replicates of the
+// mean reward of each arm, and selects the best arm of one replicate drawn
+// per decision. Rewards may be of any distribution, e.g. revenue.
+func NewBootstrapThompson(arms, replicates int) (Strategy, error) {
+	if replicates < 1 {
+		return &bootstrapThompson{}, fmt.Errorf("replicates not in [1, ∞)")
+	}
+
+	b := &bootstrapThompson{
+		Counters:   NewCounters(arms),
+		replicates: replicates,
+	}
+
+	b.clear()
+	return b, nil
+}
+
+// bootstrapThompson updates each replicate with double or nothing weights:
+// every reward counts twice in about half of the replicates, and not at all
+// in the others. Arms a replicate has no rewards of are best in it, so they
+// are explored.
+type bootstrapThompson struct {
+	Counters
+	replicates int
+	sums       [][]float64 // weighted sum of rewards, per replicate and arm
+	weights    [][]float64 // sum of weights, per replicate and arm
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (b *bootstrapThompson) SelectArm() int {
+	b.Lock()
+	defer b.Unlock()
+
+	means := b.means(b.rand.Intn(b.replicates))
+
+	var arm int
+	if b.ties == TiesOrdinal {
+		_, best := bmath.Max(means)
+		arm = best[0]
+	} else {
+		arm = bmath.ArgMax(means, b.rand)
+	}
+
+	b.counts[arm]++
+	return arm + 1
+}
+
+// means returns the mean reward of each arm in `replicate`.
+func (b *bootstrapThompson) means(replicate int) []float64 {
+	means := make([]float64, b.arms)
+	for i := range means {
+		if b.weights[replicate][i] == 0 {
+			means[i] = math.Inf(1)
+			continue
+		}
+
+		means[i] = b.estimate(i, b.sums[replicate][i]/b.weights[replicate][i])
+	}
+
+	return means
+}
+
+// Update the counters and the replicates with a reward of the 1 indexed arm.
+func (b *bootstrapThompson) Update(arm int, reward float64) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.update(arm, reward)
+}
+
+// UpdateBatch applies rewards to the 1 indexed arm in order.
+func (b *bootstrapThompson) UpdateBatch(arm int, rewards []float64) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, reward := range rewards {
+		b.update(arm, reward)
+	}
+}
+
+// update the counters and replicates without locking.
+func (b *bootstrapThompson) update(arm int, reward float64) {
+	b.Counters.update(arm, reward)
+
+	for j := range b.sums {
+		if b.rand.Intn(2) == 0 {
+			continue
+		}
+
+		b.sums[j][arm-1] += 2 * reward
+		b.weights[j][arm-1] += 2
+	}
+}
+
+// clear empties all replicates, without locking.
+func (b *bootstrapThompson) clear() {
+	b.sums = make([][]float64, b.replicates)
+	b.weights = make([][]float64, b.replicates)
+	for j := range b.sums {
+		b.sums[j] = make([]float64, b.arms)
+		b.weights[j] = make([]float64, b.arms)
+	}
+}
+
+// reseed rebuilds the replicates from the counters, without locking. Each
+// replicate keeps every reward twice with probability 1/2, as in update.
+// Single rewards are not known, so an arm with mean v in [0, 1] is taken to
+// have v n rewards of 1 and the rest 0, and the replicates disagree as they
+// would have with these rewards. Other means are kept by all replicates.
+func (b *bootstrapThompson) reseed() {
+	b.clear()
+	for i, count := range b.counts {
+		value := b.values[i]
+		binary := value >= 0 && value <= 1
+		ones := int(math.Round(value * float64(count)))
+		for j := range b.sums {
+			if binary {
+				kept := b.halves(ones)
+				b.sums[j][i] = 2 * float64(kept)
+				b.weights[j][i] = 2 * float64(kept+b.halves(count-ones))
+				continue
+			}
+
+			b.weights[j][i] = 2 * float64(b.halves(count))
+			b.sums[j][i] = value * b.weights[j][i]
+		}
+	}
+}
+
+// halves draws how many of `n` rewards a replicate keeps, from the binomial
+// distribution B(n, 1/2), without locking. Large `n` are drawn from the
+// normal approximation.
+func (b *bootstrapThompson) halves(n int) int {
+	if n > 1024 {
+		kept := math.Round(float64(n)/2 + math.Sqrt(float64(n))/2*b.rand.NormFloat64())
+		return int(math.Max(0, math.Min(float64(n), kept)))
+	}
+
+	kept := 0
+	for ; n >= 64; n -= 64 {
+		kept += bits.OnesCount64(b.rand.Uint64())
+	}
+
+	return kept + bits.OnesCount64(b.rand.Uint64()&(1<<uint(n)-1))
+}
+
+// Init the strategy to a new counter state. Snapshots do not carry
+// replicates, so they are reseeded from the counters.
+func (b *bootstrapThompson) Init(snapshot *Counters) error {
+	if err := b.Counters.Init(snapshot); err != nil {
+		return err
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.reseed()
+	return nil
+}
+
+// bootstrapState is the stored state of a bootstrap Thompson sampler.
+type bootstrapState struct {
+	Sums    [][]float64 `json:"sums"`
+	Weights [][]float64 `json:"weights"`
+}
+
+// marshalState encodes all replicates.
+func (b *bootstrapThompson) marshalState() ([]byte, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	return json.Marshal(bootstrapState{b.sums, b.weights})
+}
+
+// unmarshalState restores the replicates, replacing the ones reseeded by
+// Init.
+func (b *bootstrapThompson) unmarshalState(data []byte) error {
+	var stored bootstrapState
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	if len(stored.Sums) != b.replicates || len(stored.Weights) != b.replicates {
+		return fmt.Errorf("stored replicates are not %d", b.replicates)
+	}
+
+	for j := range stored.Sums {
+		if len(stored.Sums[j]) != b.arms || len(stored.Weights[j]) != b.arms {
+			return fmt.Errorf("replicate %d is not of %d arms", j, b.arms)
+		}
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	b.sums, b.weights = stored.Sums, stored.Weights
+	return nil
+}
+
+// Reset the strategy to initial state.
+func (b *bootstrapThompson) Reset() {
+	b.ResetWithArchive()
+}
+
+// ResetWithArchive resets the counters and replicates at once.
+func (b *bootstrapThompson) ResetWithArchive() *Counters {
+	b.Lock()
+	defer b.Unlock()
+
+	archive := b.reset()
+	b.clear()
+	return archive
+}
+
+// Discount fails, since replicates resample rewards with equal weights.
+func (b *bootstrapThompson) Discount(γ float64) error {
+	return fmt.Errorf("%s resamples undiscounted rewards and cannot be discounted", b)
+}
+
+// Distribution returns the probability of selecting each arm, which is the
+// share of replicates it is best in.
+func (b *bootstrapThompson) Distribution() []float64 {
+	b.Lock()
+	defer b.Unlock()
+
+	distribution := make([]float64, b.arms)
+	for j := 0; j < b.replicates; j++ {
+		_, best := bmath.Max(b.means(j))
+		if b.ties == TiesOrdinal {
+			best = best[:1]
+		}
+
+		for _, arm := range best {
+			distribution[arm] += 1 / float64(len(best))
+		}
+	}
+
+	for i := range distribution {
+		distribution[i] /= float64(b.replicates)
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (b *bootstrapThompson) String() string {
+	return fmt.Sprintf("BootstrapThompson(replicates=%d)", b.replicates)
+}
@@ -0,0 +1,95 @@
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"testing"
+)
+
+func TestBootstrapThompson(t *testing.T) {
+	sims := 100
+	trials := 2000
+	bestArmIndex := 3 // Normal(0.8, 0.5)
+
+	// rewards are not in [0, 1]
+	arms := []sim.Arm{
+		bmath.NormRand(0.2, 0.5),
+		bmath.NormRand(0.5, 0.5),
+		bmath.NormRand(0.8, 0.5),
+	}
+
+	strategy, err := New(len(arms), "bootstrapThompson", []float64{100})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.9 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+
+	if _, err := NewBootstrapThompson(3, 0); err == nil {
+		t.Fatalf("expected 0 replicates to fail")
+	}
+
+	if err := discount(strategy, 0.9); err == nil {
+		t.Fatalf("expected discounting replicates to fail")
+	}
+}
+
+func TestBootstrapThompsonReplicates(t *testing.T) {
+	s, err := NewBootstrapThompson(2, 50)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// unrewarded arms are explored in every replicate
+	if d := s.(Previewer).Distribution(); d[0] != 0.5 || d[1] != 0.5 {
+		t.Fatalf("expected even distribution, got %v", d)
+	}
+
+	if err := s.Init(&Counters{arms: 2, counts: []int{10, 10}, values: []float64{0.2, 0.6}}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if d := s.(Previewer).Distribution(); d[1] < 0.8 {
+		t.Fatalf("expected replicates to mostly prefer arm 2 after init, got %v", d)
+	}
+
+	s.Reset()
+	if b := s.(*bootstrapThompson); b.weights[0][1] != 0 || b.sums[0][1] != 0 {
+		t.Fatalf("expected reset to clear replicates")
+	}
+}
+
+func TestBootstrapThompsonInit(t *testing.T) {
+	b, err := NewBootstrapThompson(2, 500)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	snapshot := NewCounters(2)
+	snapshot.counts = []int{100, 100}
+	snapshot.values = []float64{0.5, 0.55}
+	if err := b.Init(&snapshot); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// close arms stay uncertain, rather than collapsing to their means
+	distribution := b.(Previewer).Distribution()
+	if !(distribution[0] > 0.1 && distribution[1] > distribution[0]) {
+		t.Fatalf("expected both arms to be explored after init, got %v", distribution)
+	}
+
+	for _, n := range []int{0, 1, 63, 64, 100, 5000} {
+		kept := b.(*bootstrapThompson).halves(n)
+		if kept < 0 || kept > n {
+			t.Fatalf("expected halves of %d in [0, %d], got %d", n, n, kept)
+		}
+	}
+}
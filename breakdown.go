@@ -0,0 +1,81 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync"
+)
+
+// NewBreakdown returns an empty breakdown over `arms` arms.
+func NewBreakdown(arms int) *Breakdown {
+	return &Breakdown{
+		arms:   arms,
+		counts: make(map[string][]int),
+	}
+}
+
+// Breakdown counts selections per arm by a caller provided dimension, such as
+// a country code. Use it to detect a strategy concentrating one variation in
+// one region.
+type Breakdown struct {
+	sync.Mutex
+	arms   int
+	counts map[string][]int // dimension -> selections per arm
+}
+
+// Add counts a selection of 1 indexed `arm` for dimension value `value`.
+func (b *Breakdown) Add(value string, arm int) {
+	b.Lock()
+	defer b.Unlock()
+
+	if _, ok := b.counts[value]; !ok {
+		b.counts[value] = make([]int, b.arms)
+	}
+
+	b.counts[value][arm-1]++
+}
+
+// reset drops all counts.
+func (b *Breakdown) reset() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.counts = make(map[string][]int)
+}
+
+// Counts returns a copy of the selections per arm for each dimension value.
+func (b *Breakdown) Counts() map[string][]int {
+	b.Lock()
+	defer b.Unlock()
+
+	counts := make(map[string][]int, len(b.counts))
+	for value, arms := range b.counts {
+		counts[value] = append([]int{}, arms...)
+	}
+
+	return counts
+}
+
+// Shares returns, for each 1 indexed arm, the share of its selections made in
+// each dimension value. A share close to 1 means the arm is concentrated in a
+// single value.
+func (b *Breakdown) Shares() []map[string]float64 {
+	counts := b.Counts()
+	shares := make([]map[string]float64, b.arms)
+	for arm := range shares {
+		total := 0
+		for _, arms := range counts {
+			total += arms[arm]
+		}
+
+		shares[arm] = make(map[string]float64)
+		for value, arms := range counts {
+			if total > 0 && arms[arm] > 0 {
+				shares[arm][value] = float64(arms[arm]) / float64(total)
+			}
+		}
+	}
+
+	return shares
+}
@@ -0,0 +1,35 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestBreakdown(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e.Breakdown.Add("DE", 1)
+	e.Breakdown.Add("DE", 1)
+	e.Breakdown.Add("FR", 1)
+	e.Breakdown.Add("FR", 2)
+
+	shares := e.Breakdown.Shares()
+	if got, expected := shares[0]["DE"], 2.0/3.0; got != expected {
+		t.Fatalf("expected DE share of arm 1 to be %f, got %f", expected, got)
+	}
+
+	if got := shares[1]["FR"]; got != 1.0 {
+		t.Fatalf("expected arm 2 to be concentrated in FR, got %f", got)
+	}
+
+	stats := e.Stats()
+	if got := stats.Arms[0].Breakdown["DE"]; got != 2 {
+		t.Fatalf("expected 2 DE selections of arm 1 in stats, got %d", got)
+	}
+
+	if got := len(stats.Arms[1].Breakdown); got != 1 {
+		t.Fatalf("expected a single dimension value for arm 2, got %d", got)
+	}
+}
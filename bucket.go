@@ -0,0 +1,138 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// Buckets is the number of buckets uids are hashed into. See the bucketing
+// section of the README for the specification implemented by Bucket,
+// BucketRanges and Assign. testdata/bucketing.json has test vectors for
+// implementations in other languages.
+const Buckets = 10000
+
+// Bucket returns the bucket in [0, Buckets) of `uid`. It is the first 8 bytes
+// of the SHA-256 digest of the utf-8 bytes of salt + "." + uid, read as a big
+// endian unsigned integer, modulo Buckets.
+func Bucket(salt, uid string) int {
+	digest := sha256.Sum256([]byte(salt + "." + uid))
+	return int(binary.BigEndian.Uint64(digest[:8]) % Buckets)
+}
+
+// BucketRanges returns the exclusive upper bound bucket of each arm, given the
+// share of traffic of each arm. Shares are summed in order as float64 and the
+// bound of arm i is floor((Buckets * sum(shares[0..i])) / sum(shares) + 0.5),
+// evaluated in float64.
+// The last bound is always Buckets.
+func BucketRanges(shares []float64) []int {
+	total := 0.0
+	for _, share := range shares {
+		total += share
+	}
+
+	ranges := make([]int, len(shares))
+	sum := 0.0
+	for i, share := range shares {
+		sum += share
+		ranges[i] = int(math.Floor(Buckets*sum/total + 0.5))
+	}
+
+	if len(ranges) > 0 {
+		ranges[len(ranges)-1] = Buckets
+	}
+
+	return ranges
+}
+
+// Assign returns the 1 indexed arm of `bucket`, which is the first arm whose
+// upper bound is greater than the bucket.
+func Assign(ranges []int, bucket int) int {
+	for i, bound := range ranges {
+		if bucket < bound {
+			return i + 1
+		}
+	}
+
+	return len(ranges)
+}
+
+// Allocation returns the share of traffic of each variation, in ordinal order.
+// Variations share in the pulls of their strategy arm, divided evenly within
+// a group. Disabled variations get no traffic. An archived experiment sends all
+// traffic to the winner. Without any pulls, traffic is split evenly.
+func (e *Experiment) Allocation() []float64 {
+	shares := make([]float64, len(e.Variations))
+	if winner, ok := e.Archived(); ok {
+		shares[winner.Ordinal-1] = 1
+		return shares
+	}
+
+	c := e.Strategy.Snapshot()
+	total := 0
+	for _, count := range c.counts {
+		total += count
+	}
+
+	enabled := 0
+	for i, v := range e.Variations {
+		if e.Disabled(v.Ordinal) {
+			continue
+		}
+
+		enabled++
+		shares[i] = 1
+		if arm := e.Arm(v.Ordinal) - 1; total > 0 && arm < c.arms {
+			members := 1
+			if e.members != nil {
+				members = len(e.members[arm])
+			}
+
+			shares[i] = float64(c.counts[arm]) / float64(total) / float64(members)
+		}
+	}
+
+	// all arms are disabled. Select serves the preferred variation.
+	if enabled == 0 {
+		shares[e.PreferredOrdinal-1] = 1
+		return shares
+	}
+
+	// every enabled arm was starved.
+	sum := 0.0
+	for _, share := range shares {
+		sum += share
+	}
+
+	if sum == 0 {
+		for i, v := range e.Variations {
+			if !e.Disabled(v.Ordinal) {
+				shares[i] = 1
+			}
+		}
+	}
+
+	return shares
+}
+
+// Salted returns the salt used to bucket uids of the experiment, which
+// defaults to its name.
+func (e *Experiment) Salted() string {
+	if e.Salt != "" {
+		return e.Salt
+	}
+
+	return e.Name
+}
+
+// SelectSticky returns the variation of `uid` under the current allocation.
+// Clients implementing the bucketing specification get the same variation
+// from the ranges published by the API, without a round trip.
+func (e *Experiment) SelectSticky(uid string) Variation {
+	ordinal := Assign(BucketRanges(e.Allocation()), Bucket(e.Salted(), uid))
+	v, _ := e.GetVariation(ordinal)
+	return v
+}
@@ -0,0 +1,80 @@
+package bandit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestBucketingVectors(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/bucketing.json")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var vectors struct {
+		Buckets []struct {
+			Salt   string `json:"salt"`
+			UID    string `json:"uid"`
+			Bucket int    `json:"bucket"`
+		} `json:"buckets"`
+		Ranges []struct {
+			Shares []float64 `json:"shares"`
+			Ranges []int     `json:"ranges"`
+		} `json:"ranges"`
+		Assignments []struct {
+			Salt   string    `json:"salt"`
+			UID    string    `json:"uid"`
+			Shares []float64 `json:"shares"`
+			Arm    int       `json:"arm"`
+		} `json:"assignments"`
+	}
+
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for _, v := range vectors.Buckets {
+		if got := Bucket(v.Salt, v.UID); got != v.Bucket {
+			t.Fatalf("expected bucket %d for %q %q, got %d", v.Bucket, v.Salt, v.UID, got)
+		}
+	}
+
+	for _, v := range vectors.Ranges {
+		got := BucketRanges(v.Shares)
+		for i := range v.Ranges {
+			if got[i] != v.Ranges[i] {
+				t.Fatalf("expected ranges %v for %v, got %v", v.Ranges, v.Shares, got)
+			}
+		}
+	}
+
+	for _, v := range vectors.Assignments {
+		if got := Assign(BucketRanges(v.Shares), Bucket(v.Salt, v.UID)); got != v.Arm {
+			t.Fatalf("expected arm %d for %q, got %d", v.Arm, v.UID, got)
+		}
+	}
+}
+
+func TestSelectSticky(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := e.Allocation(); got[0] != 1 || got[1] != 1 {
+		t.Fatalf("expected even allocation without pulls, got %v", got)
+	}
+
+	first := e.SelectSticky("user-42")
+	for i := 0; i < 10; i++ {
+		if got := e.SelectSticky("user-42"); got.Ordinal != first.Ordinal {
+			t.Fatalf("expected sticky variation %d, got %d", first.Ordinal, got.Ordinal)
+		}
+	}
+
+	e.Disable(first.Ordinal)
+	if got := e.SelectSticky("user-42"); got.Ordinal == first.Ordinal {
+		t.Fatalf("expected disabled variation %d not to be assigned", first.Ordinal)
+	}
+}
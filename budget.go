@@ -0,0 +1,222 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+)
+
+// Refuser is implemented by strategies which may have no arm to select, e.g.
+// once their budget is spent. Experiments then serve their fallback variation.
+type Refuser interface {
+	SelectAffordableArm() (int, bool)
+}
+
+// selectAffordableArm selects an arm of `s`, which may refuse if it is a
+// Refuser. Other strategies always select.
+func selectAffordableArm(s Strategy) (int, bool) {
+	if r, ok := s.(Refuser); ok {
+		return r.SelectAffordableArm()
+	}
+
+	return s.SelectArm(), true
+}
+
+// affordablePuller is implemented by strategies which can refuse pulls made
+// on their behalf, such as uniform draws of a floor, once they cannot pay for
+// them. See BudgetedUCB.pullAffordable.
+type affordablePuller interface {
+	pullAffordable(arm int) bool
+}
+
+// pullAffordable counts a selection of 1 indexed `arm` which `s` did not make
+// itself, like pull, and returns false without counting it if `s` cannot pay
+// for it.
+func pullAffordable(s Strategy, arm int) bool {
+	if p, ok := s.(affordablePuller); ok {
+		return p.pullAffordable(arm)
+	}
+
+	pull(s, arm)
+	return true
+}
+
+// NewBudgetedUCB constructs a strategy for pulls which cost money, such as
+// paid ad impressions, see Ding et al., 2013, "Multi-Armed Bandit with Budget
+// Constraint and Variable Costs". Each pull of arm i costs `costs[i]`, and all
+// pulls together may cost at most `budget`. Rewards must be in [0, 1].
+func NewBudgetedUCB(costs []float64, budget float64) (*BudgetedUCB, error) {
+	if len(costs) == 0 {
+		return &BudgetedUCB{}, fmt.Errorf("need at least 1 arm")
+	}
+
+	for _, cost := range costs {
+		if !(cost > 0) || math.IsInf(cost, 0) {
+			return &BudgetedUCB{}, fmt.Errorf("cost %f not in (0, ∞)", cost)
+		}
+	}
+
+	if !(budget >= 0) || math.IsInf(budget, 0) {
+		return &BudgetedUCB{}, fmt.Errorf("budget %f not in [0, ∞)", budget)
+	}
+
+	return &BudgetedUCB{
+		Counters: NewCounters(len(costs)),
+		prices:   append([]float64{}, costs...),
+		budget:   budget,
+	}, nil
+}
+
+// BudgetedUCB selects the affordable arm with the highest UCB1 bound of its
+// reward per cost. The spent budget is the cost of all pulls counted, so it is
+// kept in snapshots, refunded for released draws and reset with the counters.
+type BudgetedUCB struct {
+	Counters
+	prices []float64 // cost per pull. Counters.costs are costs in reward units.
+	budget float64
+}
+
+// SelectAffordableArm returns the 1 indexed arm to be tried next, and false
+// if no arm costs less than the remaining budget.
+func (b *BudgetedUCB) SelectAffordableArm() (int, bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.selectArm()
+}
+
+// SelectArm returns the 1 indexed arm to be tried next. Once the budget is
+// spent, it returns the cheapest arm, which overspends. Use
+// SelectAffordableArm to be refused instead. Experiments do, and so do all
+// wrappers of this package, such as batching, snapshots, floors and
+// prefetching.
+func (b *BudgetedUCB) SelectArm() int {
+	b.Lock()
+	defer b.Unlock()
+
+	if arm, ok := b.selectArm(); ok {
+		return arm
+	}
+
+	cheapest := 0
+	for i, price := range b.prices {
+		if price < b.prices[cheapest] {
+			cheapest = i
+		}
+	}
+
+	b.counts[cheapest]++
+	return cheapest + 1
+}
+
+// selectArm pulls the affordable arm with the highest index, without locking.
+func (b *BudgetedUCB) selectArm() (int, bool) {
+	indices, ok := b.indices()
+	if !ok {
+		return 0, false
+	}
+
+	var arm int
+	if b.ties == TiesOrdinal {
+		_, best := bmath.Max(indices)
+		arm = best[0]
+	} else {
+		arm = bmath.ArgMax(indices, b.rand)
+	}
+
+	b.counts[arm]++
+	return arm + 1, true
+}
+
+// indices returns the UCB1 bound of each arm divided by its cost. Arms which
+// were never pulled are best, and arms costing more than the remaining budget
+// are -∞. Returns false if no arm is affordable.
+func (b *BudgetedUCB) indices() ([]float64, bool) {
+	total := 0
+	for _, count := range b.counts {
+		total += count
+	}
+
+	remaining, affordable := b.remaining(), false
+	logTotal := 2 * math.Log(float64(total))
+	indices := append([]float64{}, b.estimates()...)
+	for i, count := range b.counts {
+		switch {
+		case b.prices[i] > remaining:
+			indices[i] = math.Inf(-1)
+			continue
+		case count == 0:
+			indices[i] = math.Inf(1)
+		default:
+			indices[i] = (indices[i] + math.Sqrt(logTotal/float64(count))) / b.prices[i]
+		}
+
+		affordable = true
+	}
+
+	return indices, affordable
+}
+
+// pullAffordable counts a pull of 1 indexed `arm` if it costs no more than the
+// remaining budget.
+func (b *BudgetedUCB) pullAffordable(arm int) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.prices[arm-1] > b.remaining() {
+		return false
+	}
+
+	b.counts[arm-1]++
+	return true
+}
+
+// remaining returns the budget left after all counted pulls, without locking.
+func (b *BudgetedUCB) remaining() float64 {
+	spent := 0.0
+	for i, count := range b.counts {
+		spent += float64(count) * b.prices[i]
+	}
+
+	return b.budget - spent
+}
+
+// Remaining returns the budget left.
+func (b *BudgetedUCB) Remaining() float64 {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.remaining()
+}
+
+// Distribution returns the probability of selecting each arm. It is all 0
+// once no arm is affordable.
+func (b *BudgetedUCB) Distribution() []float64 {
+	b.Lock()
+	defer b.Unlock()
+
+	distribution := make([]float64, b.arms)
+	indices, ok := b.indices()
+	if !ok {
+		return distribution
+	}
+
+	_, best := bmath.Max(indices)
+	if b.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	for _, arm := range best {
+		distribution[arm] = 1 / float64(len(best))
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (b *BudgetedUCB) String() string {
+	return fmt.Sprintf("BudgetedUCB(budget=%.2f)", b.budget)
+}
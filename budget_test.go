@@ -0,0 +1,154 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetedUCB(t *testing.T) {
+	s, err := NewBudgetedUCB([]float64{1, 2}, 5)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s.ties = TiesOrdinal
+	var arms []int
+	for {
+		arm, ok := s.SelectAffordableArm()
+		if !ok {
+			break
+		}
+
+		arms = append(arms, arm)
+		s.Update(arm, 1)
+	}
+
+	if s.Remaining() < 0 || s.Remaining() >= 1 {
+		t.Fatalf("expected less than the cheapest cost left, got %.2f", s.Remaining())
+	}
+
+	if arm := s.SelectArm(); arm != 1 {
+		t.Fatalf("expected the cheapest arm once the budget is spent, got %d", arm)
+	}
+
+	if d := s.Distribution(); d[0] != 0 || d[1] != 0 {
+		t.Fatalf("expected no arm to be selected, got %v", d)
+	}
+
+	s.Reset()
+	if s.Remaining() != 5 {
+		t.Fatalf("expected reset to restore the budget, got %.2f", s.Remaining())
+	}
+
+	if _, err := NewBudgetedUCB([]float64{1, 0}, 5); err == nil {
+		t.Fatalf("expected cost of 0 to fail")
+	}
+
+	if _, err := NewBudgetedUCB([]float64{1}, -1); err == nil {
+		t.Fatalf("expected negative budget to fail")
+	}
+}
+
+func TestBudgetedUCBRewardPerCost(t *testing.T) {
+	s, err := NewBudgetedUCB([]float64{1, 4}, 1000)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the expensive arm earns more per pull, but less per cost.
+	counts := make([]int, 2)
+	for i := 0; i < 300; i++ {
+		arm := s.SelectArm()
+		counts[arm-1]++
+		s.Update(arm, []float64{0.5, 0.9}[arm-1])
+	}
+
+	if counts[0] <= counts[1] {
+		t.Fatalf("expected the cheaper arm to be preferred, got %v", counts)
+	}
+}
+
+func TestSelectBudgetSpent(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	s, err := NewBudgetedUCB([]float64{1, 1}, 1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.Strategy = s
+	e.Select()
+	for i := 0; i < 10; i++ {
+		if got := e.Select().Ordinal; got != e.PreferredOrdinal {
+			t.Fatalf("expected control once the budget is spent, got %d", got)
+		}
+	}
+
+	// the one affordable pull was served, the fallbacks were not pulls.
+	if counts := s.Snapshot().counts; counts[0]+counts[1] != 1 {
+		t.Fatalf("expected 1 pull, got %v", counts)
+	}
+
+	// released draws are refunded.
+	s.Reset()
+	e.Disable(1)
+	e.Disable(2)
+	e.Select()
+	if s.Remaining() != 1 {
+		t.Fatalf("expected rejected draws to be refunded, got %.2f", s.Remaining())
+	}
+}
+
+func TestSelectBudgetWrapped(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	s, err := NewBudgetedUCB([]float64{1, 1}, 2)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.Strategy, err = NewBatched(s, 10, time.Hour)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer closeStrategy(e.Strategy)
+	for i := 0; i < 5; i++ {
+		e.Select()
+	}
+
+	if s.Remaining() != 0 {
+		t.Fatalf("expected wrapped strategy to keep to its budget, got %.2f", s.Remaining())
+	}
+
+}
+
+func TestSelectBudgetFloored(t *testing.T) {
+	s, err := NewBudgetedUCB([]float64{1, 1}, 3)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// uniform draws the budget cannot pay for are refused as well
+	floored, err := NewFloored(s, 2, 0.5)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	selected := 0
+	for i := 0; i < 10; i++ {
+		if _, ok := floored.(Refuser).SelectAffordableArm(); ok {
+			selected++
+		}
+	}
+
+	if selected != 3 || s.Remaining() != 0 {
+		t.Fatalf("expected 3 affordable selections, got %d with %.2f remaining", selected, s.Remaining())
+	}
+}
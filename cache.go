@@ -0,0 +1,91 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync"
+	"time"
+)
+
+// NewSelectionCache returns a cache which remembers selections for `ttl`. Use
+// a few seconds, so that bursts of requests from a single page load get the
+// same variation and don't each pay for selection and logging.
+func NewSelectionCache(ttl time.Duration) *SelectionCache {
+	return &SelectionCache{
+		ttl:     ttl,
+		entries: make(map[string]map[string]cachedSelection),
+		swept:   time.Now(),
+	}
+}
+
+// SelectionCache maps uid and experiment name to a recent selection.
+type SelectionCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	entries map[string]map[string]cachedSelection // uid -> name -> selection
+	swept   time.Time                             // last removal of expired entries
+}
+
+// cachedSelection is a variation and its timestamped tag.
+type cachedSelection struct {
+	variation Variation
+	tag       string
+	expires   time.Time
+}
+
+// Get returns the cached selection of user `uid` in experiment `name`.
+func (c *SelectionCache) Get(name, uid string) (Variation, string, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	entry, ok := c.entries[uid][name]
+	if !ok || time.Now().After(entry.expires) {
+		return Variation{}, "", false
+	}
+
+	return entry.variation, entry.tag, true
+}
+
+// Put caches a selection of user `uid` in experiment `name`.
+func (c *SelectionCache) Put(name, uid string, v Variation, tag string) {
+	c.Lock()
+	defer c.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.swept) > c.ttl {
+		for key, selections := range c.entries {
+			for name, entry := range selections {
+				if now.After(entry.expires) {
+					delete(selections, name)
+				}
+			}
+
+			if len(selections) == 0 {
+				delete(c.entries, key)
+			}
+		}
+
+		c.swept = now
+	}
+
+	if _, ok := c.entries[uid]; !ok {
+		c.entries[uid] = make(map[string]cachedSelection)
+	}
+
+	c.entries[uid][name] = cachedSelection{
+		variation: v,
+		tag:       tag,
+		expires:   now.Add(c.ttl),
+	}
+}
+
+// Erase removes all cached selections of user `uid`.
+func (c *SelectionCache) Erase(uid string) int {
+	c.Lock()
+	defer c.Unlock()
+
+	removed := len(c.entries[uid])
+	delete(c.entries, uid)
+	return removed
+}
@@ -0,0 +1,41 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectionCache(t *testing.T) {
+	c := NewSelectionCache(time.Hour)
+	v := Variation{Ordinal: 2, Tag: "shape-20130822:2"}
+	c.Put("shape-20130822", "11", v, "shape-20130822:2:1379257984")
+
+	got, tag, ok := c.Get("shape-20130822", "11")
+	if !ok {
+		t.Fatalf("expected cached selection")
+	}
+
+	if got != v || tag != "shape-20130822:2:1379257984" {
+		t.Fatalf("expected %v, got %v with tag %s", v, got, tag)
+	}
+
+	if _, _, ok := c.Get("shape-20130822", "12"); ok {
+		t.Fatalf("expected no selection for another user")
+	}
+
+	if got := Erase("11", c); got != 1 {
+		t.Fatalf("expected 1 erased selection, got %d", got)
+	}
+
+	if _, _, ok := c.Get("shape-20130822", "11"); ok {
+		t.Fatalf("expected erased selection to be gone")
+	}
+}
+
+func TestSelectionCacheExpiry(t *testing.T) {
+	c := NewSelectionCache(-time.Second)
+	c.Put("shape-20130822", "11", Variation{Ordinal: 1}, "")
+	if _, _, ok := c.Get("shape-20130822", "11"); ok {
+		t.Fatalf("expected expired selection")
+	}
+}
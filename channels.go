@@ -0,0 +1,110 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventGuardrail is emitted when an arm breaches a guardrail.
+const EventGuardrail = "guardrail"
+
+// Guardrail bounds the mean of a secondary reward metric, such as an error
+// rate. The bound is checked once an arm has `MinSamples` observations of the
+// metric. Breaching arms are reported and, with `Disable`, disabled.
+type Guardrail struct {
+	Metric     string
+	Max        float64
+	MinSamples int
+	Disable    bool
+}
+
+// NewChannels returns reward channels for the experiment's guardrails. Events
+// are sent to `n`, which may be nil.
+func NewChannels(e *Experiment, n Notifier) *Channels {
+	return &Channels{
+		experiment: e,
+		notifier:   n,
+		moments:    make(map[string][]moments),
+		breached:   make(map[string][]bool),
+	}
+}
+
+// Channels track secondary reward metrics per arm next to the primary reward,
+// which is still passed to the strategy with Update. Selection only uses the
+// primary reward; secondary metrics govern the experiment via guardrails.
+type Channels struct {
+	sync.Mutex
+	experiment *Experiment
+	notifier   Notifier
+	moments    map[string][]moments // metric -> per arm moments
+	breached   map[string][]bool    // metric -> per arm breach
+}
+
+// Observe records `value` of `metric` for 1 indexed `arm`, and checks the
+// metric's guardrails.
+func (c *Channels) Observe(arm int, metric string, value float64) {
+	c.Lock()
+	if _, ok := c.moments[metric]; !ok {
+		c.moments[metric] = make([]moments, len(c.experiment.Variations))
+		c.breached[metric] = make([]bool, len(c.experiment.Variations))
+	}
+
+	m := &c.moments[metric][arm-1]
+	m.add(value)
+
+	var breaches []Guardrail
+	for _, g := range c.experiment.Guardrails {
+		if g.Metric == metric && m.n >= g.MinSamples && m.mean > g.Max && !c.breached[metric][arm-1] {
+			breaches = append(breaches, g)
+		}
+	}
+
+	if len(breaches) > 0 {
+		c.breached[metric][arm-1] = true
+	}
+
+	mean := m.mean
+	c.Unlock()
+
+	for _, g := range breaches {
+		if g.Disable {
+			c.experiment.Disable(arm)
+		}
+
+		if c.notifier != nil {
+			c.notifier.Notify(Event{
+				Time:       time.Now(),
+				Kind:       EventGuardrail,
+				Experiment: c.experiment.Name,
+				Arm:        arm,
+				Message:    fmt.Sprintf("%s %f > %f", metric, mean, g.Max),
+			})
+		}
+	}
+}
+
+// reset forgets all observations and breaches.
+func (c *Channels) reset() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.moments = make(map[string][]moments)
+	c.breached = make(map[string][]bool)
+}
+
+// Means returns the mean of `metric` for each arm.
+func (c *Channels) Means(metric string) []float64 {
+	c.Lock()
+	defer c.Unlock()
+
+	means := make([]float64, len(c.experiment.Variations))
+	for i, m := range c.moments[metric] {
+		means[i] = m.mean
+	}
+
+	return means
+}
@@ -0,0 +1,89 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestChannelsGuardrail(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e.Guardrails = []Guardrail{{Metric: "error_rate", Max: 0.02, MinSamples: 10, Disable: true}}
+
+	var events []Event
+	c := NewChannels(e, NotifierFunc(func(e Event) { events = append(events, e) }))
+	for i := 0; i < 100; i++ {
+		c.Observe(1, "error_rate", 0)
+		c.Observe(2, "error_rate", float64(i%10/9)) // 10% errors
+	}
+
+	if len(events) != 1 || events[0].Arm != 2 || events[0].Kind != EventGuardrail {
+		t.Fatalf("expected a single guardrail event for arm 2, got %v", events)
+	}
+
+	if !e.Disabled(2) || e.Disabled(1) {
+		t.Fatalf("expected only arm 2 to be disabled")
+	}
+
+	for i := 0; i < 100; i++ {
+		if got := e.Select().Ordinal; got != 1 {
+			t.Fatalf("expected disabled arm never to be served, got %d", got)
+		}
+	}
+
+	if got := c.Means("error_rate")[1]; got != 0.1 {
+		t.Fatalf("expected error rate of 0.1 on arm 2, got %f", got)
+	}
+}
+
+func TestExperimentGuardrails(t *testing.T) {
+	config := []byte(`[{"experiment_name": "shape-20130822", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1,
+		"guardrails": [{"metric": "error_rate", "max": 0.02, "min-samples": 10, "disable": true}],
+		"variations": [
+		{"ordinal": 1, "url": "http://localhost/circle"},
+		{"ordinal": 2, "url": "http://localhost/square"}]}]`)
+
+	es, err := NewExperiments(NewBytesOpener(config))
+	if err != nil {
+		t.Fatalf("while reading config: %s", err.Error())
+	}
+
+	e, _ := es.Get("shape-20130822")
+	for i := 0; i < 10; i++ {
+		e.Observe(1, "error_rate", 0)
+		e.Observe(2, "error_rate", 1)
+	}
+
+	if !e.Disabled(2) || e.Disabled(1) {
+		t.Fatalf("expected configured guardrail to disable arm 2")
+	}
+
+	// restarting forgets the breach, so the guardrail is checked again
+	if err := e.Restart(); err != nil {
+		t.Fatalf("could not restart: %s", err.Error())
+	}
+
+	for i := 0; i < 10; i++ {
+		e.Observe(2, "error_rate", 1)
+	}
+
+	if !e.Disabled(2) {
+		t.Fatalf("expected guardrail to disable arm 2 again after a restart")
+	}
+}
+
+func TestSelectAllDisabled(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e.Disable(1)
+	e.Disable(2)
+	if got := e.Select().Ordinal; got != e.PreferredOrdinal {
+		t.Fatalf("expected control when all arms are disabled, got %d", got)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// NewClickHouseSink returns a sink inserting events into `table` through the
+// ClickHouse HTTP interface at `endpoint`, e.g. http://localhost:8123/. The
+// table is created on first use if it does not exist.
+func NewClickHouseSink(endpoint, table string) Sink {
+	return &clickHouseSink{
+		endpoint: endpoint,
+		table:    table,
+	}
+}
+
+// NewCompressedClickHouseSink returns a ClickHouse sink which compresses
+// inserts with the codec `compression`, sent as the Content-Encoding.
+// ClickHouse accepts gzip and zstd.
+func NewCompressedClickHouseSink(endpoint, table, compression string) (Sink, error) {
+	if _, _, err := getCodec(compression); err != nil {
+		return &clickHouseSink{}, err
+	}
+
+	return &clickHouseSink{
+		endpoint:    endpoint,
+		table:       table,
+		compression: compression,
+	}, nil
+}
+
+// clickHouseSink inserts rows in JSONEachRow format.
+type clickHouseSink struct {
+	sync.Mutex
+	endpoint    string
+	table       string
+	compression string // codec of inserts. blank does not compress.
+	created     bool
+}
+
+// clickHouseRow is the schema of the events table. Weights are only sent for
+// sampled selections, so tables created before sampling keep working until it
+// is enabled.
+type clickHouseRow struct {
+	Time   int64   `json:"time"`
+	Kind   string  `json:"kind"`
+	Tag    string  `json:"tag"`
+	Reward float64 `json:"reward"`
+	Weight int     `json:"weight,omitempty"`
+}
+
+// Insert creates the table if necessary and inserts all events.
+func (c *clickHouseSink) Insert(events []LogEvent) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.created {
+		schema := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s "+
+			"(time DateTime, kind String, tag String, reward Float64, weight UInt32 DEFAULT 1) "+
+			"ENGINE = MergeTree ORDER BY time", c.table)
+
+		if err := c.query(schema, nil); err != nil {
+			return fmt.Errorf("could not create table: %s", err.Error())
+		}
+
+		c.created = true
+	}
+
+	body := new(bytes.Buffer)
+	encoder := json.NewEncoder(body)
+	for _, event := range events {
+		if err := encoder.Encode(clickHouseRow{
+			Time:   event.Time.Unix(),
+			Kind:   event.Kind,
+			Tag:    event.Tag,
+			Reward: event.Reward,
+			Weight: event.Weight,
+		}); err != nil {
+			return fmt.Errorf("could not encode event: %s", err.Error())
+		}
+	}
+
+	payload, encoding := body.Bytes(), ""
+	if c.compression != "" && c.compression != "none" {
+		compressed := new(bytes.Buffer)
+		w, err := NewCompressedWriter(compressed, c.compression)
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(payload); err != nil {
+			return fmt.Errorf("could not compress events: %s", err.Error())
+		}
+
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("could not compress events: %s", err.Error())
+		}
+
+		payload, encoding = compressed.Bytes(), c.compression
+	}
+
+	return c.post(fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", c.table), payload, encoding)
+}
+
+// query posts a query with an optional body to the HTTP interface.
+func (c *clickHouseSink) query(q string, body []byte) error {
+	return c.post(q, body, "")
+}
+
+// post posts a query with a body in the content `encoding`, which may be
+// blank.
+func (c *clickHouseSink) post(q string, body []byte, encoding string) error {
+	req, err := http.NewRequest("POST", c.endpoint+"?query="+url.QueryEscape(q), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not build request: %s", err.Error())
+	}
+
+	req.Header.Set("Content-Type", "text/plain")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("http POST failed: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("http POST not 200: %d %s", resp.StatusCode, msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// builder makes the configured strategy of an experiment with fresh state.
+type builder func() (Strategy, error)
+
+// Clone returns a copy of the experiment named `name`, with the same
+// configuration but fresh state. Variations are tagged with the new name.
+// Experiments which get their state from a snapshot cannot be cloned.
+func (e *Experiment) Clone(name string) (*Experiment, error) {
+	if e.build == nil {
+		return &Experiment{}, fmt.Errorf("%s cannot be cloned", e.Name)
+	}
+
+	strategy, err := e.build()
+	if err != nil {
+		return &Experiment{}, fmt.Errorf("could not clone %s: %s", e.Name, err.Error())
+	}
+
+	samples := sampleSize
+	if e.Samples != nil {
+		samples = e.Samples.size
+	}
+
+	clone := Experiment{
+		Name:             name,
+		Strategy:         strategy,
+		PreferredOrdinal: e.PreferredOrdinal,
+		PreferredLocales: make(map[string]int),
+		Retention:        e.Retention,
+		Breakdown:        NewBreakdown(len(e.Variations)),
+		History:          NewHistory(historySize, 0),
+		Latencies:        NewLatencies(),
+		Samples:          NewSamples(len(e.Variations), samples),
+		Freshness:        NewFreshness(len(e.Variations)),
+		Seeds:            make(map[int]int),
+		SeedPulls:        e.SeedPulls,
+		Features:         make(map[int][]float64),
+		ClientURLs:       make(map[int]URLs),
+		Guardrails:       append([]Guardrail{}, e.Guardrails...),
+		Rules:            append([]Rule{}, e.Rules...),
+		MinExposure:      e.MinExposure,
+		MaxRewardGap:     e.MaxRewardGap,
+		Salt:             e.Salt,
+		CostBudget:       e.CostBudget,
+		RewardUnit:       e.RewardUnit,
+		spent:            &spending{},
+		disabled:         newArmSet(),
+		changes:          newChanges(),
+		annotations:      &annotations{},
+		arms:             e.arms,
+		members:          e.members,
+		build:            e.build,
+		config:           renameConfig(e.config, name),
+	}
+
+	for _, v := range e.Variations {
+		v.Tag = fmt.Sprintf("%s:%d", name, v.Ordinal)
+		clone.Variations = append(clone.Variations, v)
+	}
+
+	for locale, ordinal := range e.PreferredLocales {
+		clone.PreferredLocales[locale] = ordinal
+	}
+
+	for ordinal, from := range e.Seeds {
+		clone.Seeds[ordinal] = from
+	}
+
+	for ordinal, features := range e.Features {
+		clone.Features[ordinal] = append([]float64{}, features...)
+	}
+
+	for ordinal, urls := range e.ClientURLs {
+		clone.ClientURLs[ordinal] = make(URLs)
+		for client, url := range urls {
+			clone.ClientURLs[ordinal][client] = url
+		}
+	}
+
+	if err := clone.prioritize(); err != nil {
+		closeStrategy(strategy)
+		return &Experiment{}, fmt.Errorf("could not clone %s: %s", e.Name, err.Error())
+	}
+
+	if err := clone.charge(); err != nil {
+		closeStrategy(strategy)
+		return &Experiment{}, fmt.Errorf("could not clone %s: %s", e.Name, err.Error())
+	}
+
+	clone.startRules()
+	return &clone, nil
+}
+
+// renameConfig returns the experiment config `config` with the name `name`,
+// or nil if there is no config to rename.
+func renameConfig(config json.RawMessage, name string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(name)
+	if err != nil {
+		return nil
+	}
+
+	fields["experiment_name"] = encoded
+	renamed, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+
+	return renamed
+}
+
+// Restart keeps the configuration of the experiment but resets its state:
+// strategy counters, breakdown, history, reward samples, freshness, guardrail
+// metrics, disabled arms, promotion and pausing.
+// Selections made before the restart are not rewarded anymore. The lifecycle
+// is kept.
+func (e *Experiment) Restart() error {
+	fresh := NewCounters(e.Arms())
+	if err := e.Strategy.Init(&fresh); err != nil {
+		return fmt.Errorf("could not restart %s: %s", e.Name, err.Error())
+	}
+
+	e.disabled.clear()
+	atomic.StoreInt32(&e.winner, 0)
+	atomic.StoreInt32(&e.paused, 0)
+	if e.Breakdown != nil {
+		e.Breakdown.reset()
+	}
+
+	if e.History != nil {
+		e.History.reset()
+	}
+
+	if e.Samples != nil {
+		e.Samples.reset()
+	}
+
+	if e.Freshness != nil {
+		e.Freshness.reset()
+	}
+
+	if e.channels != nil {
+		e.channels.reset()
+	}
+
+	if e.spent != nil {
+		e.spent.Lock()
+		e.spent.total, e.spent.selections = 0, 0
+		e.spent.Unlock()
+	}
+
+	e.changes.record(LifecycleRestarted, 0)
+	return nil
+}
+
+// Clone adds a copy of experiment `name` named `newName`, see
+// Experiment.Clone. Experiments must not be served while cloning.
+func (e *Experiments) Clone(name, newName string) (*Experiment, error) {
+	e.Lock()
+	defer e.Unlock()
+
+	experiment, ok := e.index[name]
+	if !ok {
+		return &Experiment{}, fmt.Errorf("could not find '%s' experiment", name)
+	}
+
+	if _, ok := e.index[newName]; ok {
+		return &Experiment{}, fmt.Errorf("experiment '%s' already exists", newName)
+	}
+
+	if newName == "" || strings.Contains(newName, ":") {
+		return &Experiment{}, fmt.Errorf("invalid experiment name '%s'", newName)
+	}
+
+	clone, err := experiment.Clone(newName)
+	if err != nil {
+		return &Experiment{}, err
+	}
+
+	e.index[newName] = clone
+	return clone, nil
+}
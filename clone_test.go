@@ -0,0 +1,80 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	e.Update(1, 1)
+
+	clone, err := es.Clone("shape-20130822", "shape-v2")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got, ok := es.index["shape-v2"]; !ok || got != clone {
+		t.Fatalf("expected clone to be added to experiments")
+	}
+
+	if got := clone.Strategy.Snapshot(); got.values[0] != 0 {
+		t.Fatalf("expected clone to start with fresh state, got %v", got.values)
+	}
+
+	if got := e.Strategy.Snapshot(); got.values[0] != 1 {
+		t.Fatalf("expected original state to be kept, got %v", got.values)
+	}
+
+	if len(clone.Variations) != len(e.Variations) || clone.Variations[0].Tag != "shape-v2:1" {
+		t.Fatalf("expected variations tagged with new name, got %v", clone.Variations)
+	}
+
+	if clone.Strategy == e.Strategy {
+		t.Fatalf("expected clone to have its own strategy")
+	}
+
+	if _, err := es.Clone("shape-20130822", "shape-v2"); err == nil {
+		t.Fatalf("expected existing name to be rejected")
+	}
+
+	if _, err := es.Clone("nonexisting", "shape-v3"); err == nil {
+		t.Fatalf("expected unknown experiment to be rejected")
+	}
+}
+
+func TestRestart(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.Update(1, 1)
+	e.SelectIn("de")
+	e.Disable(2)
+	e.Archive(1)
+
+	if err := e.Restart(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := e.Strategy.Snapshot(); got.values[0] != 0 || got.counts[0] != 0 {
+		t.Fatalf("expected fresh strategy state, got %v %v", got.counts, got.values)
+	}
+
+	if _, ok := e.Archived(); ok {
+		t.Fatalf("expected restart to undo archival")
+	}
+
+	if e.Disabled(2) {
+		t.Fatalf("expected restart to enable all arms")
+	}
+
+	if got := e.Breakdown.Counts(); len(got) != 0 {
+		t.Fatalf("expected empty breakdown, got %v", got)
+	}
+}
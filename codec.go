@@ -0,0 +1,138 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// StateCodec serializes the state of experiments in a store.
+type StateCodec interface {
+	Marshal(s State) ([]byte, error)
+	Unmarshal(data []byte, s *State) error
+}
+
+// stateCodecs are the registered state codecs by name. json is human
+// readable. gob and protobuf are compact; see protobufCodec for its schema.
+var stateCodecs = map[string]StateCodec{
+	"json":     jsonCodec{},
+	"gob":      gobCodec{},
+	"protobuf": protobufCodec{},
+}
+
+var stateCodecsMutex sync.RWMutex
+
+// RegisterStateCodec makes state codec `c` available under `name`.
+func RegisterStateCodec(name string, c StateCodec) {
+	stateCodecsMutex.Lock()
+	defer stateCodecsMutex.Unlock()
+
+	stateCodecs[name] = c
+}
+
+// getStateCodec returns the state codec `name`. The blank name is json.
+func getStateCodec(name string) (StateCodec, error) {
+	if name == "" {
+		name = "json"
+	}
+
+	stateCodecsMutex.RLock()
+	defer stateCodecsMutex.RUnlock()
+
+	c, ok := stateCodecs[name]
+	if !ok {
+		return jsonCodec{}, fmt.Errorf("unknown state codec '%s'", name)
+	}
+
+	return c, nil
+}
+
+// NewCodedStore returns `s` serializing state with the codec `name`, for
+// example "protobuf". Existing state must be migrated when switching codecs,
+// since it is not readable by other codecs.
+func NewCodedStore(s Store, name string) (Store, error) {
+	c, err := getStateCodec(name)
+	if err != nil {
+		return &codedStore{}, err
+	}
+
+	return &codedStore{Store: s, codec: c}, nil
+}
+
+// coder is implemented by stores which serialize state with a codec other
+// than json.
+type coder interface {
+	stateCodec() StateCodec
+}
+
+// codec returns the state codec of `s`, which is json unless `s` is a coder.
+func codec(s Store) StateCodec {
+	if c, ok := s.(coder); ok {
+		return c.stateCodec()
+	}
+
+	return jsonCodec{}
+}
+
+// codedStore wraps a store with a codec.
+type codedStore struct {
+	Store
+	codec StateCodec
+}
+
+// stateCodec returns the codec of the store.
+func (c *codedStore) stateCodec() StateCodec {
+	return c.codec
+}
+
+// PutAll delegates to the wrapped store, putting keys one by one if it is not
+// a Batcher.
+func (c *codedStore) PutAll(values map[string][]byte) error {
+	if b, ok := c.Store.(Batcher); ok {
+		return b.PutAll(values)
+	}
+
+	for key, value := range values {
+		if err := c.Put(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jsonCodec encodes state as json.
+type jsonCodec struct{}
+
+// Marshal encodes `s` as json.
+func (jsonCodec) Marshal(s State) ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// Unmarshal decodes json into `s`.
+func (jsonCodec) Unmarshal(data []byte, s *State) error {
+	return json.Unmarshal(data, s)
+}
+
+// gobCodec encodes state as a gob stream.
+type gobCodec struct{}
+
+// Marshal encodes `s` as a gob stream.
+func (gobCodec) Marshal(s State) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		return []byte{}, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a gob stream into `s`.
+func (gobCodec) Unmarshal(data []byte, s *State) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(s)
+}
@@ -0,0 +1,87 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCodecs(t *testing.T) {
+	state := State{
+		Tags:   []string{"shape-20130822:1", "shape-20130822:2"},
+		URLs:   []string{"http://localhost:8080/widget?shape=circle", ""},
+		Counts: []int{10, 300},
+		Values: []float64{0.25, -1.5},
+		Annotations: []Annotation{
+			{Time: time.Unix(1378823906, 5).UTC(), Tag: "shape-20130822:1", Text: "new cdn"},
+			{Time: time.Unix(1378823907, 0).UTC(), Text: "release"},
+		},
+		Revision: 310,
+		Strategy: []byte(`{"strategy": "UCBTuned", "state": [0.25, 4]}`),
+	}
+
+	for _, name := range []string{"json", "gob", "protobuf"} {
+		c, err := getStateCodec(name)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		data, err := c.Marshal(state)
+		if err != nil {
+			t.Fatalf("could not marshal with %s: %s", name, err.Error())
+		}
+
+		var decoded State
+		if err := c.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("could not unmarshal with %s: %s", name, err.Error())
+		}
+
+		if !reflect.DeepEqual(decoded, state) {
+			t.Fatalf("%s: expected %v, got %v", name, state, decoded)
+		}
+	}
+
+	if _, err := getStateCodec("xml"); err == nil {
+		t.Fatalf("expected unknown codec to fail")
+	}
+
+	if err := (protobufCodec{}).Unmarshal([]byte{0x0a, 0x05, 'a'}, &State{}); err == nil {
+		t.Fatalf("expected truncated protobuf to fail")
+	}
+}
+
+func TestCodedStore(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	e.Strategy.Update(2, 1)
+
+	backing := mapStore{}
+	s, err := NewCodedStore(backing, "protobuf")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if err := SaveExperiments(s, es); err != nil {
+		t.Fatalf("could not save: %s", err.Error())
+	}
+
+	if err := (jsonCodec{}).Unmarshal(backing[e.Name], &State{}); err == nil {
+		t.Fatalf("expected state not to be json")
+	}
+
+	e.Strategy.Reset()
+	if _, err := RestoreExperiments(s, es, RestoreFail); err != nil {
+		t.Fatalf("could not restore: %s", err.Error())
+	}
+
+	if got := e.Strategy.Snapshot().values[1]; got != 1 {
+		t.Fatalf("expected restored value 1, got %f", got)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+// Records are laid out like Apache Arrow record batches without nulls: fixed
+// width columns are plain slices, and string columns use Arrow's offsets and
+// data buffers. An Arrow library can wrap the buffers as arrays without
+// copying, so this package does not depend on one.
+
+// StringColumn holds strings in Arrow's variable width layout. Value i is
+// Data[Offsets[i]:Offsets[i+1]].
+type StringColumn struct {
+	Offsets []int32
+	Data    []byte
+}
+
+// Append adds `s` to the end of the column.
+func (c *StringColumn) Append(s string) {
+	if len(c.Offsets) == 0 {
+		c.Offsets = append(c.Offsets, 0)
+	}
+
+	c.Data = append(c.Data, s...)
+	c.Offsets = append(c.Offsets, int32(len(c.Data)))
+}
+
+// Len returns the number of strings in the column.
+func (c *StringColumn) Len() int {
+	if len(c.Offsets) == 0 {
+		return 0
+	}
+
+	return len(c.Offsets) - 1
+}
+
+// Value returns the 0 indexed string `i`.
+func (c *StringColumn) Value(i int) string {
+	return string(c.Data[c.Offsets[i]:c.Offsets[i+1]])
+}
+
+// EventRecords is a batch of selection and reward events in columns. Time is
+// in nanoseconds since the epoch, like Arrow's timestamp[ns].
+type EventRecords struct {
+	Time   []int64
+	Kind   StringColumn
+	Tag    StringColumn
+	Reward []float64
+	Policy StringColumn
+	Weight []int64 // 1 unless sampled, see LogEvent
+}
+
+// NewEventRecords returns `events` in columns.
+func NewEventRecords(events []LogEvent) EventRecords {
+	r := EventRecords{
+		Time:   make([]int64, 0, len(events)),
+		Reward: make([]float64, 0, len(events)),
+		Weight: make([]int64, 0, len(events)),
+	}
+
+	for _, event := range events {
+		r.Time = append(r.Time, event.Time.UnixNano())
+		r.Kind.Append(event.Kind)
+		r.Tag.Append(event.Tag)
+		r.Reward = append(r.Reward, event.Reward)
+		r.Policy.Append(event.Policy)
+		r.Weight = append(r.Weight, int64(event.weight()))
+	}
+
+	return r
+}
+
+// Len returns the number of rows.
+func (r EventRecords) Len() int {
+	return len(r.Time)
+}
+
+// ArmRecords is a batch of per arm statistics in columns, one row per
+// variation.
+type ArmRecords struct {
+	Experiment StringColumn
+	Ordinal    []int64
+	Tag        StringColumn
+	Count      []int64
+	Value      []float64
+	Priority   []float64
+	Cost       []float64
+}
+
+// NewArmRecords returns the arms of all `stats` in columns.
+func NewArmRecords(stats []Stats) ArmRecords {
+	var r ArmRecords
+	for _, s := range stats {
+		for _, arm := range s.Arms {
+			r.Experiment.Append(s.Experiment)
+			r.Ordinal = append(r.Ordinal, int64(arm.Ordinal))
+			r.Tag.Append(arm.Tag)
+			r.Count = append(r.Count, int64(arm.Count))
+			r.Value = append(r.Value, arm.Value)
+			r.Priority = append(r.Priority, arm.Priority)
+			r.Cost = append(r.Cost, arm.Cost)
+		}
+	}
+
+	return r
+}
+
+// Len returns the number of rows.
+func (r ArmRecords) Len() int {
+	return len(r.Ordinal)
+}
+
+// NewRecordSink returns a sink which hands each batch of events to `insert`
+// in columns, e.g. to build Arrow records for an analytics engine in the same
+// process. Use it with NewExporter.
+func NewRecordSink(insert func(EventRecords) error) Sink {
+	return recordSink(insert)
+}
+
+// recordSink converts batches to columns.
+type recordSink func(EventRecords) error
+
+// Insert hands `events` to the sink function in columns.
+func (s recordSink) Insert(events []LogEvent) error {
+	return s(NewEventRecords(events))
+}
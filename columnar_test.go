@@ -0,0 +1,70 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStringColumn(t *testing.T) {
+	var c StringColumn
+	if got := c.Len(); got != 0 {
+		t.Fatalf("expected empty column, got %d", got)
+	}
+
+	for _, s := range []string{"a", "", "bcd"} {
+		c.Append(s)
+	}
+
+	if got := c.Offsets; len(got) != 4 || got[0] != 0 || got[1] != 1 || got[2] != 1 || got[3] != 4 {
+		t.Fatalf("expected arrow offsets [0 1 1 4], got %v", got)
+	}
+
+	if got := c.Value(2); got != "bcd" {
+		t.Fatalf("expected bcd, got %s", got)
+	}
+}
+
+func TestRecordSink(t *testing.T) {
+	now := time.Now()
+	events := []LogEvent{
+		{Time: now, Kind: banditSelection, Tag: "shape-20130822:1"},
+		{Time: now, Kind: banditReward, Tag: "shape-20130822:1", Reward: 0.5},
+	}
+
+	var got EventRecords
+	sink := NewRecordSink(func(r EventRecords) error {
+		got = r
+		return nil
+	})
+
+	if err := sink.Insert(events); err != nil {
+		t.Fatalf("while inserting: %s", err.Error())
+	}
+
+	if got.Len() != 2 || got.Kind.Len() != 2 || got.Policy.Len() != 2 {
+		t.Fatalf("expected 2 rows in all columns, got %v", got)
+	}
+
+	if got.Time[0] != now.UnixNano() || got.Kind.Value(1) != banditReward || got.Reward[1] != 0.5 {
+		t.Fatalf("unexpected records %v", got)
+	}
+}
+
+func TestArmRecords(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	e.Update(1, 1)
+
+	r := NewArmRecords([]Stats{e.Stats()})
+	if got := r.Len(); got != len(e.Variations) {
+		t.Fatalf("expected %d rows, got %d", len(e.Variations), got)
+	}
+
+	if r.Experiment.Value(0) != "shape-20130822" || r.Tag.Value(1) != "shape-20130822:2" || r.Value[0] != 1 {
+		t.Fatalf("unexpected records %v", r)
+	}
+}
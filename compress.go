@@ -0,0 +1,213 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// Codec is a compression format. Magic are the first bytes of compressed
+// data, used to detect the format when reading.
+type Codec struct {
+	Magic     []byte
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// codecs are the registered codecs by name. gzip is built in. zstd is not, so
+// that this package has no dependencies; register it with RegisterCodec.
+var codecs = map[string]Codec{
+	"gzip": {
+		Magic: []byte{0x1f, 0x8b},
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+}
+
+var codecsMutex sync.RWMutex
+
+// RegisterCodec makes codec `c` available under `name`, e.g. "zstd".
+func RegisterCodec(name string, c Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	codecs[name] = c
+}
+
+// getCodec returns the codec `name`. The blank name and "none" are no
+// compression, returned as false.
+func getCodec(name string) (Codec, bool, error) {
+	if name == "" || name == "none" {
+		return Codec{}, false, nil
+	}
+
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	c, ok := codecs[name]
+	if !ok {
+		return Codec{}, false, fmt.Errorf("unknown compression '%s'", name)
+	}
+
+	return c, true, nil
+}
+
+// nopCloser adds a NOP Close to a writer.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// NewCompressedWriter returns a writer compressing to `w` with the codec
+// `name`. Close it to write the end of the compressed data; `w` itself is not
+// closed. Without compression, writes go to `w` directly.
+func NewCompressedWriter(w io.Writer, name string) (io.WriteCloser, error) {
+	c, ok, err := getCodec(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nopCloser{w}, nil
+	}
+
+	return c.NewWriter(w)
+}
+
+// Decompress returns a reader of `r` which decompresses data of any
+// registered codec, detected by its magic bytes. Other data is read as is.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	for name, c := range codecs {
+		magic, err := buffered.Peek(len(c.Magic))
+		if err != nil || !bytes.Equal(magic, c.Magic) {
+			continue
+		}
+
+		decompressed, err := c.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %s", name, err.Error())
+		}
+
+		return decompressed, nil
+	}
+
+	return ioutil.NopCloser(buffered), nil
+}
+
+// NewDecompressingOpener returns an opener which decompresses what `o`
+// opens, see Decompress.
+func NewDecompressingOpener(o Opener) Opener {
+	return &decompressingOpener{opener: o}
+}
+
+type decompressingOpener struct {
+	opener Opener
+}
+
+// decompressed closes both the decompressor and the underlying file.
+type decompressed struct {
+	io.ReadCloser
+	underlying io.Closer
+}
+
+func (d decompressed) Close() error {
+	d.ReadCloser.Close()
+	return d.underlying.Close()
+}
+
+func (o *decompressingOpener) Open() (io.ReadCloser, error) {
+	file, err := o.opener.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := Decompress(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return decompressed{reader, file}, nil
+}
+
+// NewCompressedLog returns a writer for the event log, compressing to `w`
+// with the codec `name`. Compressed data is flushed every `interval`, so
+// that a crash loses at most that much of the log. Codecs which cannot flush
+// are only written when the log is closed.
+func NewCompressedLog(w io.Writer, name string, interval time.Duration) (io.WriteCloser, error) {
+	compressed, err := NewCompressedWriter(w, name)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &compressedLog{writer: compressed, done: make(chan bool)}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				l.flush()
+			case <-l.done:
+				return
+			}
+		}
+	}()
+
+	return l, nil
+}
+
+// compressedLog serializes writes to a compressor, which is not safe for
+// concurrent use.
+type compressedLog struct {
+	sync.Mutex
+	writer io.WriteCloser
+	done   chan bool
+}
+
+func (l *compressedLog) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.writer.Write(p)
+}
+
+// flush writes pending compressed data if the codec supports it.
+func (l *compressedLog) flush() {
+	l.Lock()
+	defer l.Unlock()
+
+	if f, ok := l.writer.(interface {
+		Flush() error
+	}); ok {
+		f.Flush()
+	}
+}
+
+// Close stops flushing and writes the end of the compressed data.
+func (l *compressedLog) Close() error {
+	close(l.done)
+
+	l.Lock()
+	defer l.Unlock()
+
+	return l.writer.Close()
+}
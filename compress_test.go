@@ -0,0 +1,106 @@
+package bandit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompression(t *testing.T) {
+	for _, codec := range []string{"", "gzip"} {
+		buf := new(bytes.Buffer)
+		w, err := NewCompressedWriter(buf, codec)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		io.WriteString(w, "2 0.1 0.5")
+		w.Close()
+
+		r, err := Decompress(buf)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		got, _ := ioutil.ReadAll(r)
+		if string(got) != "2 0.1 0.5" {
+			t.Fatalf("expected %s round trip, got %q", codec, got)
+		}
+	}
+
+	if _, err := NewCompressedWriter(ioutil.Discard, "lz4"); err == nil {
+		t.Fatalf("expected unknown compression to fail")
+	}
+}
+
+func TestCompressedSnapshot(t *testing.T) {
+	f, err := ioutil.TempFile("", "bandit-snapshot")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.Remove(f.Name())
+	w := gzip.NewWriter(f)
+	io.WriteString(w, "2 0.1 0.5")
+	w.Close()
+	f.Close()
+
+	c, err := GetSnapshot(NewFileOpener(f.Name()))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if c.arms != 2 || c.values[1] != 0.5 {
+		t.Fatalf("expected snapshot of 2 arms, got %v", c.values)
+	}
+}
+
+func TestCompressedLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewCompressedLog(buf, "gzip", time.Hour)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	io.WriteString(l, "1379257984 BanditSelection shape-20130822:1\n")
+	l.(*compressedLog).flush()
+	if buf.Len() == 0 {
+		t.Fatalf("expected flush to write compressed data")
+	}
+
+	l.Close()
+	r, _ := Decompress(buf)
+	got, _ := ioutil.ReadAll(r)
+	if !strings.HasPrefix(string(got), "1379257984 BanditSelection") {
+		t.Fatalf("expected log line, got %q", got)
+	}
+}
+
+func TestCompressedClickHouseExport(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+	}))
+
+	defer server.Close()
+
+	sink, err := NewCompressedClickHouseSink(server.URL+"/", "bandit_events", "gzip")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if err := sink.Insert([]LogEvent{{Kind: "BanditSelection", Tag: "shape-20130822:1"}}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(encodings) != 2 || encodings[0] != "" || encodings[1] != "gzip" {
+		t.Fatalf("expected compressed insert only, got %v", encodings)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestConcurrentStrategies selects, updates, previews and resets from many
+// goroutines at once. Run with -race.
+func TestConcurrentStrategies(t *testing.T) {
+	strategies := map[string][]float64{
+		"epsilonGreedy":           {0.1},
+		"atomicEpsilonGreedy":     {0.1},
+		"optimisticEpsilonGreedy": {0.1, 1, 1, 1},
+		"annealingEpsilonGreedy":  {0.1, 100},
+		"epsilonFirst":            {10},
+		"softmax":                 {0.1},
+		"annealingSoftmax":        {},
+		"gradient":                {0.1},
+		"moss":                    {1000},
+		"ucb1":                    {},
+		"osub":                    {},
+		"ucbTuned":                {},
+		"meanVariance":            {1},
+		"klUcb":                   {},
+		"windowedUcb1":            {10},
+		"thompson":                {1},
+		"gaussianThompson":        {},
+		"bootstrapThompson":       {10},
+		"bayesUcb":                {1},
+		"successiveElimination":   {0.1, 0.1},
+		"budgetedUcb":             {1000, 1, 2, 3},
+	}
+
+	for name, params := range strategies {
+		s, err := New(3, name, params)
+		if err != nil {
+			t.Fatalf("could not create %s: %s", name, err.Error())
+		}
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 200; i++ {
+					arm := s.SelectArm()
+					s.Update(arm, float64((g+i)%2))
+					switch i % 50 {
+					case 0:
+						if p, ok := s.(Previewer); ok {
+							p.Distribution()
+						}
+					case 25:
+						c := s.Snapshot()
+						if g == 0 {
+							s.Init(c)
+						}
+					case 49:
+						if g == 1 {
+							s.Reset()
+						}
+					}
+				}
+			}(g)
+		}
+
+		wg.Wait()
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Coster is implemented by strategies which subtract serving costs from
+// estimated values at selection time. All strategies in this package do.
+type Coster interface {
+	Charge(costs []float64) error
+}
+
+// charge sets the costs of `s`, which must be a Coster.
+func charge(s Strategy, costs []float64) error {
+	c, ok := s.(Coster)
+	if !ok {
+		return fmt.Errorf("%s does not support costs", s)
+	}
+
+	return c.Charge(costs)
+}
+
+// Costs returns the serving cost of each variation, in ordinal order. Costs
+// are declared per variation with "cost" in units of reward, and default to 0.
+func (e *Experiment) Costs() []float64 {
+	costs := make([]float64, len(e.Variations))
+	for i, v := range e.Variations {
+		costs[i] = v.Cost
+	}
+
+	return costs
+}
+
+// charge applies the variation costs to the strategy, which then optimizes
+// reward minus cost. With a cost budget, costs are enforced by Select instead.
+// Variations in a group must have the same cost.
+func (e *Experiment) charge() error {
+	arms := make([]float64, e.Arms())
+	set := make([]bool, e.Arms())
+	for i, cost := range e.Costs() {
+		arm := e.Arm(e.Variations[i].Ordinal) - 1
+		if set[arm] && arms[arm] != cost {
+			return fmt.Errorf("variations in group %s have different costs", e.Variations[i].Group)
+		}
+
+		arms[arm], set[arm] = cost, true
+	}
+
+	if e.CostBudget > 0 {
+		for arm := range arms {
+			arms[arm] = 0
+		}
+	}
+
+	return charge(e.Strategy, arms)
+}
+
+// spending is the average serving cost of selections.
+type spending struct {
+	sync.Mutex
+	total      float64
+	selections int
+}
+
+// overBudget is true if the variation with the given ordinal must not be
+// served: the average cost of selections exceeds the budget and the variation
+// costs more than the budget.
+func (e *Experiment) overBudget(ordinal int) bool {
+	if e.CostBudget <= 0 || e.spent == nil {
+		return false
+	}
+
+	v, err := e.GetVariation(ordinal)
+	if err != nil || v.Cost <= e.CostBudget {
+		return false
+	}
+
+	e.spent.Lock()
+	defer e.spent.Unlock()
+
+	return e.spent.selections > 0 && e.spent.total/float64(e.spent.selections) > e.CostBudget
+}
+
+// spend records serving the variation with the given ordinal.
+func (e *Experiment) spend(ordinal int) {
+	if e.CostBudget <= 0 || e.spent == nil {
+		return
+	}
+
+	v, err := e.GetVariation(ordinal)
+	if err != nil {
+		return
+	}
+
+	e.spent.Lock()
+	defer e.spent.Unlock()
+
+	e.spent.total += v.Cost
+	e.spent.selections++
+}
+
+// AverageCost returns the average serving cost of selections under a cost
+// budget, or 0 without a budget.
+func (e *Experiment) AverageCost() float64 {
+	if e.spent == nil {
+		return 0
+	}
+
+	e.spent.Lock()
+	defer e.spent.Unlock()
+
+	if e.spent.selections == 0 {
+		return 0
+	}
+
+	return e.spent.total / float64(e.spent.selections)
+}
@@ -0,0 +1,74 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestCharge(t *testing.T) {
+	for _, name := range []string{"epsilonGreedy", "softmax", "ucb1", "thompson"} {
+		params := map[string][]float64{
+			"epsilonGreedy": {0},
+			"softmax":       {0.01},
+			"ucb1":          {},
+			"thompson":      {1},
+		}[name]
+
+		s, err := New(2, name, params)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		c := NewCounters(2)
+		c.counts[0], c.counts[1] = 1000, 1000
+		c.values[0], c.values[1] = 0.5, 0.4
+		if err := s.Init(&c); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		// the better arm is 10x as expensive
+		if err := charge(s, []float64{0.2, 0.02}); err != nil {
+			t.Fatalf("could not charge %s: %s", name, err.Error())
+		}
+
+		second := 0
+		for i := 0; i < 100; i++ {
+			if s.SelectArm() == 2 {
+				second++
+			}
+		}
+
+		if second < 90 {
+			t.Fatalf("expected cheaper arm to be selected by %s, got %d of 100", name, second)
+		}
+	}
+
+	s := NewUCB1(2)
+	if err := charge(s, []float64{0, -1}); err == nil {
+		t.Fatalf("expected error on negative cost")
+	}
+}
+
+func TestCostBudget(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.Strategy, _ = NewEpsilonGreedy(2, 0)
+	e.Variations[0].Cost, e.Variations[1].Cost = 1, 0.1
+	e.CostBudget = 0.5
+	if err := e.charge(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the expensive variation has the better reward
+	e.Update(1, 1)
+	e.Update(2, 0.5)
+	for i := 0; i < 1000; i++ {
+		e.Select()
+	}
+
+	if got := e.AverageCost(); got > 0.51 || got < 0.4 {
+		t.Fatalf("expected average cost near budget 0.5, got %f", got)
+	}
+}
@@ -2,6 +2,8 @@ package bandit
 
 import (
 	"fmt"
+	"hash"
+	"math"
 	"math/rand"
 	"sync"
 	"time"
@@ -12,7 +14,17 @@ func NewCounters(arms int) Counters {
 	return Counters{
 		arms:   arms,
 		counts: make([]int, arms),
+		values: make([]float64, arms),
 		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// newState constructs counters without a random source, for snapshots and
+// archives which never select.
+func newState(arms int) *Counters {
+	return &Counters{
+		arms:   arms,
+		counts: make([]int, arms),
 		values: make([]float64, arms),
 	}
 }
@@ -21,23 +33,105 @@ func NewCounters(arms int) Counters {
 type Counters struct {
 	sync.Mutex
 
-	arms   int        // number of arms present in this strategy
-	counts []int      // number of pulls. len(counts) == arms.
-	rand   *rand.Rand // seeded random number generator
-	values []float64  // running average reward per arm. len(values) == arms.
+	arms       int        // number of arms present in this strategy
+	counts     []int      // number of pulls. len(counts) == arms.
+	rand       *rand.Rand // seeded random number generator
+	values     []float64  // running average reward per arm. len(values) == arms.
+	revision   uint64     // incremented whenever rewards change the state
+	priorities []float64  // multipliers of values at selection time. nil if all 1.
+	ties       string     // TiesRandom or TiesOrdinal. blank is TiesRandom.
+	costs      []float64  // subtracted from prioritized values at selection time. nil if all 0.
+	gamma      float64    // discount of old rewards, see Discount. 0 keeps the running average.
+	named      nameCache  // String of the strategy, see Fingerprint
 }
 
-// Update the running average, where arm is the 1 indexed arm
+// Update the running average, where arm is the 1 indexed arm. A reward for an
+// arm which was never selected counts as its first pull.
 func (c *Counters) Update(arm int, reward float64) {
 	c.Lock()
 	defer c.Unlock()
 
+	c.update(arm, reward)
+}
+
+// UpdateBatch applies `rewards` for the 1 indexed arm in order, taking the
+// lock once. The result is the same as calling Update for each reward.
+func (c *Counters) UpdateBatch(arm int, rewards []float64) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, reward := range rewards {
+		c.update(arm, reward)
+	}
+}
+
+// update the running average without locking.
+func (c *Counters) update(arm int, reward float64) {
 	arm--
+	if c.counts[arm] == 0 {
+		c.counts[arm] = 1
+	}
+
 	count := c.counts[arm]
-	c.values[arm] = ((c.values[arm] * float64(count-1)) + reward) / float64(count)
+	if c.gamma > 0 && count > 1 {
+		c.values[arm] = c.gamma*c.values[arm] + (1-c.gamma)*reward
+	} else {
+		c.values[arm] = ((c.values[arm] * float64(count-1)) + reward) / float64(count)
+	}
+
+	c.revision++
+}
+
+// releaser is implemented by strategies which can take back selections, such
+// as draws of disabled variations. See Counters.release.
+type releaser interface {
+	release(arms []int)
+}
+
+// release hands back selections of 1 indexed `arms` which were never served,
+// if `s` can take them back.
+func release(s Strategy, arms []int) {
+	if r, ok := s.(releaser); ok {
+		r.release(arms)
+	}
+}
+
+// release undoes the pull counts of 1 indexed `arms` which were selected but
+// never served.
+func (c *Counters) release(arms []int) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, arm := range arms {
+		if c.counts[arm-1] > 0 {
+			c.counts[arm-1]--
+		}
+	}
 }
 
-// Init the strategy to a new counter state.
+// puller is implemented by strategies which can count selections made on their
+// behalf, such as uniform draws of a floor. See Counters.pull.
+type puller interface {
+	pull(arm int)
+}
+
+// pull counts a selection of 1 indexed `arm` which `s` did not make itself, if
+// `s` can count it.
+func pull(s Strategy, arm int) {
+	if p, ok := s.(puller); ok {
+		p.pull(arm)
+	}
+}
+
+// pull counts a selection of the 1 indexed `arm`, as SelectArm would have.
+func (c *Counters) pull(arm int) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.counts[arm-1]++
+}
+
+// Init the strategy to a new counter state. The random source is kept.
 func (c *Counters) Init(snapshot *Counters) error {
 	if c.arms != snapshot.arms {
 		return fmt.Errorf("cannot %d arms with %d arms", c.arms, snapshot.arms)
@@ -51,14 +145,185 @@ func (c *Counters) Init(snapshot *Counters) error {
 	defer c.Unlock()
 
 	c.counts = snapshot.counts
-	c.rand = snapshot.rand
 	c.values = snapshot.values
+	c.revision++
 
 	return nil
 }
 
-// Reset the strategy to initial state.
+// Snapshot returns a copy of the current counters, which is safe to use while
+// the strategy keeps serving.
+func (c *Counters) Snapshot() *Counters {
+	c.Lock()
+	defer c.Unlock()
+
+	snapshot := &Counters{
+		arms:   c.arms,
+		counts: make([]int, c.arms),
+		values: make([]float64, c.arms),
+	}
+
+	copy(snapshot.counts, c.counts)
+	copy(snapshot.values, c.values)
+	snapshot.revision = c.revision
+	if c.priorities != nil {
+		snapshot.priorities = append([]float64{}, c.priorities...)
+	}
+
+	if c.costs != nil {
+		snapshot.costs = append([]float64{}, c.costs...)
+	}
+
+	return snapshot
+}
+
+// Reset the strategy to initial state. It is safe to reset while serving, see
+// ResetWithArchive.
 func (c *Counters) Reset() {
-	c.counts = make([]int, c.arms)
-	c.values = make([]float64, c.arms)
+	c.ResetWithArchive()
+}
+
+// Prioritize multiplies the estimated value of each arm with its business
+// priority when selecting. Priorities are in (0, ∞), with 1 being neutral.
+// Rewards and stored state are not affected.
+func (c *Counters) Prioritize(priorities []float64) error {
+	if len(priorities) != c.arms {
+		return fmt.Errorf("%d priorities for %d arms", len(priorities), c.arms)
+	}
+
+	neutral := true
+	for _, p := range priorities {
+		if !(p > 0) {
+			return fmt.Errorf("priority %f not in (0, ∞)", p)
+		}
+
+		neutral = neutral && p == 1
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.priorities = nil
+	if !neutral {
+		c.priorities = append([]float64{}, priorities...)
+	}
+
+	return nil
+}
+
+// Charge subtracts the serving cost of each arm from its estimated value when
+// selecting, so that strategies optimize reward minus cost. Costs are in
+// [0, ∞) and in units of reward. Rewards and stored state are not affected.
+func (c *Counters) Charge(costs []float64) error {
+	if len(costs) != c.arms {
+		return fmt.Errorf("%d costs for %d arms", len(costs), c.arms)
+	}
+
+	free := true
+	for _, cost := range costs {
+		if !(cost >= 0) || math.IsInf(cost, 0) {
+			return fmt.Errorf("cost %f not in [0, ∞)", cost)
+		}
+
+		free = free && cost == 0
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.costs = nil
+	if !free {
+		c.costs = append([]float64{}, costs...)
+	}
+
+	return nil
+}
+
+// estimates returns the values to select by, which are the prioritized
+// values minus costs. Does not allocate without priorities and costs.
+func (c *Counters) estimates() []float64 {
+	if c.priorities == nil && c.costs == nil {
+		return c.values
+	}
+
+	estimates := make([]float64, c.arms)
+	for i, value := range c.values {
+		estimates[i] = c.estimate(i, value)
+	}
+
+	return estimates
+}
+
+// estimate returns the value to select 0 indexed `arm` by, given its
+// estimated `value`.
+func (c *Counters) estimate(arm int, value float64) float64 {
+	if c.priorities != nil {
+		value *= c.priorities[arm]
+	}
+
+	if c.costs != nil {
+		value -= c.costs[arm]
+	}
+
+	return value
+}
+
+// policy writes priorities and costs to hash `h` and returns the revision,
+// without copying the counters.
+func (c *Counters) policy(h hash.Hash32) uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	hashFloats(h, c.priorities)
+	if c.costs != nil {
+		h.Write([]byte(" "))
+		hashFloats(h, c.costs)
+	}
+
+	return c.revision
+}
+
+// names returns the name cache of the strategy keeping the counters.
+func (c *Counters) names() *nameCache {
+	return &c.named
+}
+
+// leading is true if 0 indexed `arm` has the highest estimate, including
+// priorities and costs.
+func (c *Counters) leading(arm int) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	estimate := c.estimate(arm, c.values[arm])
+	for i, value := range c.values {
+		if c.estimate(i, value) > estimate {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BreakTies sets how equally good arms are chosen between, for example while
+// all values are still 0. TiesRandom picks uniformly at random. TiesOrdinal
+// picks the lowest ordinal, which is deterministic but gives it a head start.
+func (c *Counters) BreakTies(mode string) error {
+	if mode != TiesRandom && mode != TiesOrdinal {
+		return fmt.Errorf("unknown tie breaking '%s'", mode)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.ties = mode
+	return nil
+}
+
+// pick returns one of the 0 indexed, equally good arms `best`.
+func (c *Counters) pick(best []int) int {
+	if c.ties == TiesOrdinal {
+		return best[0]
+	}
+
+	return best[c.rand.Intn(len(best))]
 }
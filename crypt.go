@@ -0,0 +1,101 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// encryptedPrefix marks encrypted values in the experiments file. The prefix
+// is followed by the base64 encoded ciphertext.
+const encryptedPrefix = "enc:"
+
+// Decrypter decrypts values of the experiments file, such as signed internal
+// urls. Implementations typically call out to a key management service.
+type Decrypter interface {
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// DecrypterFunc adapts an ordinary function to the Decrypter interface.
+type DecrypterFunc func(ciphertext []byte) ([]byte, error)
+
+// Decrypt calls f(ciphertext).
+func (f DecrypterFunc) Decrypt(ciphertext []byte) ([]byte, error) {
+	return f(ciphertext)
+}
+
+// decryptValue returns `value` as is, or decrypted with `d` if it is in the
+// form enc:<base64 ciphertext>.
+func decryptValue(d Decrypter, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+
+	if d == nil {
+		return "", fmt.Errorf("found encrypted value but no decrypter")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("could not decode encrypted value: %s", err.Error())
+	}
+
+	plaintext, err := d.Decrypt(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt value: %s", err.Error())
+	}
+
+	return string(plaintext), nil
+}
+
+// NewAESKey returns a key for encrypting and decrypting values with AES-GCM,
+// for deployments without a key management service. `key` must have 16, 24
+// or 32 bytes.
+func NewAESKey(key []byte) (*AESKey, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return &AESKey{}, fmt.Errorf("invalid key: %s", err.Error())
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return &AESKey{}, fmt.Errorf("could not make gcm: %s", err.Error())
+	}
+
+	return &AESKey{gcm: gcm}, nil
+}
+
+// AESKey encrypts and decrypts values. Ciphertexts are the nonce followed by
+// the sealed value.
+type AESKey struct {
+	gcm cipher.AEAD
+}
+
+// Encrypt returns `plaintext` as a value for the experiments file, in the form
+// enc:<base64 ciphertext>.
+func (k *AESKey) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, k.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("could not make nonce: %s", err.Error())
+	}
+
+	ciphertext := k.gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a ciphertext sealed by Encrypt.
+func (k *AESKey) Decrypt(ciphertext []byte) ([]byte, error) {
+	size := k.gcm.NonceSize()
+	if len(ciphertext) < size {
+		return []byte{}, fmt.Errorf("ciphertext too short")
+	}
+
+	return k.gcm.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
@@ -0,0 +1,60 @@
+package bandit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEncryptedExperiments(t *testing.T) {
+	key, err := NewAESKey([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	url := "http://internal/widget?signature=s3cr3t"
+	encrypted, err := key.Encrypt(url)
+	if err != nil {
+		t.Fatalf("could not encrypt: %s", err.Error())
+	}
+
+	f, err := ioutil.TempFile("", "bandit-encrypted")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.Remove(f.Name())
+	fmt.Fprintf(f, `[{
+		"experiment_name": "secret",
+		"strategy": "uniform",
+		"preferred": 1,
+		"variations": [{ "ordinal": 1, "url": "%s" }]
+	}]`, encrypted)
+	f.Close()
+
+	if _, err := NewExperiments(NewFileOpener(f.Name())); err == nil {
+		t.Fatalf("expected error reading encrypted values without decrypter")
+	}
+
+	es, err := NewDecryptedExperiments(NewFileOpener(f.Name()), key)
+	if err != nil {
+		t.Fatalf("could not read encrypted experiments: %s", err.Error())
+	}
+
+	if got := es.index["secret"].Variations[0].URL; got != url {
+		t.Fatalf("expected decrypted url %s, got %s", url, got)
+	}
+
+	other, _ := NewAESKey([]byte("fedcba9876543210fedcba9876543210"))
+	if _, err := NewDecryptedExperiments(NewFileOpener(f.Name()), other); err == nil {
+		t.Fatalf("expected error decrypting with the wrong key")
+	}
+
+	binary, _ := key.Encrypt("\xff\xfe")
+	config := fmt.Sprintf(`[{"experiment_name": "binary", "strategy": "uniform", "preferred": 1,
+		"variations": [{"ordinal": 1, "url": "%s"}]}]`, binary)
+	if _, err := NewDecryptedExperiments(byteOpener(config), key); err == nil {
+		t.Fatalf("expected error decrypting invalid utf-8")
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+// Package main contains bandit-ctl, which operates many experiments on a fleet
+// of bandit-api instances at once through their admin endpoints:
+//
+//	bandit-ctl -admin http://a:8081,http://b:8081 pause 'shape-*'
+//	bandit-ctl -admin http://a:8081,http://b:8081 resume 'shape-*'
+//	bandit-ctl -admin http://a:8081,http://b:8081 reset shape-20130822 color-20130901
+//	bandit-ctl -admin http://a:8081 -min-pulls 10000 export > converged.json
+//	bandit-ctl -admin http://a:8081,http://b:8081 aggregate 'shape-*'
+//	bandit-ctl -admin http://a:8081,http://b:8081 apply experiments.json
+//	bandit-ctl -admin http://a:8081,http://b:8081 kill checkout is down
+//	bandit-ctl -admin http://a:8081,http://b:8081 revive
+//	bandit-ctl -admin http://a:8081,http://b:8081 erase user-11
+//
+// Patterns are matched with path.Match. Pause, resume and apply are all or
+// nothing: if one instance fails, the changes made so far are undone. Reset
+// cannot be undone, so it only starts once every instance has all the named
+// experiments. Export reads stats from the first instance only, so list the
+// primary first. Aggregate merges the stats of all instances, for fleets which
+// do not share state.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/purzelrakete/bandit"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ctlAdmin    = flag.String("admin", "http://localhost:8081", "comma separated admin urls of all instances")
+	ctlTimeout  = flag.Duration("timeout", 10*time.Second, "timeout of each admin request")
+	ctlMinPulls = flag.Int("min-pulls", 1000, "pulls before an experiment can converge, for export")
+	ctlMinShare = flag.Float64("min-share", 0.95, "share of pulls of the best arm of a converged experiment, for export")
+)
+
+func init() {
+	flag.Parse()
+}
+
+func main() {
+	hosts := strings.Split(*ctlAdmin, ",")
+	client := &http.Client{Timeout: *ctlTimeout}
+	args := flag.Args()
+	if len(args) == 0 {
+		log.Fatalf("please provide a command ∈ {pause,resume,reset,export,aggregate,apply,kill,revive,erase}")
+	}
+
+	switch command, params := args[0], args[1:]; command {
+	case "pause", "resume":
+		if len(params) != 1 {
+			log.Fatalf("%s needs a single pattern", command)
+		}
+
+		names := make(map[string][]string)
+		for _, host := range hosts {
+			matches, err := matching(client, host, params[0])
+			if err != nil {
+				log.Fatalf("could not list experiments on %s, nothing changed: %s", host, err.Error())
+			}
+
+			names[host] = matches
+		}
+
+		if err := toggle(client, hosts, names, command); err != nil {
+			log.Fatal(err.Error())
+		}
+	case "reset":
+		// resets cannot be undone, so all experiments must exist first
+		for _, host := range hosts {
+			names, err := matching(client, host, "*")
+			if err != nil {
+				log.Fatalf("could not list experiments on %s, nothing reset: %s", host, err.Error())
+			}
+
+			for _, name := range params {
+				if !contains(names, name) {
+					log.Fatalf("%s has no experiment %s, nothing reset", host, name)
+				}
+			}
+		}
+
+		for _, host := range hosts {
+			for _, name := range params {
+				if _, err := post(client, host+"/experiments/"+name+"/restart", nil); err != nil {
+					log.Fatalf("could not reset %s on %s: %s", name, host, err.Error())
+				}
+
+				fmt.Printf("%s\t%s\treset\n", host, name)
+			}
+		}
+	case "kill", "revive":
+		// reach as many hosts as possible, since this is used during incidents
+		reason, failed := []byte(strings.Join(params, " ")), []string{}
+		for _, host := range hosts {
+			if _, err := post(client, host+"/"+command, reason); err != nil {
+				log.Printf("could not %s %s: %s", command, host, err.Error())
+				failed = append(failed, host)
+				continue
+			}
+
+			fmt.Printf("%s\t%s\n", host, command)
+		}
+
+		if len(failed) > 0 {
+			log.Fatalf("could not %s %v", command, failed)
+		}
+	case "erase":
+		for _, host := range hosts {
+			for _, uid := range params {
+				removed, err := post(client, host+"/users/"+url.PathEscape(uid)+"/erase", nil)
+				if err != nil {
+					log.Fatalf("could not erase %s on %s: %s", uid, host, err.Error())
+				}
+
+				fmt.Printf("%s\t%s\t%s\n", host, uid, removed)
+			}
+		}
+	case "export":
+		converged := bandit.ShareConvergence(*ctlMinPulls, *ctlMinShare)
+		names, err := matching(client, hosts[0], "*")
+		if err != nil {
+			log.Fatalf("could not list experiments: %s", err.Error())
+		}
+
+		for _, name := range names {
+			var stats bandit.Stats
+			if err := get(client, hosts[0]+"/stats/"+name, &stats); err != nil {
+				log.Fatalf("could not get stats of %s: %s", name, err.Error())
+			}
+
+			if _, ok := converged(stats); ok {
+				if err := json.NewEncoder(os.Stdout).Encode(stats); err != nil {
+					log.Fatalf("could not export %s: %s", name, err.Error())
+				}
+			}
+		}
+	case "aggregate":
+		if len(params) != 1 {
+			log.Fatalf("aggregate needs a single pattern")
+		}
+
+		names, err := matching(client, hosts[0], params[0])
+		if err != nil {
+			log.Fatalf("could not list experiments: %s", err.Error())
+		}
+
+		for _, name := range names {
+			var stats []bandit.Stats
+			for _, host := range hosts {
+				var s bandit.Stats
+				if err := get(client, host+"/stats/"+name, &s); err != nil {
+					log.Fatalf("could not get stats of %s on %s: %s", name, host, err.Error())
+				}
+
+				stats = append(stats, s)
+			}
+
+			fleet, err := bandit.AggregateStats(stats)
+			if err != nil {
+				log.Fatalf("could not aggregate %s: %s", name, err.Error())
+			}
+
+			if err := json.NewEncoder(os.Stdout).Encode(fleet); err != nil {
+				log.Fatalf("could not print %s: %s", name, err.Error())
+			}
+		}
+	case "apply":
+		if len(params) != 1 {
+			log.Fatalf("apply needs a single experiments file")
+		}
+
+		config, err := ioutil.ReadFile(params[0])
+		if err != nil {
+			log.Fatalf("could not read config: %s", err.Error())
+		}
+
+		if err := apply(client, hosts, config); err != nil {
+			log.Fatal(err.Error())
+		}
+	default:
+		log.Fatalf("unknown command: %s", command)
+	}
+}
+
+// toggle pauses or resumes the experiments `names` of each host. If one
+// fails, the experiments changed so far are changed back, so that the fleet
+// is not left half paused.
+func toggle(client *http.Client, hosts []string, names map[string][]string, command string) error {
+	undo := map[string]string{"pause": "resume", "resume": "pause"}[command]
+
+	var changed []string
+	for _, host := range hosts {
+		for _, name := range names[host] {
+			url := host + "/experiments/" + name
+			result, err := post(client, url+"/"+command, nil)
+			if err != nil {
+				var failed []string
+				for _, done := range changed {
+					if _, err := post(client, done+"/"+undo, nil); err != nil {
+						log.Printf("could not %s %s: %s", undo, done, err.Error())
+						failed = append(failed, done)
+					}
+				}
+
+				if len(failed) > 0 {
+					return fmt.Errorf("could not %s %s on %s, and could not %s %v: %s", command, name, host, undo, failed, err.Error())
+				}
+
+				return fmt.Errorf("could not %s %s on %s, nothing changed: %s", command, name, host, err.Error())
+			}
+
+			if result == "true" {
+				changed = append(changed, url)
+			}
+		}
+	}
+
+	for _, host := range hosts {
+		for _, name := range names[host] {
+			fmt.Printf("%s\t%s\t%s\n", host, name, command)
+		}
+	}
+
+	return nil
+}
+
+// apply checks `config` on all hosts and only then applies it to each, so
+// that the fleet is not left half configured by a config some instance
+// rejects. If applying still fails on a host, the hosts applied to so far are
+// rolled back to the config they ran before. Changes of the first host are
+// printed.
+func apply(client *http.Client, hosts []string, config []byte) error {
+	previous := make(map[string][]byte)
+	for _, host := range hosts {
+		changes, err := post(client, host+"/experiments?dry-run=true", config)
+		if err != nil {
+			return fmt.Errorf("%s rejected config, nothing applied: %s", host, err.Error())
+		}
+
+		var running json.RawMessage
+		if err := get(client, host+"/config", &running); err != nil {
+			return fmt.Errorf("could not get config of %s, nothing applied: %s", host, err.Error())
+		}
+
+		previous[host] = running
+		if host == hosts[0] {
+			fmt.Print(changes)
+		}
+	}
+
+	for i, host := range hosts {
+		if _, err := post(client, host+"/experiments", config); err != nil {
+			var failed []string
+			for _, done := range hosts[:i] {
+				if _, err := post(client, done+"/experiments", previous[done]); err != nil {
+					log.Printf("could not roll back %s: %s", done, err.Error())
+					failed = append(failed, done)
+				}
+			}
+
+			if len(failed) > 0 {
+				return fmt.Errorf("could not apply to %s, and could not roll back %v: %s", host, failed, err.Error())
+			}
+
+			return fmt.Errorf("could not apply to %s, rolled back %v: %s", host, hosts[:i], err.Error())
+		}
+	}
+
+	return nil
+}
+
+// contains returns true if `name` is one of `names`.
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matching returns the names of the experiments on `host` matching `pattern`.
+func matching(client *http.Client, host, pattern string) ([]string, error) {
+	var names []string
+	if err := get(client, host+"/experiments", &names); err != nil {
+		return []string{}, err
+	}
+
+	var matches []string
+	for _, name := range names {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return []string{}, fmt.Errorf("bad pattern: %s", err.Error())
+		}
+
+		if ok {
+			matches = append(matches, name)
+		}
+	}
+
+	return matches, nil
+}
+
+// get decodes the json response of `url` into `v`.
+func get(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, message(resp.Body))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// post posts `body` to `url` and returns the response body.
+func post(client *http.Client, url string, body []byte) (string, error) {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", resp.Status, message(resp.Body))
+	}
+
+	return message(resp.Body), nil
+}
+
+// message reads an error or response message.
+func message(r io.Reader) string {
+	data, _ := ioutil.ReadAll(r)
+	return string(data)
+}
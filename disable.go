@@ -0,0 +1,101 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync"
+)
+
+// maxDraws bounds the number of strategy draws Select makes while skipping
+// disabled arms.
+const maxDraws = 100
+
+// Disable stops serving the variation with the given 1 indexed ordinal.
+// Select redraws from the strategy instead, which renormalizes the selection
+// probabilities over the remaining arms.
+func (e *Experiment) Disable(ordinal int) {
+	if e.disabled == nil {
+		e.disabled = newArmSet()
+	}
+
+	e.disabled.add(ordinal)
+	e.changes.record(LifecycleDisabled, ordinal)
+}
+
+// Enable serves a disabled variation again.
+func (e *Experiment) Enable(ordinal int) {
+	if e.disabled != nil {
+		e.disabled.remove(ordinal)
+		e.changes.record(LifecycleEnabled, ordinal)
+	}
+}
+
+// fallback returns the ordinal served in `locale` when the strategy refuses
+// to select, or keeps selecting disabled or over budget variations: the
+// variation preferred for the locale unless it is disabled, then the first
+// enabled one. With every variation disabled, it is the preferred variation.
+func (e *Experiment) fallback(locale string) int {
+	preferred := e.Preferred(locale).Ordinal
+	if !e.disabled.contains(preferred) {
+		return preferred
+	}
+
+	for _, v := range e.Variations {
+		if !e.disabled.contains(v.Ordinal) {
+			return v.Ordinal
+		}
+	}
+
+	return preferred
+}
+
+// Disabled returns true if the variation with the given ordinal is disabled.
+func (e *Experiment) Disabled(ordinal int) bool {
+	return e.disabled.contains(ordinal)
+}
+
+// armSet is a set of 1 indexed arms, safe for concurrent use.
+type armSet struct {
+	sync.RWMutex
+	arms map[int]bool
+}
+
+// newArmSet returns an empty set.
+func newArmSet() *armSet {
+	return &armSet{arms: make(map[int]bool)}
+}
+
+func (s *armSet) add(arm int) {
+	s.Lock()
+	defer s.Unlock()
+	s.arms[arm] = true
+}
+
+func (s *armSet) remove(arm int) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.arms, arm)
+}
+
+// clear removes all arms. NOP on the nil set.
+func (s *armSet) clear() {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.arms = make(map[int]bool)
+}
+
+// contains is false for the nil set.
+func (s *armSet) contains(arm int) bool {
+	if s == nil {
+		return false
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	return s.arms[arm]
+}
@@ -0,0 +1,51 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+)
+
+// Discounter is implemented by strategies whose value estimates can weigh
+// recent rewards more than old ones.
+type Discounter interface {
+	Discount(γ float64) error
+}
+
+// discount sets the discount of `s`, which must be a Discounter.
+func discount(s Strategy, γ float64) error {
+	d, ok := s.(Discounter)
+	if !ok {
+		return fmt.Errorf("%s does not support discounting", s)
+	}
+
+	return d.Discount(γ)
+}
+
+// NewDiscounted configures strategy `s` to discount old rewards by γ with
+// every new reward of an arm, and returns it.
+func NewDiscounted(s Strategy, γ float64) (Strategy, error) {
+	if err := discount(s, γ); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// Discount replaces the running average with an exponentially weighted one:
+// each reward moves the value of its arm by (1 - γ) towards it, so that the
+// weight of old rewards decays by γ per reward. γ is in (0, 1); 0 restores the
+// running average. Pull counts, and with them confidence bounds, are not
+// discounted.
+func (c *Counters) Discount(γ float64) error {
+	if !(γ >= 0 && γ < 1) {
+		return fmt.Errorf("γ not in [0, 1)")
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.gamma = γ
+	return nil
+}
@@ -0,0 +1,42 @@
+package bandit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDiscount(t *testing.T) {
+	for _, s := range []Strategy{NewUCB1(1), NewAnnealingSoftmax(1)} {
+		discounted, err := NewDiscounted(s, 0.5)
+		if err != nil {
+			t.Fatalf("while discounting %s: %s", s, err.Error())
+		}
+
+		for _, reward := range []float64{1, 0, 0} {
+			discounted.SelectArm()
+			discounted.Update(1, reward)
+		}
+
+		// 1, then 0.5 * 1 + 0.5 * 0, then 0.5 * 0.5 + 0.5 * 0
+		if got := discounted.Snapshot().values[0]; math.Abs(got-0.25) > 1e-9 {
+			t.Fatalf("expected discounted value 0.25 for %s, got %f", s, got)
+		}
+	}
+}
+
+func TestDiscountInvalid(t *testing.T) {
+	for _, γ := range []float64{-0.1, 1} {
+		if _, err := NewDiscounted(NewUCB1(1), γ); err == nil {
+			t.Fatalf("expected γ %f to fail", γ)
+		}
+	}
+
+	windowed, err := NewWindowedUCB1(1, 10)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	if _, err := NewDiscounted(windowed, 0.5); err == nil {
+		t.Fatalf("expected windowed UCB1 not to be discounted")
+	}
+}
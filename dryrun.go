@@ -0,0 +1,112 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kinds of changes between two sets of experiments.
+const (
+	ChangeExperimentAdded   = "experiment-added"
+	ChangeExperimentRemoved = "experiment-removed"
+	ChangeVariationAdded    = "variation-added"
+	ChangeVariationRemoved  = "variation-removed"
+	ChangeVariationChanged  = "variation-changed"
+	ChangeStrategyChanged   = "strategy-changed"
+	ChangeResetRequired     = "reset-required"
+	ChangeTagReused         = "tag-reused"
+)
+
+// Change is a single difference between running and new experiments.
+type Change struct {
+	Experiment string `json:"experiment"`
+	Kind       string `json:"kind"`
+	Detail     string `json:"detail"`
+}
+
+// String formats the change for a review report.
+func (c Change) String() string {
+	return fmt.Sprintf("%s\t%s\t%s", c.Experiment, c.Kind, c.Detail)
+}
+
+// DryRun reads new experiments from `o` and reports what would change if they
+// replaced the running experiments `es`. Nothing is applied. Encrypted values
+// are decrypted with `d`, which may be nil.
+func DryRun(es *Experiments, o Opener, d Decrypter) ([]Change, error) {
+	next, err := NewDecryptedExperiments(o, d)
+	if err != nil {
+		return []Change{}, fmt.Errorf("could not read new experiments: %s", err.Error())
+	}
+
+	defer next.Close()
+	return Diff(es, next), nil
+}
+
+// Diff lists the changes from `running` to `next`, ordered by experiment name.
+// Changing the number of arms requires the strategy state to be reset.
+func Diff(running, next *Experiments) []Change {
+	before, after, names := running.All(), next.All(), []string{}
+	for name := range before {
+		names = append(names, name)
+	}
+
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		was, hadBefore := before[name]
+		is, hasAfter := after[name]
+		switch {
+		case !hadBefore:
+			changes = append(changes, Change{name, ChangeExperimentAdded, fmt.Sprintf("%d variations", len(is.Variations))})
+		case !hasAfter:
+			changes = append(changes, Change{name, ChangeExperimentRemoved, fmt.Sprintf("%d variations", len(was.Variations))})
+		default:
+			changes = append(changes, diffExperiment(was, is)...)
+		}
+	}
+
+	return changes
+}
+
+// diffExperiment lists changes between two versions of the same experiment.
+func diffExperiment(before, after *Experiment) []Change {
+	var changes []Change
+	if o, n := fmt.Sprintf("%s", before.Strategy), fmt.Sprintf("%s", after.Strategy); o != n {
+		changes = append(changes, Change{before.Name, ChangeStrategyChanged, fmt.Sprintf("%s -> %s", o, n)})
+	}
+
+	for _, ov := range before.Variations {
+		nv, err := after.GetVariation(ov.Ordinal)
+		switch {
+		case err != nil:
+			changes = append(changes, Change{before.Name, ChangeVariationRemoved, ov.Tag})
+		case ov.URL != nv.URL && nv.Replaces != ov.URL:
+			changes = append(changes, Change{before.Name, ChangeTagReused, fmt.Sprintf("%s: %s -> %s", ov.Tag, ov.URL, nv.URL)})
+		case ov.URL != nv.URL || ov.Description != nv.Description || ov.Priority != nv.Priority || ov.Cost != nv.Cost:
+			changes = append(changes, Change{before.Name, ChangeVariationChanged, fmt.Sprintf("%s: %s -> %s", ov.Tag, ov.URL, nv.URL)})
+		}
+	}
+
+	for _, nv := range after.Variations {
+		if _, err := before.GetVariation(nv.Ordinal); err != nil {
+			changes = append(changes, Change{before.Name, ChangeVariationAdded, nv.Tag})
+		}
+	}
+
+	// seeded experiments carry state over to new arms, see Seed.
+	if o, n := len(before.Variations), len(after.Variations); o != n && after.SeedPulls == 0 {
+		changes = append(changes, Change{before.Name, ChangeResetRequired, fmt.Sprintf("arms %d -> %d", o, n)})
+	}
+
+	return changes
+}
@@ -0,0 +1,47 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	running, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	if got := Diff(running, running); len(got) != 0 {
+		t.Fatalf("expected no changes against itself, got %v", got)
+	}
+
+	shape := *running.index["shape-20130822"]
+	shape.Variations = append(Variations{}, shape.Variations...)
+	shape.Variations[1].Replaces = shape.Variations[1].URL
+	shape.Variations[1].URL = "http://localhost:8080/widget?shape=triangle"
+	shape.Variations = append(shape.Variations, Variation{Ordinal: 3, Tag: "shape-20130822:3"})
+	shape.Strategy = NewUCB1(3)
+
+	next := Experiments{index: map[string]*Experiment{
+		"shape-20130822":  &shape,
+		"plants-20121111": &Experiment{Name: "plants-20121111", Strategy: NewUCB1(1), Variations: Variations{{Ordinal: 1}}},
+	}}
+
+	expected := []string{
+		ChangeExperimentAdded,
+		ChangeStrategyChanged,
+		ChangeVariationChanged,
+		ChangeVariationAdded,
+		ChangeResetRequired,
+	}
+
+	changes := Diff(running, &next)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %v", len(expected), changes)
+	}
+
+	for i, kind := range expected {
+		if got := changes[i].Kind; got != kind {
+			t.Fatalf("expected change %d to be %s, got %s", i, kind, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DuelingBandit learns from pairwise preferences instead of rewards per arm,
+// for example a user clicking result A over result B of an interleaved list.
+// Both arms of a duel may be the same arm, in which case there is nothing to
+// learn from it.
+type DuelingBandit interface {
+	SelectArms() (int, int)
+	UpdateDuel(winner, loser int)
+}
+
+// NewRUCB constructs a Relative Upper Confidence Bound dueling bandit, see
+// Zoghi et al., 2014, http://arxiv.org/abs/1312.3393. α in (1/2, ∞) scales the
+// width of the confidence bounds of the preference probabilities.
+func NewRUCB(arms int, α float64) (DuelingBandit, error) {
+	if arms < 1 {
+		return &rUCB{}, fmt.Errorf("need at least 1 arm")
+	}
+
+	if !(α > 0.5) {
+		return &rUCB{}, fmt.Errorf("α not in (1/2, ∞)")
+	}
+
+	wins := make([][]int, arms)
+	for i := range wins {
+		wins[i] = make([]int, arms)
+	}
+
+	return &rUCB{
+		alpha: α,
+		wins:  wins,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// rUCB keeps the number of duels each arm won against each other arm. The
+// probability that i beats j after n duels between them is bounded by
+//
+//	wins(i, j) / n + sqrt(α · log(t) / n)
+//
+// at duel t. Unseen pairs are bounded by 1, and an arm beats itself with 1/2.
+type rUCB struct {
+	sync.Mutex
+	alpha float64
+	wins  [][]int // wins[i][j] is the number of duels i won against j
+	duels int
+	rand  *rand.Rand
+}
+
+// SelectArms returns the 1 indexed candidate and its challenger. The candidate
+// is drawn from the arms which are not beaten by any arm with confidence, or
+// from all arms if there are none. The challenger is the arm most likely to
+// beat the candidate, which may be the candidate itself.
+func (r *rUCB) SelectArms() (int, int) {
+	r.Lock()
+	defer r.Unlock()
+
+	bounds := r.bounds()
+
+	var candidates []int
+	for c := range bounds {
+		beaten := false
+		for j := range bounds {
+			beaten = beaten || bounds[c][j] < 0.5
+		}
+
+		if !beaten {
+			candidates = append(candidates, c)
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = r.rand.Perm(len(bounds))
+	}
+
+	c := candidates[r.rand.Intn(len(candidates))]
+	max, d, ties := math.Inf(-1), 0, 0
+	for j := range bounds {
+		if bound := bounds[j][c]; bound > max {
+			max, d, ties = bound, j, 1
+		} else if bound == max {
+			ties++
+			if r.rand.Intn(ties) == 0 {
+				d = j
+			}
+		}
+	}
+
+	return c + 1, d + 1
+}
+
+// bounds returns the upper confidence bound of the probability that i beats
+// j, for all 0 indexed arms i and j. Callers hold the lock.
+func (r *rUCB) bounds() [][]float64 {
+	logT := math.Log(float64(r.duels + 1))
+	bounds := make([][]float64, len(r.wins))
+	for i := range bounds {
+		bounds[i] = make([]float64, len(r.wins))
+		for j := range bounds[i] {
+			n := float64(r.wins[i][j] + r.wins[j][i])
+			switch {
+			case i == j:
+				bounds[i][j] = 0.5
+			case n == 0:
+				bounds[i][j] = 1
+			default:
+				bounds[i][j] = float64(r.wins[i][j])/n + math.Sqrt(r.alpha*logT/n)
+			}
+		}
+	}
+
+	return bounds
+}
+
+// UpdateDuel records that 1 indexed `winner` was preferred over `loser`.
+// Duels of an arm against itself are ignored.
+func (r *rUCB) UpdateDuel(winner, loser int) {
+	if winner == loser {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.wins[winner-1][loser-1]++
+	r.duels++
+}
+
+// String returns information on this strategy.
+func (r *rUCB) String() string {
+	return fmt.Sprintf("RUCB(alpha=%.2f)", r.alpha)
+}
@@ -0,0 +1,43 @@
+package bandit
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRUCB(t *testing.T) {
+	s, err := NewRUCB(3, 0.51)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	// arm i beats arm j with 1/2 + (utility i - utility j) / 2
+	utilities := []float64{0.2, 0.5, 0.9}
+	r := rand.New(rand.NewSource(1))
+	duels, best := 5000, 0
+	for i := 0; i < duels; i++ {
+		a, b := s.SelectArms()
+		if a == b {
+			if i >= duels-1000 && a == 3 {
+				best++
+			}
+
+			continue
+		}
+
+		if r.Float64() < 0.5+(utilities[a-1]-utilities[b-1])/2 {
+			s.UpdateDuel(a, b)
+		} else {
+			s.UpdateDuel(b, a)
+		}
+	}
+
+	// once the winner is known, it duels against itself
+	if best < 800 {
+		t.Fatalf("expected arm 3 to duel against itself, got %d of the last 1000 duels", best)
+	}
+
+	if _, err := NewRUCB(3, 0.5); err == nil {
+		t.Fatalf("expected α of 1/2 to fail")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Elector decides if this process is the leader. Only the leader runs
+// background learning such as producing snapshots from logs; followers serve
+// selections from the snapshots the leader writes.
+type Elector interface {
+	IsLeader() (bool, error)
+}
+
+// NewFileElector returns an elector which holds leadership through a lease on
+// a lock file shared by all candidates, e.g. on a network file system. `id`
+// must be unique per candidate. The leader has to call IsLeader more often
+// than `lease` to keep leadership; an expired lease can be taken over by any
+// candidate. Takeovers are best effort and may briefly yield two leaders.
+func NewFileElector(filename, id string, lease time.Duration) Elector {
+	return &fileElector{
+		filename: filename,
+		id:       id,
+		lease:    lease,
+	}
+}
+
+// fileElector stores the leader id in a file. The file's modification time is
+// the start of the current lease.
+type fileElector struct {
+	sync.Mutex
+	filename string
+	id       string
+	lease    time.Duration
+}
+
+// IsLeader renews the lease if this candidate holds it, or takes it over if it
+// has expired.
+func (f *fileElector) IsLeader() (bool, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	info, err := os.Stat(f.filename)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return false, fmt.Errorf("could not stat lock: %s", err.Error())
+	case time.Since(info.ModTime()) < f.lease:
+		leader, err := ioutil.ReadFile(f.filename)
+		if err != nil {
+			return false, fmt.Errorf("could not read lock: %s", err.Error())
+		}
+
+		if string(leader) != f.id {
+			return false, nil
+		}
+	}
+
+	if err := f.write(); err != nil {
+		return false, err
+	}
+
+	// another candidate may have taken over concurrently. last writer wins.
+	leader, err := ioutil.ReadFile(f.filename)
+	if err != nil {
+		return false, fmt.Errorf("could not read lock: %s", err.Error())
+	}
+
+	return string(leader) == f.id, nil
+}
+
+// write atomically replaces the lock file with this candidate's id.
+func (f *fileElector) write() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.filename), filepath.Base(f.filename))
+	if err != nil {
+		return fmt.Errorf("could not create lock: %s", err.Error())
+	}
+
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(f.id); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write lock: %s", err.Error())
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write lock: %s", err.Error())
+	}
+
+	if err := os.Rename(tmp.Name(), f.filename); err != nil {
+		return fmt.Errorf("could not replace lock: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package bandit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileElector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bandit-elector")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	lock := filepath.Join(dir, "leader")
+	a := NewFileElector(lock, "a", time.Hour)
+	b := NewFileElector(lock, "b", time.Hour)
+
+	if leader, err := a.IsLeader(); err != nil || !leader {
+		t.Fatalf("expected a to become leader: %v", err)
+	}
+
+	if leader, err := b.IsLeader(); err != nil || leader {
+		t.Fatalf("expected b to follow: %v", err)
+	}
+
+	if leader, err := a.IsLeader(); err != nil || !leader {
+		t.Fatalf("expected a to renew leadership: %v", err)
+	}
+
+	// expire the lease
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(lock, past, past); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if leader, err := b.IsLeader(); err != nil || !leader {
+		t.Fatalf("expected b to take over: %v", err)
+	}
+
+	if leader, err := a.IsLeader(); err != nil || leader {
+		t.Fatalf("expected a to follow: %v", err)
+	}
+}
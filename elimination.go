@@ -0,0 +1,248 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"math"
+)
+
+// NewSuccessiveElimination constructs a pure exploration strategy, see
+// Even-Dar et al., 2006, "Action Elimination and Stopping Conditions for the
+// Multi-Armed Bandit and Reinforcement Learning Problems". It pulls the
+// remaining arms in turn and eliminates those which are worse than the leader
+// with confidence. With probability at least 1 - δ, the arm returned by Best
+// is within ε of the best arm.
+func NewSuccessiveElimination(arms int, ε, δ float64) (*SuccessiveElimination, error) {
+	if !(ε >= 0 && ε < 1) {
+		return &SuccessiveElimination{}, fmt.Errorf("ε not in [0, 1)")
+	}
+
+	if !(δ > 0 && δ < 1) {
+		return &SuccessiveElimination{}, fmt.Errorf("δ not in (0, 1)")
+	}
+
+	return &SuccessiveElimination{
+		Counters:   NewCounters(arms),
+		ε:          ε,
+		δ:          δ,
+		eliminated: make([]bool, arms),
+	}, nil
+}
+
+// SuccessiveElimination keeps confidence intervals
+//
+//	value ± sqrt(log(4 K n² / δ) / 2n)
+//
+// for an arm pulled n times out of K arms, for rewards in [0, 1]. An arm is
+// eliminated once its upper bound falls below the lower bound of the leader.
+// Raw values are compared, since priorities and costs describe what to serve
+// rather than which arm is best.
+type SuccessiveElimination struct {
+	Counters
+	ε, δ       float64
+	eliminated []bool
+}
+
+// SelectArm returns the 1 indexed remaining arm with fewest pulls. Once the
+// best arm is identified, it is always returned.
+func (s *SuccessiveElimination) SelectArm() int {
+	s.Lock()
+	defer s.Unlock()
+
+	arms := s.candidates()
+	arm := arms[0]
+	if len(arms) > 1 && s.ties != TiesOrdinal {
+		arm = arms[s.rand.Intn(len(arms))]
+	}
+
+	s.counts[arm]++
+	return arm + 1
+}
+
+// candidates returns the 0 indexed arms SelectArm chooses from.
+func (s *SuccessiveElimination) candidates() []int {
+	if best, ok := s.best(); ok {
+		return []int{best}
+	}
+
+	var arms []int
+	fewest := math.MaxInt64
+	for i, count := range s.counts {
+		if s.eliminated[i] {
+			continue
+		}
+
+		if count < fewest {
+			arms, fewest = []int{i}, count
+		} else if count == fewest {
+			arms = append(arms, i)
+		}
+	}
+
+	return arms
+}
+
+// Update the running average of the 1 indexed arm and eliminate arms.
+func (s *SuccessiveElimination) Update(arm int, reward float64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.update(arm, reward)
+	s.eliminate()
+}
+
+// UpdateBatch applies rewards to the 1 indexed arm in order and eliminates
+// arms once.
+func (s *SuccessiveElimination) UpdateBatch(arm int, rewards []float64) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, reward := range rewards {
+		s.update(arm, reward)
+	}
+
+	s.eliminate()
+}
+
+// radius returns the half width of the confidence interval of an arm pulled
+// `count` times. Arms which were never pulled have infinite intervals.
+func (s *SuccessiveElimination) radius(count int) float64 {
+	if count == 0 {
+		return math.Inf(1)
+	}
+
+	n := float64(count)
+	return math.Sqrt(math.Log(4*float64(s.arms)*n*n/s.δ) / (2 * n))
+}
+
+// eliminate removes arms whose upper bound is below the lower bound of the
+// leader, without locking. Eliminated arms are never restored.
+func (s *SuccessiveElimination) eliminate() {
+	leader, lower := -1, math.Inf(-1)
+	for i, count := range s.counts {
+		if !s.eliminated[i] && s.values[i]-s.radius(count) > lower {
+			leader, lower = i, s.values[i]-s.radius(count)
+		}
+	}
+
+	for i, count := range s.counts {
+		if i != leader && !s.eliminated[i] && s.values[i]+s.radius(count) < lower {
+			s.eliminated[i] = true
+		}
+	}
+}
+
+// best returns the 0 indexed best arm once a single arm remains, or once all
+// remaining intervals are narrower than ε, without locking.
+func (s *SuccessiveElimination) best() (int, bool) {
+	leader, remaining, narrow := -1, 0, true
+	for i, count := range s.counts {
+		if s.eliminated[i] {
+			continue
+		}
+
+		remaining++
+		narrow = narrow && 2*s.radius(count) <= s.ε
+		if leader < 0 || s.values[i] > s.values[leader] {
+			leader = i
+		}
+	}
+
+	return leader, leader >= 0 && (remaining == 1 || narrow)
+}
+
+// RemainingArms returns the 1 indexed arms which were not eliminated.
+func (s *SuccessiveElimination) RemainingArms() []int {
+	s.Lock()
+	defer s.Unlock()
+
+	var arms []int
+	for i, eliminated := range s.eliminated {
+		if !eliminated {
+			arms = append(arms, i+1)
+		}
+	}
+
+	return arms
+}
+
+// Best returns the 1 indexed best arm, and true once it was identified.
+func (s *SuccessiveElimination) Best() (int, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	best, ok := s.best()
+	if !ok {
+		return 0, false
+	}
+
+	return best + 1, true
+}
+
+// Convergence considers the experiment converged once the best arm was
+// identified, so that it can be stopped with Experiment.Promote. Stats are
+// not used.
+func (s *SuccessiveElimination) Convergence() Convergence {
+	return func(Stats) (int, bool) {
+		return s.Best()
+	}
+}
+
+// Init the strategy to a new counter state. Arms are eliminated based on the
+// snapshot.
+func (s *SuccessiveElimination) Init(snapshot *Counters) error {
+	if err := s.Counters.Init(snapshot); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.eliminated = make([]bool, s.arms)
+	s.eliminate()
+	return nil
+}
+
+// Reset the strategy to initial state.
+func (s *SuccessiveElimination) Reset() {
+	s.ResetWithArchive()
+}
+
+// ResetWithArchive resets the counters and restores all arms at once.
+func (s *SuccessiveElimination) ResetWithArchive() *Counters {
+	s.Lock()
+	defer s.Unlock()
+
+	s.eliminated = make([]bool, s.arms)
+	return s.reset()
+}
+
+// Discount fails, since confidence intervals assume unweighted rewards.
+func (s *SuccessiveElimination) Discount(γ float64) error {
+	return fmt.Errorf("%s assumes stationary rewards and cannot be discounted", s)
+}
+
+// Distribution returns the probability of selecting each arm.
+func (s *SuccessiveElimination) Distribution() []float64 {
+	s.Lock()
+	defer s.Unlock()
+
+	arms := s.candidates()
+	if s.ties == TiesOrdinal {
+		arms = arms[:1]
+	}
+
+	distribution := make([]float64, s.arms)
+	for _, arm := range arms {
+		distribution[arm] = 1 / float64(len(arms))
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (s *SuccessiveElimination) String() string {
+	return fmt.Sprintf("SuccessiveElimination(ε=%.2f, δ=%.2f)", s.ε, s.δ)
+}
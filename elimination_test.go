@@ -0,0 +1,114 @@
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"reflect"
+	"testing"
+)
+
+func TestSuccessiveElimination(t *testing.T) {
+	runs := 20
+	maxPulls := 100000
+	bestArm := 4 // Bernoulli(bestArm)
+	arms := []sim.Arm{
+		bmath.BernRand(0.1),
+		bmath.BernRand(0.3),
+		bmath.BernRand(0.2),
+		bmath.BernRand(0.8),
+	}
+
+	for run := 0; run < runs; run++ {
+		strategy, err := New(len(arms), "successiveElimination", []float64{0.05, 0.05})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		s := strategy.(*SuccessiveElimination)
+		for pull := 0; pull < maxPulls; pull++ {
+			if _, ok := s.Best(); ok {
+				break
+			}
+
+			arm := s.SelectArm()
+			s.Update(arm, arms[arm-1]())
+		}
+
+		if best, ok := s.Best(); !ok || best != bestArm {
+			t.Fatalf("expected arm %d to be identified, got %d, %v", bestArm, best, ok)
+		}
+	}
+
+	if _, err := NewSuccessiveElimination(2, 1, 0.05); err == nil {
+		t.Fatalf("expected ε of 1 to fail")
+	}
+
+	if _, err := NewSuccessiveElimination(2, 0.05, 0); err == nil {
+		t.Fatalf("expected δ of 0 to fail")
+	}
+}
+
+func TestSuccessiveEliminationEliminates(t *testing.T) {
+	s, _ := NewSuccessiveElimination(3, 0, 0.05)
+	c := NewCounters(3)
+	c.counts = []int{1000, 1000, 1000}
+	c.values = []float64{0.2, 0.8, 0.78}
+	if err := s.Init(&c); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := s.RemainingArms(); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Fatalf("expected arm 1 to be eliminated, got remaining %v", got)
+	}
+
+	if _, ok := s.Best(); ok {
+		t.Fatalf("expected close arms not to be told apart")
+	}
+
+	// remaining arms are pulled in turn
+	for i := 0; i < 10; i++ {
+		if arm := s.SelectArm(); arm == 1 {
+			t.Fatalf("expected eliminated arm not to be selected")
+		}
+	}
+
+	s.Reset()
+	if got := s.RemainingArms(); len(got) != 3 {
+		t.Fatalf("expected reset to restore arms, got %v", got)
+	}
+}
+
+func TestSuccessiveEliminationPromotes(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	s, _ := NewSuccessiveElimination(2, 0.05, 0.05)
+	e.Strategy = s
+
+	var promoted []Variation
+	p := PromoterFunc(func(e *Experiment, winner Variation) error {
+		promoted = append(promoted, winner)
+		return nil
+	})
+
+	if ok, err := e.Promote(s.Convergence(), p, nil); ok || err != nil {
+		t.Fatalf("expected fresh experiment not to converge: %v", err)
+	}
+
+	c := NewCounters(2)
+	c.counts = []int{500, 500}
+	c.values = []float64{0.1, 0.9}
+	if err := s.Init(&c); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if ok, err := e.Promote(s.Convergence(), p, nil); !ok || err != nil {
+		t.Fatalf("expected promotion: %v", err)
+	}
+
+	if len(promoted) != 1 || promoted[0].Ordinal != 2 {
+		t.Fatalf("expected ordinal 2 to be promoted, got %v", promoted)
+	}
+}
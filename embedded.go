@@ -0,0 +1,297 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxRecord bounds the size of a single transaction in bytes.
+const maxRecord = 1 << 28
+
+// NewEmbeddedStore opens the single file store at `filename`, creating it if
+// necessary. It needs no server and suits single node deployments. The file is
+// locked, so that a second process cannot open it.
+func NewEmbeddedStore(filename string) (*EmbeddedStore, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return &EmbeddedStore{}, fmt.Errorf("could not open store: %s", err.Error())
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return &EmbeddedStore{}, err
+	}
+
+	s := &EmbeddedStore{
+		filename: filename,
+		file:     file,
+		data:     make(map[string][]byte),
+	}
+
+	if err := s.load(); err != nil {
+		file.Close()
+		return &EmbeddedStore{}, err
+	}
+
+	return s, nil
+}
+
+// EmbeddedStore is an append only log of transactions. Each transaction is a
+// record holding a length, a CRC-32 checksum and a json map of keys to
+// values. A transaction is visible only once its record is completely written
+// and synced, so a crash never leaves it half applied. The log is compacted
+// into a single record when it grows.
+type EmbeddedStore struct {
+	sync.Mutex
+	filename string
+	file     storeFile
+	data     map[string][]byte
+	records  int
+}
+
+// storeFile is the log file of an EmbeddedStore.
+type storeFile interface {
+	io.ReadWriteSeeker
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// Get returns the value of `key`, or ErrNotFound.
+func (s *EmbeddedStore) Get(key string) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	value, ok := s.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return value, nil
+}
+
+// Put stores `value` under `key` in its own transaction.
+func (s *EmbeddedStore) Put(key string, value []byte) error {
+	return s.PutAll(map[string][]byte{key: value})
+}
+
+// PutAll stores all values in a single transaction.
+func (s *EmbeddedStore) PutAll(values map[string][]byte) error {
+	s.Lock()
+	defer s.Unlock()
+
+	offset, err := s.file.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return fmt.Errorf("could not seek store: %s", err.Error())
+	}
+
+	if err := writeRecord(s.file, values); err != nil {
+		return s.rewind(offset, err)
+	}
+
+	if err := s.file.Sync(); err != nil {
+		return s.rewind(offset, fmt.Errorf("could not sync store: %s", err.Error()))
+	}
+
+	for key, value := range values {
+		s.data[key] = value
+	}
+
+	// the transaction is durable, so a failed compaction is retried later
+	s.records++
+	if s.records > 1000 && s.records > 2*len(s.data) {
+		if err := s.compact(); err != nil {
+			log.Printf("could not compact %s: %s", s.filename, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// rewind drops a partially written transaction at the end of the log, which
+// failed with `cause`, so that the next transaction does not follow a torn
+// record. It returns `cause`.
+func (s *EmbeddedStore) rewind(offset int64, cause error) error {
+	if err := s.file.Truncate(offset); err != nil {
+		return fmt.Errorf("%s, and could not truncate it: %s", cause.Error(), err.Error())
+	}
+
+	if _, err := s.file.Seek(offset, os.SEEK_SET); err != nil {
+		return fmt.Errorf("%s, and could not seek back: %s", cause.Error(), err.Error())
+	}
+
+	return cause
+}
+
+// Close closes the underlying file.
+func (s *EmbeddedStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.file.Close()
+}
+
+// load replays all transactions. A torn record at the end of the log, left by
+// a crash during a write, is truncated. Any other bad record is corruption,
+// and fails the load rather than dropping the records after it.
+func (s *EmbeddedStore) load() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat store: %s", err.Error())
+	}
+
+	r := bufio.NewReader(s.file)
+	var offset int64
+	for {
+		values, n, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			if err != errTorn && offset+n < info.Size() {
+				return fmt.Errorf("corrupt record at offset %d of %s, move the store aside to start over: %s", offset, s.filename, err.Error())
+			}
+
+			if err := s.file.Truncate(offset); err != nil {
+				return fmt.Errorf("could not truncate torn record: %s", err.Error())
+			}
+
+			break
+		}
+
+		for key, value := range values {
+			s.data[key] = value
+		}
+
+		offset += n
+		s.records++
+	}
+
+	if _, err := s.file.Seek(offset, os.SEEK_SET); err != nil {
+		return fmt.Errorf("could not seek store: %s", err.Error())
+	}
+
+	return nil
+}
+
+// compact rewrites the log as a single transaction. The directory is synced
+// after the rename, so that the compacted log survives a crash.
+func (s *EmbeddedStore) compact() error {
+	tmp, err := os.Create(s.filename + ".compact")
+	if err != nil {
+		return fmt.Errorf("could not compact store: %s", err.Error())
+	}
+
+	discard := func(err error) error {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := writeRecord(tmp, s.data); err != nil {
+		return discard(err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return discard(fmt.Errorf("could not sync store: %s", err.Error()))
+	}
+
+	// the lock of the old file is released once it is replaced
+	if err := lockFile(tmp); err != nil {
+		return discard(err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.filename); err != nil {
+		return discard(fmt.Errorf("could not replace store: %s", err.Error()))
+	}
+
+	s.file.Close()
+	s.file = tmp
+	s.records = 1
+
+	return syncDir(filepath.Dir(s.filename))
+}
+
+// syncDir flushes the entries of directory `dir`, such as a rename, to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("could not open directory: %s", err.Error())
+	}
+
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("could not sync directory: %s", err.Error())
+	}
+
+	return nil
+}
+
+// writeRecord appends a single transaction.
+func writeRecord(w io.Writer, values map[string][]byte) error {
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("could not marshal transaction: %s", err.Error())
+	}
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(payload))
+
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("could not write transaction: %s", err.Error())
+	}
+
+	return nil
+}
+
+// errTorn is returned for a record which the log ends in.
+var errTorn = fmt.Errorf("torn record")
+
+// readRecord reads a single transaction and returns its size in bytes. The
+// size of a bad record is returned along with the error where it is known.
+func readRecord(r io.Reader) (map[string][]byte, int64, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, 0, errTorn
+		}
+
+		return nil, 0, err
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size > maxRecord {
+		return nil, 0, fmt.Errorf("record of %d bytes too large", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, errTorn
+	}
+
+	n := int64(8 + len(payload))
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, n, fmt.Errorf("bad checksum")
+	}
+
+	var values map[string][]byte
+	if err := json.Unmarshal(payload, &values); err != nil {
+		return nil, n, fmt.Errorf("bad transaction: %s", err.Error())
+	}
+
+	return values, n, nil
+}
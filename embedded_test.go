@@ -0,0 +1,214 @@
+package bandit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestEmbeddedStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bandit-store")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "bandit.db")
+	s, err := NewEmbeddedStore(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	c := NewCounters(2)
+	c.counts[0], c.counts[1] = 10, 20
+	c.values[0], c.values[1] = 0.25, 0.5
+	if err := es.index["shape-20130822"].Strategy.Init(&c); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if err := SaveExperiments(s, es); err != nil {
+		t.Fatalf("could not save: %s", err.Error())
+	}
+
+	s.Close()
+
+	// a torn write after the last transaction is dropped on load
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	f.Write([]byte{0, 0, 0, 42, 1, 2})
+	f.Close()
+
+	s, err = NewEmbeddedStore(filename)
+	if err != nil {
+		t.Fatalf("could not reopen: %s", err.Error())
+	}
+
+	defer s.Close()
+
+	restored, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	if _, err := RestoreExperiments(s, restored, RestoreFail); err != nil {
+		t.Fatalf("could not restore: %s", err.Error())
+	}
+
+	got := restored.index["shape-20130822"].Strategy.Snapshot()
+	if got.counts[1] != 20 || got.values[1] != 0.5 {
+		t.Fatalf("expected restored state, got %v %v", got.counts, got.values)
+	}
+
+	if _, err := s.Get("unknown"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.Put("unknown", []byte("{}")); err != nil {
+		t.Fatalf("could not put after truncation: %s", err.Error())
+	}
+
+	if _, err := NewEmbeddedStore(filename); err == nil {
+		t.Fatalf("expected a store in use to be locked")
+	}
+}
+
+func TestEmbeddedStoreCorrupt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bandit-store")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "bandit.db")
+	s, err := NewEmbeddedStore(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s.Put("first", []byte("{}"))
+	s.Put("second", []byte("{}"))
+	s.Close()
+
+	// a bad checksum in the first record is followed by a valid one
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	data[4]++
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if _, err := NewEmbeddedStore(filename); err == nil {
+		t.Fatalf("expected corruption to fail the load")
+	}
+
+	if kept, _ := ioutil.ReadFile(filename); len(kept) != len(data) {
+		t.Fatalf("expected corrupt store to be kept, got %d of %d bytes", len(kept), len(data))
+	}
+}
+
+// failingFile writes half of each write to the store file and then fails,
+// like a disk running full.
+type failingFile struct {
+	*os.File
+}
+
+func (f failingFile) Write(p []byte) (int, error) {
+	n, _ := f.File.Write(p[:len(p)/2])
+	return n, syscall.ENOSPC
+}
+
+func TestEmbeddedStoreFailedWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bandit-store")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "bandit.db")
+	s, err := NewEmbeddedStore(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s.Put("first", []byte("{}"))
+	file := s.file.(*os.File)
+	s.file = failingFile{file}
+	if err := s.Put("failed", []byte("{}")); err == nil {
+		t.Fatalf("expected a failed write to fail the transaction")
+	}
+
+	// the torn bytes are dropped, so later transactions can be loaded
+	s.file = file
+	if err := s.Put("second", []byte("{}")); err != nil {
+		t.Fatalf("could not put after a failed write: %s", err.Error())
+	}
+
+	s.Close()
+	s, err = NewEmbeddedStore(filename)
+	if err != nil {
+		t.Fatalf("could not reopen after a failed write: %s", err.Error())
+	}
+
+	defer s.Close()
+	if _, err := s.Get("second"); err != nil {
+		t.Fatalf("expected transaction after the failed write, got %v", err)
+	}
+
+	if _, err := s.Get("failed"); err != ErrNotFound {
+		t.Fatalf("expected failed transaction to be dropped, got %v", err)
+	}
+}
+
+func TestEmbeddedStoreFailedCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bandit-store")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	// a directory in the way of the compacted log fails compaction
+	filename := filepath.Join(dir, "bandit.db")
+	if err := os.Mkdir(filename+".compact", 0755); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := NewEmbeddedStore(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer s.Close()
+	for i := 0; i < 1010; i++ {
+		if err := s.Put("shape", []byte(fmt.Sprintf("%d", i))); err != nil {
+			t.Fatalf("expected durable put to pass despite compaction: %s", err.Error())
+		}
+	}
+
+	if s.records != 1010 {
+		t.Fatalf("expected uncompacted log of 1010 records, got %d", s.records)
+	}
+
+	// compaction is retried once it can succeed
+	os.Remove(filename + ".compact")
+	if err := s.Put("shape", []byte("done")); err != nil || s.records != 1 {
+		t.Fatalf("expected compacted log, got %d records: %v", s.records, err)
+	}
+}
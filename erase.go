@@ -0,0 +1,110 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Eraser is implemented by components which keep state linked to a user id,
+// so that deletion requests can be served without custom scripts.
+type Eraser interface {
+	Erase(uid string) int
+}
+
+// Erase removes all state linked to `uid` from the given erasers. Returns the
+// number of removed records.
+func Erase(uid string, erasers ...Eraser) int {
+	removed := 0
+	for _, eraser := range erasers {
+		removed += eraser.Erase(uid)
+	}
+
+	return removed
+}
+
+// Erase drops the open selection keyed by `uid` without rewarding it. Use the
+// user id as the selection key if funnel state has to be erasable.
+func (f *Funnel) Erase(uid string) int {
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.pending[uid]; !ok {
+		return 0
+	}
+
+	delete(f.pending, uid)
+	return 1
+}
+
+// PurgeLog copies the selection and reward log `r` to `w`, dropping all lines
+// of experiments which are older than the experiment's retention at time
+// `now`. Lines of unknown experiments and other lines are kept. Returns the
+// number of dropped lines.
+func PurgeLog(r io.Reader, w io.Writer, es *Experiments, now time.Time) (int, error) {
+	dropped, retentions := 0, retentions(es)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if expired(line, retentions, now) {
+			dropped++
+			continue
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return dropped, fmt.Errorf("could not write log: %s", err.Error())
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return dropped, fmt.Errorf("could not read log: %s", err.Error())
+	}
+
+	return dropped, nil
+}
+
+// retentions maps the tag of each variation to the retention of its
+// experiment.
+func retentions(es *Experiments) map[string]time.Duration {
+	retentions := make(map[string]time.Duration)
+	for _, e := range es.All() {
+		for _, v := range e.Variations {
+			retentions[v.Tag] = e.Retention
+		}
+	}
+
+	return retentions
+}
+
+// expired is true if `line` is a selection or reward line past the retention
+// of its tag.
+func expired(line string, retentions map[string]time.Duration, now time.Time) bool {
+	fields := strings.Fields(line)
+	for i := 1; i+1 < len(fields); i++ {
+		if fields[i] != banditSelection && fields[i] != banditReward {
+			continue
+		}
+
+		ts, err := strconv.ParseInt(fields[i-1], 10, 64)
+		if err != nil {
+			return false
+		}
+
+		retention, ok := retentions[fields[i+1]]
+		if !ok {
+			if untimed, _, err := TimestampedTagToTag(fields[i+1]); err == nil {
+				retention, ok = retentions[untimed]
+			}
+		}
+
+		return ok && retention > 0 && now.Sub(time.Unix(ts, 0)) > retention
+	}
+
+	return false
+}
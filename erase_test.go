@@ -0,0 +1,55 @@
+package bandit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPurgeLog(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	es.index["shape-20130822"].Retention = 24 * time.Hour
+
+	log := strings.Join([]string{
+		"1379257984 BanditSelection shape-20130822:1",
+		"1379257987 BanditReward shape-20130822:1:1379257984 0.000000",
+		"1379257988 BanditSelection plants-20121111:1",
+		"1379344400 BanditSelection shape-20130822:2",
+	}, "\n")
+
+	w := new(bytes.Buffer)
+	dropped, err := PurgeLog(strings.NewReader(log), w, es, time.Unix(1379344500, 0))
+	if err != nil {
+		t.Fatalf("could not purge: %s", err.Error())
+	}
+
+	if dropped != 2 {
+		t.Fatalf("expected 2 dropped lines, got %d", dropped)
+	}
+
+	expected := "1379257988 BanditSelection plants-20121111:1\n1379344400 BanditSelection shape-20130822:2\n"
+	if got := w.String(); got != expected {
+		t.Fatalf("expected '%s' but got '%s'", expected, got)
+	}
+}
+
+func TestEraseFunnel(t *testing.T) {
+	f, err := NewFunnel(NewUCB1(2), map[string]float64{"view": 1}, time.Hour)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	f.Select("user-11", 1, time.Now())
+	if got := Erase("user-11", f); got != 1 {
+		t.Fatalf("expected 1 erased record, got %d", got)
+	}
+
+	if err := f.Step("user-11", "view", time.Now()); err == nil {
+		t.Fatalf("expected erased selection to be gone")
+	}
+}
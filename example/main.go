@@ -50,7 +50,7 @@ func main() {
 	mux := pat.New()
 	mux.Get("/es/:name", bhttp.SelectionHandler(e, *exPinTTL))
 	mux.Get("/widget", http.HandlerFunc(widget))
-	mux.Get("/feedback", bhttp.LogRewardHandler(e))
+	mux.Post("/feedback", bhttp.LogRewardHandler(e))
 	mux.Get("/", http.HandlerFunc(index))
 	http.Handle("/", mux)
 
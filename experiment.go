@@ -6,11 +6,15 @@ package bandit
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"io/fs"
 	"log"
+	"math/rand"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,11 +25,14 @@ func NewExperiment(o Opener, name string) (*Experiment, error) {
 		return &Experiment{}, err
 	}
 
-	e, ok := (*es)[name]
+	e, ok := es.index[name]
 	if !ok {
+		es.Close()
 		return &Experiment{}, fmt.Errorf("could not find '%s' experiment", name)
 	}
 
+	delete(es.index, name)
+	es.Close()
 	return e, nil
 }
 
@@ -36,16 +43,174 @@ type Experiment struct {
 	Strategy         Strategy
 	Variations       Variations
 	PreferredOrdinal int
+	PreferredLocales map[string]int    // locale to preferred ordinal
+	Retention        time.Duration     // how long logs are kept. 0 keeps them forever.
+	Breakdown        *Breakdown        // selections per arm by caller provided dimension
+	History          *History          // arm estimates over time, recorded by Track
+	Latencies        *Latencies        // selection and update latencies
+	Samples          *Samples          // raw rewards per variation
+	Seeds            map[int]int       // ordinal to ordinal of a similar variation
+	SeedPulls        int               // max pulls of new variations seeded on Apply, see Seed. 0 disables.
+	Features         map[int][]float64 // ordinal to arm features
+	ClientURLs       map[int]URLs      // ordinal to urls per client type, see ForClient
+	Guardrails       []Guardrail       // bounds on secondary reward metrics
+	Rules            []Rule            // conditions on windowed metrics, see Rules
+	MinExposure      int               // minimum selections per hour, see Exposure. 0 disables.
+	Freshness        *Freshness        // last selection and reward per variation
+	MaxRewardGap     time.Duration     // max time a selected variation goes unrewarded, see Exposure. 0 disables.
+	Salt             string            // salt of uid buckets, see Bucket. blank is the name.
+	CostBudget       float64           // max average cost of selections. 0 optimizes reward minus cost.
+	RewardUnit       string            // unit of rewards in stats, e.g. "conversions" or "EUR". blank is "reward".
+	disabled         *armSet           // arms which must not be served
+	arms             []int             // ordinal to strategy arm. nil without groups.
+	members          [][]int           // strategy arm to ordinals. nil without groups.
+	winner           int32             // ordinal of the promoted variation, 0 while running
+	paused           int32             // 1 while paused, see Pause. accessed atomically.
+	build            builder           // fresh configured strategy. nil if not cloneable.
+	config           json.RawMessage   // as configured, see Experiments.Config. nil if not parsed.
+	selections       int64             // served selections. accessed atomically.
+	spent            *spending         // costs of selections under a cost budget
+	changes          *changes          // lifecycle changes, see Lifecycle
+	annotations      *annotations      // notes by operators, see Annotate
+	rules            *Rules            // engine evaluating Rules. nil without rules.
+	channels         *Channels         // metrics checked by Guardrails. nil without guardrails.
 }
 
 // Select calls SelectArm on the strategy and returns the associated variation
 func (e *Experiment) Select() Variation {
-	selected := e.Strategy.SelectArm()
-	if selected > len(e.Variations) {
+	return e.SelectLocale("")
+}
+
+// SelectLocale is Select for a caller in `locale`, such as "de-AT". Whenever
+// the preferred variation is served instead of the strategy's selection, it
+// is the one preferred for the locale, see Preferred.
+func (e *Experiment) SelectLocale(locale string) Variation {
+	if e.Latencies != nil {
+		defer e.Latencies.Select.since(time.Now())
+	}
+
+	atomic.AddInt64(&e.selections, 1)
+	v := e.selectVariation(locale)
+	if e.Freshness != nil {
+		e.Freshness.Selected(v.Ordinal, time.Now())
+	}
+
+	return v
+}
+
+// selectVariation returns the variation to serve in `locale`: the winner of
+// an archived experiment, the fallback of a paused one, or else the
+// strategy's selection.
+func (e *Experiment) selectVariation(locale string) Variation {
+	if v, ok := e.Archived(); ok {
+		return v
+	}
+
+	if e.Paused() || Killed() {
+		v, _ := e.GetVariation(e.fallback(locale))
+		return v
+	}
+
+	var rejected []int
+	selected, ok := e.selectOrdinal()
+	for draws := 1; ok && (e.disabled.contains(selected) || e.overBudget(selected)); draws++ {
+		rejected = append(rejected, e.Arm(selected))
+
+		// all arms may be disabled or over budget. serve a fallback instead.
+		if draws >= maxDraws {
+			selected = e.fallback(locale)
+			break
+		}
+
+		selected, ok = e.selectOrdinal()
+	}
+
+	// the strategy refused to select, e.g. since its budget is spent.
+	if !ok {
+		selected = e.fallback(locale)
+	}
+
+	// rejected draws were never served and must not count as pulls.
+	if rejected != nil {
+		release(e.Strategy, rejected)
+	}
+
+	e.spend(selected)
+	v, err := e.GetVariation(selected)
+	if err != nil {
+		panic(fmt.Sprintf("selected retired ordinal %d", selected))
+	}
+
+	return v
+}
+
+// selectOrdinal calls SelectArm on the strategy and returns the ordinal of a
+// variation of the selected arm, or false if a Refuser refused to select.
+func (e *Experiment) selectOrdinal() (int, bool) {
+	arm, ok := selectAffordableArm(e.Strategy)
+	if !ok {
+		return 0, false
+	}
+
+	if arm > e.Arms() {
 		panic("selected impossible arm")
 	}
 
-	v, _ := e.GetVariation(selected)
+	return e.pick(arm), true
+}
+
+// Update credits `reward` to the strategy arm of the variation with the given
+// ordinal.
+func (e *Experiment) Update(ordinal int, reward float64) {
+	if e.Latencies != nil {
+		defer e.Latencies.Update.since(time.Now())
+	}
+
+	if e.Samples != nil {
+		e.Samples.Add(ordinal, reward, 1)
+	}
+
+	if e.Freshness != nil {
+		e.Freshness.Rewarded(ordinal, time.Now())
+	}
+
+	e.Strategy.Update(e.Arm(ordinal), reward)
+}
+
+// UpdateBatch credits all `rewards` to the strategy arm of the variation with
+// the given ordinal, like an Update per reward but with a single call into the
+// strategy. Reward aggregation jobs can push a day of rewards this way.
+func (e *Experiment) UpdateBatch(ordinal int, rewards []float64) {
+	if e.Latencies != nil {
+		defer e.Latencies.Update.since(time.Now())
+	}
+
+	if e.Samples != nil {
+		for _, reward := range rewards {
+			e.Samples.Add(ordinal, reward, 1)
+		}
+	}
+
+	if e.Freshness != nil && len(rewards) > 0 {
+		e.Freshness.Rewarded(ordinal, time.Now())
+	}
+
+	UpdateBatch(e.Strategy, e.Arm(ordinal), rewards)
+}
+
+// SelectIn calls Select and counts the selection under `dimension`, e.g. the
+// caller's country code. The blank dimension is not counted.
+func (e *Experiment) SelectIn(dimension string) Variation {
+	return e.selectIn("", dimension)
+}
+
+// selectIn is SelectLocale counting the selection under `dimension`.
+func (e *Experiment) selectIn(locale, dimension string) Variation {
+	v := e.SelectLocale(locale)
+	if e.Breakdown != nil && dimension != "" {
+		e.Breakdown.Add(dimension, v.Ordinal)
+	}
+
 	return v
 }
 
@@ -58,10 +223,32 @@ func (e *Experiment) Select() Variation {
 func (e *Experiment) SelectTimestamped(
 	timestampedTag string,
 	ttl time.Duration) (Variation, string, error) {
+	return e.SelectWith(timestampedTag, SelectOptions{TTL: ttl})
+}
+
+// SelectTimestampedLocale is SelectTimestamped for a caller in `locale`, see
+// SelectLocale.
+func (e *Experiment) SelectTimestampedLocale(
+	timestampedTag string,
+	ttl time.Duration,
+	locale string) (Variation, string, error) {
+	return e.SelectWith(timestampedTag, SelectOptions{TTL: ttl, Locale: locale})
+}
+
+// SelectOptions describe the caller of a selection, see SelectWith.
+type SelectOptions struct {
+	TTL       time.Duration // pinning of timestamped tags, see SelectTimestamped
+	Locale    string        // locale of the caller, see SelectLocale
+	Dimension string        // breakdown of the selection, see SelectIn
+}
+
+// SelectWith is SelectTimestamped for the caller described by `o`. Only new
+// selections are counted under the dimension, pinned tags are not.
+func (e *Experiment) SelectWith(timestampedTag string, o SelectOptions) (Variation, string, error) {
 	now := time.Now().Unix()
 
 	if timestampedTag == "" {
-		selected := e.Select()
+		selected := e.selectIn(o.Locale, o.Dimension)
 		return selected, makeTimestampedTag(selected, now), nil
 	}
 
@@ -71,7 +258,7 @@ func (e *Experiment) SelectTimestamped(
 	}
 
 	// return the given timestamped tag
-	if ttl > time.Since(time.Unix(ts, 0)) {
+	if o.TTL > time.Since(time.Unix(ts, 0)) {
 		v, err := e.GetTaggedVariation(tag)
 
 		// could not get tagged variation. this can occurr when switching between
@@ -79,20 +266,40 @@ func (e *Experiment) SelectTimestamped(
 		// failures because the old experiment name is unknown.
 		if err != nil {
 			log.Printf("repinned after error: %s", err.Error())
-			selected := e.Select()
+			selected := e.selectIn(o.Locale, o.Dimension)
 			return selected, makeTimestampedTag(selected, now), nil
 		}
 
 		return v, makeTimestampedTag(v, ts), err
 	}
 
-	selected := e.Select()
+	selected := e.selectIn(o.Locale, o.Dimension)
 	return selected, makeTimestampedTag(selected, now), nil
 }
 
+// Preferred returns the preferred (control) variation for the given locale,
+// such as "de-AT". Falls back from the full locale to its language and then
+// to the experiment wide preferred variation.
+func (e *Experiment) Preferred(locale string) Variation {
+	candidates := []string{locale}
+	if sep := strings.IndexAny(locale, "-_"); sep > 0 {
+		candidates = append(candidates, locale[:sep])
+	}
+
+	for _, candidate := range candidates {
+		if ordinal, ok := e.PreferredLocales[strings.ToLower(candidate)]; ok {
+			v, _ := e.GetVariation(ordinal)
+			return v
+		}
+	}
+
+	v, _ := e.GetVariation(e.PreferredOrdinal)
+	return v
+}
+
 // GetVariation selects the appropriate variation given it's 1 indexed ordinal
 func (e *Experiment) GetVariation(ordinal int) (Variation, error) {
-	if l := len(e.Variations); ordinal < 0 || ordinal > l {
+	if l := len(e.Variations); ordinal < 1 || ordinal > l {
 		return Variation{}, fmt.Errorf("ordinal %d not in [1,%d]", ordinal, l)
 	}
 
@@ -117,10 +324,29 @@ func makeTimestampedTag(v Variation, now int64) string {
 
 // Variation describes endpoints which are mapped onto strategy arms.
 type Variation struct {
-	Ordinal     int    // 1 indexed arm ordinal
-	URL         string // the url associated with this variation, for out of band
-	Tag         string // this tag is used throughout the lifecycle of the experiment
-	Description string // freitext
+	Ordinal     int     // 1 indexed arm ordinal
+	URL         string  // the url associated with this variation, for out of band
+	Tag         string  // this tag is used throughout the lifecycle of the experiment
+	Description string  // freitext
+	Group       string  // variations in the same group share statistics
+	Priority    float64 // multiplier of the estimated value when selecting. 0 is 1.
+	Replaces    string  // url previously served under this tag, see CheckTags
+	Cost        float64 // serving cost in units of reward, see Costs
+}
+
+// URLs maps client types, such as "web", "ios" or "android", to the url a
+// variation is served under on that client.
+type URLs map[string]string
+
+// ForClient returns `v` with its url for client type `client`. Variations
+// without a url for the client keep their default url. All clients share the
+// arms, so that one experiment spans platforms.
+func (e *Experiment) ForClient(v Variation, client string) Variation {
+	if url, ok := e.ClientURLs[v.Ordinal][strings.ToLower(client)]; ok {
+		v.URL = url
+	}
+
+	return v
 }
 
 // Variations is a set of variations sorted by ordinal.
@@ -130,24 +356,56 @@ func (v Variations) Len() int           { return len(v) }
 func (v Variations) Less(i, j int) bool { return v[i].Ordinal < v[j].Ordinal }
 func (v Variations) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
 
-// NewExperiments reads in a json file and converts it to a map of experiments.
+// NewExperiments reads in a json file and converts it to an index of experiments.
 func NewExperiments(o Opener) (*Experiments, error) {
+	return NewDecryptedExperiments(o, nil)
+}
+
+// ParseExperimentsFS reads experiments from `path` in `fsys`. Use it with
+// go:embed to ship binaries with default experiments.
+func ParseExperimentsFS(fsys fs.FS, path string) (*Experiments, error) {
+	return NewExperiments(NewFSOpener(fsys, path))
+}
+
+// NewDecryptedExperiments is NewExperiments for files with encrypted variation
+// urls or descriptions, given as "enc:<base64 ciphertext>". Values are
+// decrypted with `d` at load time. A nil decrypter rejects encrypted values.
+func NewDecryptedExperiments(o Opener, d Decrypter) (*Experiments, error) {
+	es := &Experiments{index: make(map[string]*Experiment)}
+	if err := parseExperiments(o, d, es.index); err != nil {
+		es.Close()
+		return &Experiments{}, err
+	}
+
+	return es, nil
+}
+
+// parseExperiments adds the experiments read from `o` to `es`. On error, `es`
+// holds the experiments parsed so far.
+func parseExperiments(o Opener, d Decrypter, es map[string]*Experiment) error {
 	file, err := o.Open()
 	if err != nil {
-		return &Experiments{}, fmt.Errorf("need a valid input file: %v", err)
+		return fmt.Errorf("need a valid input file: %v", err)
 	}
 
 	defer file.Close()
 
-	jsonString, err := ioutil.ReadAll(file)
+	jsonString, err := readConfig(file)
 	if err != nil {
-		return &Experiments{}, fmt.Errorf("could not read jsony: %s", err.Error())
+		return fmt.Errorf("could not read jsony: %s", err.Error())
 	}
 
 	type variationConfig struct {
-		URL         string `json:"url"`
-		Description string `json:"description"`
-		Ordinal     int    `json:"ordinal"`
+		URL         string    `json:"url"`
+		Description string    `json:"description"`
+		Ordinal     int       `json:"ordinal"`
+		SeedFrom    int       `json:"seed-from"`
+		Features    []float64 `json:"features"`
+		Group       string    `json:"group"`
+		Priority    float64   `json:"priority"`
+		Replaces    string    `json:"replaces"`
+		Cost        float64   `json:"cost"`
+		URLs        URLs      `json:"urls"`
 	}
 
 	type experimentsConfig struct {
@@ -158,77 +416,413 @@ func NewExperiments(o Opener) (*Experiments, error) {
 		Parameters       []float64         `json:"parameters"`
 		Variations       []variationConfig `json:"variations"`
 		PreferredOrdinal int               `json:"preferred"`
+		PreferredLocales map[string]int    `json:"preferred-locales"`
+		RetentionDays    int               `json:"retention-days"`
+		MinExposure      int               `json:"min-selections-per-hour"`
+		MaxRewardGap     int               `json:"max-reward-gap-hours"`
+		Salt             string            `json:"salt"`
+		SampleSize       int               `json:"reward-samples"`
+		SeedPulls        int               `json:"seed-pulls"`
+		CostBudget       float64           `json:"cost-budget"`
+		RewardUnit       string            `json:"reward-unit"`
+		Discount         float64           `json:"discount"`
+		Floor            float64           `json:"floor"`
+		Guardrails       []struct {
+			Metric     string  `json:"metric"`
+			Max        float64 `json:"max"`
+			MinSamples int     `json:"min-samples"`
+			Disable    bool    `json:"disable"`
+		} `json:"guardrails"`
+		Rules []string `json:"rules"`
+		Ties  string   `json:"ties"`
+		Seed  *int64   `json:"random-seed"`
+		Batch struct {
+			Size    int `json:"size"`
+			Seconds int `json:"seconds"`
+		} `json:"batch-updates"`
 	}
 
 	var cfg []experimentsConfig
 	if err := json.Unmarshal(jsonString, &cfg); err != nil {
-		return &Experiments{}, fmt.Errorf("could not marshal json: %s ", err.Error())
+		return fmt.Errorf("could not marshal json: %s ", err.Error())
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(jsonString, &raw); err != nil {
+		return fmt.Errorf("could not marshal json: %s ", err.Error())
 	}
 
 	// have to specify poll duration along with snapshot location
 	for _, c := range cfg {
 		if c.Snapshot != "" && c.SnapshotPoll == 0 {
-			return &Experiments{}, fmt.Errorf("%s is missing snapshot-poll-seconds", c.Name)
+			return fmt.Errorf("%s is missing snapshot-poll-seconds", c.Name)
 		}
 	}
 
-	es := Experiments{}
-	for _, e := range cfg {
+	for n, e := range cfg {
 		if e.PreferredOrdinal == 0 {
-			return &Experiments{}, fmt.Errorf("could not make strategy: preferred variation missing")
+			return fmt.Errorf("could not make strategy: preferred variation missing")
 		}
 
-		strategy, err := New(len(e.Variations), e.Strategy, e.Parameters)
-		if err != nil {
-			return &Experiments{}, fmt.Errorf("could not make strategy: %s ", err.Error())
+		var ordinals []int
+		for _, v := range e.Variations {
+			ordinals = append(ordinals, v.Ordinal)
 		}
 
-		// this is a delayed strategy; gets it's internal state from a snapshot
-		if e.Snapshot != "" {
-			opener := NewOpener(e.Snapshot)
-			duration := time.Duration(e.SnapshotPoll) * time.Second
-			strategy, err = NewDelayed(strategy, opener, duration)
+		if err := validateConfig(e.Name, ordinals); err != nil {
+			return err
+		}
+
+		if _, ok := es[e.Name]; ok {
+			return fmt.Errorf("duplicate experiment %s", e.Name)
+		}
+
+		if e.RetentionDays < 0 || e.RetentionDays > maxRetentionDays {
+			return fmt.Errorf("%s: retention-days not in [0,%d]", e.Name, maxRetentionDays)
+		}
+
+		if e.MinExposure < 0 || e.MaxRewardGap < 0 || e.Batch.Size < 0 || e.Batch.Seconds < 0 || e.SampleSize < 0 || e.CostBudget < 0 || e.SeedPulls < 0 {
+			return fmt.Errorf("%s: negative min-selections-per-hour, max-reward-gap-hours, batch-updates, reward-samples, cost-budget or seed-pulls", e.Name)
+		}
+
+		var groups []string
+		for _, v := range e.Variations {
+			groups = append(groups, v.Group)
+		}
+
+		arms := countGroups(groups)
+		if arms != len(e.Variations) && e.Snapshot != "" {
+			return fmt.Errorf("%s: groups cannot be used with snapshots", e.Name)
+		}
+
+		if arms != len(e.Variations) && e.SeedPulls > 0 {
+			return fmt.Errorf("%s: groups cannot be used with seed-pulls", e.Name)
+		}
+
+		// build makes the configured strategy with fresh state, for clones.
+		c := e
+		build := func() (Strategy, error) {
+			strategy, err := New(arms, c.Strategy, c.Parameters)
 			if err != nil {
-				return &Experiments{}, fmt.Errorf("could not delay strategy: %s ", err.Error())
+				return strategy, fmt.Errorf("could not make strategy: %s ", err.Error())
+			}
+
+			// this is a delayed strategy; gets it's internal state from a snapshot
+			if c.Snapshot != "" {
+				opener := NewOpener(c.Snapshot)
+				duration := time.Duration(c.SnapshotPoll) * time.Second
+				strategy, err = NewDelayed(strategy, opener, duration)
+				if err != nil {
+					return strategy, fmt.Errorf("could not delay strategy: %s ", err.Error())
+				}
 			}
+
+			// rewards are buffered and applied in batches
+			if c.Batch.Size > 0 {
+				interval := time.Duration(c.Batch.Seconds) * time.Second
+				strategy, err = NewBatched(strategy, c.Batch.Size, interval)
+				if err != nil {
+					return strategy, fmt.Errorf("could not batch strategy: %s ", err.Error())
+				}
+			}
+
+			// every arm keeps a minimum share of traffic
+			if c.Floor != 0 {
+				floored, err := NewFloored(strategy, arms, c.Floor)
+				if err != nil {
+					closeStrategy(strategy)
+					return floored, fmt.Errorf("%s: %s", c.Name, err.Error())
+				}
+
+				strategy = floored
+			}
+
+			if err := configure(strategy, c.Ties, c.Discount, c.Seed); err != nil {
+				closeStrategy(strategy)
+				return strategy, fmt.Errorf("%s: %s", c.Name, err.Error())
+			}
+
+			return strategy, nil
+		}
+
+		strategy, err := build()
+		if err != nil {
+			return err
+		}
+
+		// delayed strategies share their state through the snapshot
+		if e.Snapshot != "" {
+			build = nil
+		}
+
+		samples := sampleSize
+		if e.SampleSize > 0 {
+			samples = e.SampleSize
 		}
 
 		experiment := Experiment{
-			Name:     e.Name,
-			Strategy: strategy,
+			Name:         e.Name,
+			Strategy:     strategy,
+			Retention:    time.Duration(e.RetentionDays) * 24 * time.Hour,
+			MinExposure:  e.MinExposure,
+			MaxRewardGap: time.Duration(e.MaxRewardGap) * time.Hour,
+			Salt:         e.Salt,
+			CostBudget:   e.CostBudget,
+			RewardUnit:   e.RewardUnit,
+			spent:        &spending{},
+			Breakdown:    NewBreakdown(len(e.Variations)),
+			History:      NewHistory(historySize, 0),
+			Latencies:    NewLatencies(),
+			Samples:      NewSamples(len(e.Variations), samples),
+			Freshness:    NewFreshness(len(e.Variations)),
+			Seeds:        make(map[int]int),
+			SeedPulls:    e.SeedPulls,
+			Features:     make(map[int][]float64),
+			ClientURLs:   make(map[int]URLs),
+			disabled:     newArmSet(),
+			changes:      newChanges(),
+			annotations:  &annotations{},
+			build:        build,
+			config:       raw[n],
 		}
 
 		es[e.Name] = &experiment
 
+		for _, g := range e.Guardrails {
+			if g.Metric == "" {
+				return fmt.Errorf("%s has a guardrail without metric", e.Name)
+			}
+
+			experiment.Guardrails = append(experiment.Guardrails, Guardrail{
+				Metric:     g.Metric,
+				Max:        g.Max,
+				MinSamples: g.MinSamples,
+				Disable:    g.Disable,
+			})
+		}
+
+		for _, declared := range e.Rules {
+			rule, err := ParseRule(declared)
+			if err != nil {
+				return fmt.Errorf("%s: %s", e.Name, err.Error())
+			}
+
+			experiment.Rules = append(experiment.Rules, rule)
+		}
+
 		for _, v := range e.Variations {
+			url, err := decryptValue(d, v.URL)
+			if err != nil {
+				return fmt.Errorf("%s url of variation %d: %s", e.Name, v.Ordinal, err.Error())
+			}
+
+			description, err := decryptValue(d, v.Description)
+			if err != nil {
+				return fmt.Errorf("%s description of variation %d: %s", e.Name, v.Ordinal, err.Error())
+			}
+
+			for _, text := range []string{url, description} {
+				if err := validateText(text); err != nil {
+					return fmt.Errorf("%s variation %d: %s", e.Name, v.Ordinal, err.Error())
+				}
+			}
+
+			for client, clientURL := range v.URLs {
+				decrypted, err := decryptValue(d, clientURL)
+				if err != nil {
+					return fmt.Errorf("%s %s url of variation %d: %s", e.Name, client, v.Ordinal, err.Error())
+				}
+
+				if err := validateText(decrypted); err != nil {
+					return fmt.Errorf("%s variation %d: %s", e.Name, v.Ordinal, err.Error())
+				}
+
+				if experiment.ClientURLs[v.Ordinal] == nil {
+					experiment.ClientURLs[v.Ordinal] = make(URLs)
+				}
+
+				experiment.ClientURLs[v.Ordinal][strings.ToLower(client)] = decrypted
+			}
+
 			if v.Ordinal == e.PreferredOrdinal {
 				experiment.PreferredOrdinal = v.Ordinal
 			}
 
 			experiment.Variations = append(experiment.Variations, Variation{
 				Ordinal:     v.Ordinal,
-				URL:         v.URL,
+				URL:         url,
 				Tag:         fmt.Sprintf("%s:%d", e.Name, v.Ordinal),
-				Description: v.Description,
+				Description: description,
+				Group:       v.Group,
+				Priority:    v.Priority,
+				Replaces:    v.Replaces,
+				Cost:        v.Cost,
 			})
+
+			if v.SeedFrom != 0 {
+				if v.SeedFrom < 1 || v.SeedFrom > len(e.Variations) {
+					return fmt.Errorf("%s variation %d: seed-from %d not in [1,%d]", e.Name, v.Ordinal, v.SeedFrom, len(e.Variations))
+				}
+
+				experiment.Seeds[v.Ordinal] = v.SeedFrom
+			}
+
+			if len(v.Features) > 0 {
+				experiment.Features[v.Ordinal] = v.Features
+			}
 		}
 
 		if experiment.PreferredOrdinal == 0 {
-			return &Experiments{}, fmt.Errorf("preferred variation ordinal %d not found in variations", e.PreferredOrdinal)
+			return fmt.Errorf("preferred variation ordinal %d not found in variations", e.PreferredOrdinal)
 		}
 
 		sort.Sort(experiment.Variations)
+		experiment.group()
+
+		if err := experiment.prioritize(); err != nil {
+			return fmt.Errorf("%s: %s", e.Name, err.Error())
+		}
+
+		if err := experiment.charge(); err != nil {
+			return fmt.Errorf("%s: %s", e.Name, err.Error())
+		}
+
+		experiment.PreferredLocales = make(map[string]int)
+		for locale, ordinal := range e.PreferredLocales {
+			if _, err := experiment.GetVariation(ordinal); err != nil {
+				return fmt.Errorf("preferred variation for locale %s: %s", locale, err.Error())
+			}
+
+			experiment.PreferredLocales[strings.ToLower(locale)] = ordinal
+		}
+
+		experiment.startRules()
+	}
+
+	return nil
+}
+
+// configure sets the tie breaking, discount and random seed of `s`. Blank
+// ties, a zero discount and a nil seed are not set.
+func configure(s Strategy, ties string, γ float64, seed *int64) error {
+	if ties != "" {
+		if err := breakTies(s, ties); err != nil {
+			return err
+		}
+	}
+
+	if γ != 0 {
+		if err := discount(s, γ); err != nil {
+			return err
+		}
+	}
+
+	// rebuilt strategies start from the same seed
+	if seed != nil {
+		if err := useSource(s, rand.NewSource(*seed)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Experiments is an index of names to experiment. Apply replaces the index
+// while it is served, so read it with Get and All. The lock guards the index
+// against Apply, Clone and Override.
+type Experiments struct {
+	sync.RWMutex
+	Registry  *Registry // checks and records applied experiments. nil disables.
+	index     map[string]*Experiment
+	importing sync.Mutex // serializes Apply
+}
+
+// Get returns experiment `name`, and false if there is none.
+func (e *Experiments) Get(name string) (*Experiment, bool) {
+	e.RLock()
+	defer e.RUnlock()
+
+	experiment, ok := e.index[name]
+	return experiment, ok
+}
+
+// All returns a copy of the index, which can be ranged over while
+// experiments are applied.
+func (e *Experiments) All() map[string]*Experiment {
+	e.RLock()
+	defer e.RUnlock()
+
+	all := make(map[string]*Experiment, len(e.index))
+	for name, experiment := range e.index {
+		all[name] = experiment
 	}
 
-	return &es, nil
+	return all
+}
+
+// Len returns the number of experiments.
+func (e *Experiments) Len() int {
+	e.RLock()
+	defer e.RUnlock()
+
+	return len(e.index)
+}
+
+// Override replaces experiments with the experiments of the same name in
+// `overrides`, and adds all other experiments in `overrides`.
+func (e *Experiments) Override(overrides *Experiments) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.index == nil {
+		e.index = make(map[string]*Experiment)
+	}
+
+	for name, experiment := range overrides.All() {
+		if replaced, ok := e.index[name]; ok {
+			replaced.close()
+		}
+
+		e.index[name] = experiment
+	}
 }
 
-// Experiments is an index of names to experiment
-type Experiments map[string]*Experiment
+// Close stops the background work of all experiments, such as flushing
+// batches and evaluating rules, and returns the first error. Close
+// experiments which are no longer served; replaced experiments are closed by
+// Apply.
+func (e *Experiments) Close() error {
+	var first error
+	for name, experiment := range e.All() {
+		if err := experiment.close(); err != nil && first == nil {
+			first = fmt.Errorf("could not close %s: %s", name, err.Error())
+		}
+	}
+
+	return first
+}
+
+// close stops the rules engine and the background work of the strategy.
+func (e *Experiment) close() error {
+	if e.rules != nil {
+		e.rules.Close()
+	}
+
+	return closeStrategy(e.Strategy)
+}
+
+// closeStrategy stops the background work of `s`, if it has any.
+func closeStrategy(s Strategy) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
 
 // GetVariation returns the Experiment and variation pointed to by a string tag.
 func (e *Experiments) GetVariation(tag string) (Experiment, Variation, error) {
-	for _, experiment := range *e {
+	for _, experiment := range e.All() {
 		for _, variation := range experiment.Variations {
 			if variation.Tag == tag {
 				return *experiment, variation, nil
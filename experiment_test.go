@@ -5,8 +5,11 @@ package bandit
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -16,7 +19,7 @@ func TestExperiment(t *testing.T) {
 		t.Fatalf("while reading experiment fixture: %s", err.Error())
 	}
 
-	e, ok := (*es)["shape-20130822"]
+	e, ok := es.index["shape-20130822"]
 	if !ok {
 		t.Fatalf("could not find test campaign")
 	}
@@ -37,6 +40,76 @@ func TestExperiment(t *testing.T) {
 	}
 }
 
+func TestExperimentPreferredLocale(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	for locale, expected := range map[string]int{"de": 1, "de-AT": 1, "DE_ch": 1, "fr-FR": 2, "": 2} {
+		if got := e.Preferred(locale).Ordinal; got != expected {
+			t.Fatalf("expected ordinal %d for locale '%s', got %d", expected, locale, got)
+		}
+	}
+
+	// paused experiments serve the variation preferred in the caller's locale
+	e.Pause()
+	for locale, expected := range map[string]int{"de-AT": 1, "fr": 2} {
+		if got := e.SelectLocale(locale).Ordinal; got != expected {
+			t.Fatalf("expected paused ordinal %d for locale '%s', got %d", expected, locale, got)
+		}
+	}
+
+	e.Disable(1)
+	if got := e.SelectLocale("de").Ordinal; got != 2 {
+		t.Fatalf("expected disabled preferred variation to fall back to 2, got %d", got)
+	}
+}
+
+func TestExperimentDelayedDisabled(t *testing.T) {
+	f, err := ioutil.TempFile("", "bandit-snapshot")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.Remove(f.Name())
+	f.WriteString("2 0.1 0.5")
+	f.Close()
+
+	config := fmt.Sprintf(`[{"experiment_name": "delayed", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "snapshot": "%s", "snapshot-poll-seconds": 60,
+		"variations": [{"ordinal": 1, "url": "https://web/1"}, {"ordinal": 2, "url": "https://web/2"}]}]`, f.Name())
+	es, err := NewExperiments(byteOpener(config))
+	if err != nil {
+		t.Fatalf("could not read experiments: %s", err.Error())
+	}
+
+	// rejected draws are released through the delayed strategy
+	e := es.index["delayed"]
+	e.Disable(2)
+	for i := 0; i < 100; i++ {
+		if got := e.Select().Ordinal; got != 1 {
+			t.Fatalf("expected disabled variation 2 not to be served, got %d", got)
+		}
+	}
+}
+
+func TestVariationForClient(t *testing.T) {
+	config := `[{"experiment_name": "platforms", "strategy": "uniform", "preferred": 1,
+		"variations": [{"ordinal": 1, "url": "https://web/1", "urls": {"iOS": "app://ios/1"}}]}]`
+	es, err := NewExperiments(byteOpener(config))
+	if err != nil {
+		t.Fatalf("could not read experiments: %s", err.Error())
+	}
+
+	v := es.index["platforms"].Select()
+	for client, expected := range map[string]string{"ios": "app://ios/1", "IOS": "app://ios/1", "android": "https://web/1", "": "https://web/1"} {
+		if got := es.index["platforms"].ForClient(v, client); got.URL != expected || got.Tag != v.Tag {
+			t.Fatalf("expected url %s for client '%s', got %s", expected, client, got.URL)
+		}
+	}
+}
+
 func TestTimestampedTagToTag(t *testing.T) {
 	tag, ts, err := TimestampedTagToTag("shape-20130822:c8-circle:1378823906")
 	if err != nil {
@@ -58,7 +131,7 @@ func TestExperimentCutoverUnexpiredTag(t *testing.T) {
 		t.Fatalf("while reading experiment fixture: %s", err.Error())
 	}
 
-	e, ok := (*es)["shape-20130822"]
+	e, ok := es.index["shape-20130822"]
 	if !ok {
 		t.Fatalf("could not find test campaign")
 	}
@@ -73,3 +146,63 @@ func TestExperimentCutoverUnexpiredTag(t *testing.T) {
 		t.Fatalf("did not get repinned to shape.")
 	}
 }
+
+func TestSelectWith(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	o := SelectOptions{TTL: time.Hour, Dimension: "DE"}
+	_, tag, err := e.SelectWith("", o)
+	if err != nil {
+		t.Fatalf("could not select: %s", err.Error())
+	}
+
+	// pinned tags are served again, but not counted again
+	if _, _, err := e.SelectWith(tag, o); err != nil {
+		t.Fatalf("could not select pinned: %s", err.Error())
+	}
+
+	selections := 0
+	for _, count := range e.Breakdown.Counts()["DE"] {
+		selections += count
+	}
+
+	if selections != 1 {
+		t.Fatalf("expected a single selection under DE, got %d", selections)
+	}
+}
+
+func TestParseExperimentsFS(t *testing.T) {
+	es, err := ParseExperimentsFS(os.DirFS("."), "experiments.json")
+	if err != nil {
+		t.Fatalf("could not parse experiments from fs: %s", err.Error())
+	}
+
+	overrides, err := ParseExperimentsFS(fstest.MapFS{
+		"overrides.json": &fstest.MapFile{Data: []byte(`[{
+			"experiment_name": "shape-20130822",
+			"strategy": "uniform",
+			"preferred": 1,
+			"variations": [{ "ordinal": 1, "url": "http://localhost:8080/widget?shape=star" }]
+		}]`)},
+	}, "overrides.json")
+	if err != nil {
+		t.Fatalf("could not parse overrides: %s", err.Error())
+	}
+
+	n := es.Len()
+	es.Override(overrides)
+	if got := es.Len(); got != n {
+		t.Fatalf("expected override to replace experiment, got %d experiments", got)
+	}
+
+	if got := es.index["shape-20130822"].Variations[0].URL; got != "http://localhost:8080/widget?shape=star" {
+		t.Fatalf("expected overridden variation, got %s", got)
+	}
+
+	if _, err := ParseExperimentsFS(fstest.MapFS{}, "missing.json"); err == nil {
+		t.Fatalf("expected error on missing file")
+	}
+}
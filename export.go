@@ -0,0 +1,129 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Sink receives batches of selection and reward events, e.g. an analytics
+// database.
+type Sink interface {
+	Insert(events []LogEvent) error
+}
+
+// NewExporter returns an exporter which inserts events into `s` in batches of
+// `size`, or every `interval` if fewer events arrived. Failed inserts are
+// retried with backoff. At most 10 batches are buffered; older events are
+// dropped when the sink is down for longer.
+func NewExporter(s Sink, size int, interval time.Duration) *Exporter {
+	x := &Exporter{
+		sink:    s,
+		size:    size,
+		retries: 3,
+		backoff: time.Second,
+		flush:   make(chan bool, 1),
+	}
+
+	go func() {
+		t := time.NewTicker(interval)
+		for {
+			select {
+			case <-t.C:
+			case <-x.flush:
+			}
+
+			if err := x.Flush(); err != nil {
+				log.Printf("could not export events: %s", err.Error())
+			}
+		}
+	}()
+
+	return x
+}
+
+// Exporter streams selection and reward lines to a sink. It is an io.Writer,
+// so that it can be attached to the standard logger with
+//
+//	log.SetOutput(io.MultiWriter(os.Stderr, exporter))
+//
+// Lines which are neither selections nor rewards are ignored.
+type Exporter struct {
+	sync.Mutex
+	sink    Sink
+	size    int
+	retries int
+	backoff time.Duration
+	pending []LogEvent
+	flush   chan bool
+}
+
+// Write parses log lines from `p` and queues selections and rewards.
+func (x *Exporter) Write(p []byte) (int, error) {
+	events, err := ParseLog(bytes.NewReader(p))
+	if err != nil {
+		return 0, err
+	}
+
+	x.Add(events...)
+	return len(p), nil
+}
+
+// Add queues events for export.
+func (x *Exporter) Add(events ...LogEvent) {
+	x.Lock()
+	defer x.Unlock()
+
+	x.pending = append(x.pending, events...)
+	if over := len(x.pending) - 10*x.size; over > 0 {
+		x.pending = x.pending[over:]
+	}
+
+	if len(x.pending) >= x.size {
+		select {
+		case x.flush <- true:
+		default:
+		}
+	}
+}
+
+// Flush inserts all queued events, retrying failed batches. Events of a batch
+// which failed all retries are queued again.
+func (x *Exporter) Flush() error {
+	x.Lock()
+	events := x.pending
+	x.pending = nil
+	x.Unlock()
+
+	for len(events) > 0 {
+		n := len(events)
+		if n > x.size {
+			n = x.size
+		}
+
+		var err error
+		for attempt, wait := 0, x.backoff; attempt < x.retries; attempt, wait = attempt+1, 2*wait {
+			if err = x.sink.Insert(events[:n]); err == nil {
+				break
+			}
+
+			time.Sleep(wait)
+		}
+
+		if err != nil {
+			x.Lock()
+			x.pending = append(events, x.pending...)
+			x.Unlock()
+			return fmt.Errorf("giving up after %d attempts: %s", x.retries, err.Error())
+		}
+
+		events = events[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package bandit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClickHouseExport(t *testing.T) {
+	var queries, rows []string
+	fail := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail > 0 {
+			fail--
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		queries = append(queries, r.URL.Query().Get("query"))
+		rows = append(rows, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+	}))
+
+	defer server.Close()
+
+	x := &Exporter{
+		sink:    NewClickHouseSink(server.URL+"/", "bandit_events"),
+		size:    2,
+		retries: 3,
+		flush:   make(chan bool, 1),
+	}
+
+	log := strings.Join([]string{
+		"2013/09/15 15:13:04 1379257984 BanditSelection shape-20130822:1",
+		"2013/09/15 15:13:04 unrelated line",
+		"2013/09/15 15:13:04 1379257987 BanditReward shape-20130822:1 1.000000",
+		"2013/09/15 15:13:04 1379257988 BanditSelection shape-20130822:2",
+	}, "\n")
+
+	if _, err := fmt.Fprint(x, log); err != nil {
+		t.Fatalf("could not write log: %s", err.Error())
+	}
+
+	if err := x.Flush(); err != nil {
+		t.Fatalf("could not flush: %s", err.Error())
+	}
+
+	if len(queries) != 3 || !strings.HasPrefix(queries[0], "CREATE TABLE IF NOT EXISTS bandit_events") {
+		t.Fatalf("expected table creation and 2 inserts, got %v", queries)
+	}
+
+	expected := `{"time":1379257987,"kind":"BanditReward","tag":"shape-20130822:1","reward":1}`
+	if len(rows) != 4 || rows[2] != expected {
+		t.Fatalf("expected row %s, got %v", expected, rows)
+	}
+
+	if got := len(x.pending); got != 0 {
+		t.Fatalf("expected no pending events, got %d", got)
+	}
+}
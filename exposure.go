@@ -0,0 +1,127 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventExposure is emitted when an experiment is selected less often than its
+// configured minimum.
+const EventExposure = "exposure"
+
+// Selections returns the number of selections served by the experiment,
+// including selections of an archived experiment.
+func (e *Experiment) Selections() int64 {
+	return atomic.LoadInt64(&e.selections)
+}
+
+// NewExposure returns a monitor of the selection rate of all experiments
+// with a MinExposure. Events are sent to `n`, which may be nil.
+func NewExposure(es *Experiments, n Notifier) *Exposure {
+	return &Exposure{
+		experiments: es,
+		notifier:    n,
+		last:        make(map[string]int64),
+		firing:      make(map[string]bool),
+	}
+}
+
+// Exposure detects experiments which stopped receiving traffic, for example
+// because an integration broke or a route was removed. An experiment fires
+// once when its selection rate falls below its minimum, and may fire again
+// after it recovered. Likewise, a variation fires once when it is selected
+// but has not been rewarded for longer than its experiment's MaxRewardGap,
+// which usually means that attribution of its rewards is broken.
+type Exposure struct {
+	sync.Mutex
+	experiments *Experiments
+	notifier    Notifier
+	checked     time.Time        // time of the last check. zero before the first.
+	last        map[string]int64 // selections per experiment at the last check
+	firing      map[string]bool  // by experiment, or experiment:ordinal if stale
+}
+
+// Check computes the hourly selection rate of each experiment since the last
+// check and returns events for experiments which started falling short, and
+// for variations which became stale. The first check only records the
+// current selections.
+func (x *Exposure) Check(now time.Time) []Event {
+	x.Lock()
+	all, names := x.experiments.All(), []string{}
+	for name := range all {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var fired []Event
+	elapsed := now.Sub(x.checked)
+	for _, name := range names {
+		e := all[name]
+		selections, last, seen := e.Selections(), x.last[name], !x.checked.IsZero()
+		x.last[name] = selections
+		if e.MinExposure == 0 || !seen || elapsed <= 0 {
+			continue
+		}
+
+		rate := float64(selections-last) / elapsed.Hours()
+		short := rate < float64(e.MinExposure)
+		if short && !x.firing[name] {
+			fired = append(fired, Event{
+				Time:       now,
+				Kind:       EventExposure,
+				Experiment: name,
+				Message:    fmt.Sprintf("%.1f selections per hour, expected %d", rate, e.MinExposure),
+			})
+		}
+
+		x.firing[name] = short
+	}
+
+	for _, name := range names {
+		e := all[name]
+		if e.MaxRewardGap == 0 || e.Freshness == nil {
+			continue
+		}
+
+		for _, v := range e.Variations {
+			key := fmt.Sprintf("%s:%d", name, v.Ordinal)
+			stale := e.Freshness.Stale(v.Ordinal, now, e.MaxRewardGap)
+			if stale && !x.firing[key] {
+				fired = append(fired, Event{
+					Time:       now,
+					Kind:       EventStale,
+					Experiment: name,
+					Arm:        v.Ordinal,
+					Message:    fmt.Sprintf("%s selected but not rewarded for %s", v.Tag, e.Freshness.Staleness(v.Ordinal, now)),
+				})
+			}
+
+			x.firing[key] = stale
+		}
+	}
+
+	x.checked = now
+	x.Unlock()
+
+	if x.notifier != nil {
+		for _, e := range fired {
+			x.notifier.Notify(e)
+		}
+	}
+
+	return fired
+}
+
+// Run checks exposure every `interval`. Never returns.
+func (x *Exposure) Run(interval time.Duration) {
+	for now := range time.Tick(interval) {
+		x.Check(now)
+	}
+}
@@ -0,0 +1,89 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExposure(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	e.MinExposure = 10
+
+	var notified []Event
+	x := NewExposure(es, NotifierFunc(func(e Event) { notified = append(notified, e) }))
+
+	now := time.Now()
+	if got := x.Check(now); len(got) != 0 {
+		t.Fatalf("expected first check to only record, got %v", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		e.Select()
+	}
+
+	if got := x.Check(now.Add(time.Hour)); len(got) != 0 {
+		t.Fatalf("expected sufficient traffic, got %v", got)
+	}
+
+	e.Select()
+	got := x.Check(now.Add(2 * time.Hour))
+	if len(got) != 1 || got[0].Kind != EventExposure || got[0].Experiment != e.Name {
+		t.Fatalf("expected exposure event, got %v", got)
+	}
+
+	if got := x.Check(now.Add(3 * time.Hour)); len(got) != 0 {
+		t.Fatalf("expected event to fire once, got %v", got)
+	}
+
+	if len(notified) != 1 {
+		t.Fatalf("expected 1 notification, got %v", notified)
+	}
+}
+
+func TestExposureStale(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	e.MaxRewardGap = time.Hour
+	x := NewExposure(es, nil)
+
+	now := time.Now()
+	e.Freshness.Selected(1, now)
+	e.Freshness.Selected(2, now)
+	e.Freshness.Rewarded(2, now.Add(50*time.Minute))
+
+	// both still selected. only ordinal 2 was rewarded within the hour.
+	e.Freshness.Selected(1, now.Add(90*time.Minute))
+	e.Freshness.Selected(2, now.Add(90*time.Minute))
+	got := x.Check(now.Add(90 * time.Minute))
+	if len(got) != 1 || got[0].Kind != EventStale || got[0].Arm != 1 {
+		t.Fatalf("expected ordinal 1 to be stale, got %v", got)
+	}
+
+	if got := x.Check(now.Add(100 * time.Minute)); len(got) != 0 {
+		t.Fatalf("expected event to fire once, got %v", got)
+	}
+
+	// traffic stopped, so ordinal 2 is not stale, but falls short of exposure.
+	if got := x.Check(now.Add(4 * time.Hour)); len(got) != 0 {
+		t.Fatalf("expected unselected variations not to be stale, got %v", got)
+	}
+
+	if staleness := e.Freshness.Staleness(2, now.Add(4*time.Hour)); staleness != 190*time.Minute {
+		t.Fatalf("expected staleness since the last reward, got %s", staleness)
+	}
+
+	e.Select()
+	e.Update(1, 1)
+	if arm := e.Stats().Arms[0]; arm.Rewarded == nil || arm.Staleness > 0.01 {
+		t.Fatalf("expected fresh reward in stats, got %v", arm)
+	}
+}
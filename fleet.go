@@ -0,0 +1,97 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+)
+
+// AggregateStats merges the stats of one experiment served by several
+// instances without shared state into fleet wide stats. Arms are matched by
+// tag, in the order of the first instance, followed by arms only some other
+// instances serve. Counts and breakdowns add up, values are averaged weighted
+// by counts and reward samples are pooled. The last reward is the latest of
+// all instances, and staleness the least of the instances which selected the
+// arm. Priorities and costs are taken from the first instance serving an arm.
+// History and latencies are per instance and dropped.
+func AggregateStats(stats []Stats) (Stats, error) {
+	if len(stats) == 0 {
+		return Stats{}, fmt.Errorf("no stats to aggregate")
+	}
+
+	fleet := Stats{
+		Experiment: stats[0].Experiment,
+		Strategy:   stats[0].Strategy,
+		Policy:     stats[0].Policy,
+		Metrics:    stats[0].Metrics,
+	}
+
+	byTag := make(map[string]int)
+	sums, selected := []float64{}, []bool{}
+	for _, s := range stats {
+		if s.Experiment != fleet.Experiment {
+			return Stats{}, fmt.Errorf("cannot aggregate %s with %s", s.Experiment, fleet.Experiment)
+		}
+
+		// instances serving different policies have no common one
+		if s.Policy != fleet.Policy {
+			fleet.Policy = ""
+		}
+
+		for _, arm := range s.Arms {
+			i, ok := byTag[arm.Tag]
+			if !ok {
+				i, byTag[arm.Tag] = len(fleet.Arms), len(fleet.Arms)
+				fleet.Arms = append(fleet.Arms, ArmStats{
+					Ordinal:  arm.Ordinal,
+					Tag:      arm.Tag,
+					Group:    arm.Group,
+					Priority: arm.Priority,
+					Cost:     arm.Cost,
+				})
+
+				sums, selected = append(sums, 0), append(selected, false)
+			}
+
+			// staleness is 0 on instances which never selected the arm
+			if arm.Staleness > 0 || arm.Rewarded != nil {
+				if !selected[i] || arm.Staleness < fleet.Arms[i].Staleness {
+					fleet.Arms[i].Staleness = arm.Staleness
+				}
+
+				selected[i] = true
+			}
+
+			aggregateArm(&fleet.Arms[i], arm)
+			sums[i] += arm.Value * float64(arm.Count)
+		}
+	}
+
+	for i := range fleet.Arms {
+		if fleet.Arms[i].Count > 0 {
+			fleet.Arms[i].Value = sums[i] / float64(fleet.Arms[i].Count)
+		}
+	}
+
+	return fleet, nil
+}
+
+// aggregateArm adds the counts, breakdown, samples and last reward of `arm` on
+// one instance to `fleet`. Values and staleness are aggregated by the caller.
+func aggregateArm(fleet *ArmStats, arm ArmStats) {
+	fleet.Count += arm.Count
+	fleet.Samples = append(fleet.Samples, arm.Samples...)
+	for value, count := range arm.Breakdown {
+		if fleet.Breakdown == nil {
+			fleet.Breakdown = make(map[string]int)
+		}
+
+		fleet.Breakdown[value] += count
+	}
+
+	if arm.Rewarded != nil && (fleet.Rewarded == nil || arm.Rewarded.After(*fleet.Rewarded)) {
+		rewarded := *arm.Rewarded
+		fleet.Rewarded = &rewarded
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAggregateStats(t *testing.T) {
+	early, late := time.Unix(1000, 0), time.Unix(2000, 0)
+	a := Stats{
+		Experiment: "shape-20130822",
+		Arms: []ArmStats{
+			{Ordinal: 1, Tag: "shape-20130822:1", Count: 10, Value: 0.5, Breakdown: map[string]int{"de": 4}, Rewarded: &early, Staleness: 3},
+			{Ordinal: 2, Tag: "shape-20130822:2", Count: 0},
+		},
+	}
+
+	b := Stats{
+		Experiment: "shape-20130822",
+		Arms: []ArmStats{
+			{Ordinal: 2, Tag: "shape-20130822:2", Count: 5, Value: 0.2, Staleness: 2},
+			{Ordinal: 1, Tag: "shape-20130822:1", Count: 30, Value: 0.1, Breakdown: map[string]int{"de": 1, "en": 2}, Rewarded: &late, Staleness: 1},
+		},
+	}
+
+	fleet, err := AggregateStats([]Stats{a, b})
+	if err != nil {
+		t.Fatalf("could not aggregate: %s", err.Error())
+	}
+
+	if len(fleet.Arms) != 2 || fleet.Arms[0].Tag != "shape-20130822:1" {
+		t.Fatalf("expected arms in the order of the first instance, got %v", fleet.Arms)
+	}
+
+	circle, square := fleet.Arms[0], fleet.Arms[1]
+	if circle.Count != 40 || math.Abs(circle.Value-0.2) > 1e-9 {
+		t.Fatalf("expected 40 pulls of value 0.2, got %d of %f", circle.Count, circle.Value)
+	}
+
+	if circle.Breakdown["de"] != 5 || circle.Breakdown["en"] != 2 {
+		t.Fatalf("expected breakdowns to add up, got %v", circle.Breakdown)
+	}
+
+	if !circle.Rewarded.Equal(late) || circle.Staleness != 1 {
+		t.Fatalf("expected latest reward and least staleness, got %v, %f", circle.Rewarded, circle.Staleness)
+	}
+
+	// the first instance never selected the square
+	if square.Count != 5 || square.Value != 0.2 || square.Staleness != 2 {
+		t.Fatalf("expected square of the second instance, got %v", square)
+	}
+
+	a.Policy, b.Policy = "0a1b2c3d-7", "0a1b2c3d-8"
+	if fleet, err := AggregateStats([]Stats{a, b}); err != nil || fleet.Policy != "" {
+		t.Fatalf("expected no common policy, got %s", fleet.Policy)
+	}
+
+	b.Policy = a.Policy
+	if fleet, err := AggregateStats([]Stats{a, b}); err != nil || fleet.Policy != a.Policy {
+		t.Fatalf("expected common policy %s, got %s", a.Policy, fleet.Policy)
+	}
+
+	b.Experiment = "color-20130901"
+	if _, err := AggregateStats([]Stats{a, b}); err == nil {
+		t.Fatalf("expected different experiments not to aggregate")
+	}
+
+	if _, err := AggregateStats([]Stats{}); err == nil {
+		t.Fatalf("expected error without stats")
+	}
+}
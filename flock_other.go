@@ -0,0 +1,15 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package bandit
+
+import (
+	"os"
+)
+
+// lockFile does not lock on this platform. Processes must not share a file.
+func lockFile(f *os.File) error {
+	return nil
+}
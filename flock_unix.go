@@ -0,0 +1,22 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+//go:build unix
+
+package bandit
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on `f`, which is released when
+// `f` is closed. Fails if another process holds the lock.
+func lockFile(f *os.File) error {
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		return fmt.Errorf("could not lock %s, is it in use by another process? %s", f.Name(), err.Error())
+	}
+
+	return nil
+}
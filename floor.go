@@ -0,0 +1,164 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// NewFloored wraps a strategy so that each of its `arms` is selected with a
+// probability of at least `floor`, however bad the wrapped strategy believes
+// it is. An arm which only looks bad for now keeps receiving enough traffic
+// to be found when the environment changes and it becomes the winner. The
+// floor is in (0, 1/arms].
+func NewFloored(s Strategy, arms int, floor float64) (Strategy, error) {
+	if arms < 1 {
+		return &flooredStrategy{}, fmt.Errorf("need at least 1 arm")
+	}
+
+	if !(floor > 0 && floor*float64(arms) <= 1) {
+		return &flooredStrategy{}, fmt.Errorf("floor not in (0, 1/%d]", arms)
+	}
+
+	return &flooredStrategy{
+		wrapper: wrapper{strategy: s},
+		arms:    arms,
+		floor:   floor,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// flooredStrategy selects uniformly at random with probability floor * arms,
+// and asks the wrapped strategy otherwise. Uniform selections are counted as
+// pulls of the wrapped strategy, so its estimates stay correct.
+type flooredStrategy struct {
+	wrapper
+	arms  int
+	floor float64
+	rand  *rand.Rand // draws the floor
+}
+
+// SelectArm returns a uniformly random arm or the arm of the wrapped strategy.
+func (f *flooredStrategy) SelectArm() int {
+	f.Lock()
+	uniform := f.rand.Float64() < f.floor*float64(f.arms)
+	arm := f.rand.Intn(f.arms) + 1
+	f.Unlock()
+
+	if !uniform {
+		return f.strategy.SelectArm()
+	}
+
+	pull(f.strategy, arm)
+	return arm
+}
+
+// SelectAffordableArm is SelectArm for wrapped strategies which may refuse. A
+// uniform draw the wrapped strategy cannot pay for is left to it instead.
+func (f *flooredStrategy) SelectAffordableArm() (int, bool) {
+	f.Lock()
+	uniform := f.rand.Float64() < f.floor*float64(f.arms)
+	arm := f.rand.Intn(f.arms) + 1
+	f.Unlock()
+
+	if uniform && pullAffordable(f.strategy, arm) {
+		return arm, true
+	}
+
+	return selectAffordableArm(f.strategy)
+}
+
+// SelectArms fills `arms` like SelectArm. All selections which are not
+// uniform are made by the wrapped strategy at once.
+func (f *flooredStrategy) SelectArms(arms []int) {
+	uniform, wrapped := make([]bool, len(arms)), 0
+	f.Lock()
+	for i := range arms {
+		if f.rand.Float64() < f.floor*float64(f.arms) {
+			uniform[i], arms[i] = true, f.rand.Intn(f.arms)+1
+		} else {
+			wrapped++
+		}
+	}
+	f.Unlock()
+
+	selected := make([]int, wrapped)
+	SelectArms(f.strategy, selected)
+	for i := range arms {
+		if uniform[i] {
+			pull(f.strategy, arms[i])
+			continue
+		}
+
+		arms[i], selected = selected[0], selected[1:]
+	}
+}
+
+// SelectSlate replaces each arm of the wrapped strategy's slate with a
+// uniformly random arm with probability floor * arms, unless that arm is in
+// the slate already. Replaced arms are released, so the wrapped estimates
+// stay correct.
+func (f *flooredStrategy) SelectSlate(k int) []int {
+	slate, _ := SelectSlate(f.strategy, k)
+	for i := range slate {
+		f.Lock()
+		uniform := f.rand.Float64() < f.floor*float64(f.arms)
+		arm := f.rand.Intn(f.arms) + 1
+		f.Unlock()
+
+		if uniform && !containsArm(slate, arm) {
+			release(f.strategy, slate[i:i+1])
+			pull(f.strategy, arm)
+			slate[i] = arm
+		}
+	}
+
+	return slate
+}
+
+// containsArm returns true if `arm` is one of `arms`.
+func containsArm(arms []int, arm int) bool {
+	for _, a := range arms {
+		if a == arm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Distribution mixes the distribution of the wrapped strategy with the
+// uniform floor. It is nil if the wrapped strategy is not a Previewer.
+func (f *flooredStrategy) Distribution() []float64 {
+	wrapped := distribution(f.strategy)
+	if wrapped == nil {
+		return nil
+	}
+
+	uniform := f.floor * float64(f.arms)
+	floored := make([]float64, len(wrapped))
+	for i, p := range wrapped {
+		floored[i] = f.floor + (1-uniform)*p
+	}
+
+	return floored
+}
+
+// UseSource draws the floor from a source seeded by `src`, which is then
+// handed to the wrapped strategy. Sources cannot be shared, see
+// Counters.UseSource.
+func (f *flooredStrategy) UseSource(src rand.Source) error {
+	f.Lock()
+	f.rand = rand.New(rand.NewSource(src.Int63()))
+	f.Unlock()
+
+	return useSource(f.strategy, src)
+}
+
+// String returns information on this strategy.
+func (f *flooredStrategy) String() string {
+	return fmt.Sprintf("Floored(%s, floor=%.4f)", f.strategy, f.floor)
+}
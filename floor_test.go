@@ -0,0 +1,95 @@
+package bandit
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestFloored(t *testing.T) {
+	greedy, err := NewEpsilonGreedy(3, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	greedy.Update(1, 1)
+	s, err := NewFloored(greedy, 3, 0.1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if _, err := NewSeeded(s, 42); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	selections, draws := make([]int, 3), 10000
+	for i := 0; i < draws; i++ {
+		selections[s.SelectArm()-1]++
+	}
+
+	// arms 2 and 3 only get the floor, arm 1 gets the rest
+	for arm, expected := range []float64{0.8, 0.1, 0.1} {
+		if got := float64(selections[arm]) / float64(draws); math.Abs(got-expected) > 0.02 {
+			t.Fatalf("expected share %f of arm %d, got %f", expected, arm+1, got)
+		}
+	}
+
+	// uniform draws are counted by the wrapped strategy
+	pulls := 0
+	for _, count := range s.Snapshot().counts {
+		pulls += count
+	}
+
+	if pulls != draws+1 {
+		t.Fatalf("expected %d pulls, got %d", draws+1, pulls)
+	}
+
+	distribution := s.(Previewer).Distribution()
+	for arm, expected := range []float64{0.8, 0.1, 0.1} {
+		if math.Abs(distribution[arm]-expected) > 1e-9 {
+			t.Fatalf("expected probability %f of arm %d, got %f", expected, arm+1, distribution[arm])
+		}
+	}
+}
+
+func TestFlooredInvalid(t *testing.T) {
+	for _, floor := range []float64{-0.1, 0, 0.5} {
+		if _, err := NewFloored(NewUCB1(3), 3, floor); err == nil {
+			t.Fatalf("expected floor %f to fail for 3 arms", floor)
+		}
+	}
+
+	if _, err := NewFloored(NewUCB1(3), 3, 1.0/3); err != nil {
+		t.Fatalf("expected floor 1/3 to be uniform: %s", err.Error())
+	}
+}
+
+func TestFlooredExperiment(t *testing.T) {
+	f, err := ioutil.TempFile("", "bandit-floor")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.Remove(f.Name())
+	f.WriteString(`[{
+		"experiment_name": "shape-20130822",
+		"strategy": "ucb1",
+		"floor": 0.05,
+		"preferred": 1,
+		"variations": [
+			{ "ordinal": 1, "url": "http://localhost/circle" },
+			{ "ordinal": 2, "url": "http://localhost/square" }
+		]
+	}]`)
+	f.Close()
+
+	e, err := NewExperiment(NewFileOpener(f.Name()), "shape-20130822")
+	if err != nil {
+		t.Fatalf("could not read floored experiment: %s", err.Error())
+	}
+
+	if _, ok := e.Strategy.(*flooredStrategy); !ok {
+		t.Fatalf("expected floored strategy, got %s", e.Strategy)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// EventStale is emitted when a variation is still selected, but has not been
+// rewarded for longer than the experiment's MaxRewardGap.
+const EventStale = "stale-rewards"
+
+// NewFreshness returns freshness of `arms` 1 indexed variations which were
+// never selected nor rewarded.
+func NewFreshness(arms int) *Freshness {
+	return &Freshness{
+		first:    make([]int64, arms),
+		selected: make([]int64, arms),
+		rewarded: make([]int64, arms),
+	}
+}
+
+// Freshness records when each variation was first and last selected, and when
+// it was last rewarded, in unix nanoseconds. It is safe for concurrent use
+// without locking, so that selections are not serialized.
+type Freshness struct {
+	first    []int64
+	selected []int64
+	rewarded []int64
+}
+
+// Selected records a selection of the variation with `ordinal` at `at`.
+func (f *Freshness) Selected(ordinal int, at time.Time) {
+	if ordinal < 1 || ordinal > len(f.selected) {
+		return
+	}
+
+	nanos := at.UnixNano()
+	atomic.CompareAndSwapInt64(&f.first[ordinal-1], 0, nanos)
+	atomic.StoreInt64(&f.selected[ordinal-1], nanos)
+}
+
+// Rewarded records a reward of the variation with `ordinal` at `at`.
+func (f *Freshness) Rewarded(ordinal int, at time.Time) {
+	if ordinal < 1 || ordinal > len(f.rewarded) {
+		return
+	}
+
+	atomic.StoreInt64(&f.rewarded[ordinal-1], at.UnixNano())
+}
+
+// LastRewarded returns when the variation with `ordinal` was last rewarded,
+// and false if it never was.
+func (f *Freshness) LastRewarded(ordinal int) (time.Time, bool) {
+	if ordinal < 1 || ordinal > len(f.rewarded) {
+		return time.Time{}, false
+	}
+
+	return unixNano(atomic.LoadInt64(&f.rewarded[ordinal-1]))
+}
+
+// Staleness returns how long the variation with `ordinal` has been waiting
+// for a reward at `now`: since its last reward, or since its first selection
+// if it was never rewarded. Variations which were never selected are not
+// stale.
+func (f *Freshness) Staleness(ordinal int, now time.Time) time.Duration {
+	if ordinal < 1 || ordinal > len(f.rewarded) {
+		return 0
+	}
+
+	since, ok := unixNano(atomic.LoadInt64(&f.rewarded[ordinal-1]))
+	if !ok {
+		if since, ok = unixNano(atomic.LoadInt64(&f.first[ordinal-1])); !ok {
+			return 0
+		}
+	}
+
+	if staleness := now.Sub(since); staleness > 0 {
+		return staleness
+	}
+
+	return 0
+}
+
+// Stale returns true if the variation with `ordinal` was selected within
+// `gap` of `now`, but has been waiting longer than `gap` for a reward. This
+// usually means that rewards of the variation are not attributed to it.
+func (f *Freshness) Stale(ordinal int, now time.Time, gap time.Duration) bool {
+	if ordinal < 1 || ordinal > len(f.selected) {
+		return false
+	}
+
+	selected, ok := unixNano(atomic.LoadInt64(&f.selected[ordinal-1]))
+	return ok && now.Sub(selected) <= gap && f.Staleness(ordinal, now) > gap
+}
+
+// reset forgets all selections and rewards.
+func (f *Freshness) reset() {
+	for i := range f.selected {
+		atomic.StoreInt64(&f.first[i], 0)
+		atomic.StoreInt64(&f.selected[i], 0)
+		atomic.StoreInt64(&f.rewarded[i], 0)
+	}
+}
+
+// unixNano returns the time of unix nanoseconds `nanos`, and false for 0.
+func unixNano(nanos int64) (time.Time, bool) {
+	if nanos == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, nanos), true
+}
@@ -0,0 +1,115 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NewFunnel returns a funnel which credits strategy `s` with a single
+// composite reward per selection. `weights` maps funnel steps such as "view",
+// "cart" and "purchase" to their contribution. Steps are attributed to a
+// selection only if they happen within `window` of it.
+func NewFunnel(s Strategy, weights map[string]float64, window time.Duration) (*Funnel, error) {
+	if len(weights) == 0 {
+		return &Funnel{}, fmt.Errorf("funnel needs at least one step")
+	}
+
+	if !(window > 0) {
+		return &Funnel{}, fmt.Errorf("attribution window not in (0, ∞)")
+	}
+
+	return &Funnel{
+		strategy: s,
+		weights:  weights,
+		window:   window,
+		pending:  make(map[string]*funnelEntry),
+	}, nil
+}
+
+// Funnel collects the steps following each selection until the attribution
+// window closes, and then updates the strategy with the sum of the weights of
+// all distinct steps reached. Selections without any steps are rewarded 0.
+type Funnel struct {
+	sync.Mutex
+	strategy Strategy
+	weights  map[string]float64
+	window   time.Duration
+	pending  map[string]*funnelEntry // keyed by selection
+}
+
+// funnelEntry tracks a single selection through the funnel.
+type funnelEntry struct {
+	arm     int
+	at      time.Time
+	reached map[string]bool
+}
+
+// Select opens attribution for the selection of 1 indexed `arm` at time `at`.
+// `key` identifies the selection, for example the timestamped tag plus a
+// user id.
+func (f *Funnel) Select(key string, arm int, at time.Time) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.pending[key] = &funnelEntry{
+		arm:     arm,
+		at:      at,
+		reached: make(map[string]bool),
+	}
+}
+
+// Step records that selection `key` reached funnel step `step` at time `at`.
+// Steps outside of the attribution window are ignored.
+func (f *Funnel) Step(key, step string, at time.Time) error {
+	if _, ok := f.weights[step]; !ok {
+		return fmt.Errorf("unknown funnel step '%s'", step)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	entry, ok := f.pending[key]
+	if !ok {
+		return fmt.Errorf("no open selection '%s'", key)
+	}
+
+	if at.Sub(entry.at) <= f.window {
+		entry.reached[step] = true
+	}
+
+	return nil
+}
+
+// Flush applies the composite reward of all selections whose attribution
+// window has closed at time `now`. Returns the number of rewarded selections.
+func (f *Funnel) Flush(now time.Time) int {
+	f.Lock()
+	var closed []*funnelEntry
+	for key, entry := range f.pending {
+		if now.Sub(entry.at) > f.window {
+			closed = append(closed, entry)
+			delete(f.pending, key)
+		}
+	}
+	f.Unlock()
+
+	for _, entry := range closed {
+		f.strategy.Update(entry.arm, f.reward(entry))
+	}
+
+	return len(closed)
+}
+
+// reward is the composite reward of a single selection.
+func (f *Funnel) reward(entry *funnelEntry) float64 {
+	reward := 0.0
+	for step := range entry.reached {
+		reward += f.weights[step]
+	}
+
+	return reward
+}
@@ -0,0 +1,58 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFunnel(t *testing.T) {
+	strategy, err := NewEpsilonGreedy(2, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	weights := map[string]float64{"view": 0.1, "cart": 0.3, "purchase": 0.6}
+	f, err := NewFunnel(strategy, weights, time.Hour)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	counters := &strategy.(*epsilonGreedy).Counters
+	start := time.Unix(1379257984, 0)
+
+	counters.counts[0]++
+	f.Select("a", 1, start)
+	counters.counts[1]++
+	f.Select("b", 2, start)
+
+	for _, step := range []string{"view", "cart", "view"} {
+		if err := f.Step("a", step, start.Add(time.Minute)); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	// outside of the attribution window
+	if err := f.Step("a", "purchase", start.Add(2*time.Hour)); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if err := f.Step("a", "bounce", start); err == nil {
+		t.Fatalf("expected error on unknown step")
+	}
+
+	if got := f.Flush(start.Add(time.Minute)); got != 0 {
+		t.Fatalf("expected open windows not to be flushed, got %d", got)
+	}
+
+	if got := f.Flush(start.Add(2 * time.Hour)); got != 2 {
+		t.Fatalf("expected 2 flushed selections, got %d", got)
+	}
+
+	if got, expected := counters.values[0], 0.4; got != expected {
+		t.Fatalf("expected composite reward %f, got %f", expected, got)
+	}
+
+	if got, expected := counters.values[1], 0.0; got != expected {
+		t.Fatalf("expected composite reward %f, got %f", expected, got)
+	}
+}
@@ -0,0 +1,149 @@
+package bandit
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// byteOpener opens an in memory file.
+type byteOpener []byte
+
+func (o byteOpener) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(o)), nil
+}
+
+func FuzzNewExperiments(f *testing.F) {
+	fixture, err := ioutil.ReadFile("experiments.json")
+	if err != nil {
+		f.Fatalf(err.Error())
+	}
+
+	f.Add(fixture)
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`[{"experiment_name": "e", "strategy": "ucb1", "preferred": 1, "variations": [{"ordinal": 1}]}]`))
+	f.Add([]byte(`[{"experiment_name": "e", "strategy": "ucb1", "preferred": 2, "variations": [{"ordinal": 2}, {"ordinal": 9}]}]`))
+	f.Add([]byte(`[{"experiment_name": "e", "strategy": "thompson", "parameters": [1], "preferred": 1, "variations": [{"ordinal": 1, "group": "g"}, {"ordinal": 1, "group": "g"}]}]`))
+	f.Add([]byte("[{\"experiment_name\": \"\xff\xfe\", \"strategy\": \"ucb1\", \"preferred\": 1, \"variations\": [{\"ordinal\": 1}]}]"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		es, err := NewExperiments(byteOpener(data))
+		if err != nil {
+			return
+		}
+
+		// loaded experiments must be servable
+		for _, e := range es.All() {
+			v := e.Select()
+			e.Update(v.Ordinal, 1)
+			e.Stats()
+			if _, err := e.GetVariation(e.PreferredOrdinal); err != nil {
+				t.Fatalf("preferred variation of %s: %s", e.Name, err.Error())
+			}
+
+			for i, v := range e.Variations {
+				if v.Ordinal != i+1 {
+					t.Fatalf("expected contiguous ordinals, got %v", e.Variations)
+				}
+			}
+		}
+	})
+}
+
+func FuzzParseSnapshot(f *testing.F) {
+	f.Add("2\t0.1\t0.5")
+	f.Add("")
+	f.Add("-1")
+	f.Add("2 0.1\n0.5")
+	f.Fuzz(func(t *testing.T, snapshot string) {
+		c, err := ParseSnapshot(strings.NewReader(snapshot))
+		if err == nil && len(c.values) != c.arms {
+			t.Fatalf("expected %d values, got %v", c.arms, c.values)
+		}
+	})
+}
+
+func FuzzParseRule(f *testing.F) {
+	f.Add("error_rate > 2% over 10m then disable and notify")
+	f.Add("latency < 300 over 1h then notify")
+	f.Fuzz(func(t *testing.T, declared string) {
+		rule, err := ParseRule(declared)
+		if err != nil {
+			return
+		}
+
+		if _, err := ParseRule(rule.String()); err != nil {
+			t.Fatalf("could not parse %q of %q: %s", rule, declared, err.Error())
+		}
+	})
+}
+
+func FuzzParseLog(f *testing.F) {
+	f.Add("1379257984 BanditSelection shape-20130822:1 a0b1c2d3-42\n1379257987 BanditReward shape-20130822:1 1.000000\n")
+	f.Add("2013/09/15 15:13:04 1379257984 BanditSelection shape-20130822:1\n")
+	f.Fuzz(func(t *testing.T, log string) {
+		ParseLog(strings.NewReader(log))
+	})
+}
+
+// failingOpener opens a file which fails after `n` bytes.
+type failingOpener struct {
+	data []byte
+	n    int
+}
+
+func (o failingOpener) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(io.MultiReader(bytes.NewReader(o.data[:o.n]), failingReader{})), nil
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestMalformedExperiments(t *testing.T) {
+	variation := func(fields string) string {
+		return `[{"experiment_name": "e", "strategy": "ucb1", "preferred": 1, ` + fields + `}]`
+	}
+
+	for _, config := range []string{
+		variation(`"variations": []`),
+		variation(`"variations": [{"ordinal": 1}, {"ordinal": 3}]`),
+		variation(`"variations": [{"ordinal": 1}, {"ordinal": 1}]`),
+		variation(`"variations": [{"ordinal": -1}]`),
+		variation(`"variations": [{"ordinal": 1, "seed-from": 7}]`),
+		variation(`"retention-days": 99999999999, "variations": [{"ordinal": 1}]`),
+		variation(`"batch-updates": {"size": -1}, "variations": [{"ordinal": 1}]`),
+		`[{"strategy": "ucb1", "preferred": 1, "variations": [{"ordinal": 1}]}]`,
+		`[{"experiment_name": "e", "strategy": "ucb1", "preferred": 0, "variations": [{"ordinal": 1}]}]`,
+		variation(`"preferred-locales": {"de": 0}, "variations": [{"ordinal": 1}]`),
+		`[{"experiment_name": "e", "strategy": "ucb1", "preferred": 1, "variations": [{"ordinal": 1}]},
+		  {"experiment_name": "e", "strategy": "ucb1", "preferred": 1, "variations": [{"ordinal": 1}]}]`,
+		`[{"experiment_name": "e"`,
+		strings.Repeat(" ", maxConfigSize+1),
+	} {
+		if _, err := NewExperiments(byteOpener(config)); err == nil {
+			t.Fatalf("expected error for %.200s", config)
+		}
+	}
+
+	fixture, err := ioutil.ReadFile("experiments.json")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for _, n := range []int{0, len(fixture) / 2, len(fixture)} {
+		if _, err := NewExperiments(failingOpener{fixture, n}); err == nil {
+			t.Fatalf("expected read failure after %d bytes to fail", n)
+		}
+	}
+
+	for _, snapshot := range []string{"", "0", "-1 0.5", "2 0.5", "x 0.5"} {
+		if _, err := ParseSnapshot(strings.NewReader(snapshot)); err == nil {
+			t.Fatalf("expected error for snapshot %q", snapshot)
+		}
+	}
+}
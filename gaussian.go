@@ -0,0 +1,133 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// GaussianPrior is a Normal-Inverse-Gamma prior over the mean μ and variance
+// σ² of an arm's rewards: σ² ~ InvGamma(Alpha, Beta), μ ~ N(Mean, σ² / Kappa).
+// Kappa and Alpha count like pseudo rewards.
+type GaussianPrior struct {
+	Mean  float64
+	Kappa float64
+	Alpha float64
+	Beta  float64
+}
+
+// UninformativeGaussianPrior is the limit of priors which know nothing about
+// the scale of rewards. The posterior mean is then Student-t distributed
+// around the sample mean, with n - 1 degrees of freedom, so each arm is
+// pulled twice before its posterior is sampled.
+var UninformativeGaussianPrior = GaussianPrior{Mean: 0, Kappa: 0, Alpha: -0.5, Beta: 0}
+
+// NewGaussianThompson constructs a Thompson sampler for continuous rewards of
+// any scale, such as revenue or latency, see Honda & Takemura, 2014,
+// "Optimality of Thompson Sampling for Gaussian Bandits Depends on Priors".
+// Rewards of each arm are assumed normally distributed with unknown mean and
+// variance, with Normal-Inverse-Gamma `prior`.
+func NewGaussianThompson(arms int, prior GaussianPrior) (Strategy, error) {
+	for _, p := range []float64{prior.Mean, prior.Kappa, prior.Alpha, prior.Beta} {
+		if math.IsNaN(p) || math.IsInf(p, 0) {
+			return &gaussianThompson{}, fmt.Errorf("prior parameters must be finite")
+		}
+	}
+
+	if prior.Kappa < 0 || prior.Beta < 0 {
+		return &gaussianThompson{}, fmt.Errorf("κ and β not in [0, ∞)")
+	}
+
+	return &gaussianThompson{
+		uCBTuned: uCBTuned{
+			Counters: NewCounters(arms),
+			squares:  make([]float64, arms),
+		},
+		prior: prior,
+	}, nil
+}
+
+// gaussianThompson keeps squared rewards like UCB-Tuned, which together with
+// the counts and mean rewards are the sufficient statistics of the Normal
+// posterior. It selects the arm with the highest mean drawn from its
+// posterior. Snapshots do not carry squared rewards, see uCBTuned.Init, so
+// it needs rewards applied online rather than through delayed snapshots.
+type gaussianThompson struct {
+	uCBTuned
+	prior GaussianPrior
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (g *gaussianThompson) SelectArm() int {
+	g.Lock()
+	defer g.Unlock()
+
+	draws := make([]float64, g.arms)
+	for i := range draws {
+		draws[i] = g.draw(i, g.rand)
+	}
+
+	var arm int
+	if g.ties == TiesOrdinal {
+		_, best := bmath.Max(draws)
+		arm = best[0]
+	} else {
+		arm = bmath.ArgMax(draws, g.rand)
+	}
+
+	g.counts[arm]++
+	return arm + 1
+}
+
+// draw returns a mean of 0 indexed `arm` drawn from its posterior with `r`,
+// without locking. Arms whose posterior is improper are best.
+func (g *gaussianThompson) draw(arm int, r *rand.Rand) float64 {
+	n, mean := float64(g.counts[arm]), g.values[arm]
+	variance := math.Max(g.squares[arm]-mean*mean, 0)
+
+	kappa := g.prior.Kappa + n
+	alpha := g.prior.Alpha + n/2
+	if !(kappa > 0 && alpha > 0) {
+		return math.Inf(1)
+	}
+
+	μ := (g.prior.Kappa*g.prior.Mean + n*mean) / kappa
+	β := g.prior.Beta + n*variance/2 + g.prior.Kappa*n*(mean-g.prior.Mean)*(mean-g.prior.Mean)/(2*kappa)
+	σ2 := β / bmath.GammaRand(r, alpha)
+
+	return g.estimate(arm, μ+math.Sqrt(σ2/kappa)*r.NormFloat64())
+}
+
+// Distribution estimates the probability of each arm having the highest
+// posterior draw. It draws from its own random source, so that selections are
+// not affected.
+func (g *gaussianThompson) Distribution() []float64 {
+	g.Lock()
+	defer g.Unlock()
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	distribution := make([]float64, g.arms)
+	draws := make([]float64, g.arms)
+	for draw := 0; draw < previewDraws; draw++ {
+		for i := range draws {
+			draws[i] = g.draw(i, r)
+		}
+
+		_, best := bmath.Max(draws)
+		for _, arm := range best {
+			distribution[arm] += 1 / float64(len(best)) / previewDraws
+		}
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (g *gaussianThompson) String() string {
+	return fmt.Sprintf("GaussianThompson")
+}
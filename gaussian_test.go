@@ -0,0 +1,67 @@
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"math"
+	"testing"
+)
+
+func TestGaussianThompson(t *testing.T) {
+	sims := 100
+	trials := 1000
+	bestArmIndex := 3 // Normal(42, 10)
+
+	// revenue in dollars
+	arms := []sim.Arm{
+		bmath.NormRand(30, 10),
+		bmath.NormRand(38, 10),
+		bmath.NormRand(42, 10),
+	}
+
+	for _, params := range [][]float64{{}, {0, 0.01, 1, 1}} {
+		strategy, err := New(len(arms), "gaussianThompson", params)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+		if got := accuracies[len(accuracies)-1]; got < 0.9 {
+			t.Fatalf("accuracy is only %f with prior %v. %d sims, %d trials", got, params, sims, trials)
+		}
+	}
+
+	if _, err := NewGaussianThompson(3, GaussianPrior{Kappa: -1}); err == nil {
+		t.Fatalf("expected negative κ to fail")
+	}
+}
+
+func TestGaussianThompsonExploresTwice(t *testing.T) {
+	s, err := NewGaussianThompson(2, UninformativeGaussianPrior)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	g := s.(*gaussianThompson)
+	g.counts[0]++
+	g.Update(1, 100)
+	if d := g.Distribution(); math.Abs(d[0]-0.5) > 1e-9 || math.Abs(d[1]-0.5) > 1e-9 {
+		t.Fatalf("expected arms pulled less than twice to be best, got %v", d)
+	}
+
+	for i := 0; i < 10; i++ {
+		g.counts[0]++
+		g.Update(1, 100)
+		g.counts[1]++
+		g.Update(2, float64(i%2))
+	}
+
+	if d := g.Distribution(); math.Abs(d[0]-1) > 1e-9 {
+		t.Fatalf("expected arm 1 to be best, got %v", d)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// NewGradient constructs a gradient bandit strategy, see Sutton & Barto, 2018,
+// "Reinforcement Learning: An Introduction", 2.8. It learns a preference per
+// arm with step size `α` and selects with the softmax of the preferences.
+// Only differences between rewards and their running average matter, so it
+// adapts to shifting reward scales, unlike value based softmax.
+func NewGradient(arms int, α float64) (Strategy, error) {
+	if !(α > 0.0) {
+		return &gradient{}, fmt.Errorf("α not in (0, ∞)")
+	}
+
+	return &gradient{
+		Counters:      NewCounters(arms),
+		alpha:         α,
+		preferences:   make([]float64, arms),
+		probabilities: make([]float64, arms),
+	}, nil
+}
+
+// gradient keeps preferences next to the counters, which still hold pulls and
+// mean rewards for stats. A reward r of arm a moves each preference H along
+// the gradient of the expected reward, against the baseline r̄ of all rewards:
+//
+//	H(a) += α (r - r̄) (1 - π(a)), H(b) -= α (r - r̄) π(b) for b ≠ a
+//
+// Priorities multiply the selection probabilities π and costs are subtracted
+// from rewards.
+type gradient struct {
+	Counters
+	alpha         float64   // step size
+	preferences   []float64 // learned preference per arm. rebuilt on Init, see rebuild.
+	baseline      float64   // running average of all rewards so far
+	rewards       int       // number of rewards in the baseline
+	probabilities []float64 // preallocated selection probabilities
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (g *gradient) SelectArm() int {
+	g.Lock()
+	defer g.Unlock()
+
+	g.distribution()
+	z, arm := g.rand.Float64(), g.arms-1
+	for i, p := range g.probabilities {
+		if z < p {
+			arm = i
+			break
+		}
+
+		z -= p
+	}
+
+	g.counts[arm]++
+	return arm + 1
+}
+
+// distribution fills the selection probabilities from the preferences.
+func (g *gradient) distribution() {
+	max := math.Inf(-1)
+	for _, h := range g.preferences {
+		max = math.Max(max, h)
+	}
+
+	total := 0.0
+	for i, h := range g.preferences {
+		g.probabilities[i] = math.Exp(h - max)
+		if g.priorities != nil {
+			g.probabilities[i] *= g.priorities[i]
+		}
+
+		total += g.probabilities[i]
+	}
+
+	for i := range g.probabilities {
+		g.probabilities[i] /= total
+	}
+}
+
+// Update takes a gradient step for a reward of the 1 indexed arm.
+func (g *gradient) Update(arm int, reward float64) {
+	g.Lock()
+	defer g.Unlock()
+
+	g.update(arm, reward)
+}
+
+// UpdateBatch takes a gradient step for each reward of the 1 indexed arm in
+// order.
+func (g *gradient) UpdateBatch(arm int, rewards []float64) {
+	g.Lock()
+	defer g.Unlock()
+
+	for _, reward := range rewards {
+		g.update(arm, reward)
+	}
+}
+
+// update the counters, baseline and preferences without locking. The
+// baseline includes the reward, so that the first reward is no surprise. It is
+// discounted like values, see Discount.
+func (g *gradient) update(arm int, reward float64) {
+	g.Counters.update(arm, reward)
+	if g.costs != nil {
+		reward -= g.costs[arm-1]
+	}
+
+	g.rewards++
+	if g.gamma > 0 && g.rewards > 1 {
+		g.baseline = g.gamma*g.baseline + (1-g.gamma)*reward
+	} else {
+		g.baseline += (reward - g.baseline) / float64(g.rewards)
+	}
+
+	g.distribution()
+	step := g.alpha * (reward - g.baseline)
+	for i, p := range g.probabilities {
+		if i == arm-1 {
+			g.preferences[i] += step * (1 - p)
+		} else {
+			g.preferences[i] -= step * p
+		}
+	}
+}
+
+// Init the strategy to a new counter state. Snapshots do not carry
+// preferences, so they are rebuilt from the counters, with the mean reward of
+// the snapshot as baseline, see rebuild.
+func (g *gradient) Init(snapshot *Counters) error {
+	if err := g.Counters.Init(snapshot); err != nil {
+		return err
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	g.baseline, g.rewards = 0, 0
+	for i, count := range g.counts {
+		g.baseline += g.net(i) * float64(count)
+		g.rewards += count
+	}
+
+	if g.rewards > 0 {
+		g.baseline /= float64(g.rewards)
+	}
+
+	g.rebuild()
+	return nil
+}
+
+// net returns the mean reward of 0 indexed `arm` minus its cost, as rewards
+// enter the baseline.
+func (g *gradient) net(arm int) float64 {
+	if g.costs != nil {
+		return g.values[arm] - g.costs[arm]
+	}
+
+	return g.values[arm]
+}
+
+// rebuild approximates the preferences from the counters without locking.
+// Each arm gets the sum of the steps its rewards would take against the
+// final baseline, α n(a) (v(a) - c(a) - r̄), ignoring how the steps move the
+// other preferences through π. Arms which did better than average are
+// preferred as before, and more so the more often they were rewarded.
+func (g *gradient) rebuild() {
+	g.preferences = make([]float64, g.arms)
+	for i, count := range g.counts {
+		g.preferences[i] = g.alpha * float64(count) * (g.net(i) - g.baseline)
+	}
+}
+
+// gradientState is the stored state of a gradient strategy.
+type gradientState struct {
+	Preferences []float64 `json:"preferences"`
+	Baseline    float64   `json:"baseline"`
+	Rewards     int       `json:"rewards"`
+}
+
+// marshalState encodes the preferences and the baseline.
+func (g *gradient) marshalState() ([]byte, error) {
+	g.Lock()
+	defer g.Unlock()
+
+	return json.Marshal(gradientState{g.preferences, g.baseline, g.rewards})
+}
+
+// unmarshalState restores the preferences and the baseline, replacing the
+// ones rebuilt by Init.
+func (g *gradient) unmarshalState(data []byte) error {
+	var stored gradientState
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	if len(stored.Preferences) != g.arms {
+		return fmt.Errorf("%d preferences for %d arms", len(stored.Preferences), g.arms)
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	g.preferences = stored.Preferences
+	g.baseline, g.rewards = stored.Baseline, stored.Rewards
+	return nil
+}
+
+// Reset the strategy to initial state.
+func (g *gradient) Reset() {
+	g.ResetWithArchive()
+}
+
+// ResetWithArchive resets the counters, preferences and baseline at once.
+func (g *gradient) ResetWithArchive() *Counters {
+	g.Lock()
+	defer g.Unlock()
+
+	g.preferences = make([]float64, g.arms)
+	g.baseline, g.rewards = 0, 0
+	return g.reset()
+}
+
+// Distribution returns the probability of selecting each arm.
+func (g *gradient) Distribution() []float64 {
+	g.Lock()
+	defer g.Unlock()
+
+	g.distribution()
+	return append([]float64{}, g.probabilities...)
+}
+
+// String returns information on this Strategy
+func (g *gradient) String() string {
+	return fmt.Sprintf("Gradient(alpha=%.2f)", g.alpha)
+}
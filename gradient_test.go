@@ -0,0 +1,85 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"testing"
+)
+
+func TestGradient(t *testing.T) {
+	sims := 500
+	trials := 1000
+	bestArmIndex := 4 // Bernoulli(bestArm)
+	arms := []sim.Arm{
+		bmath.BernRand(0.1),
+		bmath.BernRand(0.3),
+		bmath.BernRand(0.2),
+		bmath.BernRand(0.8),
+	}
+
+	strategy, err := New(len(arms), "gradient", []float64{0.1})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.9 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+}
+
+func TestGradientShiftedRewards(t *testing.T) {
+	// the same rewards, shifted by 100, give the same preferences
+	a, _ := NewGradient(2, 0.1)
+	b, _ := NewGradient(2, 0.1)
+	for i := 0; i < 100; i++ {
+		arm, reward := i%2+1, float64(i%2)
+		a.Update(arm, reward)
+		b.Update(arm, reward+100)
+	}
+
+	pa, pb := a.(*gradient).Distribution(), b.(*gradient).Distribution()
+	for i := range pa {
+		if diff := pa[i] - pb[i]; diff > 1e-3 || diff < -1e-3 {
+			t.Fatalf("expected shift invariance after warmup, got %v and %v", pa, pb)
+		}
+	}
+
+	if !(pa[1] > pa[0]) {
+		t.Fatalf("expected rewarded arm to be preferred, got %v", pa)
+	}
+}
+
+func TestGradientInit(t *testing.T) {
+	g, _ := NewGradient(3, 0.1)
+	for i := 0; i < 300; i++ {
+		arm := i%3 + 1
+		pull(g, arm)
+		g.Update(arm, float64(arm-1)/2)
+	}
+
+	before := g.(Previewer).Distribution()
+	restored, _ := NewGradient(3, 0.1)
+	snapshot := g.Snapshot()
+	if err := restored.Init(snapshot); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the best arm is still preferred after a restore
+	after := restored.(Previewer).Distribution()
+	if !(after[2] > after[1] && after[1] > after[0]) {
+		t.Fatalf("expected restored preferences in order of value, got %v", after)
+	}
+
+	if !(before[2] > 1.0/3 && after[2] > 1.0/3) {
+		t.Fatalf("expected arm 3 preferred before %v and after %v", before, after)
+	}
+}
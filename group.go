@@ -0,0 +1,84 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"math/rand"
+)
+
+// Arms returns the number of strategy arms. Variations in the same group
+// share a single arm, so this may be smaller than the number of variations.
+func (e *Experiment) Arms() int {
+	if e.members == nil {
+		return len(e.Variations)
+	}
+
+	return len(e.members)
+}
+
+// Arm returns the 1 indexed strategy arm of the variation with the given
+// ordinal. Rewards for a variation are credited to its arm.
+func (e *Experiment) Arm(ordinal int) int {
+	if e.arms == nil {
+		return ordinal
+	}
+
+	return e.arms[ordinal-1]
+}
+
+// pick returns the ordinal of a variation of 1 indexed strategy arm `arm`,
+// uniformly at random within its group.
+func (e *Experiment) pick(arm int) int {
+	if e.members == nil {
+		return arm
+	}
+
+	members := e.members[arm-1]
+	return members[rand.Intn(len(members))]
+}
+
+// group maps the sorted variations onto strategy arms. Grouped variations
+// share the arm of the group; every other variation has an arm of its own.
+// Arms are ordered by the lowest ordinal mapped onto them.
+func (e *Experiment) group() {
+	e.arms, e.members = nil, nil
+
+	grouped := false
+	for _, v := range e.Variations {
+		grouped = grouped || v.Group != ""
+	}
+
+	if !grouped {
+		return
+	}
+
+	index := make(map[string]int)
+	for _, v := range e.Variations {
+		arm, ok := index[v.Group]
+		if !ok || v.Group == "" {
+			e.members = append(e.members, nil)
+			arm = len(e.members)
+			if v.Group != "" {
+				index[v.Group] = arm
+			}
+		}
+
+		e.arms = append(e.arms, arm)
+		e.members[arm-1] = append(e.members[arm-1], v.Ordinal)
+	}
+}
+
+// countGroups returns the number of strategy arms for variations with the
+// given groups, where the blank group is a group of its own.
+func countGroups(groups []string) int {
+	arms, seen := 0, make(map[string]bool)
+	for _, g := range groups {
+		if g == "" || !seen[g] {
+			arms++
+			seen[g] = true
+		}
+	}
+
+	return arms
+}
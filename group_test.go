@@ -0,0 +1,68 @@
+package bandit
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestExperimentGroups(t *testing.T) {
+	f, err := ioutil.TempFile("", "bandit-groups")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.Remove(f.Name())
+	f.WriteString(`[{
+		"experiment_name": "cdn",
+		"strategy": "epsilonGreedy",
+		"parameters": [0],
+		"preferred": 1,
+		"variations": [
+			{ "ordinal": 1, "url": "http://a.example.com/widget", "group": "widget" },
+			{ "ordinal": 2, "url": "http://localhost/other" },
+			{ "ordinal": 3, "url": "http://b.example.com/widget", "group": "widget" },
+			{ "ordinal": 4, "url": "http://c.example.com/widget", "group": "widget" }
+		]
+	}]`)
+	f.Close()
+
+	e, err := NewExperiment(NewFileOpener(f.Name()), "cdn")
+	if err != nil {
+		t.Fatalf("could not read grouped experiment: %s", err.Error())
+	}
+
+	if got := e.Arms(); got != 2 {
+		t.Fatalf("expected 2 strategy arms, got %d", got)
+	}
+
+	for ordinal, arm := range map[int]int{1: 1, 2: 2, 3: 1, 4: 1} {
+		if got := e.Arm(ordinal); got != arm {
+			t.Fatalf("expected ordinal %d on arm %d, got %d", ordinal, arm, got)
+		}
+	}
+
+	// rewards to any member are pooled on the group's arm
+	e.Strategy.Update(e.Arm(3), 1)
+	e.Strategy.Update(e.Arm(2), 0)
+
+	served := make(map[int]int)
+	for i := 0; i < 3000; i++ {
+		served[e.Select().Ordinal]++
+	}
+
+	if served[2] != 0 {
+		t.Fatalf("expected the worse arm never to be served, got %v", served)
+	}
+
+	for _, ordinal := range []int{1, 3, 4} {
+		if served[ordinal] < 800 {
+			t.Fatalf("expected uniform selection within group, got %v", served)
+		}
+	}
+
+	stats := e.Stats()
+	if stats.Arms[0].Value != 1 || stats.Arms[3].Value != 1 || stats.Arms[0].Group != "widget" {
+		t.Fatalf("expected pooled group stats, got %v", stats.Arms)
+	}
+}
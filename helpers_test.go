@@ -4,12 +4,10 @@ package bandit
 // the underlying strategy after `flush` number of updates.
 func NewSimulatedDelayedStrategy(b Strategy, arms, flush int) Strategy {
 	return &simulatedDelayedStrategy{
-		limit:   flush,
-		updates: flush,
-		delayedStrategy: delayedStrategy{
-			strategy: b,
-			Counters: NewCounters(arms),
-		},
+		limit:           flush,
+		updates:         flush,
+		counters:        newState(arms),
+		delayedStrategy: delayedStrategy{wrapper: wrapper{strategy: b}},
 	}
 }
 
@@ -18,8 +16,9 @@ func NewSimulatedDelayedStrategy(b Strategy, arms, flush int) Strategy {
 // underlying strategy after `limit` number of updates.
 type simulatedDelayedStrategy struct {
 	delayedStrategy
-	limit   int // #updates to wait before flushing Counters to underlying strategy
-	updates int // #updates since last flush
+	counters *Counters
+	limit    int // #updates to wait before flushing Counters to underlying strategy
+	updates  int // #updates since last flush
 }
 
 // Update flushes counters to the underlying strategy every n updates. This is
@@ -29,13 +28,13 @@ func (b *simulatedDelayedStrategy) Update(arm int, reward float64) {
 	defer b.Unlock()
 
 	arm--
-	b.counts[arm]++
-	count := b.counts[arm]
-	b.values[arm] = ((b.values[arm] * float64(count-1)) + reward) / float64(count)
+	b.counters.counts[arm]++
+	count := b.counters.counts[arm]
+	b.counters.values[arm] = ((b.counters.values[arm] * float64(count-1)) + reward) / float64(count)
 
 	b.updates++
 	if b.updates >= b.limit {
-		b.strategy.Init(&b.Counters)
+		b.strategy.Init(b.counters)
 		b.updates = 0
 	}
 }
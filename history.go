@@ -0,0 +1,112 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync"
+	"time"
+)
+
+// historySize is the number of points kept per experiment.
+const historySize = 512
+
+// Point is the state of an experiment at a point in time. Values are the mean
+// rewards and Shares the shares of pulls of each strategy arm.
+type Point struct {
+	Time   time.Time `json:"time"`
+	Values []float64 `json:"values"`
+	Shares []float64 `json:"shares"`
+}
+
+// NewHistory returns a history of at most `size` points, recorded at most
+// every `interval`.
+func NewHistory(size int, interval time.Duration) *History {
+	return &History{
+		size:     size,
+		interval: interval,
+		initial:  interval,
+	}
+}
+
+// History is a downsampled time series of arm estimates over the life of an
+// experiment. Once full, every other point is dropped and the interval becomes
+// the new average spacing of points, so the history always spans the whole
+// experiment.
+type History struct {
+	sync.Mutex
+	size     int
+	interval time.Duration
+	initial  time.Duration // interval before any downsampling
+	points   []Point
+}
+
+// Record adds the state `c` at time `now`, unless the last point is more
+// recent than the current interval.
+func (h *History) Record(c *Counters, now time.Time) {
+	h.Lock()
+	defer h.Unlock()
+
+	if l := len(h.points); l > 0 && now.Sub(h.points[l-1].Time) < h.interval {
+		return
+	}
+
+	if l := len(h.points); l >= h.size {
+		span := h.points[l-1].Time.Sub(h.points[0].Time)
+		h.interval = 2 * span / time.Duration(l)
+
+		kept := h.points[:0]
+		for i := 0; i < len(h.points); i += 2 {
+			kept = append(kept, h.points[i])
+		}
+
+		h.points = kept
+	}
+
+	total := 0
+	for _, count := range c.counts {
+		total += count
+	}
+
+	p := Point{
+		Time:   now,
+		Values: append([]float64{}, c.values...),
+		Shares: make([]float64, c.arms),
+	}
+
+	for i, count := range c.counts {
+		if total > 0 {
+			p.Shares[i] = float64(count) / float64(total)
+		}
+	}
+
+	h.points = append(h.points, p)
+}
+
+// reset drops all points and restores the initial interval.
+func (h *History) reset() {
+	h.Lock()
+	defer h.Unlock()
+
+	h.points, h.interval = nil, h.initial
+}
+
+// Points returns a copy of all recorded points, oldest first.
+func (h *History) Points() []Point {
+	h.Lock()
+	defer h.Unlock()
+
+	return append([]Point{}, h.points...)
+}
+
+// Track records the history of all experiments every `interval`. Never
+// returns.
+func Track(es *Experiments, interval time.Duration) {
+	for now := range time.Tick(interval) {
+		for _, e := range es.All() {
+			if e.History != nil {
+				e.History.Record(e.Strategy.Snapshot(), now)
+			}
+		}
+	}
+}
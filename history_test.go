@@ -0,0 +1,50 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(4, time.Minute)
+	c := NewCounters(2)
+	start := time.Unix(1379257984, 0)
+
+	c.counts[0], c.values[0] = 3, 0.5
+	c.counts[1], c.values[1] = 1, 0.1
+	h.Record(&c, start)
+	h.Record(&c, start.Add(time.Second)) // within the interval
+
+	points := h.Points()
+	if len(points) != 1 {
+		t.Fatalf("expected 1 point, got %d", len(points))
+	}
+
+	if got := points[0].Shares; got[0] != 0.75 || got[1] != 0.25 {
+		t.Fatalf("expected shares [0.75 0.25], got %v", got)
+	}
+
+	c.values[0] = 0.9
+	if got := points[0].Values[0]; got != 0.5 {
+		t.Fatalf("expected recorded values to be copied, got %f", got)
+	}
+
+	for i := 1; i <= 4; i++ {
+		h.Record(&c, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	points = h.Points()
+	if len(points) != 3 {
+		t.Fatalf("expected downsampling to 3 points, got %d", len(points))
+	}
+
+	if !points[0].Time.Equal(start) || !points[2].Time.Equal(start.Add(4*time.Minute)) {
+		t.Fatalf("expected history to span the whole experiment, got %v", points)
+	}
+
+	// a point within the doubled interval is dropped
+	h.Record(&c, start.Add(5*time.Minute))
+	if got := len(h.Points()); got != 3 {
+		t.Fatalf("expected coarser interval after downsampling, got %d points", got)
+	}
+}
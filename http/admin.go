@@ -0,0 +1,319 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/purzelrakete/bandit"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// RestartHandler resets the state of an experiment while keeping its
+// configuration. Intended to be routed on an admin interface only.
+func RestartHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/application")
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		if err := e.Restart(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("restarted experiment %s", e.Name)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// AnnotateHandler adds a note to an experiment, or with `tag` to one of its
+// variations. The note is `text`, at `time` in RFC 3339 or else now:
+//
+//	POST /experiments/shape-20130822/annotations
+//	tag=shape-20130822:2&text=deploy+fixed+rendering+bug
+//
+// Intended to be routed on an admin interface only.
+func AnnotateHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/application")
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		at := time.Now()
+		if value := r.FormValue("time"); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, "invalid time", http.StatusBadRequest)
+				return
+			}
+
+			at = parsed
+		}
+
+		if err := e.Annotate(r.FormValue("tag"), r.FormValue("text"), at); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// AnnotationsHandler serves the notes on an experiment and its variations.
+func AnnotationsHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/json")
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		json, err := json.Marshal(e.Annotations())
+		if err != nil {
+			http.Error(w, "could not build annotations", http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(json)
+	}
+}
+
+// ExperimentsHandler serves the sorted names of all experiments.
+func ExperimentsHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/json")
+
+		names := []string{}
+		for name := range es.All() {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		json, err := json.Marshal(names)
+		if err != nil {
+			http.Error(w, "could not build experiments", http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(json)
+	}
+}
+
+// ConfigHandler serves the configs of all experiments, which can be posted to
+// ApplyHandler to roll an apply back. See Experiments.Config. Intended to be
+// routed on an admin interface only.
+func ConfigHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/json")
+
+		json, err := es.Config()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(json)
+	}
+}
+
+// PauseHandler pauses an experiment, see Experiment.Pause, and answers with
+// true if it was running before. Intended to be routed on an admin interface
+// only.
+func PauseHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/application")
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		changed := e.Pause()
+		log.Printf("paused experiment %s", e.Name)
+		fmt.Fprintf(w, "%t", changed)
+	}
+}
+
+// ResumeHandler resumes a paused experiment, and answers with true if it was
+// paused before. Intended to be routed on an admin interface only.
+func ResumeHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/application")
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		changed := e.Resume()
+		log.Printf("resumed experiment %s", e.Name)
+		fmt.Fprintf(w, "%t", changed)
+	}
+}
+
+// KillHandler turns the process wide kill switch on, see bandit.Kill, with
+// the request body as reason. Events are sent to `n`. Intended to be routed on
+// an admin interface only.
+func KillHandler(n bandit.Notifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/application")
+
+		reason, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "could not read reason", http.StatusBadRequest)
+			return
+		}
+
+		bandit.Kill(string(reason), n)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ReviveHandler turns the kill switch off again. Events are sent to `n`.
+// Intended to be routed on an admin interface only.
+func ReviveHandler(n bandit.Notifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/application")
+
+		bandit.Revive(n)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// EraseHandler removes all state linked to the user `:uid` from `erasers`, see
+// bandit.Erase, and answers with the number of removed records. Intended to
+// be routed on an admin interface only.
+func EraseHandler(erasers ...bandit.Eraser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/application")
+
+		uid := r.URL.Query().Get(":uid")
+		if uid == "" {
+			http.Error(w, "invalid uid", http.StatusBadRequest)
+			return
+		}
+
+		removed := bandit.Erase(uid, erasers...)
+		log.Printf("erased %d records of a user", removed)
+		fmt.Fprintf(w, "%d", removed)
+	}
+}
+
+// ApplyHandler replaces all experiments with the experiments config in the
+// request body, carrying over state with restore policy `policy`, see
+// Experiments.Apply. Encrypted values are decrypted with `d`, which may be
+// nil. With `dry-run=true`, the config is only checked. Either way, the
+// changes are served one per line:
+//
+//	POST /experiments?dry-run=true
+//
+// Intended to be routed on an admin interface only.
+func ApplyHandler(es *bandit.Experiments, d bandit.Decrypter, policy string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/plain")
+
+		_, changes, ok := apply(w, r, es, d, policy)
+		if !ok {
+			return
+		}
+
+		for _, change := range changes {
+			fmt.Fprintln(w, change)
+		}
+	}
+}
+
+// ImportHandler imports the experiments config in the request body, all or
+// nothing, carrying over state with restore policy `policy`, see
+// Experiments.Import. Encrypted values are decrypted with `d`, which may be
+// nil. With `dry-run=true`, the config is only checked. Either way, it serves
+// a json report of the created, updated and retired experiments:
+//
+//	POST /experiments/import?dry-run=true
+//
+// Intended to be routed on an admin interface only.
+func ImportHandler(es *bandit.Experiments, d bandit.Decrypter, policy string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		next, changes, ok := apply(w, r, es, d, policy)
+		if !ok {
+			return
+		}
+
+		json, err := json.Marshal(bandit.NewImportReport(next, changes))
+		if err != nil {
+			http.Error(w, "could not build report", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(json)
+	}
+}
+
+// apply parses the experiments config in the body of `r` and reconciles it
+// with `es`, or with `dry-run=true` only checks it. Returns the parsed
+// experiments and the changes, or answers `w` with an error and false. Parsed
+// experiments which are not applied are closed.
+func apply(w http.ResponseWriter, r *http.Request, es *bandit.Experiments, d bandit.Decrypter, policy string) (*bandit.Experiments, []bandit.Change, bool) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "could not read config", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	next, err := bandit.NewDecryptedExperiments(bandit.NewBytesOpener(body), d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	dryRun := r.URL.Query().Get("dry-run") == "true"
+	var changes []bandit.Change
+	if dryRun {
+		changes, err = es.Reconcile(next, policy)
+	} else {
+		changes, err = es.Apply(next, policy)
+	}
+
+	if dryRun || err != nil {
+		next.Close()
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return nil, nil, false
+	}
+
+	return next, changes, true
+}
@@ -0,0 +1,57 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"encoding/json"
+	"github.com/purzelrakete/bandit"
+	"net/http"
+)
+
+// BucketsResponse is what clients need to assign uids to variations locally,
+// following the bucketing specification. Ranges and Tags are in ordinal order.
+type BucketsResponse struct {
+	Experiment string   `json:"experiment"`
+	Salt       string   `json:"salt"`
+	Buckets    int      `json:"buckets"`
+	Ranges     []int    `json:"ranges"`
+	Tags       []string `json:"tags"`
+	URLs       []string `json:"urls"`
+}
+
+// BucketsHandler serves the current bucket ranges of an experiment. Clients
+// in any language can hash uids themselves and get the same variation as
+// Experiment.SelectSticky.
+func BucketsHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/json")
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		response := BucketsResponse{
+			Experiment: e.Name,
+			Salt:       e.Salted(),
+			Buckets:    bandit.Buckets,
+			Ranges:     bandit.BucketRanges(e.Allocation()),
+		}
+
+		for _, v := range e.Variations {
+			response.Tags = append(response.Tags, v.Tag)
+			response.URLs = append(response.URLs, v.URL)
+		}
+
+		json, err := json.Marshal(response)
+		if err != nil {
+			http.Error(w, "could not build buckets", http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(json)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"sync"
+	"unicode/utf8"
+)
+
+// maxPrefixes bounds the number of cached response prefixes. The cache is
+// dropped when it is full, which sheds prefixes of removed experiments and
+// urls after config changes.
+const maxPrefixes = 1 << 12
+
+// selectionPrefixes caches selection responses up to the tag, which is the
+// only part that differs between requests.
+var selectionPrefixes = &prefixes{m: make(map[prefixKey][]byte)}
+
+// buffers are reused to build selection responses without allocating.
+var buffers = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// prefixKey identifies a prefix. Prefixes only depend on the experiment name
+// and the url, so changes to either are never served from a stale prefix.
+type prefixKey struct {
+	experiment, url string
+}
+
+// prefixes are pre-marshaled APIResponse prefixes.
+type prefixes struct {
+	sync.RWMutex
+	m map[prefixKey][]byte
+}
+
+// get returns the marshaled response up to and including the "tag" key.
+func (p *prefixes) get(experiment, url string) []byte {
+	key := prefixKey{experiment, url}
+
+	p.RLock()
+	prefix, ok := p.m[key]
+	p.RUnlock()
+	if ok {
+		return prefix
+	}
+
+	prefix = append([]byte{}, `{"experiment":`...)
+	prefix = appendJSONString(prefix, experiment)
+	prefix = append(prefix, `,"url":`...)
+	prefix = appendJSONString(prefix, url)
+	prefix = append(prefix, `,"tag":`...)
+
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.m) >= maxPrefixes {
+		p.m = make(map[prefixKey][]byte)
+	}
+
+	p.m[key] = prefix
+	return prefix
+}
+
+// appendSelection appends the json encoding of an APIResponse to `dst`. The
+// result is the same as that of json.Marshal.
+func appendSelection(dst []byte, experiment, url, tag string) []byte {
+	dst = append(dst, selectionPrefixes.get(experiment, url)...)
+	dst = appendJSONString(dst, tag)
+	return append(dst, '}')
+}
+
+const hex = "0123456789abcdef"
+
+// appendJSONString appends `s` as a quoted json string, escaping like
+// encoding/json, including the html characters <, > and &.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			}
+
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, string(utf8.RuneError)...)
+			i += size
+			start = i
+			continue
+		}
+
+		// line and paragraph separators break javascript string literals
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+
+		i += size
+	}
+
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,35 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAppendSelection(t *testing.T) {
+	tests := []string{
+		"",
+		"widget-sauce-flf89",
+		"quote \" and backslash \\",
+		"\x00\x01\x07\b\t\n\v\f\r\x1b\x1f\x7f",
+		"<script>a && b</script>",
+		"line paragraph ",
+		"ünïcödé ✓ 🎲",
+		"invalid \xff utf-8 \xe2\x82",
+		"truncated \xf0\x9f",
+	}
+
+	for _, s := range tests {
+		expected, err := json.Marshal(APIResponse{Experiment: s, URL: s, Tag: s})
+		if err != nil {
+			t.Fatalf("could not marshal %q: %s", s, err.Error())
+		}
+
+		selectionPrefixes = &prefixes{m: make(map[prefixKey][]byte)}
+		if got := appendSelection(nil, s, s, s); string(got) != string(expected) {
+			t.Fatalf("expected %s for %q, got %s", expected, s, got)
+		}
+	}
+}
@@ -8,11 +8,13 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 
 	"github.com/purzelrakete/bandit"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -49,50 +51,114 @@ type APIResponse struct {
 // This two phase approach can be collapsed by using the strategy directly
 // inside a golang api endpoint.
 func SelectionHandler(es *bandit.Experiments, ttl time.Duration) http.HandlerFunc {
+	return NewSelectionHandler(es, HandlerOptions{TTL: ttl})
+}
+
+// HandlerOptions configure the selection and reward handlers. The zero value
+// pins nothing, caches nothing, logs every selection and fails rewards for
+// unknown tags.
+type HandlerOptions struct {
+	TTL     time.Duration           // pinning of timestamped tags
+	Cache   *bandit.SelectionCache  // answers repeated uids, which are not logged again
+	Sampler *bandit.DecisionSampler // logs sampled selections only, weighted
+	Orphans *bandit.Orphans         // resolves rewards for unknown tags
+}
+
+// NewSelectionHandler is a SelectionHandler configured by `o`. Selections are
+// counted under the `dim` parameter, see bandit.SelectOptions.
+func NewSelectionHandler(es *bandit.Experiments, o HandlerOptions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer r.Body.Close()
 		w.Header().Set("Content-Type", "text/json")
 
 		name := r.URL.Query().Get(":name")
-		e, ok := (*es)[name]
+		e, ok := es.Get(name)
 		if ok != true {
 			http.Error(w, "invalid experiment", http.StatusBadRequest)
 			return
 		}
 
+		client := r.URL.Query().Get("client")
+		uid := r.URL.Query().Get("uid")
+		if o.Cache != nil && uid != "" {
+			if variation, tag, ok := o.Cache.Get(e.Name, uid); ok {
+				writeSelection(w, e, e.ForClient(variation, client), tag)
+				return
+			}
+		}
+
 		timestampedTag := r.URL.Query().Get(":tag")
-		variation, newTag, err := e.SelectTimestamped(timestampedTag, ttl)
+		variation, newTag, err := e.SelectWith(timestampedTag, bandit.SelectOptions{
+			TTL:       o.TTL,
+			Locale:    locale(r),
+			Dimension: r.URL.Query().Get("dim"),
+		})
 		if err != nil {
 			http.Error(w, "could not select variation", http.StatusInternalServerError)
 			return
 		}
 
-		json, err := json.Marshal(APIResponse{
-			Experiment: e.Name,
-			URL:        variation.URL,
-			Tag:        newTag,
-		})
+		if o.Cache != nil && uid != "" {
+			o.Cache.Put(e.Name, uid, variation, newTag)
+		}
 
-		if err != nil {
-			http.Error(w, "could not build variation", http.StatusInternalServerError)
-			return
+		if o.Sampler == nil {
+			log.Println(bandit.SelectionLine(*e, variation))
+		} else if weight := o.Sampler.Sample(e, variation); weight > 0 {
+			log.Println(bandit.WeightedSelectionLine(*e, variation, weight))
 		}
 
-		log.Println(bandit.SelectionLine(*e, variation))
-		w.Write(json)
+		writeSelection(w, e, e.ForClient(variation, client), newTag)
+	}
+}
+
+// writeSelection writes the json response of a selection. This is the hot
+// path, so the APIResponse is encoded into a pooled buffer from a cached
+// prefix instead of with json.Marshal.
+func writeSelection(w http.ResponseWriter, e *bandit.Experiment, variation bandit.Variation, tag string) {
+	buf := buffers.Get().(*[]byte)
+	*buf = appendSelection((*buf)[:0], e.Name, variation.URL, tag)
+	w.Write(*buf)
+	buffers.Put(buf)
+}
+
+// locale returns the locale of the caller from the `locale` parameter, or
+// else the first language of the Accept-Language header. Fallbacks serve the
+// variation preferred in this locale.
+func locale(r *http.Request) string {
+	if l := r.URL.Query().Get("locale"); l != "" {
+		return l
 	}
+
+	l := r.Header.Get("Accept-Language")
+	if i := strings.IndexAny(l, ",;"); i >= 0 {
+		l = l[:i]
+	}
+
+	return strings.TrimSpace(l)
 }
 
 // LogRewardHandler logs reward lines. It's better to log rewards directly
 // through your main logging pipeline, but the handler is here in case you
 // can't do that. This handler is currently updates the supplied strategys
-// directly, which makes it unsuitable for real use.
+// directly, which makes it unsuitable for real use. Rewards for unknown tags
+// fail.
 func LogRewardHandler(es *bandit.Experiments) http.HandlerFunc {
+	return NewRewardHandler(es, HandlerOptions{})
+}
+
+// NewRewardHandler is a LogRewardHandler configured by `o`. Rewards change
+// state, so they are posted as `tag` and `reward` form values. Secondary
+// metrics for the experiment's guardrails and rules are passed as
+// `metric.<name>=<value>` values, e.g. `metric.error_rate=1`. Rewards for
+// unknown tags are handed to the orphans, and answered with 202 Accepted and
+// not applied if they remain unresolved.
+func NewRewardHandler(es *bandit.Experiments, o HandlerOptions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer r.Body.Close()
 		w.Header().Set("Content-Type", "text/application")
 
-		timestampedTag := r.URL.Query().Get("tag")
+		timestampedTag := r.FormValue("tag")
 		if timestampedTag == "" {
 			http.Error(w, "cannot reward without tag", http.StatusBadRequest)
 			return
@@ -104,7 +170,7 @@ func LogRewardHandler(es *bandit.Experiments) http.HandlerFunc {
 			return
 		}
 
-		reward := r.URL.Query().Get("reward")
+		reward := r.FormValue("reward")
 		if reward == "" {
 			http.Error(w, "reward missing", http.StatusBadRequest)
 			return
@@ -116,16 +182,101 @@ func LogRewardHandler(es *bandit.Experiments) http.HandlerFunc {
 			return
 		}
 
+		metrics, err := metrics(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		e, variation, err := es.GetVariation(tag)
+		if err != nil && o.Orphans != nil {
+			resolved, v, ok := o.Orphans.Resolve(es, tag, fReward)
+			if !ok {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+
+			e, variation, err = resolved, v, nil
+		}
+
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		b := (*es)[e.Name].Strategy
-		b.Update(variation.Ordinal, fReward)
+		running, ok := es.Get(e.Name)
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusInternalServerError)
+			return
+		}
+
+		running.Update(variation.Ordinal, fReward)
+		for metric, value := range metrics {
+			running.Observe(variation.Ordinal, metric, value)
+		}
 
 		log.Println(bandit.RewardLine(e, variation, fReward))
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+// metrics returns the secondary metrics passed as `metric.<name>` form values
+// of `r`, by name. The form is parsed by FormValue.
+func metrics(r *http.Request) (map[string]float64, error) {
+	metrics := make(map[string]float64)
+	for key, values := range r.Form {
+		if !strings.HasPrefix(key, "metric.") || len(values) == 0 {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return metrics, fmt.Errorf("%s is not a float", key)
+		}
+
+		metrics[strings.TrimPrefix(key, "metric.")] = value
+	}
+
+	return metrics, nil
+}
+
+// ReplicationHandler streams state deltas of the primary's experiments to read
+// only replicas every `interval`. Replicas connect with bandit.Replicate.
+func ReplicationHandler(es *bandit.Experiments, interval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/plain")
+
+		// deltas stream indefinitely, past the server's write timeout
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("could not clear write deadline: %s", err.Error())
+		}
+
+		if err := bandit.StreamDeltas(es, w, interval); err != nil {
+			log.Printf("replica disconnected: %s", err.Error())
+		}
+	}
+}
+
+// StatsHandler serves the current state of an experiment as json, including
+// selections per arm broken down by the `dim` selection parameter.
+func StatsHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/json")
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		json, err := json.Marshal(e.Stats())
+		if err != nil {
+			http.Error(w, "could not build stats", http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(json)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/purzelrakete/bandit"
+)
+
+func TestLogRewardHandler(t *testing.T) {
+	es, err := bandit.NewExperiments(bandit.NewFileOpener("../experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	h := LogRewardHandler(es)
+	form := url.Values{"tag": {"shape-20130822:2:1377172800"}, "reward": {"1"}}
+	r := httptest.NewRequest("POST", "/feedback", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	e, _ := es.Get("shape-20130822")
+	if got := e.Stats().Arms[1]; got.Value != 1 {
+		t.Fatalf("expected posted reward to be applied, got %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/feedback", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected reward without tag to fail, got %d", w.Code)
+	}
+}
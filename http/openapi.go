@@ -0,0 +1,250 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// object is a json object of the OpenAPI document.
+type object map[string]interface{}
+
+// OpenAPI returns the OpenAPI 3 document describing the public selection,
+// stats, bucket and preview endpoints as routed by bandit-api. Keep it in sync
+// with the handlers in this package.
+func OpenAPI() map[string]interface{} {
+	name := object{
+		"name":        "name",
+		"in":          "path",
+		"required":    true,
+		"description": "experiment name",
+		"schema":      object{"type": "string"},
+	}
+
+	query := func(param, description, typ string) object {
+		return object{
+			"name":        param,
+			"in":          "query",
+			"description": description,
+			"schema":      object{"type": typ},
+		}
+	}
+
+	badRequest := object{"description": "unknown experiment"}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "bandit",
+			"version": "0.0.0-alpha.1",
+		},
+		"paths": object{
+			"/experiments/{name}": object{
+				"get": object{
+					"operationId": "select",
+					"summary":     "Select a variation of the experiment.",
+					"parameters": []object{
+						name,
+						query("uid", "caller id, used to cache selections", "string"),
+						query("dim", "dimension to count the selection under, such as a country code", "string"),
+						query("client", "client type to return the url for, such as ios", "string"),
+						query("locale", "locale of the caller, such as de-AT. defaults to the Accept-Language header", "string"),
+					},
+					"responses": object{
+						"200": object{
+							"description": "selected variation",
+							"content": object{
+								"text/json": object{"schema": object{"$ref": "#/components/schemas/Selection"}},
+							},
+						},
+						"400": badRequest,
+					},
+				},
+			},
+			"/stats/{name}": object{
+				"get": object{
+					"operationId": "stats",
+					"summary":     "Current state of the experiment.",
+					"parameters":  []object{name},
+					"responses": object{
+						"200": object{
+							"description": "experiment stats",
+							"content": object{
+								"text/json": object{"schema": object{"$ref": "#/components/schemas/Stats"}},
+							},
+						},
+						"400": badRequest,
+					},
+				},
+			},
+			"/buckets/{name}": object{
+				"get": object{
+					"operationId": "buckets",
+					"summary":     "Bucket ranges to assign uids to variations locally.",
+					"parameters":  []object{name},
+					"responses": object{
+						"200": object{
+							"description": "current bucket ranges",
+							"content": object{
+								"text/json": object{"schema": object{"$ref": "#/components/schemas/Buckets"}},
+							},
+						},
+						"400": badRequest,
+					},
+				},
+			},
+			"/preview/{name}": object{
+				"get": object{
+					"operationId": "preview",
+					"summary":     "Selection probabilities of each variation, without selecting.",
+					"parameters":  []object{name},
+					"responses": object{
+						"200": object{
+							"description": "would-be allocation",
+							"content": object{
+								"text/json": object{"schema": object{"$ref": "#/components/schemas/Preview"}},
+							},
+						},
+						"400": badRequest,
+					},
+				},
+			},
+		},
+		"components": object{
+			"schemas": object{
+				"Selection": object{
+					"type": "object",
+					"properties": object{
+						"experiment": object{"type": "string"},
+						"url":        object{"type": "string"},
+						"tag":        object{"type": "string", "description": "timestamped tag to reward the selection with"},
+					},
+				},
+				"Stats": object{
+					"type": "object",
+					"properties": object{
+						"experiment": object{"type": "string"},
+						"strategy":   object{"type": "string"},
+						"policy":     object{"type": "string", "description": "fingerprint of the serving policy"},
+						"metrics": object{
+							"type":                 "object",
+							"description":          "by arm stat",
+							"additionalProperties": object{"$ref": "#/components/schemas/Metric"},
+						},
+						"arms": object{
+							"type":  "array",
+							"items": object{"$ref": "#/components/schemas/ArmStats"},
+						},
+						"history": object{
+							"type":  "array",
+							"items": object{"$ref": "#/components/schemas/Point"},
+						},
+						"latency": object{
+							"type":                 "object",
+							"description":          "by select and update",
+							"additionalProperties": object{"$ref": "#/components/schemas/LatencyStats"},
+						},
+					},
+				},
+				"Buckets": object{
+					"type": "object",
+					"properties": object{
+						"experiment": object{"type": "string"},
+						"salt":       object{"type": "string"},
+						"buckets":    object{"type": "integer"},
+						"ranges":     object{"type": "array", "items": object{"type": "integer"}, "description": "exclusive upper bucket per variation"},
+						"tags":       object{"type": "array", "items": object{"type": "string"}},
+						"urls":       object{"type": "array", "items": object{"type": "string"}},
+					},
+				},
+				"Preview": object{
+					"type": "object",
+					"properties": object{
+						"experiment":   object{"type": "string"},
+						"distribution": object{"type": "array", "items": object{"type": "number"}},
+						"tags":         object{"type": "array", "items": object{"type": "string"}},
+						"best":         object{"type": "string", "description": "tag of the variation with the highest estimated value"},
+					},
+				},
+				"LatencyStats": object{
+					"type": "object",
+					"properties": object{
+						"count":   object{"type": "integer"},
+						"mean_ns": object{"type": "integer"},
+						"p50_ns":  object{"type": "integer"},
+						"p99_ns":  object{"type": "integer"},
+						"buckets": object{
+							"type": "array",
+							"items": object{
+								"type": "object",
+								"properties": object{
+									"le_ns": object{"type": "integer"},
+									"count": object{"type": "integer"},
+								},
+							},
+						},
+					},
+				},
+				"Point": object{
+					"type": "object",
+					"properties": object{
+						"time":   object{"type": "string", "format": "date-time"},
+						"values": object{"type": "array", "items": object{"type": "number"}},
+						"shares": object{"type": "array", "items": object{"type": "number"}},
+					},
+				},
+				"Metric": object{
+					"type": "object",
+					"properties": object{
+						"unit":       object{"type": "string"},
+						"direction":  object{"type": "string", "enum": []string{"higher", "lower", "none"}},
+						"definition": object{"type": "string"},
+					},
+				},
+				"ArmStats": object{
+					"type": "object",
+					"properties": object{
+						"ordinal":  object{"type": "integer"},
+						"tag":      object{"type": "string"},
+						"group":    object{"type": "string"},
+						"priority": object{"type": "number"},
+						"cost":     object{"type": "number"},
+						"count":    object{"type": "integer"},
+						"value":    object{"type": "number"},
+						"breakdown": object{
+							"type":                 "object",
+							"additionalProperties": object{"type": "integer"},
+						},
+						"samples": object{
+							"type":        "array",
+							"items":       object{"type": "number"},
+							"description": "random sample of raw rewards",
+						},
+						"last_reward": object{"type": "string", "format": "date-time"},
+						"staleness_hours": object{
+							"type":        "number",
+							"description": "hours waiting for a reward since selected",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// OpenAPIHandler serves the OpenAPI document, for generating clients.
+func OpenAPIHandler() http.HandlerFunc {
+	spec, err := json.Marshal(OpenAPI())
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		if err != nil {
+			http.Error(w, "could not build openapi document", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"github.com/purzelrakete/bandit"
+	"html/template"
+	"log"
+	"net/http"
+)
+
+// page is the data of an experiment page.
+type page struct {
+	Experiment *bandit.Experiment
+	Stats      bandit.Stats
+	Allocation []float64
+	Disabled   []bool
+	Winner     string
+	Lifecycle  []bandit.Lifecycle
+	Notes      []bandit.Annotation
+}
+
+// pageTemplate renders an experiment page. It has no external assets, so it
+// can be saved and attached to documents as is.
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Experiment.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Experiment.Name}}</h1>
+
+<h2>Configuration</h2>
+<table>
+<tr><th>strategy</th><td>{{.Stats.Strategy}}</td></tr>
+<tr><th>preferred ordinal</th><td>{{.Experiment.PreferredOrdinal}}</td></tr>
+<tr><th>salt</th><td>{{.Experiment.Salted}}</td></tr>
+<tr><th>retention</th><td>{{if .Experiment.Retention}}{{.Experiment.Retention}}{{else}}forever{{end}}</td></tr>
+<tr><th>min selections per hour</th><td>{{.Experiment.MinExposure}}</td></tr>
+<tr><th>max reward gap</th><td>{{if .Experiment.MaxRewardGap}}{{.Experiment.MaxRewardGap}}{{else}}none{{end}}</td></tr>
+<tr><th>cost budget</th><td>{{.Experiment.CostBudget}}</td></tr>
+<tr><th>selections</th><td>{{.Experiment.Selections}}</td></tr>
+<tr><th>archived</th><td>{{if .Winner}}to {{.Winner}}{{else}}no{{end}}</td></tr>
+</table>
+
+<h2>Variations</h2>
+<table>
+<tr><th>ordinal</th><th>tag</th><th>url</th><th>group</th><th title="{{(index .Stats.Metrics "priority").Definition}}">priority</th><th title="{{(index .Stats.Metrics "cost").Definition}}">cost</th><th title="{{(index .Stats.Metrics "count").Definition}}">count</th><th title="{{(index .Stats.Metrics "value").Definition}}">value ({{(index .Stats.Metrics "value").Unit}})</th><th>allocation</th><th title="{{(index .Stats.Metrics "staleness_hours").Definition}}">hours unrewarded</th><th>disabled</th></tr>
+{{range $i, $arm := .Stats.Arms}}<tr><td>{{$arm.Ordinal}}</td><td>{{$arm.Tag}}</td><td>{{(index $.Experiment.Variations $i).URL}}</td><td>{{$arm.Group}}</td><td>{{$arm.Priority}}</td><td>{{$arm.Cost}}</td><td>{{$arm.Count}}</td><td>{{printf "%.4f" $arm.Value}}</td><td>{{printf "%.4f" (index $.Allocation $i)}}</td><td>{{printf "%.1f" $arm.Staleness}}</td><td>{{if index $.Disabled $i}}yes{{end}}</td></tr>
+{{end}}</table>
+
+<h2>History</h2>
+<table>
+<tr><th>time</th><th>values</th><th>shares</th></tr>
+{{range .Stats.History}}<tr><td>{{.Time.UTC.Format "2006-01-02T15:04:05Z"}}</td><td>{{range .Values}}{{printf "%.4f" .}} {{end}}</td><td>{{range .Shares}}{{printf "%.4f" .}} {{end}}</td></tr>
+{{else}}<tr><td colspan="3">no history recorded</td></tr>
+{{end}}</table>
+
+<h2>Lifecycle</h2>
+<table>
+<tr><th>time</th><th>change</th><th>ordinal</th></tr>
+{{range .Lifecycle}}<tr><td>{{.Time.UTC.Format "2006-01-02T15:04:05Z"}}</td><td>{{.Kind}}</td><td>{{if .Ordinal}}{{.Ordinal}}{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Annotations</h2>
+<table>
+<tr><th>time</th><th>tag</th><th>note</th></tr>
+{{range .Notes}}<tr><td>{{.Time.UTC.Format "2006-01-02T15:04:05Z"}}</td><td>{{.Tag}}</td><td>{{.Text}}</td></tr>
+{{else}}<tr><td colspan="3">no annotations</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// PageHandler serves a human readable page per experiment with its
+// configuration, current stats, history, lifecycle and annotations. Intended
+// to be routed on an admin interface and linked from launch and incident
+// documents.
+func PageHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		p := page{
+			Experiment: e,
+			Stats:      e.Stats(),
+			Allocation: e.Allocation(),
+			Lifecycle:  e.Lifecycle(),
+			Notes:      e.Annotations(),
+		}
+
+		for _, v := range e.Variations {
+			p.Disabled = append(p.Disabled, e.Disabled(v.Ordinal))
+		}
+
+		if v, ok := e.Archived(); ok {
+			p.Winner = v.Tag
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := pageTemplate.Execute(w, p); err != nil {
+			log.Printf("could not render %s: %s", e.Name, err.Error())
+		}
+	}
+}
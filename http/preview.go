@@ -0,0 +1,59 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"encoding/json"
+	"github.com/purzelrakete/bandit"
+	"net/http"
+)
+
+// PreviewResponse is the would-be allocation of an experiment. Distribution
+// and Tags are in ordinal order.
+type PreviewResponse struct {
+	Experiment   string    `json:"experiment"`
+	Distribution []float64 `json:"distribution"`
+	Tags         []string  `json:"tags"`
+	Best         string    `json:"best"`
+}
+
+// PreviewHandler serves the current selection probabilities of each
+// variation without selecting, for UIs and debugging tools. No counters
+// change and nothing is logged.
+func PreviewHandler(es *bandit.Experiments) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		w.Header().Set("Content-Type", "text/json")
+
+		e, ok := es.Get(r.URL.Query().Get(":name"))
+		if !ok {
+			http.Error(w, "invalid experiment", http.StatusBadRequest)
+			return
+		}
+
+		preview, err := e.Preview()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := PreviewResponse{
+			Experiment:   e.Name,
+			Distribution: preview.Distribution,
+			Best:         preview.Best.Tag,
+		}
+
+		for _, v := range e.Variations {
+			response.Tags = append(response.Tags, v.Tag)
+		}
+
+		json, err := json.Marshal(response)
+		if err != nil {
+			http.Error(w, "could not build preview", http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(json)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// unixPrefix marks addresses of unix domain sockets, as in unix:/run/bandit.sock
+const unixPrefix = "unix:"
+
+// Listen listens on a TCP address like :8080 or [::1]:8080, or on a unix
+// domain socket given as unix:/path/to/socket. A stale socket file left by a
+// previous run is removed, see removeStale.
+func Listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, unixPrefix) {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, unixPrefix)
+	if path == "" {
+		return nil, fmt.Errorf("missing socket path in '%s'", addr)
+	}
+
+	if err := removeStale(path); err != nil {
+		return nil, err
+	}
+
+	return net.Listen("unix", path)
+}
+
+// removeStale removes the socket at `path` if nothing listens on it anymore.
+// Files which are not sockets and sockets of running servers are kept, and
+// fail instead.
+func removeStale(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not check socket: %s", err.Error())
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by a running server", path)
+	}
+
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("could not check socket: %s", err.Error())
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove stale socket: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ServerOptions harden a server. Zero values disable the respective limit.
+type ServerOptions struct {
+	ReadTimeout    time.Duration // reading the whole request, including the body
+	WriteTimeout   time.Duration // writing the response. /replication streams without it.
+	IdleTimeout    time.Duration // keep alive connections waiting for the next request
+	MaxHeaderBytes int           // request headers. 0 is the net/http default.
+	MaxBodyBytes   int64         // request bodies
+}
+
+// NewServer returns a server for `h` with the limits in `o`, which recovers
+// from panics in handlers.
+func NewServer(h http.Handler, o ServerOptions) *http.Server {
+	if o.MaxBodyBytes > 0 {
+		h = LimitBody(h, o.MaxBodyBytes)
+	}
+
+	return &http.Server{
+		Handler:        Recover(h),
+		ReadTimeout:    o.ReadTimeout,
+		WriteTimeout:   o.WriteTimeout,
+		IdleTimeout:    o.IdleTimeout,
+		MaxHeaderBytes: o.MaxHeaderBytes,
+	}
+}
+
+// Recover answers requests whose handler panics with a 500 and logs the panic
+// with its stack, instead of dropping the connection.
+func Recover(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				log.Printf("panic serving %s: %v\n%s", r.URL.Path, err, debug.Stack())
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+		}()
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// LimitBody fails reads of request bodies larger than `max` bytes.
+func LimitBody(h http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		h.ServeHTTP(w, r)
+	})
+}
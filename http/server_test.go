@@ -0,0 +1,161 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/purzelrakete/bandit"
+)
+
+func TestListen(t *testing.T) {
+	l, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen on tcp: %s", err.Error())
+	}
+
+	l.Close()
+
+	if _, err := Listen("unix:"); err == nil {
+		t.Fatalf("expected missing socket path to fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "bandit.sock")
+	l, err = Listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("could not listen on socket: %s", err.Error())
+	}
+
+	defer l.Close()
+
+	if l.Addr().Network() != "unix" {
+		t.Fatalf("expected a unix listener, got %s", l.Addr().Network())
+	}
+}
+
+func TestRemoveStale(t *testing.T) {
+	dir := t.TempDir()
+	if err := removeStale(filepath.Join(dir, "missing.sock")); err != nil {
+		t.Fatalf("expected missing socket to pass: %s", err.Error())
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, []byte("keep"), 0644); err != nil {
+		t.Fatalf("could not write file: %s", err.Error())
+	}
+
+	if err := removeStale(file); err == nil {
+		t.Fatalf("expected a file which is not a socket to fail")
+	}
+
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("expected file to be kept: %s", err.Error())
+	}
+
+	// a running server keeps its socket
+	running := filepath.Join(dir, "running.sock")
+	l, err := net.Listen("unix", running)
+	if err != nil {
+		t.Fatalf("could not listen: %s", err.Error())
+	}
+
+	defer l.Close()
+
+	if err := removeStale(running); err == nil {
+		t.Fatalf("expected socket in use to fail")
+	}
+
+	// a socket nobody listens on anymore is removed
+	stale := filepath.Join(dir, "stale.sock")
+	sl, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatalf("could not listen: %s", err.Error())
+	}
+
+	sl.(*net.UnixListener).SetUnlinkOnClose(false)
+	sl.Close()
+
+	if err := removeStale(stale); err != nil {
+		t.Fatalf("could not remove stale socket: %s", err.Error())
+	}
+
+	if _, err := os.Lstat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale socket to be removed")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	h := LimitBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	}), 4)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("1234")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected body within limit to pass, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("12345")))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected body over limit to fail, got %d", w.Code)
+	}
+}
+
+func TestReplicationWriteTimeout(t *testing.T) {
+	es, err := bandit.NewExperiments(bandit.NewFileOpener("../experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	// replication streams past the write timeout of the server
+	timeout := 50 * time.Millisecond
+	h := ReplicationHandler(es, 10*time.Millisecond)
+	ts := httptest.NewUnstartedServer(h)
+	ts.Config = NewServer(h, ServerOptions{WriteTimeout: timeout})
+	ts.Start()
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("could not connect: %s", err.Error())
+	}
+
+	defer resp.Body.Close()
+
+	r, deadline := bufio.NewReader(resp.Body), time.Now().Add(4*timeout)
+	for time.Now().Before(deadline) {
+		if _, err := r.ReadString('\n'); err != nil {
+			if err == io.EOF {
+				t.Fatalf("expected stream to outlast the write timeout")
+			}
+
+			t.Fatalf("could not read deltas: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package bandit
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// chaoticExperiments returns experiments with and without groups, whose
+// strategies explore uniformly.
+func chaoticExperiments(t *testing.T) []*Experiment {
+	var es []*Experiment
+	for _, groups := range [][]string{{"", "", "", ""}, {"a", "a", "", "b"}} {
+		e := &Experiment{Name: "chaos", PreferredOrdinal: 1}
+		for i, group := range groups {
+			e.Variations = append(e.Variations, Variation{Ordinal: i + 1, Tag: "chaos:" + string(rune('1'+i)), Group: group})
+		}
+
+		e.group()
+		strategy, err := NewEpsilonGreedy(e.Arms(), 1)
+		if err != nil {
+			t.Fatalf("while creating strategy: %s", err.Error())
+		}
+
+		e.Strategy = strategy
+		es = append(es, e)
+	}
+
+	return es
+}
+
+func TestChaoticDisable(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, e := range chaoticExperiments(t) {
+		served := 0
+		for round := 0; round < 200; round++ {
+			ordinal := r.Intn(len(e.Variations)) + 1
+			if r.Intn(2) == 0 {
+				e.Disable(ordinal)
+			} else {
+				e.Enable(ordinal)
+			}
+
+			enabled := 0
+			for _, v := range e.Variations {
+				if !e.Disabled(v.Ordinal) {
+					enabled++
+				}
+			}
+
+			for i := 0; i < 10; i++ {
+				got := e.Select().Ordinal
+				if got < 1 || got > len(e.Variations) {
+					t.Fatalf("selected retired ordinal %d", got)
+				}
+
+				if enabled > 0 && e.Disabled(got) {
+					t.Fatalf("selected disabled ordinal %d with %d enabled", got, enabled)
+				}
+
+				if enabled > 0 {
+					served++
+				}
+			}
+
+			if enabled == 0 {
+				continue
+			}
+
+			// every pull was served
+			pulls, c := 0, e.Strategy.Snapshot()
+			for _, count := range c.counts {
+				pulls += count
+			}
+
+			if pulls != served {
+				t.Fatalf("expected %d pulls, got %d", served, pulls)
+			}
+
+			// disabled variations get nothing, the rest renormalizes
+			preview, err := e.Preview()
+			if err != nil {
+				t.Fatalf("while previewing: %s", err.Error())
+			}
+
+			total := 0.0
+			for i, p := range preview.Distribution {
+				if e.Disabled(i+1) && p != 0 {
+					t.Fatalf("expected disabled ordinal %d to get 0, got %f", i+1, p)
+				}
+
+				total += p
+			}
+
+			if math.Abs(total-1) > 1e-9 {
+				t.Fatalf("expected probabilities to sum to 1, got %f", total)
+			}
+
+			for i, share := range e.Allocation() {
+				if e.Disabled(i+1) && share != 0 {
+					t.Fatalf("expected disabled ordinal %d to get no traffic, got %f", i+1, share)
+				}
+			}
+		}
+	}
+}
+
+func TestDisabledPreferredFallback(t *testing.T) {
+	e := chaoticExperiments(t)[0]
+	greedy, err := NewEpsilonGreedy(e.Arms(), 0)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	e.Strategy = greedy
+	e.Strategy.Init(&Counters{arms: 4, counts: []int{1, 1, 1, 1}, values: []float64{0, 0, 0, 1}, rand: NewCounters(4).rand})
+
+	// the strategy insists on disabled ordinal 4 and the preferred 1 is off.
+	e.Disable(1)
+	e.Disable(4)
+	if got := e.Select().Ordinal; got != 2 {
+		t.Fatalf("expected first enabled ordinal 2, got %d", got)
+	}
+
+	if got := e.Strategy.Snapshot().counts[3]; got != 1 {
+		t.Fatalf("expected rejected draws not to count, got %d pulls", got)
+	}
+}
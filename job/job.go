@@ -3,8 +3,10 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"github.com/purzelrakete/bandit"
 	"io"
 	"strings"
+	"time"
 )
 
 // mapper returns a hadoop streaming mapper function. Emits (arm, reward)
@@ -60,6 +62,19 @@ func collector(s *statistics, r io.Reader, w io.Writer) func() {
 	}
 }
 
+// purge copies the log from `r` to `w` without the lines of experiments
+// which are past their retention in the `experiments` config at time `now`.
+// Returns the number of dropped lines.
+func purge(experiments string, r io.Reader, w io.Writer, now time.Time) (int, error) {
+	es, err := bandit.NewExperiments(bandit.NewOpener(experiments))
+	if err != nil {
+		return 0, fmt.Errorf("could not read experiments: %s", err.Error())
+	}
+
+	defer es.Close()
+	return bandit.PurgeLog(r, w, es, now)
+}
+
 // tsvSnapshot is the tsv formatted snapshot file.
 func tsvSnapshot(counts []int, rewards []float64) string {
 	var values []string
@@ -12,26 +12,32 @@
 //
 // experiment-name:variation-ordinal:pinning-time
 //
+// The purge kind copies the log from stdin to stdout without the lines of
+// experiments which are past their "retention-days" in the -experiments
+// config. Run it regularly to enforce retention.
 package main
 
 import (
 	"flag"
+	"fmt"
+	"github.com/purzelrakete/bandit"
 	"log"
 	"os"
+	"time"
 )
 
 var (
 	jobExperimentName = flag.String("experiment-name", "default", "name of experiment")
-	jobKind           = flag.String("kind", "", "kind ∈ {map,reduce,poll}")
+	jobKind           = flag.String("kind", "", "kind ∈ {map,reduce,poll,purge}")
 	jobLogfile        = flag.String("log-file", "bandit-log.txt", "log file to read")
 	jobLogPoll        = flag.Duration("log-poll", 1e13, "produce snapshots with this fq")
+	jobLeaderLock     = flag.String("leader-lock", "", "lock file shared by poll jobs. only the leader polls")
+	jobCompression    = flag.String("snapshot-compression", "", "compression of snapshot files ∈ {,gzip}")
+	jobExperiments    = flag.String("experiments", "experiments.json", "experiments config with retentions, for purge")
 )
 
-func init() {
-	flag.Parse()
-}
-
 func main() {
+	flag.Parse()
 	stats := newStatistics(*jobExperimentName)
 
 	switch *jobKind {
@@ -42,11 +48,29 @@ func main() {
 	case "collect":
 		collector(stats, os.Stdin, os.Stdout)()
 	case "poll":
-		if err := simple(stats, *jobLogfile, *jobLogPoll); err != nil {
+		var elector bandit.Elector
+		if *jobLeaderLock != "" {
+			host, err := os.Hostname()
+			if err != nil {
+				log.Fatalf("could not get hostname: %s", err.Error())
+			}
+
+			id := fmt.Sprintf("%s-%d", host, os.Getpid())
+			elector = bandit.NewFileElector(*jobLeaderLock, id, 3**jobLogPoll)
+		}
+
+		if err := simple(stats, *jobLogfile, *jobLogPoll, elector, *jobCompression); err != nil {
 			log.Fatalf("could not start polling job: %s", err.Error())
 		}
+	case "purge":
+		dropped, err := purge(*jobExperiments, os.Stdin, os.Stdout, time.Now())
+		if err != nil {
+			log.Fatalf("could not purge log: %s", err.Error())
+		}
+
+		log.Printf("dropped %d expired lines", dropped)
 	case "":
-		log.Fatalf("please provide a job kind ∈ {map,reduce,poll}")
+		log.Fatalf("please provide a job kind ∈ {map,reduce,poll,purge}")
 	default:
 		log.Fatalf("unkown job kind: %s", *jobKind)
 	}
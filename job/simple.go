@@ -4,25 +4,43 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/purzelrakete/bandit"
+	"io/ioutil"
 	"log"
 	"os"
 	"strings"
 	"time"
 )
 
-// simple produces a snapshot every `poll` duration. FIXME: O(N) memory
-func simple(s *statistics, logFile string, poll time.Duration) error {
+// simple produces a snapshot every `poll` duration. FIXME: O(N) memory. With
+// an elector, only the leader produces snapshots. Compressed logs are read
+// transparently; snapshots are compressed with `compression`.
+func simple(s *statistics, logFile string, poll time.Duration, e bandit.Elector, compression string) error {
 	snapshotFile := s.experimentName + ".tsv"
-	opener := bandit.NewOpener(logFile)
+	opener := bandit.NewDecompressingOpener(bandit.NewOpener(logFile))
 	file, err := opener.Open()
 	if err != nil {
 		return fmt.Errorf("could not open logs: %s", err.Error())
 	}
 
 	defer file.Close()
+	if _, err := bandit.NewCompressedWriter(ioutil.Discard, compression); err != nil {
+		return fmt.Errorf("could not compress snapshots: %s", err.Error())
+	}
+
 	go func() {
 		t := time.NewTicker(poll)
 		for _ = range t.C {
+			if e != nil {
+				leader, err := e.IsLeader()
+				if err != nil {
+					log.Printf("error electing leader: %s", err.Error())
+				}
+
+				if !leader {
+					continue
+				}
+			}
+
 			file, err := opener.Open()
 			if err != nil {
 				log.Printf("error opening log: %s", err.Error())
@@ -46,7 +64,14 @@ func simple(s *statistics, logFile string, poll time.Duration) error {
 			}
 
 			defer snapshot.Close()
-			snapshot.Write([]byte(reduced))
+			w, err := bandit.NewCompressedWriter(snapshot, compression)
+			if err != nil {
+				log.Printf("error compressing snapshot: %s", err.Error())
+				continue
+			}
+
+			w.Write([]byte(reduced))
+			w.Close()
 		}
 	}()
 
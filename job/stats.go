@@ -88,26 +88,31 @@ func (c *countSelects) getPrefix() string {
 // mapLine to count selects from a log file
 func (c *countSelects) mapLine(line string) (string, string, bool) {
 	selection := banditSelection + "\t" + c.experimentName
-	selectionLen := 3
+	selectionLen := 3 // optionally followed by the policy fingerprint and weight
 	if strings.Index(line, selection) >= 0 {
 		fields := strings.Fields(line)
-		if len(fields) != selectionLen {
+		if len(fields) < selectionLen || len(fields) > selectionLen+2 {
 			log.Fatalf("line does not have %d fields: '%s'", selectionLen, line)
 		}
 
+		weight := "1"
+		if len(fields) == selectionLen+2 {
+			weight = fields[selectionLen+1]
+		}
+
 		splittedString := strings.Split(fields[2], ":")
 		variation, err := strconv.ParseInt(splittedString[1], 10, 0)
 		if err != nil {
 			log.Fatalf("invalid variation in line '%s': %s", line, err.Error())
 		}
 
-		return fmt.Sprintf("%s_%d", c.prefix, variation), "1", true
+		return fmt.Sprintf("%s_%d", c.prefix, variation), weight, true
 	}
 
 	return "", "", false
 }
 
-// reduceLine to sum the selects per arm
+// reduceLine to sum the selects per arm, weighted for sampled logs
 func (c *countSelects) reduceLine(line string) {
 	if strings.Index(line, c.prefix) >= 0 {
 		preparedString := strings.Replace(line, "_", "\t", 1)
@@ -116,7 +121,15 @@ func (c *countSelects) reduceLine(line string) {
 		if err != nil {
 			log.Fatalf("non-integral arm on line '%s': %s", line, err.Error())
 		}
-		c.selects[variation-1]++
+
+		weight := 1.0
+		if len(fields) > 2 {
+			if weight, err = strconv.ParseFloat(fields[2], 64); err != nil {
+				log.Fatalf("non-float weight on line '%s': %s", line, err.Error())
+			}
+		}
+
+		c.selects[variation-1] += weight
 	}
 }
 
@@ -2,8 +2,11 @@ package main
 
 import (
 	"bytes"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestMapper(t *testing.T) {
@@ -102,6 +105,26 @@ func TestMapperReducer(t *testing.T) {
 	}
 }
 
+func TestMapperReducerWeighted(t *testing.T) {
+	log := []string{
+		"1379069548	BanditSelection	shape-20130822:2:1	f6a1e0b2-3",
+		"1379069749	BanditSelection	shape-20130822:2:2	f6a1e0b2-3	100",
+	}
+
+	stats := newStatistics("shape-20130822")
+
+	r, w := strings.NewReader(strings.Join(log, "\n")), new(bytes.Buffer)
+	mapper(stats, r, w)()
+
+	r, w = strings.NewReader(w.String()), new(bytes.Buffer)
+	reducer(stats, r, w)()
+
+	expected := "BanditSelection	2	101.000000"
+	if got := strings.TrimRight(w.String(), "\n "); got != expected {
+		t.Fatalf("expected '%s' but got '%s'", expected, got)
+	}
+}
+
 func TestCollect(t *testing.T) {
 	log := []string{
 		"BanditReward	2	1.000000",
@@ -146,3 +169,40 @@ func TestSnapshotCounter(t *testing.T) {
 		t.Fatalf("expected '%s' but got '%s'", expected, got)
 	}
 }
+
+func TestPurge(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "experiments.json")
+	if err := ioutil.WriteFile(config, []byte(`[{"experiment_name": "shape-20130822",
+		"strategy": "epsilonGreedy", "parameters": [0.1], "preferred": 1,
+		"retention-days": 1, "variations": [
+		{"ordinal": 1, "url": "http://localhost/circle"},
+		{"ordinal": 2, "url": "http://localhost/square"}]}]`), 0644); err != nil {
+		t.Fatalf("could not write config: %s", err.Error())
+	}
+
+	log := []string{
+		"1379257984 BanditSelection shape-20130822:1",
+		"1379257987 BanditReward shape-20130822:1:1379257984 0.000000",
+		"1379257988 BanditSelection plants-20121111:1",
+		"1379344400 BanditSelection shape-20130822:2",
+	}
+
+	r, w := strings.NewReader(strings.Join(log, "\n")), new(bytes.Buffer)
+	dropped, err := purge(config, r, w, time.Unix(1379344500, 0))
+	if err != nil {
+		t.Fatalf("could not purge: %s", err.Error())
+	}
+
+	if dropped != 2 {
+		t.Fatalf("expected 2 dropped lines, got %d", dropped)
+	}
+
+	expected := strings.Join(log[2:], "\n") + "\n"
+	if got := w.String(); got != expected {
+		t.Fatalf("expected '%s' but got '%s'", expected, got)
+	}
+
+	if _, err := purge(filepath.Join(t.TempDir(), "missing.json"), r, w, time.Now()); err == nil {
+		t.Fatalf("expected missing config to fail")
+	}
+}
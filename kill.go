@@ -0,0 +1,58 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// EventKilled is emitted when the kill switch stops all experiments.
+	EventKilled = "killed"
+
+	// EventRevived is emitted when experiments serve again after a kill.
+	EventRevived = "revived"
+)
+
+// killed is the process wide kill switch. 1 if on.
+var killed int32
+
+// Kill stops all experiments in the process at once, e.g. during a site wide
+// incident. Until Revive, every experiment serves its preferred variation as
+// if it was paused, or its winner if archived, and strategies are not used.
+// Rewards of earlier selections are still applied. Killing again is a NOP. An
+// EventKilled with `reason` is emitted to `n`, if not nil, and true returned
+// if the switch was off before.
+func Kill(reason string, n Notifier) bool {
+	if !atomic.CompareAndSwapInt32(&killed, 0, 1) {
+		return false
+	}
+
+	if n != nil {
+		n.Notify(Event{Time: time.Now(), Kind: EventKilled, Experiment: "*", Message: reason})
+	}
+
+	return true
+}
+
+// Revive lets experiments select with their strategies again after Kill.
+// Experiments paused or archived before stay so. An EventRevived is emitted
+// to `n`, if not nil, and true returned if the switch was on before.
+func Revive(n Notifier) bool {
+	if !atomic.CompareAndSwapInt32(&killed, 1, 0) {
+		return false
+	}
+
+	if n != nil {
+		n.Notify(Event{Time: time.Now(), Kind: EventRevived, Experiment: "*"})
+	}
+
+	return true
+}
+
+// Killed returns true while the kill switch is on.
+func Killed() bool {
+	return atomic.LoadInt32(&killed) == 1
+}
@@ -0,0 +1,52 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"testing"
+)
+
+func TestKill(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	var events []Event
+	n := NotifierFunc(func(e Event) { events = append(events, e) })
+	if !Kill("incident", n) || Kill("incident", n) {
+		t.Fatalf("expected only the first kill to switch")
+	}
+
+	defer Revive(nil)
+
+	for _, e := range es.All() {
+		before := e.Strategy.Snapshot()
+		for i := 0; i < 100; i++ {
+			if got := e.Select().Ordinal; got != e.PreferredOrdinal {
+				t.Fatalf("expected killed %s to serve %d, got %d", e.Name, e.PreferredOrdinal, got)
+			}
+		}
+
+		after := e.Strategy.Snapshot()
+		for arm := range after.counts {
+			if after.counts[arm] != before.counts[arm] {
+				t.Fatalf("expected strategy of %s not to be used while killed", e.Name)
+			}
+		}
+
+		preview, err := e.Preview()
+		if err != nil || preview.Distribution[e.PreferredOrdinal-1] != 1 {
+			t.Fatalf("expected preview of the preferred variation, got %v, %v", preview, err)
+		}
+	}
+
+	if !Revive(n) || Revive(n) || Killed() {
+		t.Fatalf("expected only the first revive to switch")
+	}
+
+	if len(events) != 2 || events[0].Kind != EventKilled || events[0].Message != "incident" || events[1].Kind != EventRevived {
+		t.Fatalf("expected a single kill and revive event, got %v", events)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+)
+
+// klucbPrecision is the default precision of the KL-UCB bisection.
+const klucbPrecision = 1e-6
+
+// NewKLUCB constructs a KL-UCB strategy for Bernoulli rewards, see Garivier &
+// Cappé, 2011, http://arxiv.org/abs/1102.2490. Its upper confidence bounds
+// are tighter than those of UCB1. Each bound is found by bisection to within
+// `precision`.
+func NewKLUCB(arms int, precision float64) (Strategy, error) {
+	if !(precision > 0 && precision < 1) {
+		return &klUCB{}, fmt.Errorf("precision not in (0, 1)")
+	}
+
+	return &klUCB{
+		Counters:  NewCounters(arms),
+		precision: precision,
+	}, nil
+}
+
+// klUCB selects the arm with the highest q such that n · KL(p, q) ≤ log(t),
+// with p the mean reward of an arm pulled n times in t pulls overall. Means
+// are clamped to [0, 1]; priorities and costs apply to the bound.
+type klUCB struct {
+	Counters
+	precision float64
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (k *klUCB) SelectArm() int {
+	k.Lock()
+	defer k.Unlock()
+
+	var arm int
+	if k.ties == TiesOrdinal {
+		_, best := bmath.Max(k.bounds())
+		arm = best[0]
+	} else {
+		arm = bmath.ArgMax(k.bounds(), k.rand)
+	}
+
+	k.counts[arm]++
+	return arm + 1
+}
+
+// bounds returns the upper confidence bound of each arm. Arms which were
+// never pulled are best.
+func (k *klUCB) bounds() []float64 {
+	total := 0
+	for _, count := range k.counts {
+		total += count
+	}
+
+	budget := math.Log(float64(total))
+	bounds := make([]float64, k.arms)
+	for i, count := range k.counts {
+		if count == 0 {
+			bounds[i] = math.Inf(1)
+			continue
+		}
+
+		p := math.Min(math.Max(k.values[i], 0), 1)
+		bounds[i] = k.estimate(i, klBound(p, budget/float64(count), k.precision))
+	}
+
+	return bounds
+}
+
+// klBound returns the largest q in [p, 1] with KL(p, q) ≤ `budget`, to within
+// `precision`.
+func klBound(p, budget, precision float64) float64 {
+	low, high := p, 1.0
+	for high-low > precision {
+		q := (low + high) / 2
+		if bernoulliKL(p, q) > budget {
+			high = q
+		} else {
+			low = q
+		}
+	}
+
+	return low
+}
+
+// bernoulliKL returns the Kullback-Leibler divergence of Bernoulli(q) from
+// Bernoulli(p).
+func bernoulliKL(p, q float64) float64 {
+	const ε = 1e-15
+	p = math.Min(math.Max(p, ε), 1-ε)
+	q = math.Min(math.Max(q, ε), 1-ε)
+
+	return p*math.Log(p/q) + (1-p)*math.Log((1-p)/(1-q))
+}
+
+// Distribution returns the probability of selecting each arm.
+func (k *klUCB) Distribution() []float64 {
+	k.Lock()
+	defer k.Unlock()
+
+	_, best := bmath.Max(k.bounds())
+	if k.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	distribution := make([]float64, k.arms)
+	for _, arm := range best {
+		distribution[arm] = 1 / float64(len(best))
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (k *klUCB) String() string {
+	return fmt.Sprintf("KLUCB(precision=%g)", k.precision)
+}
@@ -0,0 +1,54 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"math"
+	"testing"
+)
+
+func TestKLUCB(t *testing.T) {
+	sims := 500
+	trials := 1000
+	bestArmIndex := 4 // Bernoulli(bestArm)
+	arms := []sim.Arm{
+		bmath.BernRand(0.1),
+		bmath.BernRand(0.3),
+		bmath.BernRand(0.2),
+		bmath.BernRand(0.8),
+	}
+
+	strategy, err := New(len(arms), "klUcb", []float64{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.9 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+}
+
+func TestKLBound(t *testing.T) {
+	// KL(0.5, 0.75) = log(4/3) / 2
+	if got := klBound(0.5, math.Log(4.0/3)/2, 1e-9); math.Abs(got-0.75) > 1e-6 {
+		t.Fatalf("expected bound 0.75, got %f", got)
+	}
+
+	got := klBound(0.3, 0.1, 1e-9)
+	if got <= 0.3 || math.Abs(bernoulliKL(0.3, got)-0.1) > 1e-6 {
+		t.Fatalf("expected KL(0.3, %f) to be 0.1, got %f", got, bernoulliKL(0.3, got))
+	}
+
+	if _, err := NewKLUCB(2, 0); err == nil {
+		t.Fatalf("expected zero precision to fail")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latencyBounds are the upper bounds of histogram buckets, from 250ns to 1s.
+// The last bucket is unbounded.
+var latencyBounds = func() []time.Duration {
+	var bounds []time.Duration
+	for bound := 250 * time.Nanosecond; bound <= time.Second; bound *= 2 {
+		bounds = append(bounds, bound)
+	}
+
+	return bounds
+}()
+
+// NewHistogram returns an empty latency histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{
+		counts: make([]int64, len(latencyBounds)+1),
+	}
+}
+
+// Histogram counts latencies in exponential buckets. It is lock free, so it
+// can be used on the selection path.
+type Histogram struct {
+	counts []int64 // per bucket. the last bucket is unbounded.
+	count  int64
+	sum    int64 // nanoseconds
+}
+
+// Observe records latency `d`.
+func (h *Histogram) Observe(d time.Duration) {
+	bucket := len(latencyBounds)
+	for i, bound := range latencyBounds {
+		if d <= bound {
+			bucket = i
+			break
+		}
+	}
+
+	atomic.AddInt64(&h.counts[bucket], 1)
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(d))
+}
+
+// since observes the latency since `start`. Used with defer.
+func (h *Histogram) since(start time.Time) {
+	h.Observe(time.Since(start))
+}
+
+// LatencyBucket is the number of latencies up to LE nanoseconds. The last
+// bucket has no upper bound and LE 0.
+type LatencyBucket struct {
+	LE    int64 `json:"le_ns"`
+	Count int64 `json:"count"`
+}
+
+// LatencyStats summarizes a histogram. Quantiles are the upper bounds of the
+// buckets they fall into, or 0 if they fall into the unbounded bucket.
+type LatencyStats struct {
+	Count   int64           `json:"count"`
+	Mean    int64           `json:"mean_ns"`
+	P50     int64           `json:"p50_ns"`
+	P99     int64           `json:"p99_ns"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// Stats summarizes the histogram.
+func (h *Histogram) Stats() LatencyStats {
+	s := LatencyStats{Count: atomic.LoadInt64(&h.count)}
+	if s.Count > 0 {
+		s.Mean = atomic.LoadInt64(&h.sum) / s.Count
+	}
+
+	total := int64(0)
+	for i := range h.counts {
+		b := LatencyBucket{Count: atomic.LoadInt64(&h.counts[i])}
+		if i < len(latencyBounds) {
+			b.LE = int64(latencyBounds[i])
+		}
+
+		s.Buckets = append(s.Buckets, b)
+
+		total += b.Count
+		if s.P50 == 0 && total*2 >= s.Count && s.Count > 0 {
+			s.P50 = b.LE
+		}
+
+		if s.P99 == 0 && total*100 >= s.Count*99 && s.Count > 0 {
+			s.P99 = b.LE
+		}
+	}
+
+	return s
+}
+
+// NewLatencies returns empty latencies.
+func NewLatencies() *Latencies {
+	return &Latencies{
+		Select: NewHistogram(),
+		Update: NewHistogram(),
+	}
+}
+
+// Latencies are the selection and update latencies of an experiment,
+// including the time spent in its strategy.
+type Latencies struct {
+	Select *Histogram
+	Update *Histogram
+}
@@ -0,0 +1,44 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram()
+	for i := 0; i < 98; i++ {
+		h.Observe(100 * time.Nanosecond)
+	}
+
+	h.Observe(3 * time.Microsecond)
+	h.Observe(time.Minute)
+
+	s := h.Stats()
+	if s.Count != 100 || len(s.Buckets) != len(latencyBounds)+1 {
+		t.Fatalf("unexpected stats %v", s)
+	}
+
+	if s.P50 != 250 || s.P99 != 4000 {
+		t.Fatalf("expected p50 250ns and p99 4µs, got %d %d", s.P50, s.P99)
+	}
+
+	if got := s.Buckets[len(s.Buckets)-1]; got.Count != 1 || got.LE != 0 {
+		t.Fatalf("expected a minute in the unbounded bucket, got %v", got)
+	}
+}
+
+func TestExperimentLatencies(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e.Select()
+	e.Update(1, 1)
+
+	latency := e.Stats().Latency
+	if latency["select"].Count != 1 || latency["update"].Count != 1 {
+		t.Fatalf("expected a select and an update, got %v", latency)
+	}
+}
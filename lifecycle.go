@@ -0,0 +1,77 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync"
+	"time"
+)
+
+// Kinds of lifecycle changes.
+const (
+	LifecycleStarted   = "started"
+	LifecycleDisabled  = "disabled"
+	LifecycleEnabled   = "enabled"
+	LifecycleArchived  = "archived"
+	LifecycleRestarted = "restarted"
+	LifecyclePaused    = "paused"
+	LifecycleResumed   = "resumed"
+)
+
+// maxLifecycle bounds the number of lifecycle changes kept per experiment.
+const maxLifecycle = 1000
+
+// Lifecycle is a change in the life of an experiment. Ordinal is the affected
+// variation, or 0 for changes to the whole experiment.
+type Lifecycle struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Ordinal int       `json:"ordinal,omitempty"`
+}
+
+// Lifecycle returns the changes of the experiment since it was loaded, oldest
+// first.
+func (e *Experiment) Lifecycle() []Lifecycle {
+	return e.changes.list()
+}
+
+// changes is a bounded log of lifecycle changes, safe for concurrent use.
+type changes struct {
+	sync.Mutex
+	log []Lifecycle
+}
+
+// newChanges returns a log starting with LifecycleStarted.
+func newChanges() *changes {
+	c := &changes{}
+	c.record(LifecycleStarted, 0)
+	return c
+}
+
+// record appends a change. NOP on the nil log.
+func (c *changes) record(kind string, ordinal int) {
+	if c == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.log = append(c.log, Lifecycle{Time: time.Now(), Kind: kind, Ordinal: ordinal})
+	if len(c.log) > maxLifecycle {
+		c.log = c.log[len(c.log)-maxLifecycle:]
+	}
+}
+
+// list copies the log. It is empty for the nil log.
+func (c *changes) list() []Lifecycle {
+	if c == nil {
+		return []Lifecycle{}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	return append([]Lifecycle{}, c.log...)
+}
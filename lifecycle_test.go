@@ -0,0 +1,54 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestLifecycle(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	e.Disable(2)
+	e.Enable(2)
+	e.Archive(1)
+	if err := e.Restart(); err != nil {
+		t.Fatalf("while restarting: %s", err.Error())
+	}
+
+	expected := []Lifecycle{
+		{Kind: LifecycleStarted},
+		{Kind: LifecycleDisabled, Ordinal: 2},
+		{Kind: LifecycleEnabled, Ordinal: 2},
+		{Kind: LifecycleArchived, Ordinal: 1},
+		{Kind: LifecycleRestarted},
+	}
+
+	got := e.Lifecycle()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d changes, got %v", len(expected), got)
+	}
+
+	for i, change := range expected {
+		if got[i].Kind != change.Kind || got[i].Ordinal != change.Ordinal {
+			t.Fatalf("expected change %d to be %v, got %v", i, change, got[i])
+		}
+	}
+
+	if got := (&Experiment{}).Lifecycle(); len(got) != 0 {
+		t.Fatalf("expected no changes without log, got %v", got)
+	}
+}
+
+func TestLifecycleBounded(t *testing.T) {
+	c := newChanges()
+	for i := 0; i < 2*maxLifecycle; i++ {
+		c.record(LifecycleDisabled, 1)
+	}
+
+	if got := len(c.list()); got != maxLifecycle {
+		t.Fatalf("expected %d changes, got %d", maxLifecycle, got)
+	}
+}
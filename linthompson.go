@@ -0,0 +1,55 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"math"
+)
+
+// NewLinearThompson constructs a contextual Thompson sampling strategy, see
+// Agrawal & Goyal, 2013, http://arxiv.org/abs/1209.3352. The weights of each
+// arm have a Gaussian posterior N(A⁻¹ b, v² A⁻¹) under Bayesian linear
+// regression on the `dimensions` features. Selection draws weights from each
+// posterior and picks the arm with the highest predicted reward. v scales
+// exploration.
+func NewLinearThompson(arms, dimensions int, v float64) (ContextualStrategy, error) {
+	if arms < 1 || dimensions < 1 {
+		return &linearThompson{}, fmt.Errorf("need at least 1 arm and 1 dimension")
+	}
+
+	if !(v >= 0) {
+		return &linearThompson{}, fmt.Errorf("v not in [0, ∞)")
+	}
+
+	l := &linearThompson{v: v}
+	l.init(arms, dimensions)
+	return l, nil
+}
+
+// linearThompson draws the predicted reward θᵀx directly. With weights θ ~
+// N(μ, v² A⁻¹), it is distributed N(μᵀx, v² xᵀ A⁻¹ x), so no weight vector
+// has to be drawn.
+type linearThompson struct {
+	linearModels
+	v float64
+}
+
+// SelectArm returns the 1 indexed arm with the highest sampled reward for
+// `features`.
+func (l *linearThompson) SelectArm(features []float64) int {
+	l.check(features)
+
+	l.Lock()
+	defer l.Unlock()
+
+	return l.best(features, func(mean, variance float64) float64 {
+		return mean + l.v*math.Sqrt(variance)*l.rand.NormFloat64()
+	})
+}
+
+// String returns information on this strategy.
+func (l *linearThompson) String() string {
+	return fmt.Sprintf("LinearThompson(v=%.2f)", l.v)
+}
@@ -0,0 +1,173 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ContextualStrategy selects arms given the features of the request, for
+// example of the user, so that different users can be served different arms.
+// Features must always have the same dimensions.
+type ContextualStrategy interface {
+	SelectArm(features []float64) int
+	Update(arm int, features []float64, reward float64)
+}
+
+// NewLinUCB constructs a LinUCB strategy with disjoint linear models, see Li
+// et al., 2010, http://arxiv.org/abs/1003.0146. The expected reward of each
+// arm is linear in the `dimensions` features, and α scales the width of the
+// upper confidence bound.
+func NewLinUCB(arms, dimensions int, α float64) (ContextualStrategy, error) {
+	if arms < 1 || dimensions < 1 {
+		return &linUCB{}, fmt.Errorf("need at least 1 arm and 1 dimension")
+	}
+
+	if !(α >= 0) {
+		return &linUCB{}, fmt.Errorf("α not in [0, ∞)")
+	}
+
+	l := &linUCB{alpha: α}
+	l.init(arms, dimensions)
+	return l, nil
+}
+
+// linUCB selects by upper confidence bounds of linear models.
+type linUCB struct {
+	linearModels
+	alpha float64
+}
+
+// linearModels are ridge regressions of the reward on the features, one per
+// arm. They keep the inverse of A = I + Σ x xᵀ and b = Σ r x per arm. The
+// inverse is updated in place with Sherman-Morrison, so each update and
+// selection takes O(arms · dimensions²).
+type linearModels struct {
+	sync.Mutex
+	dimensions int
+	inverses   [][]float64 // A⁻¹ per arm, row major
+	responses  [][]float64 // b per arm
+	rand       *rand.Rand
+}
+
+// init sets up models without observations, in place since they hold a lock.
+func (m *linearModels) init(arms, dimensions int) {
+	m.dimensions = dimensions
+	m.inverses = make([][]float64, arms)
+	m.responses = make([][]float64, arms)
+	m.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for arm := range m.inverses {
+		m.inverses[arm] = identity(dimensions)
+		m.responses[arm] = make([]float64, dimensions)
+	}
+}
+
+// SelectArm returns the 1 indexed arm with the highest upper confidence bound
+// for `features`. Ties are broken uniformly at random.
+func (l *linUCB) SelectArm(features []float64) int {
+	l.check(features)
+
+	l.Lock()
+	defer l.Unlock()
+
+	return l.best(features, func(mean, variance float64) float64 {
+		return mean + l.alpha*math.Sqrt(variance)
+	})
+}
+
+// best returns the 1 indexed arm with the highest score for features `x`.
+// `score` is given the predicted reward θᵀx, with θ = A⁻¹ b, and xᵀ A⁻¹ x,
+// which is proportional to its variance. Callers hold the lock.
+func (m *linearModels) best(x []float64, score func(mean, variance float64) float64) int {
+	max, arm, ties := math.Inf(-1), 0, 0
+	ax := make([]float64, m.dimensions)
+	for i := range m.inverses {
+		multiply(m.inverses[i], x, ax)
+
+		// A⁻¹ is symmetric, so θᵀx = bᵀ A⁻¹ x
+		value := score(dot(m.responses[i], ax), math.Max(dot(x, ax), 0))
+		if value > max {
+			max, arm, ties = value, i, 1
+		} else if value == max {
+			ties++
+			if m.rand.Intn(ties) == 0 {
+				arm = i
+			}
+		}
+	}
+
+	return arm + 1
+}
+
+// Update adds the observation of `reward` for 1 indexed `arm` given
+// `features`.
+func (m *linearModels) Update(arm int, features []float64, reward float64) {
+	m.check(features)
+
+	m.Lock()
+	defer m.Unlock()
+
+	inverse, b := m.inverses[arm-1], m.responses[arm-1]
+	ax := make([]float64, m.dimensions)
+	multiply(inverse, features, ax)
+
+	// A⁻¹ - (A⁻¹ x)(A⁻¹ x)ᵀ / (1 + xᵀ A⁻¹ x)
+	denominator := 1 + dot(features, ax)
+	for i := 0; i < m.dimensions; i++ {
+		for j := 0; j < m.dimensions; j++ {
+			inverse[i*m.dimensions+j] -= ax[i] * ax[j] / denominator
+		}
+	}
+
+	for i, x := range features {
+		b[i] += reward * x
+	}
+}
+
+// check panics on features of the wrong dimensions, like SelectArm on
+// impossible arms.
+func (m *linearModels) check(features []float64) {
+	if len(features) != m.dimensions {
+		panic(fmt.Sprintf("expected %d features, got %d", m.dimensions, len(features)))
+	}
+}
+
+// String returns information on this strategy.
+func (l *linUCB) String() string {
+	return fmt.Sprintf("LinUCB(alpha=%.2f)", l.alpha)
+}
+
+// identity returns the row major n x n identity matrix.
+func identity(n int) []float64 {
+	m := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		m[i*n+i] = 1
+	}
+
+	return m
+}
+
+// multiply stores the product of the row major square matrix `m` and `x` in
+// `out`.
+func multiply(m, x, out []float64) {
+	n := len(x)
+	for i := 0; i < n; i++ {
+		out[i] = dot(m[i*n:(i+1)*n], x)
+	}
+}
+
+// dot returns the dot product of `a` and `b`.
+func dot(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+
+	return sum
+}
@@ -0,0 +1,80 @@
+package bandit
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLinUCB(t *testing.T) {
+	s, err := NewLinUCB(2, 2, 0.5)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	testSegments(t, s)
+}
+
+func TestLinearThompson(t *testing.T) {
+	s, err := NewLinearThompson(2, 2, 0.1)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	testSegments(t, s)
+}
+
+// testSegments checks that `s` learns the best arm per user segment.
+func testSegments(t *testing.T, s ContextualStrategy) {
+	// arm 1 converts users of segment a, arm 2 users of segment b
+	segments := [][]float64{{1, 0}, {0, 1}}
+	rates := [][]float64{{0.8, 0.2}, {0.1, 0.7}}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		segment := r.Intn(2)
+		arm := s.SelectArm(segments[segment])
+
+		reward := 0.0
+		if r.Float64() < rates[segment][arm-1] {
+			reward = 1
+		}
+
+		s.Update(arm, segments[segment], reward)
+	}
+
+	for segment, expected := range []int{1, 2} {
+		if got := s.SelectArm(segments[segment]); got != expected {
+			t.Fatalf("expected %s to select arm %d for segment %d, got %d", s, expected, segment, got)
+		}
+	}
+}
+
+func TestLinUCBInverse(t *testing.T) {
+	s, err := NewLinUCB(1, 2, 1)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	// A = I + x xᵀ with x = (1, 2), so A⁻¹ = I - x xᵀ / 6
+	s.Update(1, []float64{1, 2}, 1)
+	expected := []float64{1 - 1.0/6, -2.0 / 6, -2.0 / 6, 1 - 4.0/6}
+	for i, got := range s.(*linUCB).inverses[0] {
+		if math.Abs(got-expected[i]) > 1e-9 {
+			t.Fatalf("expected inverse %v, got %v", expected, s.(*linUCB).inverses[0])
+		}
+	}
+}
+
+func TestLinUCBInvalid(t *testing.T) {
+	if _, err := NewLinUCB(0, 2, 1); err == nil {
+		t.Fatalf("expected no arms to fail")
+	}
+
+	if _, err := NewLinUCB(2, 2, -1); err == nil {
+		t.Fatalf("expected negative α to fail")
+	}
+
+	if _, err := NewLinearThompson(2, 2, -1); err == nil {
+		t.Fatalf("expected negative v to fail")
+	}
+}
@@ -4,8 +4,14 @@
 package bandit
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,17 +21,118 @@ const (
 )
 
 // SelectionLine captures all selected arms. This log can be used in conjunction
-// with reward logs to fully rebuild strategys.
+// with reward logs to fully rebuild strategys. The last field is the
+// fingerprint of the policy which made the selection.
 func SelectionLine(experiment Experiment, selected Variation) string {
+	return WeightedSelectionLine(experiment, selected, 1)
+}
+
+// WeightedSelectionLine is a SelectionLine standing for `weight` selections,
+// for logs sampled with a DecisionSampler. Weights above 1 follow the
+// fingerprint.
+func WeightedSelectionLine(experiment Experiment, selected Variation, weight int) string {
 	record := []string{
 		fmt.Sprintf("%d", time.Now().Unix()),
 		banditSelection,
 		selected.Tag,
+		Fingerprint(experiment.Strategy),
+	}
+
+	if weight > 1 {
+		record = append(record, fmt.Sprintf("%d", weight))
 	}
 
 	return strings.Join(record, " ")
 }
 
+// Fingerprint identifies the exact policy version of a strategy as
+// <parameters hash>-<state revision>. The hash is the fnv-1a hash of the
+// strategy, its parameters, priorities and costs; the revision increments with
+// every change to its state.
+func Fingerprint(s Strategy) string {
+	h := fnv.New32a()
+	h.Write([]byte(strategyName(s)))
+	revision := policy(h, s)
+
+	// formatted without fmt, since every selection is fingerprinted
+	sum, fingerprint := h.Sum32(), make([]byte, 0, 32)
+	for shift := 28; shift >= 0; shift -= 4 {
+		fingerprint = append(fingerprint, "0123456789abcdef"[sum>>uint(shift)&0xf])
+	}
+
+	fingerprint = strconv.AppendUint(append(fingerprint, '-'), revision, 10)
+	return string(fingerprint)
+}
+
+// nameCache holds the String of a strategy, which is fixed once the strategy
+// is constructed, so that it is not formatted for every fingerprint.
+type nameCache struct {
+	once sync.Once
+	name string
+}
+
+// namer is implemented by strategies which cache their String.
+type namer interface {
+	names() *nameCache
+}
+
+// strategyName returns the String of `s`, from its cache if it keeps one.
+func strategyName(s Strategy) string {
+	format := func() string {
+		if stringer, ok := s.(fmt.Stringer); ok {
+			return stringer.String()
+		}
+
+		return fmt.Sprint(s)
+	}
+
+	n, ok := s.(namer)
+	if !ok {
+		return format()
+	}
+
+	c := n.names()
+	c.once.Do(func() { c.name = format() })
+	return c.name
+}
+
+// policer is implemented by strategies which can hash their priorities and
+// costs and return their revision without taking a snapshot.
+type policer interface {
+	policy(h hash.Hash32) uint64
+}
+
+// policy writes the priorities and costs of `s` to hash `h` and returns the
+// revision of `s`.
+func policy(h hash.Hash32, s Strategy) uint64 {
+	if p, ok := s.(policer); ok {
+		return p.policy(h)
+	}
+
+	c := s.Snapshot()
+	hashFloats(h, c.priorities)
+	if c.costs != nil {
+		h.Write([]byte(" "))
+		hashFloats(h, c.costs)
+	}
+
+	return c.revision
+}
+
+// hashFloats writes the little endian bits of `xs` to hash `h`.
+func hashFloats(h hash.Hash32, xs []float64) {
+	if len(xs) == 0 {
+		return
+	}
+
+	buf := make([]byte, 8*len(xs))
+	for i, x := range xs {
+		binary.LittleEndian.PutUint64(buf[8*i:], math.Float64bits(x))
+	}
+
+	h.Write(buf)
+}
+
 // RewardLine captures all selected arms. This log can be used in conjunction
 // with reward logs to fully rebuild strategys.
 func RewardLine(experiment Experiment, selected Variation, reward float64) string {
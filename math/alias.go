@@ -0,0 +1,80 @@
+package math
+
+import (
+	"math/rand"
+)
+
+// Alias samples from a categorical distribution in O(1) with Walker's alias
+// method. Building the table takes O(n).
+type Alias struct {
+	probabilities []float64 // of keeping the drawn column
+	aliases       []int     // column taken otherwise
+	small, large  []int     // columns below and above 1, kept for rebuilds
+}
+
+// NewAlias builds an alias table for non negative `weights`, which need not be
+// normalized. At least one weight must be positive.
+func NewAlias(weights []float64) *Alias {
+	a := &Alias{}
+	a.Rebuild(weights)
+	return a
+}
+
+// Rebuild replaces the table with one for `weights`, see NewAlias. It does not
+// allocate unless the number of weights grew.
+func (a *Alias) Rebuild(weights []float64) {
+	n := len(weights)
+	if cap(a.probabilities) < n {
+		a.probabilities, a.aliases = make([]float64, n), make([]int, n)
+		a.small, a.large = make([]int, 0, n), make([]int, 0, n)
+	}
+
+	a.probabilities, a.aliases = a.probabilities[:n], a.aliases[:n]
+
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+
+	// scale weights so that the mean is 1, and split into small and large
+	small, large := a.small[:0], a.large[:0]
+	for i, w := range weights {
+		a.probabilities[i] = w * float64(n) / total
+		if a.probabilities[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	// fill each small column up to 1 with a large one
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+
+		a.aliases[s] = l
+		a.probabilities[l] -= 1 - a.probabilities[s]
+		if a.probabilities[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+
+	// remaining columns are full, up to rounding errors
+	for _, columns := range [][]int{small, large} {
+		for _, i := range columns {
+			a.probabilities[i] = 1
+			a.aliases[i] = i
+		}
+	}
+}
+
+// Sample returns a 0 indexed category using `r`.
+func (a *Alias) Sample(r *rand.Rand) int {
+	i := r.Intn(len(a.probabilities))
+	if r.Float64() < a.probabilities[i] {
+		return i
+	}
+
+	return a.aliases[i]
+}
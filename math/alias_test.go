@@ -0,0 +1,50 @@
+package math
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	weights := []float64{1, 0, 3, 6}
+	a := NewAlias(weights)
+	r := rand.New(rand.NewSource(123))
+
+	draws := 100000
+	counts := make([]int, len(weights))
+	for i := 0; i < draws; i++ {
+		counts[a.Sample(r)]++
+	}
+
+	for i, w := range weights {
+		if got := float64(counts[i]) / float64(draws); math.Abs(got-w/10) > 0.01 {
+			t.Fatalf("expected category %d with probability %f, got %f", i, w/10, got)
+		}
+	}
+}
+
+func TestAliasSingle(t *testing.T) {
+	a := NewAlias([]float64{0, 0, 2})
+	r := rand.New(rand.NewSource(123))
+	for i := 0; i < 100; i++ {
+		if got := a.Sample(r); got != 2 {
+			t.Fatalf("expected only category 2, got %d", got)
+		}
+	}
+}
+
+func TestAliasRebuild(t *testing.T) {
+	a := NewAlias([]float64{1, 0, 3, 6})
+	weights := []float64{0, 5, 0, 0}
+	if allocs := testing.AllocsPerRun(100, func() { a.Rebuild(weights) }); allocs != 0 {
+		t.Fatalf("expected rebuild not to allocate, got %.0f allocations", allocs)
+	}
+
+	r := rand.New(rand.NewSource(123))
+	for i := 0; i < 100; i++ {
+		if got := a.Sample(r); got != 1 {
+			t.Fatalf("expected only category 1 after rebuild, got %d", got)
+		}
+	}
+}
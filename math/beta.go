@@ -0,0 +1,86 @@
+package math
+
+import (
+	"math"
+)
+
+// BetaCDF returns the probability P(x' ≤ x) for x' ~ Beta(α, β), which is the
+// regularized incomplete beta function. It follows Numerical Recipes, 6.4.
+func BetaCDF(x, α, β float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+
+	if x >= 1 {
+		return 1
+	}
+
+	lab, _ := math.Lgamma(α + β)
+	la, _ := math.Lgamma(α)
+	lb, _ := math.Lgamma(β)
+	front := math.Exp(lab - la - lb + α*math.Log(x) + β*math.Log(1-x))
+
+	// the continued fraction converges fast below the mean
+	if x < (α+1)/(α+β+2) {
+		return front * betaFraction(x, α, β) / α
+	}
+
+	return 1 - front*betaFraction(1-x, β, α)/β
+}
+
+// BetaQuantile returns x such that BetaCDF(x, α, β) = p, by bisection to
+// within 1e-9.
+func BetaQuantile(p, α, β float64) float64 {
+	low, high := 0.0, 1.0
+	for high-low > 1e-9 {
+		x := (low + high) / 2
+		if BetaCDF(x, α, β) < p {
+			low = x
+		} else {
+			high = x
+		}
+	}
+
+	return (low + high) / 2
+}
+
+// betaFraction evaluates the continued fraction of the incomplete beta
+// function with the modified Lentz method.
+func betaFraction(x, α, β float64) float64 {
+	const (
+		tiny       = 1e-300
+		ε          = 1e-14
+		iterations = 300
+	)
+
+	nonzero := func(v float64) float64 {
+		if math.Abs(v) < tiny {
+			return tiny
+		}
+
+		return v
+	}
+
+	c, d := 1.0, 1/nonzero(1-(α+β)*x/(α+1))
+	h := d
+	for m := 1.0; m <= iterations; m++ {
+		// even step
+		a := m * (β - m) * x / ((α + 2*m - 1) * (α + 2*m))
+		d = 1 / nonzero(1+a*d)
+		c = nonzero(1 + a/c)
+		h *= d * c
+
+		// odd step
+		a = -(α + m) * (α + β + m) * x / ((α + 2*m) * (α + 2*m + 1))
+		d = 1 / nonzero(1+a*d)
+		c = nonzero(1 + a/c)
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < ε {
+			break
+		}
+	}
+
+	return h
+}
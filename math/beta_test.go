@@ -0,0 +1,30 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBetaCDF(t *testing.T) {
+	for _, c := range []struct{ x, α, β, expected float64 }{
+		{0.3, 1, 1, 0.3},                  // uniform
+		{0.5, 2, 2, 0.5},                  // symmetric
+		{0.2, 2, 1, 0.04},                 // x²
+		{0.2, 1, 3, 1 - math.Pow(0.8, 3)}, // 1 - (1 - x)³
+		{0.9, 50, 10, 0.933411},           // numerically integrated
+	} {
+		if got := BetaCDF(c.x, c.α, c.β); math.Abs(got-c.expected) > 1e-4 {
+			t.Fatalf("expected Beta(%f, %f) cdf %f at %f, got %f", c.α, c.β, c.expected, c.x, got)
+		}
+	}
+}
+
+func TestBetaQuantile(t *testing.T) {
+	if got := BetaQuantile(0.04, 2, 1); math.Abs(got-0.2) > 1e-6 {
+		t.Fatalf("expected quantile 0.2, got %f", got)
+	}
+
+	if got := BetaQuantile(0.95, 30, 70); got < 0.3 || got > 0.4 {
+		t.Fatalf("expected quantile above the mean 0.3, got %f", got)
+	}
+}
@@ -0,0 +1,15 @@
+package math
+
+import "math"
+
+// MSPRT returns the likelihood ratio Λ of the mixture sequential probability
+// ratio test for H0: θ = 0, given an observed difference in means `δ` whose
+// estimator has variance `v`, using a normal mixing distribution N(0, τ²):
+//
+// Λ = sqrt(v / (v + τ²)) exp(τ² δ² / (2 v (v + τ²)))
+//
+// See Johari et al.: Always Valid Inference: Bringing Sequential Analysis to
+// A/B Testing.
+func MSPRT(δ, v, τ2 float64) float64 {
+	return math.Sqrt(v/(v+τ2)) * math.Exp(τ2*δ*δ/(2*v*(v+τ2)))
+}
@@ -0,0 +1,17 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMSPRT(t *testing.T) {
+	// no difference in means is evidence for H0
+	if got, expected := MSPRT(0, 1, 3), 0.5; math.Abs(got-expected) > 1e-12 {
+		t.Fatalf("expected Λ = %f, got %f", expected, got)
+	}
+
+	if got := MSPRT(1, 0.01, 0.1); got < 1e10 {
+		t.Fatalf("expected large Λ on a large effect, got %f", got)
+	}
+}
@@ -17,6 +17,11 @@ func NewBetaRand(seed int64) *BetaRand {
 	return &BetaRand{rand.New(rand.NewSource(seed))}
 }
 
+// NewBetaRandFrom returns a new BetaRand that draws from `r`.
+func NewBetaRandFrom(r *rand.Rand) *BetaRand {
+	return &BetaRand{r}
+}
+
 // NextBeta returns beta distributed random variables: x ~ Beta(α, β)
 // implementation follows R.C.H. Cheng: Generating Beta Variates with Nonintegral Shape Parameters
 func (r *BetaRand) NextBeta(α, β float64) float64 {
@@ -42,6 +47,32 @@ func (r *BetaRand) NextBeta(α, β float64) float64 {
 	return (W / (β + W))
 }
 
+// GammaRand returns gamma distributed random variables with shape α and scale
+// 1: x ~ Gamma(α, 1), drawing from `r`. Implementation follows G. Marsaglia and
+// W. Tsang: A Simple Method for Generating Gamma Variables.
+func GammaRand(r *rand.Rand, α float64) float64 {
+	// boost shapes below 1: Gamma(α) = Gamma(α + 1) · U^(1 / α)
+	if α < 1 {
+		return GammaRand(r, α+1) * math.Pow(r.Float64(), 1/α)
+	}
+
+	d := α - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+
+		v = v * v * v
+		u := r.Float64()
+		if math.Log(u) < x*x/2+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
 // NormRand returns normally distributed random variables: x ~ N(x|μ,σ)
 func NormRand(μ, σ float64) func() float64 {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -50,6 +81,14 @@ func NormRand(μ, σ float64) func() float64 {
 	}
 }
 
+// ExpRand returns exponentially distributed random variables: x ~ Exp(x|λ)
+func ExpRand(λ float64) func() float64 {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return func() float64 {
+		return r.ExpFloat64() / λ
+	}
+}
+
 // DiracRand returns a constant value c
 func DiracRand(c float64) func() float64 {
 	return func() float64 {
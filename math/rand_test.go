@@ -2,6 +2,7 @@ package math
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -43,3 +44,27 @@ func TestBetaSeed(t *testing.T) {
 		t.Fatalf("beta random variable should be %f, but is %f", expected, got)
 	}
 }
+
+func TestGammaRand(t *testing.T) {
+	r := rand.New(rand.NewSource(123))
+	numSamples := 1000000
+	for _, α := range []float64{0.5, 1, 4.5} {
+		mean, mean2 := 0.0, 0.0
+		for i := 0; i < numSamples; i++ {
+			x := GammaRand(r, α)
+			mean += x
+			mean2 += x * x
+		}
+		mean /= float64(numSamples)
+		mean2 /= float64(numSamples)
+
+		// mean and variance of Gamma(α, 1) are both α
+		if math.Abs(mean-α) > 0.01 {
+			t.Fatalf("mean converge to %f. is %f", α, mean)
+		}
+
+		if got := mean2 - mean*mean; math.Abs(got-α) > 0.05 {
+			t.Fatalf("variance converge to %f. is %f", α, got)
+		}
+	}
+}
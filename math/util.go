@@ -1,6 +1,9 @@
 package math
 
-import "math"
+import (
+	"math"
+	"math/rand"
+)
 
 // Max returns maximal value and its indices of a slice
 func Max(array []float64) (float64, []int) {
@@ -15,3 +18,20 @@ func Max(array []float64) (float64, []int) {
 	}
 	return max, imax
 }
+
+// ArgMax returns the index of the maximal value of a slice in a single pass
+// without allocating. Ties are broken uniformly at random using `r`.
+func ArgMax(array []float64, r *rand.Rand) int {
+	max, imax, ties := -math.MaxFloat64, -1, 0
+	for idx, value := range array {
+		if max < value {
+			max, imax, ties = value, idx, 1
+		} else if value == max {
+			ties++
+			if r.Intn(ties) == 0 {
+				imax = idx
+			}
+		}
+	}
+	return imax
+}
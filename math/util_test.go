@@ -0,0 +1,22 @@
+package math
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestArgMax(t *testing.T) {
+	r := rand.New(rand.NewSource(123))
+	if got := ArgMax([]float64{0.1, 0.9, 0.3}, r); got != 1 {
+		t.Fatalf("expected argmax 1, got %d", got)
+	}
+
+	picked := make([]int, 3)
+	for i := 0; i < 3000; i++ {
+		picked[ArgMax([]float64{0.5, 0.1, 0.5}, r)]++
+	}
+
+	if picked[1] != 0 || picked[0] < 1300 || picked[2] < 1300 {
+		t.Fatalf("expected ties to be broken uniformly, got %v", picked)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+)
+
+// NewMeanVariance constructs a risk averse strategy which maximizes the mean
+// minus λ times the variance of rewards, instead of the mean alone, see Sani
+// et al., 2012, "Risk-Aversion in Multi-armed Bandits". Arms with a slightly
+// better mean but much more variable rewards lose, which suits revenue. With
+// λ = 0, it behaves like UCB1. Rewards must be in [0, 1], e.g. revenue divided
+// by the highest revenue.
+func NewMeanVariance(arms int, λ float64) (Strategy, error) {
+	if !(λ >= 0) || math.IsInf(λ, 0) {
+		return &meanVariance{}, fmt.Errorf("λ not in [0, ∞)")
+	}
+
+	return &meanVariance{
+		uCBTuned: uCBTuned{
+			Counters: NewCounters(arms),
+			squares:  make([]float64, arms),
+		},
+		lambda: λ,
+	}, nil
+}
+
+// meanVariance keeps squared rewards like UCB-Tuned, and selects the highest
+// bound of mean - λ · variance. When the mean and the mean square of an arm
+// are each within ε, the objective is within (1 + 3λ) · ε, so the bound of an
+// arm pulled n times in t pulls is
+//
+//	mean - λ · variance + (1 + 3λ) · sqrt(log(t) / 2n)
+type meanVariance struct {
+	uCBTuned
+	lambda float64 // risk aversion λ
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (m *meanVariance) SelectArm() int {
+	m.Lock()
+	defer m.Unlock()
+
+	var arm int
+	if m.ties == TiesOrdinal {
+		_, best := bmath.Max(m.bounds())
+		arm = best[0]
+	} else {
+		arm = bmath.ArgMax(m.bounds(), m.rand)
+	}
+
+	m.counts[arm]++
+	return arm + 1
+}
+
+// bounds returns the upper confidence bound of the risk adjusted reward of
+// each arm. Arms which were never pulled are best.
+func (m *meanVariance) bounds() []float64 {
+	total := 0
+	for _, count := range m.counts {
+		total += count
+	}
+
+	logTotal := math.Log(float64(total))
+	ucbs := make([]float64, m.arms)
+	for i, count := range m.counts {
+		if count == 0 {
+			ucbs[i] = math.Inf(1)
+			continue
+		}
+
+		variance := math.Max(m.squares[i]-m.values[i]*m.values[i], 0)
+		ucbs[i] = m.estimate(i, m.values[i]-m.lambda*variance)
+		ucbs[i] += (1 + 3*m.lambda) * math.Sqrt(logTotal/(2*float64(count)))
+	}
+
+	return ucbs
+}
+
+// Distribution returns the probability of selecting each arm.
+func (m *meanVariance) Distribution() []float64 {
+	m.Lock()
+	defer m.Unlock()
+
+	_, best := bmath.Max(m.bounds())
+	if m.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	distribution := make([]float64, m.arms)
+	for _, arm := range best {
+		distribution[arm] = 1 / float64(len(best))
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (m *meanVariance) String() string {
+	return fmt.Sprintf("MeanVariance(λ=%.2f)", m.lambda)
+}
@@ -0,0 +1,63 @@
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"testing"
+)
+
+func TestMeanVariance(t *testing.T) {
+	sims := 100
+	trials := 10000
+	bestArmIndex := 2 // Dirac(0.5)
+
+	// arm 1 has the better mean, but a variance of 0.2475
+	arms := []sim.Arm{
+		bmath.BernRand(0.55),
+		bmath.DiracRand(0.5),
+	}
+
+	strategy, err := New(len(arms), "meanVariance", []float64{1})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.9 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+
+	if _, err := NewMeanVariance(2, -1); err == nil {
+		t.Fatalf("expected negative λ to fail")
+	}
+}
+
+func TestMeanVarianceRiskAversion(t *testing.T) {
+	s, err := NewMeanVariance(2, 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// arm 2 has the higher mean 0.5, with a variance of 0.25
+	m := s.(*meanVariance)
+	for i := 0; i < 100; i++ {
+		m.counts[0]++
+		m.Update(1, 0.4)
+		m.counts[1]++
+		m.Update(2, float64(i%2))
+	}
+
+	if d := s.(Previewer).Distribution(); d[1] != 1 {
+		t.Fatalf("expected the higher mean to win without risk aversion, got %v", d)
+	}
+
+	m.lambda = 1
+	if d := s.(Previewer).Distribution(); d[0] != 1 {
+		t.Fatalf("expected the steady arm to win with λ = 1, got %v", d)
+	}
+}
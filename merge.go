@@ -0,0 +1,116 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeStateMerged is reported for each arm whose state was merged into an
+// arm of another experiment.
+const ChangeStateMerged = "state-merged"
+
+// MergeState merges the state `from` of an old experiment into the state
+// `into` of experiment `e`, for example when experiments are renamed to a new
+// naming convention while both are served. An old arm is merged into the arm
+// of `e` whose tag or url `aliases` maps the old tag or url to, or else into
+// the arm with the same url. Counts of merged arms add up, and values are
+// averaged weighted by counts. Old arms without a match are reported as lost.
+// `into` may be the zero State if `e` has no state yet, but otherwise must be
+// the state of its current arms. Annotations of both are kept.
+func MergeState(e *Experiment, into, from State, aliases map[string]string) (State, []Change, error) {
+	variations := e.armVariations()
+	merged := State{
+		Counts:      make([]int, len(variations)),
+		Values:      make([]float64, len(variations)),
+		Annotations: append(append([]Annotation{}, into.Annotations...), from.Annotations...),
+	}
+
+	for _, v := range variations {
+		merged.Tags, merged.URLs = append(merged.Tags, v.Tag), append(merged.URLs, v.URL)
+	}
+
+	if into.Counts != nil {
+		if len(into.Counts) != len(variations) || len(into.Values) != len(variations) ||
+			(into.Tags != nil && strings.Join(into.Tags, " ") != strings.Join(merged.Tags, " ")) {
+			return State{}, []Change{}, fmt.Errorf("state of %s does not match its variations %v", e.Name, merged.Tags)
+		}
+
+		copy(merged.Counts, into.Counts)
+		copy(merged.Values, into.Values)
+	}
+
+	if len(from.Counts) != len(from.Values) || len(from.Tags) != len(from.Counts) {
+		return State{}, []Change{}, fmt.Errorf("merged state needs a tag, count and value per arm")
+	}
+
+	byTag, byURL := make(map[string]int), make(map[string]int)
+	for arm := range variations {
+		byTag[merged.Tags[arm]], byURL[merged.URLs[arm]] = arm, arm
+	}
+
+	var changes []Change
+	for old, tag := range from.Tags {
+		url := ""
+		if old < len(from.URLs) {
+			url = from.URLs[old]
+		}
+
+		target := aliases[tag]
+		if target == "" && url != "" {
+			target = aliases[url]
+		}
+
+		if target == "" {
+			target = url
+		}
+
+		to, ok := byTag[target]
+		if !ok {
+			to, ok = byURL[target]
+		}
+
+		if !ok {
+			changes = append(changes, Change{e.Name, ChangeStateLost, tag})
+			continue
+		}
+
+		count := merged.Counts[to] + from.Counts[old]
+		if count > 0 {
+			merged.Values[to] = (merged.Values[to]*float64(merged.Counts[to]) + from.Values[old]*float64(from.Counts[old])) / float64(count)
+		}
+
+		merged.Counts[to] = count
+		changes = append(changes, Change{e.Name, ChangeStateMerged, fmt.Sprintf("%s -> %s: %d pulls", tag, merged.Tags[to], from.Counts[old])})
+	}
+
+	return merged, changes, nil
+}
+
+// LoadState reads the stored state of experiment `name` from `s`. Returns
+// ErrNotFound if there is none.
+func LoadState(s Store, name string) (State, error) {
+	data, err := s.Get(name)
+	if err != nil {
+		return State{}, err
+	}
+
+	var stored State
+	if err := codec(s).Unmarshal(data, &stored); err != nil {
+		return State{}, fmt.Errorf("could not unmarshal %s: %s", name, err.Error())
+	}
+
+	return stored, nil
+}
+
+// SaveState writes `state` of experiment `name` to `s`.
+func SaveState(s Store, name string, state State) error {
+	data, err := codec(s).Marshal(state)
+	if err != nil {
+		return fmt.Errorf("could not marshal %s: %s", name, err.Error())
+	}
+
+	return s.Put(name, data)
+}
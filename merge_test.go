@@ -0,0 +1,93 @@
+package bandit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMergeState(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	from := State{
+		Tags:   []string{"shape-old:1", "shape-old:2", "shape-old:3"},
+		URLs:   []string{"http://localhost:8080/widget?shape=circle", "http://localhost:8080/square", "http://localhost:8080/triangle"},
+		Counts: []int{10, 30, 5},
+		Values: []float64{0.25, 0.5, 0.1},
+	}
+
+	into := State{
+		Tags:   []string{"shape-20130822:1", "shape-20130822:2"},
+		Counts: []int{30, 10},
+		Values: []float64{0.5, 0.9},
+	}
+
+	// circle matches by url, the square moved and is aliased by tag.
+	aliases := map[string]string{"shape-old:2": "shape-20130822:2"}
+	merged, changes, err := MergeState(e, into, from, aliases)
+	if err != nil {
+		t.Fatalf("could not merge: %s", err.Error())
+	}
+
+	if len(changes) != 3 || changes[0].Kind != ChangeStateMerged ||
+		changes[1].Kind != ChangeStateMerged || changes[2].Kind != ChangeStateLost {
+		t.Fatalf("expected circle and square merged, triangle lost, got %v", changes)
+	}
+
+	if merged.Counts[0] != 40 || merged.Counts[1] != 40 {
+		t.Fatalf("expected counts to add up, got %v", merged.Counts)
+	}
+
+	if math.Abs(merged.Values[0]-0.4375) > 1e-9 || math.Abs(merged.Values[1]-0.6) > 1e-9 {
+		t.Fatalf("expected values weighted by counts, got %v", merged.Values)
+	}
+
+	if merged.Tags[1] != "shape-20130822:2" || merged.URLs[1] != e.Variations[1].URL {
+		t.Fatalf("expected arms of %s, got %v", e.Name, merged.Tags)
+	}
+
+	// the new experiment has no state yet.
+	merged, _, err = MergeState(e, State{}, from, map[string]string{})
+	if err != nil {
+		t.Fatalf("could not merge into nothing: %s", err.Error())
+	}
+
+	if merged.Counts[0] != 10 || merged.Counts[1] != 0 || merged.Values[0] != 0.25 {
+		t.Fatalf("expected only circle merged, got %v %v", merged.Counts, merged.Values)
+	}
+
+	into.Counts = []int{1, 2, 3}
+	if _, _, err := MergeState(e, into, from, aliases); err == nil {
+		t.Fatalf("expected state not matching the arms to fail")
+	}
+}
+
+func TestLoadSaveState(t *testing.T) {
+	s := mapStore{}
+	if _, err := LoadState(s, "shape-20130822"); err != ErrNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	state := State{
+		Tags:   []string{"shape-20130822:1"},
+		URLs:   []string{"http://localhost:8080/widget?shape=circle"},
+		Counts: []int{10},
+		Values: []float64{0.25},
+	}
+
+	if err := SaveState(s, "shape-20130822", state); err != nil {
+		t.Fatalf("could not save: %s", err.Error())
+	}
+
+	got, err := LoadState(s, "shape-20130822")
+	if err != nil {
+		t.Fatalf("could not load: %s", err.Error())
+	}
+
+	if got.Tags[0] != state.Tags[0] || got.Counts[0] != 10 || got.Values[0] != 0.25 {
+		t.Fatalf("expected %v, got %v", state, got)
+	}
+}
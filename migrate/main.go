@@ -0,0 +1,98 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+// Package main contains bandit-migrate, which merges the stored state of an
+// experiment into a renamed experiment, so that renaming does not lose its
+// data. Arms are matched by url, or by aliases of old tags or urls:
+//
+//	bandit-migrate -store state.db -from shape-20130822 -to widget-shape \
+//	  -aliases shape-20130822:1=widget-shape:2 -dry-run
+//
+// The report lists the merged and lost arms. Run it while no bandit-api
+// holds the store open.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/purzelrakete/bandit"
+	"log"
+	"strings"
+)
+
+var (
+	migrateExperiments = flag.String("experiments", "experiments.json", "local file or http endpoint with the renamed experiment")
+	migrateStore       = flag.String("store", "", "embedded store file with the state of both experiments")
+	migrateStoreCodec  = flag.String("store-codec", "json", "serialization of stored state ∈ {json,gob,protobuf}")
+	migrateFrom        = flag.String("from", "", "old experiment name")
+	migrateTo          = flag.String("to", "", "new experiment name")
+	migrateAliases     = flag.String("aliases", "", "comma separated old=new tags or urls of arms which changed url")
+	migrateDryRun      = flag.Bool("dry-run", false, "report the merge without saving it")
+)
+
+func init() {
+	flag.Parse()
+}
+
+func main() {
+	if *migrateStore == "" || *migrateFrom == "" || *migrateTo == "" {
+		log.Fatalf("please provide -store, -from and -to")
+	}
+
+	aliases := make(map[string]string)
+	if *migrateAliases != "" {
+		for _, pair := range strings.Split(*migrateAliases, ",") {
+			names := strings.SplitN(pair, "=", 2)
+			if len(names) != 2 {
+				log.Fatalf("could not alias '%s': expected old=new", pair)
+			}
+
+			aliases[names[0]] = names[1]
+		}
+	}
+
+	e, err := bandit.NewExperiment(bandit.NewOpener(*migrateExperiments), *migrateTo)
+	if err != nil {
+		log.Fatalf("could not read experiment: %s", err.Error())
+	}
+
+	embedded, err := bandit.NewEmbeddedStore(*migrateStore)
+	if err != nil {
+		log.Fatalf("could not open store: %s", err.Error())
+	}
+
+	defer embedded.Close()
+
+	store, err := bandit.NewCodedStore(embedded, *migrateStoreCodec)
+	if err != nil {
+		log.Fatalf("could not open store: %s", err.Error())
+	}
+
+	from, err := bandit.LoadState(store, *migrateFrom)
+	if err != nil {
+		log.Fatalf("could not load %s: %s", *migrateFrom, err.Error())
+	}
+
+	// the new experiment may not have been served yet.
+	into, err := bandit.LoadState(store, *migrateTo)
+	if err != nil && err != bandit.ErrNotFound {
+		log.Fatalf("could not load %s: %s", *migrateTo, err.Error())
+	}
+
+	merged, changes, err := bandit.MergeState(e, into, from, aliases)
+	if err != nil {
+		log.Fatalf("could not merge: %s", err.Error())
+	}
+
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+
+	if *migrateDryRun {
+		return
+	}
+
+	if err := bandit.SaveState(store, *migrateTo, merged); err != nil {
+		log.Fatalf("could not save %s: %s", *migrateTo, err.Error())
+	}
+}
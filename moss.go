@@ -0,0 +1,92 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+)
+
+// NewMOSS constructs a MOSS strategy, see Audibert & Bubeck, 2009, "Minimax
+// Policies for Adversarial and Stochastic Bandits". It suits campaigns of a
+// known length, such as a fixed number of impressions `horizon`.
+func NewMOSS(arms, horizon int) (Strategy, error) {
+	if horizon < 1 {
+		return &moss{}, fmt.Errorf("horizon not in [1, ∞)")
+	}
+
+	return &moss{
+		Counters: NewCounters(arms),
+		horizon:  horizon,
+	}, nil
+}
+
+// moss selects the arm with the highest index
+//
+//	value + sqrt(max(log(T / (K n)), 0) / n)
+//
+// for an arm pulled n times out of K arms and horizon T. Arms stop exploring
+// once pulled T / K times. Pulls beyond the horizon exploit.
+type moss struct {
+	Counters
+	horizon int
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (m *moss) SelectArm() int {
+	m.Lock()
+	defer m.Unlock()
+
+	var arm int
+	if m.ties == TiesOrdinal {
+		_, best := bmath.Max(m.indices())
+		arm = best[0]
+	} else {
+		arm = bmath.ArgMax(m.indices(), m.rand)
+	}
+
+	m.counts[arm]++
+	return arm + 1
+}
+
+// indices returns the index of each arm. Arms which were never pulled are
+// best.
+func (m *moss) indices() []float64 {
+	indices := append([]float64{}, m.estimates()...)
+	for i, count := range m.counts {
+		if count == 0 {
+			indices[i] = math.Inf(1)
+			continue
+		}
+
+		n := float64(count)
+		indices[i] += math.Sqrt(math.Max(math.Log(float64(m.horizon)/(float64(m.arms)*n)), 0) / n)
+	}
+
+	return indices
+}
+
+// Distribution returns the probability of selecting each arm.
+func (m *moss) Distribution() []float64 {
+	m.Lock()
+	defer m.Unlock()
+
+	_, best := bmath.Max(m.indices())
+	if m.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	distribution := make([]float64, m.arms)
+	for _, arm := range best {
+		distribution[arm] = 1 / float64(len(best))
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (m *moss) String() string {
+	return fmt.Sprintf("MOSS(horizon=%d)", m.horizon)
+}
@@ -0,0 +1,53 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"testing"
+)
+
+func TestMOSS(t *testing.T) {
+	sims := 500
+	trials := 1000
+	bestArmIndex := 4 // Bernoulli(bestArm)
+	arms := []sim.Arm{
+		bmath.BernRand(0.1),
+		bmath.BernRand(0.3),
+		bmath.BernRand(0.2),
+		bmath.BernRand(0.8),
+	}
+
+	strategy, err := New(len(arms), "moss", []float64{float64(trials)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.9 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+
+	if _, err := NewMOSS(2, 0); err == nil {
+		t.Fatalf("expected horizon of 0 to fail")
+	}
+}
+
+func TestMOSSStopsExploring(t *testing.T) {
+	s, _ := NewMOSS(2, 20)
+	m := s.(*moss)
+	m.counts = []int{10, 10}
+	m.values = []float64{0.5, 0.6}
+
+	// both arms were pulled T / K times, so indices are the values
+	if indices := m.indices(); indices[0] != 0.5 || indices[1] != 0.6 {
+		t.Fatalf("expected no exploration bonus, got %v", indices)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ChangeNameReused is reported for experiments named like a retired one.
+const ChangeNameReused = "name-reused"
+
+// namesKey is the store key of the names registry. Experiments cannot use it
+// as their name.
+const namesKey = "bandit:experiment-names"
+
+// names are the experiments served at the last RecordNames, and those which
+// were served before but no longer are, with the time they were found gone.
+// The registry is json regardless of the state codec.
+type names struct {
+	Active  []string             `json:"active"`
+	Retired map[string]time.Time `json:"retired"`
+}
+
+// loadNames returns the registry in `s`, or an empty one.
+func loadNames(s Store) (names, error) {
+	registry := names{Retired: make(map[string]time.Time)}
+	data, err := s.Get(namesKey)
+	if err == ErrNotFound {
+		return registry, nil
+	}
+
+	if err != nil {
+		return names{}, fmt.Errorf("could not get experiment names: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return names{}, fmt.Errorf("could not unmarshal experiment names: %s", err.Error())
+	}
+
+	if registry.Retired == nil {
+		registry.Retired = make(map[string]time.Time)
+	}
+
+	return registry, nil
+}
+
+// RecordNames registers the names of `es` as served in `s`. Experiments which
+// were registered before but are missing from `es`, e.g. removed by Apply, are
+// registered as retired at `now`. Retired names served again are accepted,
+// reported as ChangeNameReused once and not by CheckNames afterwards.
+func RecordNames(s Store, es *Experiments, now time.Time) ([]Change, error) {
+	registry, err := loadNames(s)
+	if err != nil {
+		return []Change{}, err
+	}
+
+	reused, err := registry.check(es)
+	if err != nil {
+		return []Change{}, err
+	}
+
+	active := make(map[string]bool)
+	for name := range es.All() {
+		active[name] = true
+	}
+
+	for _, name := range registry.Active {
+		if !active[name] {
+			registry.Retired[name] = now
+		}
+	}
+
+	registry.Active = registry.Active[:0]
+	for name := range active {
+		registry.Active = append(registry.Active, name)
+		delete(registry.Retired, name)
+	}
+
+	sort.Strings(registry.Active)
+	data, err := json.Marshal(registry)
+	if err != nil {
+		return []Change{}, fmt.Errorf("could not marshal experiment names: %s", err.Error())
+	}
+
+	if err := s.Put(namesKey, data); err != nil {
+		return []Change{}, fmt.Errorf("could not put experiment names: %s", err.Error())
+	}
+
+	return reused, nil
+}
+
+// CheckNames reports experiments of `es` named like an experiment retired in
+// `s`. Analyses keyed by experiment name would mix the data of both, so pick
+// a new name unless the old experiment is continued on purpose.
+func CheckNames(s Store, es *Experiments) ([]Change, error) {
+	registry, err := loadNames(s)
+	if err != nil {
+		return []Change{}, err
+	}
+
+	return registry.check(es)
+}
+
+// check reports experiments of `es` with retired names.
+func (n names) check(es *Experiments) ([]Change, error) {
+	var changes []Change
+	for name := range es.All() {
+		if name == namesKey {
+			return []Change{}, fmt.Errorf("experiment name %s is reserved", name)
+		}
+
+		if retired, ok := n.Retired[name]; ok {
+			changes = append(changes, Change{name, ChangeNameReused, fmt.Sprintf("retired %s", retired.Format(time.RFC3339))})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Experiment < changes[j].Experiment })
+	return changes, nil
+}
+
+// Registry checks the names and tags of experiments against the state stored
+// in Store. Set it on Experiments to have Apply and Import check the
+// experiments they apply and record their names, see Check and RecordNames.
+type Registry struct {
+	Store       Store
+	StrictTags  bool // refuse tags reused for different urls, see CheckTags
+	StrictNames bool // refuse names of retired experiments instead of reporting them
+}
+
+// Check refuses `es` if it reuses a stored tag with StrictTags, or a retired
+// name with StrictNames. Otherwise, it reports reused names, see CheckNames.
+func (r *Registry) Check(es *Experiments) ([]Change, error) {
+	if r.StrictTags {
+		if err := CheckTags(r.Store, es); err != nil {
+			return []Change{}, fmt.Errorf("strict tags: %s", err.Error())
+		}
+	}
+
+	reused, err := CheckNames(r.Store, es)
+	if err != nil {
+		return []Change{}, err
+	}
+
+	if r.StrictNames && len(reused) > 0 {
+		var names []string
+		for _, change := range reused {
+			names = append(names, change.Experiment)
+		}
+
+		return []Change{}, fmt.Errorf("strict names: retired experiment names reused: %s", strings.Join(names, ", "))
+	}
+
+	return reused, nil
+}
+
+// record records the names of `es`, see RecordNames, and logs failures,
+// since the experiments are served by then.
+func (r *Registry) record(es *Experiments) {
+	reused, err := RecordNames(r.Store, es, time.Now())
+	if err != nil {
+		log.Printf("could not record names: %s", err.Error())
+	}
+
+	for _, change := range reused {
+		log.Printf("experiment name reused: %s", change)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNames(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	s, now := mapStore{}, time.Unix(1378823906, 0)
+	if reused, err := RecordNames(s, es, now); err != nil || len(reused) != 0 {
+		t.Fatalf("expected names to be recorded, got %v, %v", reused, err)
+	}
+
+	// shape is removed, and later served again
+	e := es.index["shape-20130822"]
+	delete(es.index, "shape-20130822")
+	if _, err := RecordNames(s, es, now); err != nil {
+		t.Fatalf("could not record names: %s", err.Error())
+	}
+
+	es.index["shape-20130822"] = e
+	changes, err := CheckNames(s, es)
+	if err != nil {
+		t.Fatalf("could not check names: %s", err.Error())
+	}
+
+	if len(changes) != 1 || changes[0].Experiment != "shape-20130822" || changes[0].Kind != ChangeNameReused {
+		t.Fatalf("expected shape to be reported as reused, got %v", changes)
+	}
+
+	// serving it again accepts the reuse once
+	if reused, err := RecordNames(s, es, now); err != nil || len(reused) != 1 {
+		t.Fatalf("expected reuse to be reported when recorded, got %v, %v", reused, err)
+	}
+
+	if changes, err := CheckNames(s, es); err != nil || len(changes) != 0 {
+		t.Fatalf("expected accepted name not to be reported again, got %v, %v", changes, err)
+	}
+
+	es.index[namesKey] = e
+	if _, err := CheckNames(s, es); err == nil {
+		t.Fatalf("expected reserved name to be refused")
+	}
+}
+
+func TestNamesApplied(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	s := mapStore{}
+	es.Registry = &Registry{Store: s, StrictNames: true}
+	if _, err := RecordNames(s, es, time.Now()); err != nil {
+		t.Fatalf("could not record names: %s", err.Error())
+	}
+
+	// applying a config without shape retires it
+	config := []byte(`[{"experiment_name": "color-20130901", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "variations": [
+		{"ordinal": 1, "url": "http://localhost:8080/widget?color=red"}]}]`)
+
+	next, _ := NewExperiments(NewBytesOpener(config))
+	if _, err := es.Apply(next, RestoreFail); err != nil {
+		t.Fatalf("could not apply: %s", err.Error())
+	}
+
+	shape, _ := NewExperiments(NewFileOpener("experiments.json"))
+	if _, err := es.Apply(shape, RestoreFail); err == nil {
+		t.Fatalf("expected retired name to be refused")
+	}
+
+	es.Registry.StrictNames = false
+	shape, _ = NewExperiments(NewFileOpener("experiments.json"))
+	report, err := es.Import(shape, RestoreFail, false)
+	if err != nil {
+		t.Fatalf("could not import: %s", err.Error())
+	}
+
+	var reused []string
+	for _, change := range report.Changes {
+		if change.Kind == ChangeNameReused {
+			reused = append(reused, change.Experiment)
+		}
+	}
+
+	if len(reused) != 1 || reused[0] != "shape-20130822" {
+		t.Fatalf("expected reused shape to be reported, got %v", report.Changes)
+	}
+
+	if changes, err := CheckNames(s, es); err != nil || len(changes) != 0 {
+		t.Fatalf("expected imported name to be recorded, got %v, %v", changes, err)
+	}
+}
@@ -0,0 +1,9 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+//go:build !race
+
+package bandit
+
+// raceEnabled is true under the race detector, which allocates on its own.
+const raceEnabled = false
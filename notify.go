@@ -0,0 +1,58 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	banditEvent = "BanditEvent"
+
+	// EventAnomaly is emitted when an arm receives anomalous rewards.
+	EventAnomaly = "anomaly"
+
+	// EventReset is emitted when an experiment is reset while serving.
+	EventReset = "reset"
+)
+
+// Event is an operational event, for example an anomalous burst of rewards on
+// a single arm. Arm is 1 indexed, or 0 if the event concerns all arms.
+// Experiment is "*" if the event concerns all experiments.
+type Event struct {
+	Time       time.Time
+	Kind       string
+	Experiment string
+	Arm        int
+	Message    string
+}
+
+// Notifier receives operational events emitted by strategies and experiments.
+type Notifier interface {
+	Notify(e Event)
+}
+
+// NotifierFunc adapts an ordinary function to the Notifier interface.
+type NotifierFunc func(e Event)
+
+// Notify calls f(e).
+func (f NotifierFunc) Notify(e Event) {
+	f(e)
+}
+
+// NewLogNotifier returns a notifier which writes events to the standard logger
+// in the same whitespace separated format as selection and reward lines.
+func NewLogNotifier() Notifier {
+	return NotifierFunc(func(e Event) {
+		log.Println(EventLine(e))
+	})
+}
+
+// EventLine formats an event as a log line.
+func EventLine(e Event) string {
+	return fmt.Sprintf("%d %s %s %s %d %s",
+		e.Time.Unix(), banditEvent, e.Kind, e.Experiment, e.Arm, e.Message)
+}
@@ -1,8 +1,10 @@
 package bandit
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"strings"
@@ -67,3 +69,33 @@ func (o *fileOpener) Open() (io.ReadCloser, error) {
 
 	return reader, err
 }
+
+// NewBytesOpener returns an Opener of in memory `data`, such as a request
+// body.
+func NewBytesOpener(data []byte) Opener {
+	return bytesOpener(data)
+}
+
+type bytesOpener []byte
+
+func (o bytesOpener) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(o)), nil
+}
+
+// NewFSOpener returns an Opener using a file in a file system, such as files
+// embedded in the binary with go:embed.
+func NewFSOpener(fsys fs.FS, path string) Opener {
+	return &fsOpener{
+		FS:   fsys,
+		Path: path,
+	}
+}
+
+type fsOpener struct {
+	FS   fs.FS
+	Path string
+}
+
+func (o *fsOpener) Open() (io.ReadCloser, error) {
+	return o.FS.Open(o.Path)
+}
@@ -0,0 +1,112 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ways of handling rewards for tags which are in no experiment, for example
+// of retired variations or renamed experiments.
+const (
+	OrphanDrop       = "drop"        // count per tag and drop
+	OrphanDeadLetter = "dead-letter" // count and write to the dead letters
+	OrphanAlias      = "alias"       // map to the current tag, else count and drop
+)
+
+// NewOrphans returns a handler of orphaned rewards with `policy`. `aliases`
+// map old tags to current ones, or old experiment names to current ones, as
+// in "shape-20130822" -> "shape-20140101". Dead letters are written to `dead`
+// with the time, tag and reward of each reward.
+func NewOrphans(policy string, aliases map[string]string, dead io.Writer) (*Orphans, error) {
+	switch policy {
+	case OrphanDrop, OrphanAlias:
+	case OrphanDeadLetter:
+		if dead == nil {
+			return &Orphans{}, fmt.Errorf("dead letters need a writer")
+		}
+	default:
+		return &Orphans{}, fmt.Errorf("unknown orphan policy '%s'", policy)
+	}
+
+	return &Orphans{
+		policy:  policy,
+		aliases: aliases,
+		dead:    dead,
+		dropped: make(map[string]int),
+	}, nil
+}
+
+// Orphans resolve reward tags to variations and handle rewards for unknown
+// tags according to their policy.
+type Orphans struct {
+	sync.Mutex
+	policy  string
+	aliases map[string]string
+	dead    io.Writer
+	dropped map[string]int // orphaned rewards by tag
+}
+
+// Resolve returns the experiment and variation of untimed `tag`. For unknown
+// tags, it returns false after applying the policy; the reward must not be
+// applied then.
+func (o *Orphans) Resolve(es *Experiments, tag string, reward float64) (Experiment, Variation, bool) {
+	if e, v, err := es.GetVariation(tag); err == nil {
+		return e, v, true
+	}
+
+	if o.policy == OrphanAlias {
+		if alias, ok := o.alias(tag); ok {
+			if e, v, err := es.GetVariation(alias); err == nil {
+				return e, v, true
+			}
+		}
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	o.dropped[tag]++
+	if o.policy == OrphanDeadLetter {
+		fmt.Fprintf(o.dead, "%d %s %f\n", time.Now().Unix(), tag, reward)
+	}
+
+	return Experiment{}, Variation{}, false
+}
+
+// alias returns the current tag of `tag`, mapped by tag or else by the
+// experiment name in front of the ordinal.
+func (o *Orphans) alias(tag string) (string, bool) {
+	if alias, ok := o.aliases[tag]; ok {
+		return alias, true
+	}
+
+	sep := strings.LastIndex(tag, ":")
+	if sep == -1 {
+		return "", false
+	}
+
+	if name, ok := o.aliases[tag[:sep]]; ok {
+		return name + tag[sep:], true
+	}
+
+	return "", false
+}
+
+// Dropped returns the number of orphaned rewards by tag.
+func (o *Orphans) Dropped() map[string]int {
+	o.Lock()
+	defer o.Unlock()
+
+	dropped := make(map[string]int, len(o.dropped))
+	for tag, n := range o.dropped {
+		dropped[tag] = n
+	}
+
+	return dropped
+}
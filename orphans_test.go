@@ -0,0 +1,69 @@
+package bandit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestOrphans(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	aliases := map[string]string{
+		"shape-20130101":   "shape-20130822",
+		"shape-20130822:9": "shape-20130822:1",
+	}
+
+	o, err := NewOrphans(OrphanAlias, aliases, nil)
+	if err != nil {
+		t.Fatalf("while creating orphans: %s", err.Error())
+	}
+
+	for tag, expected := range map[string]string{
+		"shape-20130822:2": "shape-20130822:2",
+		"shape-20130101:2": "shape-20130822:2",
+		"shape-20130822:9": "shape-20130822:1",
+	} {
+		_, v, ok := o.Resolve(es, tag, 1)
+		if !ok || v.Tag != expected {
+			t.Fatalf("expected %s to resolve to %s, got %s", tag, expected, v.Tag)
+		}
+	}
+
+	if _, _, ok := o.Resolve(es, "retired-20120101:1", 1); ok {
+		t.Fatalf("expected unknown tag not to resolve")
+	}
+
+	if got := o.Dropped()["retired-20120101:1"]; got != 1 {
+		t.Fatalf("expected 1 dropped reward, got %d", got)
+	}
+}
+
+func TestOrphansDeadLetter(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	dead := new(bytes.Buffer)
+	o, err := NewOrphans(OrphanDeadLetter, nil, dead)
+	if err != nil {
+		t.Fatalf("while creating orphans: %s", err.Error())
+	}
+
+	o.Resolve(es, "retired-20120101:1", 0.5)
+	if got := dead.String(); !strings.HasSuffix(got, " retired-20120101:1 0.500000\n") {
+		t.Fatalf("expected dead letter, got '%s'", got)
+	}
+
+	if _, err := NewOrphans(OrphanDeadLetter, nil, nil); err == nil {
+		t.Fatalf("expected dead letters without writer to fail")
+	}
+
+	if _, err := NewOrphans("ignore", nil, nil); err == nil {
+		t.Fatalf("expected unknown policy to fail")
+	}
+}
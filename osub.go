@@ -0,0 +1,147 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+)
+
+// NewOSUB constructs an OSUB strategy for ordered arms whose mean rewards are
+// unimodal in the arm index, such as conversions times price over a ladder of
+// price points. See Combes & Proutière, 2014, "Unimodal Bandits: Regret Lower
+// Bounds and Optimal Algorithms". It only explores the neighbours of the
+// current leader, so its regret does not grow with the number of arms.
+// Rewards must be in [0, 1], e.g. the price of a sale divided by the highest
+// price.
+func NewOSUB(arms int) Strategy {
+	return &osub{
+		Counters: NewCounters(arms),
+		leads:    make([]int, arms),
+	}
+}
+
+// osub counts how often each arm was the leader, the arm with the highest
+// estimate. Every third time an arm leads, it is exploited. Otherwise, the
+// arm with the highest KL-UCB bound among the leader and its two neighbours
+// is selected, using log(leads) instead of log(t) as the exploration budget.
+type osub struct {
+	Counters
+	leads []int
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (o *osub) SelectArm() int {
+	o.Lock()
+	defer o.Unlock()
+
+	leader := o.leader()
+	o.leads[leader]++
+
+	arm := o.next(leader)
+	o.counts[arm]++
+	return arm + 1
+}
+
+// leader returns the 0 indexed arm with the highest estimate.
+func (o *osub) leader() int {
+	if o.ties == TiesOrdinal {
+		_, best := bmath.Max(o.estimates())
+		return best[0]
+	}
+
+	return bmath.ArgMax(o.estimates(), o.rand)
+}
+
+// next returns the 0 indexed arm to select, given the leader whose leads
+// were already counted.
+func (o *osub) next(leader int) int {
+	if (o.leads[leader]-1)%3 == 0 {
+		return leader
+	}
+
+	budget := math.Log(float64(o.leads[leader]))
+	neighbours := []int{leader}
+	bounds := []float64{o.bound(leader, budget)}
+	for _, arm := range []int{leader - 1, leader + 1} {
+		if arm >= 0 && arm < o.arms {
+			neighbours = append(neighbours, arm)
+			bounds = append(bounds, o.bound(arm, budget))
+		}
+	}
+
+	if o.ties == TiesOrdinal {
+		_, best := bmath.Max(bounds)
+		return neighbours[best[0]]
+	}
+
+	return neighbours[bmath.ArgMax(bounds, o.rand)]
+}
+
+// bound returns the KL-UCB bound of 0 indexed `arm` for `budget`. Arms which
+// were never pulled are best.
+func (o *osub) bound(arm int, budget float64) float64 {
+	count := o.counts[arm]
+	if count == 0 {
+		return math.Inf(1)
+	}
+
+	p := math.Min(math.Max(o.values[arm], 0), 1)
+	return o.estimate(arm, klBound(p, budget/float64(count), klucbPrecision))
+}
+
+// Init the strategy to a new counter state. Snapshots do not carry leads, so
+// leaders are counted afresh.
+func (o *osub) Init(snapshot *Counters) error {
+	if err := o.Counters.Init(snapshot); err != nil {
+		return err
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	o.leads = make([]int, o.arms)
+	return nil
+}
+
+// Reset the strategy to initial state.
+func (o *osub) Reset() {
+	o.ResetWithArchive()
+}
+
+// ResetWithArchive resets the counters and leads at once.
+func (o *osub) ResetWithArchive() *Counters {
+	o.Lock()
+	defer o.Unlock()
+
+	o.leads = make([]int, o.arms)
+	return o.reset()
+}
+
+// Distribution returns the probability of selecting each arm. Leaders drawn
+// among equal estimates are weighted equally.
+func (o *osub) Distribution() []float64 {
+	o.Lock()
+	defer o.Unlock()
+
+	_, leaders := bmath.Max(o.estimates())
+	if o.ties == TiesOrdinal {
+		leaders = leaders[:1]
+	}
+
+	distribution := make([]float64, o.arms)
+	for _, leader := range leaders {
+		o.leads[leader]++
+		distribution[o.next(leader)] += 1 / float64(len(leaders))
+		o.leads[leader]--
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (o *osub) String() string {
+	return fmt.Sprintf("OSUB")
+}
@@ -0,0 +1,56 @@
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"math"
+	"testing"
+)
+
+func TestOSUB(t *testing.T) {
+	sims := 100
+	trials := 2000
+	bestArmIndex := 14 // Bernoulli(bestArm)
+
+	// 20 price points with unimodal mean rewards
+	var arms []sim.Arm
+	for i := 1; i <= 20; i++ {
+		arms = append(arms, bmath.BernRand(math.Max(0.9-0.1*math.Abs(float64(i-bestArmIndex)), 0)))
+	}
+
+	strategy, err := New(len(arms), "osub", []float64{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.9 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+}
+
+func TestOSUBExploresNeighbours(t *testing.T) {
+	s := NewOSUB(5)
+	o := s.(*osub)
+	o.counts = []int{10, 10, 10, 10, 10}
+	o.values = []float64{0.1, 0.2, 0.5, 0.3, 0.2}
+
+	for i := 0; i < 30; i++ {
+		if arm := s.SelectArm(); arm < 2 || arm > 4 {
+			t.Fatalf("expected leader 3 or its neighbours, got %d", arm)
+		}
+	}
+
+	// the first time an arm leads, it is exploited
+	s.Reset()
+	o.values[2] = 1
+	o.counts[2] = 1
+	if arm := s.SelectArm(); arm != 3 {
+		t.Fatalf("expected leader 3 to be exploited, got %d", arm)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync/atomic"
+)
+
+// Pause serves the preferred variation to everyone, without using the
+// strategy, until Resume. Unlike Archive, the experiment continues where it
+// left off when resumed. Rewards of earlier selections are still applied.
+// Returns false if the experiment was paused already.
+func (e *Experiment) Pause() bool {
+	if !atomic.CompareAndSwapInt32(&e.paused, 0, 1) {
+		return false
+	}
+
+	e.changes.record(LifecyclePaused, 0)
+	return true
+}
+
+// Resume selects with the strategy again after Pause. Returns false if the
+// experiment was not paused.
+func (e *Experiment) Resume() bool {
+	if !atomic.CompareAndSwapInt32(&e.paused, 1, 0) {
+		return false
+	}
+
+	e.changes.record(LifecycleResumed, 0)
+	return true
+}
+
+// Paused returns true if the experiment is paused.
+func (e *Experiment) Paused() bool {
+	return atomic.LoadInt32(&e.paused) == 1
+}
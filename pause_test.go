@@ -0,0 +1,49 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	if !e.Pause() || e.Pause() {
+		t.Fatalf("expected only the first pause to change the experiment")
+	}
+
+	before := e.Strategy.Snapshot()
+	for i := 0; i < 100; i++ {
+		if got := e.Select().Ordinal; got != e.PreferredOrdinal {
+			t.Fatalf("expected paused experiment to serve %d, got %d", e.PreferredOrdinal, got)
+		}
+	}
+
+	if after := e.Strategy.Snapshot(); after.counts[0]+after.counts[1] != before.counts[0]+before.counts[1] {
+		t.Fatalf("expected strategy not to be used while paused")
+	}
+
+	preview, err := e.Preview()
+	if err != nil || preview.Distribution[e.PreferredOrdinal-1] != 1 {
+		t.Fatalf("expected preview of the preferred variation, got %v, %v", preview, err)
+	}
+
+	e.Resume()
+	if e.Paused() {
+		t.Fatalf("expected experiment to be resumed")
+	}
+
+	var kinds []string
+	for _, change := range e.Lifecycle() {
+		kinds = append(kinds, change.Kind)
+	}
+
+	if len(kinds) < 2 || kinds[len(kinds)-2] != LifecyclePaused || kinds[len(kinds)-1] != LifecycleResumed {
+		t.Fatalf("expected pause and resume in lifecycle, got %v", kinds)
+	}
+}
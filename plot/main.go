@@ -10,14 +10,19 @@ import (
 	"github.com/purzelrakete/bandit/math"
 	"github.com/purzelrakete/bandit/sim"
 	"log"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	mcSims    = flag.Int("mcSims", 5000, "monte carlo simulations to run")
 	mcHorizon = flag.Int("mcHorizon", 300, "trials per simulation")
 	mcMus     = flag.String("mcMus", "0.1,0.3,0.2,0.8", "bernoulli μs")
+	mcTrace   = flag.String("mcTrace", "", "log of selections and rewards to resample arms from, instead of bernoulli μs")
+	mcConfig  = flag.String("mcExperiments", "experiments.json", "experiments of the traced selections and rewards")
+	mcName    = flag.String("mcExperiment", "", "traced experiment name")
 )
 
 func init() {
@@ -33,6 +38,10 @@ func init() {
 //
 // plot -mus 0.22,0.1,0.7
 //
+// or resample arms from the selections and rewards logged in production:
+//
+// plot -mcTrace events.log -mcExperiment shape-20130822
+//
 func main() {
 	μs, bestArms, err := parseArms(*mcMus)
 	if err != nil {
@@ -45,6 +54,16 @@ func main() {
 		arms = append(arms, math.BernRand(μ))
 	}
 
+	// alternatively, resample the rewards recorded in production.
+	if *mcTrace != "" {
+		arms, bestArms, err = traceArms(*mcTrace, *mcConfig, *mcName)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		μs = make([]float64, len(arms))
+	}
+
 	// groups of graphs to draw
 	groups := []group{}
 
@@ -171,6 +190,40 @@ func main() {
 	}
 }
 
+// traceArms returns arms resampling the rewards of each variation of
+// experiment `name` in `config`, as logged to the file `trace`, and the best
+// arms (1 indexed).
+func traceArms(trace, config, name string) (arms, []int, error) {
+	e, err := bandit.NewExperiment(bandit.NewFileOpener(config), name)
+	if err != nil {
+		return arms{}, []int{}, fmt.Errorf("could not read experiment: %s", err.Error())
+	}
+
+	f, err := os.Open(trace)
+	if err != nil {
+		return arms{}, []int{}, fmt.Errorf("could not open trace: %s", err.Error())
+	}
+
+	defer f.Close()
+
+	events, err := bandit.ParseLog(f)
+	if err != nil {
+		return arms{}, []int{}, err
+	}
+
+	traces, err := bandit.Traces(events, e)
+	if err != nil {
+		return arms{}, []int{}, err
+	}
+
+	traced, best, err := sim.NewTraceArms(traces, time.Now().UnixNano())
+	if err != nil {
+		return arms{}, []int{}, err
+	}
+
+	return arms(traced), best, nil
+}
+
 // parseArms converts command line 0.1,0.2 into a slice of floats. Returns
 // the the best arm (1 indexed). In the case of equally good best arms there
 // will be multiple indices in the returned slice.
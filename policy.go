@@ -0,0 +1,100 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policy is an operational setting for calls to a dependency: how often to
+// retry, how long to wait for each attempt and how long to back off before
+// the first retry. The backoff doubles with each further retry.
+type Policy struct {
+	Retries int
+	Timeout time.Duration
+	Backoff time.Duration
+}
+
+// String returns information on this policy.
+func (p Policy) String() string {
+	return fmt.Sprintf("Policy(%d, %s, %s)", p.Retries, p.Timeout, p.Backoff)
+}
+
+// NewPolicies returns a selector over `policies`, backed by strategy `s` with
+// one arm per policy. Successful calls taking no longer than `target` are
+// rewarded 1, slower successful calls are rewarded target / latency and
+// failed calls are rewarded 0.
+func NewPolicies(s Strategy, policies []Policy, target time.Duration) (*Policies, error) {
+	if len(policies) == 0 {
+		return &Policies{}, fmt.Errorf("need at least one policy")
+	}
+
+	if c := s.Snapshot(); c.arms != len(policies) {
+		return &Policies{}, fmt.Errorf("%d arms but %d policies", c.arms, len(policies))
+	}
+
+	if !(target > 0) {
+		return &Policies{}, fmt.Errorf("target latency not in (0, ∞)")
+	}
+
+	for i, p := range policies {
+		if p.Retries < 0 || !(p.Timeout > 0) || p.Backoff < 0 {
+			return &Policies{}, fmt.Errorf("invalid policy %d: %s", i+1, p)
+		}
+	}
+
+	return &Policies{
+		strategy: s,
+		policies: policies,
+		target:   target,
+		sleep:    time.Sleep,
+	}, nil
+}
+
+// Policies selects a policy for each call and rewards the strategy with the
+// outcome of the call, so the best retry and timeout settings are learned
+// while serving traffic.
+type Policies struct {
+	strategy Strategy
+	policies []Policy
+	target   time.Duration
+	sleep    func(time.Duration)
+}
+
+// Do calls `f` under a selected policy and updates the strategy with the
+// outcome. `f` is passed the policy's timeout and must give up on its attempt
+// once it expires. Do returns the error of the last attempt.
+func (p *Policies) Do(f func(timeout time.Duration) error) error {
+	arm := p.strategy.SelectArm()
+	policy := p.policies[arm-1]
+
+	start := time.Now()
+	err := f(policy.Timeout)
+	for retry := 0; err != nil && retry < policy.Retries; retry++ {
+		p.sleep(policy.Backoff << uint(retry))
+		err = f(policy.Timeout)
+	}
+
+	p.strategy.Update(arm, policyReward(err, time.Since(start), p.target))
+	return err
+}
+
+// Policies returns the configured policies. Arm i corresponds to policy i-1.
+func (p *Policies) Policies() []Policy {
+	return p.policies
+}
+
+// policyReward rewards a call taking `latency` ending with `err`.
+func policyReward(err error, latency, target time.Duration) float64 {
+	if err != nil {
+		return 0
+	}
+
+	if latency <= target {
+		return 1
+	}
+
+	return float64(target) / float64(latency)
+}
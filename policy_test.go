@@ -0,0 +1,88 @@
+package bandit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPolicies(t *testing.T) {
+	s, err := NewEpsilonGreedy(2, 0)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	policies := []Policy{
+		{Retries: 0, Timeout: time.Second},
+		{Retries: 2, Timeout: time.Second, Backoff: time.Millisecond},
+	}
+
+	p, err := NewPolicies(s, policies, time.Hour)
+	if err != nil {
+		t.Fatalf("while creating policies: %s", err.Error())
+	}
+
+	var slept []time.Duration
+	p.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	// every call fails on its first attempt only
+	for i := 0; i < 50; i++ {
+		calls := 0
+		p.Do(func(timeout time.Duration) error {
+			if calls++; calls == 1 {
+				return fmt.Errorf("unavailable")
+			}
+
+			return nil
+		})
+	}
+
+	c := s.Snapshot()
+	if got := c.values[1]; got != 1 {
+		t.Fatalf("expected retrying policy to always succeed, got %f", got)
+	}
+
+	if got := c.values[0]; got != 0 {
+		t.Fatalf("expected single attempt policy to always fail, got %f", got)
+	}
+
+	for _, d := range slept {
+		if d != time.Millisecond {
+			t.Fatalf("expected first retry to back off 1ms, got %s", d)
+		}
+	}
+}
+
+func TestPolicyReward(t *testing.T) {
+	if got := policyReward(fmt.Errorf("failed"), time.Millisecond, time.Second); got != 0 {
+		t.Fatalf("expected failure to be rewarded 0, got %f", got)
+	}
+
+	if got := policyReward(nil, time.Millisecond, time.Second); got != 1 {
+		t.Fatalf("expected fast success to be rewarded 1, got %f", got)
+	}
+
+	if got := policyReward(nil, 2*time.Second, time.Second); got != 0.5 {
+		t.Fatalf("expected slow success to be rewarded 0.5, got %f", got)
+	}
+}
+
+func TestPoliciesInvalid(t *testing.T) {
+	s, err := NewEpsilonGreedy(1, 0)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	invalid := [][]Policy{
+		{},
+		{{Timeout: time.Second}, {Timeout: time.Second}},
+		{{Timeout: 0}},
+		{{Retries: -1, Timeout: time.Second}},
+	}
+
+	for _, policies := range invalid {
+		if _, err := NewPolicies(s, policies, time.Second); err == nil {
+			t.Fatalf("expected %v to be invalid", policies)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// NewPrefetcher wraps strategy `s`, so that selections can be computed ahead
+// of time with Prefetch and served by popping them from a queue.
+func NewPrefetcher(s Strategy) *Prefetcher {
+	return &Prefetcher{wrapper: wrapper{strategy: s}}
+}
+
+// Prefetcher serves precomputed selections for latency critical paths. The
+// queue is dropped whenever the state of the strategy changes, so precomputed
+// selections never outlive the policy they were drawn from. Dropped
+// selections are not counted as pulls, if the strategy keeps Counters.
+type Prefetcher struct {
+	wrapper
+	queue []int
+}
+
+// Prefetch tops up the queue to `n` selections under the current state of the
+// strategy. A Refuser is asked for affordable selections, and the queue stays
+// short once it refuses.
+func (p *Prefetcher) Prefetch(n int) {
+	p.Lock()
+	defer p.Unlock()
+
+	if _, ok := p.strategy.(Refuser); ok {
+		for len(p.queue) < n {
+			arm, ok := selectAffordableArm(p.strategy)
+			if !ok {
+				return
+			}
+
+			p.queue = append(p.queue, arm)
+		}
+
+		return
+	}
+
+	if missing := n - len(p.queue); missing > 0 {
+		arms := make([]int, missing)
+		SelectArms(p.strategy, arms)
+		p.queue = append(p.queue, arms...)
+	}
+}
+
+// Prefetched returns the number of queued selections.
+func (p *Prefetcher) Prefetched() int {
+	p.Lock()
+	defer p.Unlock()
+
+	return len(p.queue)
+}
+
+// invalidate drops the queue. Callers hold the lock.
+func (p *Prefetcher) invalidate() {
+	if len(p.queue) == 0 {
+		return
+	}
+
+	release(p.strategy, p.queue)
+	p.queue = p.queue[:0]
+}
+
+// SelectArm pops a prefetched selection, or selects from the strategy if the
+// queue is empty.
+func (p *Prefetcher) SelectArm() int {
+	p.Lock()
+	if len(p.queue) > 0 {
+		arm := p.queue[0]
+		p.queue = p.queue[1:]
+		p.Unlock()
+		return arm
+	}
+
+	p.Unlock()
+	return p.strategy.SelectArm()
+}
+
+// SelectAffordableArm pops a prefetched selection, or selects from the
+// strategy if the queue is empty, which may refuse.
+func (p *Prefetcher) SelectAffordableArm() (int, bool) {
+	p.Lock()
+	if len(p.queue) > 0 {
+		arm := p.queue[0]
+		p.queue = p.queue[1:]
+		p.Unlock()
+		return arm, true
+	}
+
+	p.Unlock()
+	return selectAffordableArm(p.strategy)
+}
+
+// SelectArms pops prefetched selections, and selects the rest from the
+// strategy.
+func (p *Prefetcher) SelectArms(arms []int) {
+	p.Lock()
+	n := copy(arms, p.queue)
+	p.queue = p.queue[n:]
+	p.Unlock()
+
+	if n < len(arms) {
+		SelectArms(p.strategy, arms[n:])
+	}
+}
+
+// Update drops prefetched selections and delegates to the wrapped strategy.
+func (p *Prefetcher) Update(arm int, reward float64) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.invalidate()
+	p.strategy.Update(arm, reward)
+}
+
+// UpdateBatch drops prefetched selections and delegates to the wrapped
+// strategy.
+func (p *Prefetcher) UpdateBatch(arm int, rewards []float64) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.invalidate()
+	UpdateBatch(p.strategy, arm, rewards)
+}
+
+// Init drops prefetched selections and delegates to the wrapped strategy. The
+// queue is not released, since the counts it was drawn from are replaced.
+func (p *Prefetcher) Init(c *Counters) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.queue = p.queue[:0]
+	return p.strategy.Init(c)
+}
+
+// Reset drops prefetched selections and delegates to the wrapped strategy.
+func (p *Prefetcher) Reset() {
+	p.ResetWithArchive()
+}
+
+// BreakTies drops prefetched selections and delegates to the wrapped strategy.
+func (p *Prefetcher) BreakTies(mode string) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.invalidate()
+	return breakTies(p.strategy, mode)
+}
+
+// UseSource drops prefetched selections, which were drawn from the replaced
+// source, and delegates to the wrapped strategy.
+func (p *Prefetcher) UseSource(src rand.Source) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.invalidate()
+	return useSource(p.strategy, src)
+}
+
+// Prioritize drops prefetched selections and delegates to the wrapped
+// strategy.
+func (p *Prefetcher) Prioritize(priorities []float64) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.invalidate()
+	return prioritize(p.strategy, priorities)
+}
+
+// Charge drops prefetched selections and delegates to the wrapped strategy.
+func (p *Prefetcher) Charge(costs []float64) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.invalidate()
+	return charge(p.strategy, costs)
+}
+
+// String returns information on this strategy.
+func (p *Prefetcher) String() string {
+	return fmt.Sprintf("Prefetcher(%s)", p.strategy)
+}
@@ -0,0 +1,65 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestPrefetcher(t *testing.T) {
+	s, err := NewEpsilonGreedy(2, 0)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	s.Init(&Counters{arms: 2, counts: []int{10, 10}, values: []float64{0.1, 0.9}, rand: NewCounters(2).rand})
+
+	p := NewPrefetcher(s)
+	p.Prefetch(5)
+	if got := p.Prefetched(); got != 5 {
+		t.Fatalf("expected 5 prefetched selections, got %d", got)
+	}
+
+	if got := p.SelectArm(); got != 2 {
+		t.Fatalf("expected prefetched arm 2, got %d", got)
+	}
+
+	if got := s.Snapshot().counts[1]; got != 15 {
+		t.Fatalf("expected prefetched selections to be pulls, got %d", got)
+	}
+
+	p.Update(2, 0.9)
+	if got := p.Prefetched(); got != 0 {
+		t.Fatalf("expected update to drop the queue, got %d", got)
+	}
+
+	if got := s.Snapshot().counts[1]; got != 11 {
+		t.Fatalf("expected unserved selections to be released, got %d", got)
+	}
+
+	if got := p.SelectArm(); got != 2 {
+		t.Fatalf("expected arm 2 from the strategy, got %d", got)
+	}
+}
+
+func TestPrefetcherBudget(t *testing.T) {
+	s, err := NewBudgetedUCB([]float64{1, 1}, 3)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	p := NewPrefetcher(s)
+	p.Prefetch(5)
+	if got := p.Prefetched(); got != 3 {
+		t.Fatalf("expected 3 affordable prefetched selections, got %d", got)
+	}
+
+	selected := 0
+	for i := 0; i < 5; i++ {
+		if _, ok := p.SelectAffordableArm(); ok {
+			selected++
+		}
+	}
+
+	if selected != 3 || s.Remaining() != 0 {
+		t.Fatalf("expected 3 selections within budget, got %d with %.2f remaining", selected, s.Remaining())
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+	"time"
+)
+
+// previewDraws is the number of posterior draws used to estimate the
+// distribution of Thompson sampling.
+const previewDraws = 1000
+
+// Previewer is implemented by strategies which can report the probability of
+// selecting each arm without selecting. All strategies in this package do.
+type Previewer interface {
+	Distribution() []float64
+}
+
+// Preview is the would-be allocation of an experiment. Distribution is the
+// probability of selecting each variation, in ordinal order. Best is the
+// variation with the highest estimated value.
+type Preview struct {
+	Distribution []float64
+	Best         Variation
+}
+
+// Preview returns the current allocation without selecting, so that no
+// counters change and nothing is logged. Disabled variations get probability
+// 0, an archived experiment always selects its winner and a paused one its
+// fallback.
+func (e *Experiment) Preview() (Preview, error) {
+	p, ok := e.Strategy.(Previewer)
+	if !ok {
+		return Preview{}, fmt.Errorf("%s does not support previews", e.Strategy)
+	}
+
+	c := e.Strategy.Snapshot()
+	best, bestValue := 0, math.Inf(-1)
+	for arm := 0; arm < c.arms; arm++ {
+		if value := c.estimate(arm, c.values[arm]); value > bestValue {
+			best, bestValue = arm, value
+		}
+	}
+
+	preview := Preview{
+		Distribution: make([]float64, len(e.Variations)),
+		Best:         e.armVariations()[best],
+	}
+
+	if winner, ok := e.Archived(); ok {
+		preview.Distribution[winner.Ordinal-1] = 1
+		return preview, nil
+	}
+
+	if e.Paused() || Killed() {
+		preview.Distribution[e.fallback("")-1] = 1
+		return preview, nil
+	}
+
+	arms := p.Distribution()
+	if len(arms) != e.Arms() {
+		return Preview{}, fmt.Errorf("%s does not support previews", e.Strategy)
+	}
+
+	total := 0.0
+	for i, v := range e.Variations {
+		if e.Disabled(v.Ordinal) {
+			continue
+		}
+
+		arm := e.Arm(v.Ordinal) - 1
+		members := 1
+		if e.members != nil {
+			members = len(e.members[arm])
+		}
+
+		preview.Distribution[i] = arms[arm] / float64(members)
+		total += preview.Distribution[i]
+	}
+
+	// Select serves the preferred variation if nothing else can be served.
+	if total == 0 {
+		preview.Distribution[e.PreferredOrdinal-1] = 1
+		return preview, nil
+	}
+
+	for i := range preview.Distribution {
+		preview.Distribution[i] /= total
+	}
+
+	return preview, nil
+}
+
+// Distribution returns the probability of selecting each arm.
+func (e *epsilonGreedy) Distribution() []float64 {
+	e.Lock()
+	defer e.Unlock()
+
+	_, best := bmath.Max(e.greedyEstimates())
+	epsilon := e.exploration()
+	distribution := make([]float64, e.arms)
+	for i := range distribution {
+		distribution[i] = epsilon / float64(e.arms)
+	}
+
+	if e.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	for _, arm := range best {
+		distribution[arm] += (1 - epsilon) / float64(len(best))
+	}
+
+	return distribution
+}
+
+// Distribution returns the probability of selecting each arm.
+func (s *softmax) Distribution() []float64 {
+	s.Lock()
+	defer s.Unlock()
+
+	s.distribution()
+	distribution := make([]float64, s.arms)
+	total := 0.0
+	for _, weight := range s.weights {
+		total += weight
+	}
+
+	for i, weight := range s.weights {
+		distribution[i] = weight / total
+	}
+
+	return distribution
+}
+
+// Distribution returns the probability of selecting each arm. Arms which were
+// never pulled are selected first.
+func (u *uCB1) Distribution() []float64 {
+	u.Lock()
+	defer u.Unlock()
+
+	_, best := bmath.Max(u.bounds())
+	if u.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	distribution := make([]float64, u.arms)
+	for _, arm := range best {
+		distribution[arm] = 1 / float64(len(best))
+	}
+
+	return distribution
+}
+
+// Distribution estimates the probability of each arm having the highest
+// posterior draw. It draws from its own random source, so that selections are
+// not affected.
+func (t *thompson) Distribution() []float64 {
+	t.Lock()
+	defer t.Unlock()
+
+	r := bmath.NewBetaRand(time.Now().UnixNano())
+	distribution := make([]float64, t.arms)
+	thetas := make([]float64, t.arms)
+	for draw := 0; draw < previewDraws; draw++ {
+		for i := 0; i < t.arms; i++ {
+			α, β := t.posterior(i, t.alpha)
+			thetas[i] = t.estimate(i, r.NextBeta(α, β))
+		}
+
+		_, best := bmath.Max(thetas)
+		for _, arm := range best {
+			distribution[arm] += 1 / float64(len(best)) / previewDraws
+		}
+	}
+
+	return distribution
+}
+
+// distribution returns the distribution of `s`, or nil if it is not a
+// Previewer.
+func distribution(s Strategy) []float64 {
+	p, ok := s.(Previewer)
+	if !ok {
+		return nil
+	}
+
+	return p.Distribution()
+}
@@ -0,0 +1,71 @@
+package bandit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	for _, name := range []string{"epsilonGreedy", "softmax", "ucb1", "thompson"} {
+		params := map[string][]float64{
+			"epsilonGreedy": {0.1},
+			"softmax":       {0.1},
+			"ucb1":          {},
+			"thompson":      {1},
+		}[name]
+
+		e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		e.Strategy, _ = New(2, name, params)
+		c := NewCounters(2)
+		c.counts[0], c.counts[1] = 1000, 1000
+		c.values[0], c.values[1] = 0.2, 0.5
+		e.Strategy.Init(&c)
+
+		before := Fingerprint(e.Strategy)
+		preview, err := e.Preview()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if Fingerprint(e.Strategy) != before {
+			t.Fatalf("expected %s preview not to change state", name)
+		}
+
+		if got := e.Strategy.Snapshot().counts; got[0] != 1000 || got[1] != 1000 {
+			t.Fatalf("expected %s preview not to count, got %v", name, got)
+		}
+
+		d := preview.Distribution
+		if math.Abs(d[0]+d[1]-1) > 1e-9 || d[1] < d[0] {
+			t.Fatalf("expected %s distribution favoring arm 2, got %v", name, d)
+		}
+
+		if preview.Best.Ordinal != 2 {
+			t.Fatalf("expected best variation 2, got %d", preview.Best.Ordinal)
+		}
+	}
+}
+
+func TestPreviewEpsilonGreedy(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.Strategy, _ = NewEpsilonGreedy(2, 0.1)
+	e.Update(2, 1)
+	preview, _ := e.Preview()
+	if d := preview.Distribution; math.Abs(d[0]-0.05) > 1e-9 || math.Abs(d[1]-0.95) > 1e-9 {
+		t.Fatalf("expected [0.05 0.95], got %v", d)
+	}
+
+	e.Disable(2)
+	preview, _ = e.Preview()
+	if d := preview.Distribution; d[0] != 1 || d[1] != 0 {
+		t.Fatalf("expected disabled variation to get nothing, got %v", d)
+	}
+}
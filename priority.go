@@ -0,0 +1,55 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+)
+
+// Prioritizer is implemented by strategies which weigh estimated values by
+// business priority at selection time. All strategies in this package do.
+type Prioritizer interface {
+	Prioritize(priorities []float64) error
+}
+
+// prioritize sets the priorities of `s`, which must be a Prioritizer.
+func prioritize(s Strategy, priorities []float64) error {
+	p, ok := s.(Prioritizer)
+	if !ok {
+		return fmt.Errorf("%s does not support priorities", s)
+	}
+
+	return p.Prioritize(priorities)
+}
+
+// Priorities returns the business priority of each variation, in ordinal
+// order. Priorities are declared per variation with "priority", and default
+// to 1.
+func (e *Experiment) Priorities() []float64 {
+	priorities := make([]float64, len(e.Variations))
+	for i, v := range e.Variations {
+		priorities[i] = v.Priority
+		if priorities[i] == 0 {
+			priorities[i] = 1
+		}
+	}
+
+	return priorities
+}
+
+// prioritize applies the variation priorities to the strategy. Variations in
+// a group must have the same priority.
+func (e *Experiment) prioritize() error {
+	arms := make([]float64, e.Arms())
+	for i, p := range e.Priorities() {
+		arm := e.Arm(e.Variations[i].Ordinal) - 1
+		if arms[arm] != 0 && arms[arm] != p {
+			return fmt.Errorf("variations in group %s have different priorities", e.Variations[i].Group)
+		}
+
+		arms[arm] = p
+	}
+
+	return prioritize(e.Strategy, arms)
+}
@@ -0,0 +1,57 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestPrioritize(t *testing.T) {
+	for _, name := range []string{"epsilonGreedy", "softmax", "ucb1", "thompson"} {
+		params := map[string][]float64{
+			"epsilonGreedy": {0},
+			"softmax":       {0.01},
+			"ucb1":          {},
+			"thompson":      {1},
+		}[name]
+
+		s, err := New(2, name, params)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		c := NewCounters(2)
+		c.counts[0], c.counts[1] = 1000, 1000
+		c.values[0], c.values[1] = 0.5, 0.4
+		if err := s.Init(&c); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		before := Fingerprint(s)
+		if err := prioritize(s, []float64{1, 1.5}); err != nil {
+			t.Fatalf("could not prioritize %s: %s", name, err.Error())
+		}
+
+		if Fingerprint(s) == before {
+			t.Fatalf("expected priorities to change the fingerprint of %s", name)
+		}
+
+		second := 0
+		for i := 0; i < 100; i++ {
+			if s.SelectArm() == 2 {
+				second++
+			}
+		}
+
+		if second < 90 {
+			t.Fatalf("expected prioritized arm to be selected by %s, got %d of 100", name, second)
+		}
+	}
+
+	s := NewUCB1(2)
+	if err := prioritize(s, []float64{1, 0}); err == nil {
+		t.Fatalf("expected error on priority 0")
+	}
+
+	if err := prioritize(s, []float64{1}); err == nil {
+		t.Fatalf("expected error on missing priority")
+	}
+}
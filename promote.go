@@ -0,0 +1,111 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// EventPromoted is emitted when an experiment's winner was promoted.
+const EventPromoted = "promoted"
+
+// Promoter rolls out the winning variation of a converged experiment, e.g. by
+// calling a deployment API, flipping a feature flag or rewriting config.
+type Promoter interface {
+	Promote(e *Experiment, winner Variation) error
+}
+
+// PromoterFunc adapts an ordinary function to the Promoter interface.
+type PromoterFunc func(e *Experiment, winner Variation) error
+
+// Promote calls f(e, winner).
+func (f PromoterFunc) Promote(e *Experiment, winner Variation) error {
+	return f(e, winner)
+}
+
+// Convergence decides if an experiment has converged given its stats, and
+// returns the 1 indexed winning ordinal.
+type Convergence func(s Stats) (int, bool)
+
+// ShareConvergence considers an experiment converged once it has at least
+// `pulls` pulls in total, and a single arm received at least `share` of them.
+// Pooled counts of a group are counted once.
+func ShareConvergence(pulls int, share float64) Convergence {
+	return func(s Stats) (int, bool) {
+		total, best, seen := 0, ArmStats{}, make(map[string]bool)
+		for _, arm := range s.Arms {
+			if arm.Group != "" && seen[arm.Group] {
+				continue
+			}
+
+			seen[arm.Group] = true
+			total += arm.Count
+			if arm.Count > best.Count {
+				best = arm
+			}
+		}
+
+		if total == 0 || total < pulls {
+			return 0, false
+		}
+
+		return best.Ordinal, float64(best.Count)/float64(total) >= share
+	}
+}
+
+// Promote checks the experiment for convergence. If it converged, the winner
+// is handed to `p` and, if that succeeds, the experiment is archived and an
+// EventPromoted is sent to `n`. Returns true if the winner was promoted.
+func (e *Experiment) Promote(c Convergence, p Promoter, n Notifier) (bool, error) {
+	if _, ok := e.Archived(); ok {
+		return false, nil
+	}
+
+	ordinal, ok := c(e.Stats())
+	if !ok {
+		return false, nil
+	}
+
+	winner, err := e.GetVariation(ordinal)
+	if err != nil {
+		return false, fmt.Errorf("invalid winner: %s", err.Error())
+	}
+
+	if err := p.Promote(e, winner); err != nil {
+		return false, fmt.Errorf("could not promote %s: %s", winner.Tag, err.Error())
+	}
+
+	e.Archive(ordinal)
+	if n != nil {
+		n.Notify(Event{
+			Time:       time.Now(),
+			Kind:       EventPromoted,
+			Experiment: e.Name,
+			Arm:        ordinal,
+			Message:    fmt.Sprintf("promoted %s", winner.Tag),
+		})
+	}
+
+	return true, nil
+}
+
+// Archive stops the experiment. From now on, Select always returns the
+// variation with the given 1 indexed ordinal and the strategy is not used.
+func (e *Experiment) Archive(ordinal int) {
+	atomic.StoreInt32(&e.winner, int32(ordinal))
+	e.changes.record(LifecycleArchived, ordinal)
+}
+
+// Archived returns the winning variation if the experiment was archived.
+func (e *Experiment) Archived() (Variation, bool) {
+	ordinal := int(atomic.LoadInt32(&e.winner))
+	if ordinal == 0 {
+		return Variation{}, false
+	}
+
+	v, err := e.GetVariation(ordinal)
+	return v, err == nil
+}
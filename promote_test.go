@@ -0,0 +1,62 @@
+package bandit
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPromote(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	var promoted []Variation
+	p := PromoterFunc(func(e *Experiment, winner Variation) error {
+		promoted = append(promoted, winner)
+		return nil
+	})
+
+	converged := ShareConvergence(100, 0.9)
+	if ok, err := e.Promote(converged, p, nil); ok || err != nil {
+		t.Fatalf("expected fresh experiment not to converge: %v", err)
+	}
+
+	c := NewCounters(2)
+	c.counts[0], c.counts[1] = 5, 95
+	if err := e.Strategy.Init(&c); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	failing := PromoterFunc(func(e *Experiment, winner Variation) error {
+		return fmt.Errorf("deployment API down")
+	})
+
+	if ok, err := e.Promote(converged, failing, nil); ok || err == nil {
+		t.Fatalf("expected failed promotion not to archive")
+	}
+
+	if _, ok := e.Archived(); ok {
+		t.Fatalf("expected experiment to keep running after failed promotion")
+	}
+
+	var events []Event
+	n := NotifierFunc(func(e Event) { events = append(events, e) })
+	if ok, err := e.Promote(converged, p, n); !ok || err != nil {
+		t.Fatalf("expected promotion: %v", err)
+	}
+
+	if len(promoted) != 1 || promoted[0].Ordinal != 2 {
+		t.Fatalf("expected ordinal 2 to be promoted, got %v", promoted)
+	}
+
+	if len(events) != 1 || events[0].Kind != EventPromoted {
+		t.Fatalf("expected promotion event, got %v", events)
+	}
+
+	for i := 0; i < 10; i++ {
+		if got := e.Select().Ordinal; got != 2 {
+			t.Fatalf("expected archived experiment to serve the winner, got %d", got)
+		}
+	}
+}
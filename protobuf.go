@@ -0,0 +1,205 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// protobufCodec writes the protobuf wire format of
+//
+//	message State {
+//	  repeated string tags = 1;
+//	  repeated string urls = 2;
+//	  repeated int64 counts = 3 [packed = true];
+//	  repeated double values = 4 [packed = true];
+//	  repeated Annotation annotations = 5;
+//	  uint64 revision = 6;
+//	  bytes strategy = 7;
+//	}
+//
+//	message Annotation {
+//	  int64 time_unix_nano = 1;
+//	  string tag = 2;
+//	  string text = 3;
+//	}
+//
+// by hand, so that no generated code is needed. Unknown fields are skipped.
+type protobufCodec struct{}
+
+// Marshal encodes `s` as a protobuf State message.
+func (protobufCodec) Marshal(s State) ([]byte, error) {
+	var buf []byte
+	for _, tag := range s.Tags {
+		buf = appendBytes(buf, 1, []byte(tag))
+	}
+
+	for _, url := range s.URLs {
+		buf = appendBytes(buf, 2, []byte(url))
+	}
+
+	var counts []byte
+	for _, count := range s.Counts {
+		counts = binary.AppendUvarint(counts, uint64(count))
+	}
+
+	var values []byte
+	for _, value := range s.Values {
+		values = binary.LittleEndian.AppendUint64(values, math.Float64bits(value))
+	}
+
+	buf = appendBytes(buf, 3, counts)
+	buf = appendBytes(buf, 4, values)
+	for _, a := range s.Annotations {
+		var annotation []byte
+		annotation = appendKey(annotation, 1, wireVarint)
+		annotation = binary.AppendUvarint(annotation, uint64(a.Time.UnixNano()))
+		annotation = appendBytes(annotation, 2, []byte(a.Tag))
+		annotation = appendBytes(annotation, 3, []byte(a.Text))
+		buf = appendBytes(buf, 5, annotation)
+	}
+
+	if s.Revision != 0 {
+		buf = appendKey(buf, 6, wireVarint)
+		buf = binary.AppendUvarint(buf, s.Revision)
+	}
+
+	if s.Strategy != nil {
+		buf = appendBytes(buf, 7, s.Strategy)
+	}
+
+	return buf, nil
+}
+
+// Unmarshal decodes a protobuf State message into `s`.
+func (protobufCodec) Unmarshal(data []byte, s *State) error {
+	*s = State{}
+	return decodeFields(data, func(field int, wire int, varint uint64, payload []byte) error {
+		switch {
+		case field == 1 && wire == wireBytes:
+			s.Tags = append(s.Tags, string(payload))
+		case field == 2 && wire == wireBytes:
+			s.URLs = append(s.URLs, string(payload))
+		case field == 3 && wire == wireBytes:
+			for len(payload) > 0 {
+				count, n := binary.Uvarint(payload)
+				if n <= 0 {
+					return fmt.Errorf("bad count")
+				}
+
+				s.Counts = append(s.Counts, int(count))
+				payload = payload[n:]
+			}
+		case field == 4 && wire == wireBytes:
+			if len(payload)%8 != 0 {
+				return fmt.Errorf("bad values")
+			}
+
+			for i := 0; i < len(payload); i += 8 {
+				s.Values = append(s.Values, math.Float64frombits(binary.LittleEndian.Uint64(payload[i:])))
+			}
+		case field == 5 && wire == wireBytes:
+			var a Annotation
+			err := decodeFields(payload, func(field int, wire int, varint uint64, payload []byte) error {
+				switch {
+				case field == 1 && wire == wireVarint:
+					a.Time = time.Unix(0, int64(varint)).UTC()
+				case field == 2 && wire == wireBytes:
+					a.Tag = string(payload)
+				case field == 3 && wire == wireBytes:
+					a.Text = string(payload)
+				}
+
+				return nil
+			})
+
+			if err != nil {
+				return fmt.Errorf("bad annotation: %s", err.Error())
+			}
+
+			s.Annotations = append(s.Annotations, a)
+		case field == 6 && wire == wireVarint:
+			s.Revision = varint
+		case field == 7 && wire == wireBytes:
+			s.Strategy = append([]byte{}, payload...)
+		}
+
+		return nil
+	})
+}
+
+// appendKey appends the key of field `field` with wire type `wire`.
+func appendKey(buf []byte, field, wire int) []byte {
+	return binary.AppendUvarint(buf, uint64(field<<3|wire))
+}
+
+// appendBytes appends length delimited `payload` as field `field`.
+func appendBytes(buf []byte, field int, payload []byte) []byte {
+	buf = appendKey(buf, field, wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(payload)))
+	return append(buf, payload...)
+}
+
+// decodeFields calls `f` for each field in the message `data`. Varints are
+// passed in `varint`, length delimited and fixed width fields in `payload`.
+func decodeFields(data []byte, f func(field, wire int, varint uint64, payload []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("bad field key")
+		}
+
+		data = data[n:]
+		field, wire := int(key>>3), int(key&7)
+
+		var varint uint64
+		var payload []byte
+		switch wire {
+		case wireVarint:
+			varint, n = binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("bad varint in field %d", field)
+			}
+		case wireFixed64, wireFixed32:
+			n = 8
+			if wire == wireFixed32 {
+				n = 4
+			}
+
+			if len(data) < n {
+				return fmt.Errorf("truncated field %d", field)
+			}
+
+			payload = data[:n]
+		case wireBytes:
+			length, m := binary.Uvarint(data)
+			if m <= 0 || uint64(len(data)-m) < length {
+				return fmt.Errorf("truncated field %d", field)
+			}
+
+			payload, n = data[m:m+int(length)], m+int(length)
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", wire, field)
+		}
+
+		if err := f(field, wire, varint, payload); err != nil {
+			return err
+		}
+
+		data = data[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// maxEventSize bounds the size of a single event read from a stream, so that a
+// corrupt size cannot exhaust memory.
+const maxEventSize = 1 << 20
+
+// MarshalLogEvent encodes `e` as a protobuf LogEvent message, see
+// events.proto. Like the state codec, it is written by hand so that no
+// generated code is needed.
+func MarshalLogEvent(e LogEvent) ([]byte, error) {
+	var event []byte
+	event = appendKey(event, 1, wireVarint)
+	event = binary.AppendUvarint(event, uint64(e.Time.UnixNano()))
+	event = appendBytes(event, 2, []byte(e.Tag))
+
+	switch e.Kind {
+	case banditSelection:
+		if e.Policy != "" {
+			event = appendBytes(event, 3, []byte(e.Policy))
+		}
+
+		if e.Weight > 1 {
+			event = appendKey(event, 4, wireVarint)
+			event = binary.AppendUvarint(event, uint64(e.Weight))
+		}
+
+		return appendBytes(nil, 1, event), nil
+	case banditReward:
+		event = appendKey(event, 3, wireFixed64)
+		event = binary.LittleEndian.AppendUint64(event, math.Float64bits(e.Reward))
+		return appendBytes(nil, 2, event), nil
+	}
+
+	return []byte{}, fmt.Errorf("unknown event kind '%s'", e.Kind)
+}
+
+// UnmarshalLogEvent decodes a protobuf LogEvent message. Unknown fields are
+// skipped.
+func UnmarshalLogEvent(data []byte) (LogEvent, error) {
+	var e LogEvent
+	err := decodeFields(data, func(field int, wire int, varint uint64, payload []byte) error {
+		if wire != wireBytes || (field != 1 && field != 2) {
+			return nil
+		}
+
+		e = LogEvent{Kind: banditSelection}
+		if field == 2 {
+			e.Kind = banditReward
+		}
+
+		return decodeFields(payload, func(field int, wire int, varint uint64, payload []byte) error {
+			switch {
+			case field == 1 && wire == wireVarint:
+				e.Time = time.Unix(0, int64(varint))
+			case field == 2 && wire == wireBytes:
+				e.Tag = string(payload)
+			case field == 3 && wire == wireBytes && e.Kind == banditSelection:
+				e.Policy = string(payload)
+			case field == 4 && wire == wireVarint && e.Kind == banditSelection:
+				e.Weight = int(varint)
+			case field == 3 && wire == wireFixed64 && e.Kind == banditReward:
+				e.Reward = math.Float64frombits(binary.LittleEndian.Uint64(payload))
+			}
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		return LogEvent{}, fmt.Errorf("bad event: %s", err.Error())
+	}
+
+	if e.Kind == "" {
+		return LogEvent{}, fmt.Errorf("event is neither a selection nor a reward")
+	}
+
+	return e, nil
+}
+
+// NewProtobufSink returns a sink which writes events to `w` as a stream of
+// length delimited protobuf LogEvent messages, e.g. a file or a message
+// queue producer. Use with an Exporter.
+func NewProtobufSink(w io.Writer) Sink {
+	return &protobufSink{w: w}
+}
+
+// protobufSink writes each batch at once, so that batches are not interleaved.
+type protobufSink struct {
+	sync.Mutex
+	w io.Writer
+}
+
+// Insert writes all events.
+func (s *protobufSink) Insert(events []LogEvent) error {
+	var buf []byte
+	for _, event := range events {
+		data, err := MarshalLogEvent(event)
+		if err != nil {
+			return err
+		}
+
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		buf = append(buf, data...)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	_, err := s.w.Write(buf)
+	return err
+}
+
+// ParseProtobufLog reads a stream of length delimited protobuf LogEvent
+// messages, as written by NewProtobufSink. The events can be replayed and
+// evaluated like those of ParseLog.
+func ParseProtobufLog(r io.Reader) ([]LogEvent, error) {
+	var events []LogEvent
+	br := bufio.NewReader(r)
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return events, nil
+		}
+
+		if err != nil {
+			return []LogEvent{}, fmt.Errorf("event %d: bad size: %s", len(events)+1, err.Error())
+		}
+
+		if size > maxEventSize {
+			return []LogEvent{}, fmt.Errorf("event %d: size %d too large", len(events)+1, size)
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return []LogEvent{}, fmt.Errorf("event %d: truncated: %s", len(events)+1, err.Error())
+		}
+
+		event, err := UnmarshalLogEvent(data)
+		if err != nil {
+			return []LogEvent{}, fmt.Errorf("event %d: %s", len(events)+1, err.Error())
+		}
+
+		events = append(events, event)
+	}
+}
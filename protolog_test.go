@@ -0,0 +1,59 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestProtobufLog(t *testing.T) {
+	events := []LogEvent{
+		{Time: time.Unix(1378823906, 0), Kind: banditSelection, Tag: "shape-20130822:1", Policy: "0a1b2c3d-7"},
+		{Time: time.Unix(1378823907, 0), Kind: banditSelection, Tag: "shape-20130822:2", Weight: 100},
+		{Time: time.Unix(1378823908, 5), Kind: banditReward, Tag: "shape-20130822:1", Reward: -1.5},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := NewProtobufSink(buf).Insert(events); err != nil {
+		t.Fatalf("could not write: %s", err.Error())
+	}
+
+	parsed, err := ParseProtobufLog(buf)
+	if err != nil {
+		t.Fatalf("could not parse: %s", err.Error())
+	}
+
+	if len(parsed) != len(events) {
+		t.Fatalf("expected %d events, got %d", len(events), len(parsed))
+	}
+
+	for i, got := range parsed {
+		expected := events[i]
+		if !got.Time.Equal(expected.Time) || got.Kind != expected.Kind || got.Tag != expected.Tag ||
+			got.Policy != expected.Policy || got.Weight != expected.Weight || got.Reward != expected.Reward {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+
+	// the wire format is fixed by events.proto
+	data, err := MarshalLogEvent(LogEvent{Time: time.Unix(0, 1), Kind: banditReward, Tag: "a", Reward: 1})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	golden := []byte{0x12, 0x0e, 0x08, 0x01, 0x12, 0x01, 'a', 0x19, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f}
+	if !bytes.Equal(data, golden) {
+		t.Fatalf("expected % x, got % x", golden, data)
+	}
+
+	if _, err := MarshalLogEvent(LogEvent{Kind: "BanditEvent"}); err == nil {
+		t.Fatalf("expected error on unknown kind")
+	}
+
+	if _, err := ParseProtobufLog(bytes.NewReader([]byte{0x05, 0x12})); err == nil {
+		t.Fatalf("expected error on truncated stream")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// NewReadThrough wraps a strategy whose state is kept in a remote store under
+// `key`. Selections are served from the wrapped strategy's in memory state,
+// which is refreshed from the store every `staleness` in the background, so
+// store latency never is on the request path. Stored state is only used if
+// its tags are the arm `tags`, see Experiment.Tags; nil tags are not checked.
+// The state is read once before returning; a missing key starts from scratch.
+// Close stops refreshing.
+func NewReadThrough(s Strategy, store Store, key string, tags []string, staleness time.Duration) (Strategy, error) {
+	if !(staleness > 0) {
+		return &readThrough{}, fmt.Errorf("staleness not in (0, ∞)")
+	}
+
+	r := &readThrough{
+		wrapper: wrapper{strategy: s},
+		store:   store,
+		key:     key,
+		tags:    tags,
+		done:    make(chan bool),
+	}
+
+	if err := r.refresh(); err != nil {
+		return &readThrough{}, fmt.Errorf("could not read %s: %s", key, err.Error())
+	}
+
+	go func(done chan bool) {
+		t := time.NewTicker(staleness)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if err := r.refresh(); err != nil {
+					log.Printf("could not refresh %s: %s", key, err.Error())
+				}
+			case <-done:
+				return
+			}
+		}
+	}(r.done)
+
+	return r, nil
+}
+
+// readThrough is an in memory cache of state in a store. Rewards are applied
+// to the cached state, so that selections reflect them until the next
+// refresh replaces it. Whoever aggregates rewards, for example a primary
+// running SaveExperiments, must write them to the store.
+type readThrough struct {
+	wrapper
+	store     Store
+	key       string
+	tags      []string
+	refreshed time.Time
+	revision  uint64    // stored revision of the cached state. 0 if unknown.
+	done      chan bool // closed to stop refreshing. nil if stopped.
+}
+
+// refresh replaces the cached state with the stored state, unless the stored
+// revision is the one already cached.
+func (r *readThrough) refresh() error {
+	data, err := r.store.Get(r.key)
+	if err == ErrNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var stored State
+	if err := codec(r.store).Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("could not unmarshal: %s", err.Error())
+	}
+
+	if len(stored.Counts) != len(stored.Values) {
+		return fmt.Errorf("%d counts but %d values", len(stored.Counts), len(stored.Values))
+	}
+
+	if r.tags != nil && stored.Tags != nil && strings.Join(stored.Tags, " ") != strings.Join(r.tags, " ") {
+		return fmt.Errorf("stored tags %v are not %v", stored.Tags, r.tags)
+	}
+
+	r.Lock()
+	unchanged := stored.Revision != 0 && stored.Revision == r.revision
+	r.Unlock()
+
+	if !unchanged {
+		c := newState(len(stored.Counts))
+		copy(c.counts, stored.Counts)
+		copy(c.values, stored.Values)
+		if err := r.strategy.Init(c); err != nil {
+			return err
+		}
+
+		if err := unmarshalState(r.strategy, stored.Strategy); err != nil {
+			return err
+		}
+	}
+
+	r.Lock()
+	r.refreshed = time.Now()
+	r.revision = stored.Revision
+	r.Unlock()
+
+	return nil
+}
+
+// Refreshed returns the time of the last successful refresh from the store.
+// It is zero if the key was never found.
+func (r *readThrough) Refreshed() time.Time {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.refreshed
+}
+
+// Close stops refreshing and closes the wrapped strategy.
+func (r *readThrough) Close() error {
+	r.Lock()
+	if r.done != nil {
+		close(r.done)
+		r.done = nil
+	}
+	r.Unlock()
+
+	return closeStrategy(r.strategy)
+}
+
+// String returns information on this strategy.
+func (r *readThrough) String() string {
+	return fmt.Sprintf("ReadThrough(%s)", r.strategy)
+}
@@ -0,0 +1,104 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadThrough(t *testing.T) {
+	eg, err := NewEpsilonGreedy(2, 0)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	store := mapStore{}
+	s, err := NewReadThrough(eg, store, "shape", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("while creating read through: %s", err.Error())
+	}
+
+	if got := s.(*readThrough).Refreshed(); !got.IsZero() {
+		t.Fatalf("expected no refresh for missing key, got %v", got)
+	}
+
+	store["shape"] = []byte(`{"counts": [10, 20], "values": [0.1, 0.9]}`)
+	if err := s.(*readThrough).refresh(); err != nil {
+		t.Fatalf("while refreshing: %s", err.Error())
+	}
+
+	if got := s.Snapshot().counts[1]; got != 20 {
+		t.Fatalf("expected 20 cached pulls, got %d", got)
+	}
+
+	if got := s.SelectArm(); got != 2 {
+		t.Fatalf("expected arm 2 from cached state, got %d", got)
+	}
+
+	store["shape"] = []byte(`{"counts": [10, 20, 30], "values": [0.1, 0.9, 0.2]}`)
+	if err := s.(*readThrough).refresh(); err == nil {
+		t.Fatalf("expected mismatched arms to fail refresh")
+	}
+
+	if got := s.Snapshot().counts[1]; got != 21 {
+		t.Fatalf("expected failed refresh to keep cached state, got %d", got)
+	}
+}
+
+func TestReadThroughRevision(t *testing.T) {
+	eg, err := NewEpsilonGreedy(2, 0)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	store := mapStore{"shape": []byte(`{"tags": ["shape:1", "shape:2"], "counts": [10, 20], "values": [0.1, 0.9], "revision": 7}`)}
+	s, err := NewReadThrough(eg, store, "shape", []string{"shape:1", "shape:2"}, time.Hour)
+	if err != nil {
+		t.Fatalf("while creating read through: %s", err.Error())
+	}
+
+	defer closeStrategy(s)
+
+	// local rewards are kept until the stored revision changes
+	s.Update(1, 1)
+	if err := s.(*readThrough).refresh(); err != nil {
+		t.Fatalf("while refreshing: %s", err.Error())
+	}
+
+	if got := s.Snapshot().values[0]; got == 0.1 {
+		t.Fatalf("expected unchanged revision to keep local state, got %f", got)
+	}
+
+	store["shape"] = []byte(`{"tags": ["shape:1", "shape:2"], "counts": [12, 20], "values": [0.1, 0.9], "revision": 8}`)
+	if err := s.(*readThrough).refresh(); err != nil {
+		t.Fatalf("while refreshing: %s", err.Error())
+	}
+
+	if got := s.Snapshot().counts[0]; got != 12 {
+		t.Fatalf("expected new revision to replace local state, got %d pulls", got)
+	}
+
+	store["shape"] = []byte(`{"tags": ["shape:2", "shape:1"], "counts": [1, 2], "values": [0.9, 0.1], "revision": 9}`)
+	if err := s.(*readThrough).refresh(); err == nil {
+		t.Fatalf("expected mismatched tags to fail refresh")
+	}
+
+	if got := s.Snapshot().counts[0]; got != 12 {
+		t.Fatalf("expected failed refresh to keep cached state, got %d pulls", got)
+	}
+}
+
+func TestReadThroughInvalid(t *testing.T) {
+	eg, err := NewEpsilonGreedy(2, 0)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	if _, err := NewReadThrough(eg, mapStore{}, "shape", nil, 0); err == nil {
+		t.Fatalf("expected zero staleness to fail")
+	}
+
+	store := mapStore{"shape": []byte(`{`)}
+	if _, err := NewReadThrough(eg, store, "shape", nil, time.Hour); err == nil {
+		t.Fatalf("expected malformed state to fail")
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Policies for restored state which does not match the current variations.
+const (
+	RestoreFail  = "fail"       // refuse to start
+	RestoreReset = "reset"      // start the experiment from scratch
+	RestoreByTag = "map-by-tag" // keep the state of arms whose variation is still present
+)
+
+// Kinds of changes made while restoring state.
+const (
+	ChangeStateReset  = "state-reset"
+	ChangeStateMapped = "state-mapped"
+	ChangeStateLost   = "state-lost"
+)
+
+// RestoreExperiments initializes the strategies of all experiments from `s`.
+// Experiments without stored state are left untouched. Stored state must
+// match the current arms of an experiment, by number and, if stored, by tag.
+// Mismatches are reconciled according to `policy` and reported as changes,
+// ordered by experiment name.
+func RestoreExperiments(s Store, es *Experiments, policy string) ([]Change, error) {
+	if policy != RestoreFail && policy != RestoreReset && policy != RestoreByTag {
+		return []Change{}, fmt.Errorf("unknown restore policy '%s'", policy)
+	}
+
+	all, names := es.All(), []string{}
+	for name := range all {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var changes []Change
+	for _, name := range names {
+		e := all[name]
+		data, err := s.Get(name)
+		if err == ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return []Change{}, fmt.Errorf("could not get %s: %s", name, err.Error())
+		}
+
+		var stored State
+		if err := codec(s).Unmarshal(data, &stored); err != nil {
+			return []Change{}, fmt.Errorf("could not unmarshal %s: %s", name, err.Error())
+		}
+
+		if len(stored.Counts) != len(stored.Values) {
+			return []Change{}, fmt.Errorf("%s has %d counts but %d values", name, len(stored.Counts), len(stored.Values))
+		}
+
+		c, reconciled, err := reconcile(e, stored, policy)
+		if err != nil {
+			return []Change{}, err
+		}
+
+		changes = append(changes, reconciled...)
+		if err := e.Strategy.Init(c); err != nil {
+			return []Change{}, fmt.Errorf("could not restore %s: %s", name, err.Error())
+		}
+
+		// strategy state is per arm, and lost with any arm
+		if len(reconciled) == 0 {
+			if err := unmarshalState(e.Strategy, stored.Strategy); err != nil {
+				return []Change{}, fmt.Errorf("could not restore %s: %s", name, err.Error())
+			}
+		}
+
+		e.annotations.restore(stored.Annotations)
+	}
+
+	return changes, nil
+}
+
+// reconcile returns the counters to restore experiment `e` with.
+func reconcile(e *Experiment, stored State, policy string) (*Counters, []Change, error) {
+	var tags, urls []string
+	for _, v := range e.armVariations() {
+		tags, urls = append(tags, v.Tag), append(urls, v.URL)
+	}
+
+	c := newState(len(tags))
+
+	consistent := len(stored.Counts) == len(tags)
+	if consistent && stored.Tags != nil {
+		consistent = strings.Join(stored.Tags, " ") == strings.Join(tags, " ")
+	}
+
+	if consistent {
+		copy(c.counts, stored.Counts)
+		copy(c.values, stored.Values)
+		return c, nil, nil
+	}
+
+	detail := fmt.Sprintf("stored %d arms %v, configured %d arms %v", len(stored.Counts), stored.Tags, len(tags), tags)
+	switch policy {
+	case RestoreReset:
+		return c, []Change{{e.Name, ChangeStateReset, detail}}, nil
+	case RestoreByTag:
+		if stored.Tags == nil {
+			return nil, nil, fmt.Errorf("cannot map %s by tag: stored state has no tags", e.Name)
+		}
+	default:
+		return nil, nil, fmt.Errorf("restored state of %s does not match its variations: %s", e.Name, detail)
+	}
+
+	// arms are mapped by tag. a stored tag which is gone may still be a
+	// renumbered variation, found by its url, unless several arms share it.
+	byTag, byURL, shared := make(map[string]int), make(map[string]int), make(map[string]bool)
+	for arm := range tags {
+		if _, ok := byURL[urls[arm]]; ok {
+			shared[urls[arm]] = true
+		}
+
+		byTag[tags[arm]], byURL[urls[arm]] = arm, arm
+	}
+
+	for from, url := range stored.URLs {
+		for _, other := range stored.URLs[:from] {
+			if other == url {
+				shared[url] = true
+			}
+		}
+	}
+
+	targets, taken := make([]int, len(stored.Tags)), make(map[int]bool)
+	for from, tag := range stored.Tags {
+		targets[from] = -1
+		if to, ok := byTag[tag]; ok && from < len(stored.Counts) {
+			targets[from], taken[to] = to, true
+		}
+	}
+
+	for from := range stored.Tags {
+		if targets[from] >= 0 || from >= len(stored.URLs) || from >= len(stored.Counts) {
+			continue
+		}
+
+		url := stored.URLs[from]
+		if to, ok := byURL[url]; ok && !shared[url] && !taken[to] {
+			targets[from], taken[to] = to, true
+		}
+	}
+
+	var changes []Change
+	for from, to := range targets {
+		tag := stored.Tags[from]
+		if to < 0 {
+			changes = append(changes, Change{e.Name, ChangeStateLost, tag})
+			continue
+		}
+
+		c.counts[to] = stored.Counts[from]
+		c.values[to] = stored.Values[from]
+		if from != to {
+			changes = append(changes, Change{e.Name, ChangeStateMapped, fmt.Sprintf("%s: arm %d -> %d", tag, from+1, to+1)})
+		}
+	}
+
+	return c, changes, nil
+}
+
+// Tags returns the tag of each arm, in arm order. Arms of grouped variations
+// are tagged with the first variation of the group.
+func (e *Experiment) Tags() []string {
+	var tags []string
+	for _, v := range e.armVariations() {
+		tags = append(tags, v.Tag)
+	}
+
+	return tags
+}
+
+// armVariations returns the variation of each strategy arm. A group of
+// variations is represented by its lowest ordinal.
+func (e *Experiment) armVariations() []Variation {
+	vs := make([]Variation, e.Arms())
+	for i := len(e.Variations) - 1; i >= 0; i-- {
+		v := e.Variations[i]
+		vs[e.Arm(v.Ordinal)-1] = v
+	}
+
+	return vs
+}
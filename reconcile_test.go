@@ -0,0 +1,92 @@
+package bandit
+
+import (
+	"testing"
+)
+
+// mapStore is an in memory Store.
+type mapStore map[string][]byte
+
+func (s mapStore) Get(key string) ([]byte, error) {
+	value, ok := s[key]
+	if !ok {
+		return []byte{}, ErrNotFound
+	}
+
+	return value, nil
+}
+
+func (s mapStore) Put(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+func TestRestoreReconcile(t *testing.T) {
+	// circle was 1 and square was 2. square is now 1, circle is gone.
+	s := mapStore{"shape-20130822": []byte(`{
+		"tags": ["shape-20130822:1", "shape-20130822:2"],
+		"urls": ["http://localhost:8080/widget?shape=circle", "http://localhost:8080/widget?shape=square"],
+		"counts": [10, 20],
+		"values": [0.25, 0.5]
+	}`)}
+
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	e.Variations = Variations{{Ordinal: 1, Tag: "shape-20130822:1", URL: "http://localhost:8080/widget?shape=square"}}
+	e.Strategy = NewUCB1(1)
+
+	if _, err := RestoreExperiments(s, es, RestoreFail); err == nil {
+		t.Fatalf("expected mismatch to fail")
+	}
+
+	changes, err := RestoreExperiments(s, es, RestoreReset)
+	if err != nil || len(changes) != 1 || changes[0].Kind != ChangeStateReset {
+		t.Fatalf("expected reset, got %v %v", changes, err)
+	}
+
+	// tags come first: square took over circle's tag, and keeps its state
+	changes, err = RestoreExperiments(s, es, RestoreByTag)
+	if err != nil {
+		t.Fatalf("could not map by tag: %s", err.Error())
+	}
+
+	if len(changes) != 1 || changes[0].Kind != ChangeStateLost {
+		t.Fatalf("expected square's old tag to be lost, got %v", changes)
+	}
+
+	got := e.Strategy.Snapshot()
+	if got.counts[0] != 10 || got.values[0] != 0.25 {
+		t.Fatalf("expected state of tag 1 on arm 1, got %v %v", got.counts, got.values)
+	}
+
+	// tags which are gone are found by url, unless it is shared
+	for stored, expected := range map[string]int{
+		`"urls": ["http://localhost/circle", "http://localhost/square"]`: 20,
+		`"urls": ["http://localhost/circle", "http://localhost/circle"]`: 0,
+	} {
+		s["shape-20130822"] = []byte(`{"tags": ["shape-20130822:1", "shape-20130822:3"], ` + stored + `,
+			"counts": [10, 20], "values": [0.25, 0.5]}`)
+
+		e.Variations = Variations{
+			{Ordinal: 1, Tag: "shape-20130822:1", URL: "http://localhost/circle"},
+			{Ordinal: 2, Tag: "shape-20130822:2", URL: "http://localhost/square"},
+		}
+		e.Strategy = NewUCB1(2)
+
+		if _, err := RestoreExperiments(s, es, RestoreByTag); err != nil {
+			t.Fatalf("could not map by tag: %s", err.Error())
+		}
+
+		if got := e.Strategy.Snapshot(); got.counts[0] != 10 || got.counts[1] != expected {
+			t.Fatalf("expected counts [10 %d] with %s, got %v", expected, stored, got.counts)
+		}
+	}
+
+	if _, err := RestoreExperiments(s, es, "guess"); err == nil {
+		t.Fatalf("expected error on unknown policy")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogEvent is a single selection or reward line read back from the logs.
+type LogEvent struct {
+	Time   time.Time
+	Kind   string // BanditSelection or BanditReward
+	Tag    string // variation tag, without pinning timestamp
+	Reward float64
+	Policy string // fingerprint of the selecting policy, if logged
+	Weight int    // selections a sampled selection line stands for, if above 1
+}
+
+// weight returns the number of selections of a selection event.
+func (e LogEvent) weight() int {
+	if e.Weight > 1 {
+		return e.Weight
+	}
+
+	return 1
+}
+
+// ParseLog reads selection and reward lines as written by SelectionLine and
+// RewardLine. Lines may carry a prefix, such as the date and time added by the
+// standard logger. Lines which are neither selections nor rewards are skipped.
+func ParseLog(r io.Reader) ([]LogEvent, error) {
+	var events []LogEvent
+	scanner := bufio.NewScanner(r)
+	for lines := 1; scanner.Scan(); lines++ {
+		fields := strings.Fields(scanner.Text())
+
+		kind := -1
+		for i, field := range fields {
+			if field == banditSelection || field == banditReward {
+				kind = i
+				break
+			}
+		}
+
+		if kind < 1 || kind+1 >= len(fields) {
+			continue
+		}
+
+		ts, err := strconv.ParseInt(fields[kind-1], 10, 64)
+		if err != nil {
+			return []LogEvent{}, fmt.Errorf("line %d: bad timestamp: %s", lines, err.Error())
+		}
+
+		event := LogEvent{
+			Time: time.Unix(ts, 0),
+			Kind: fields[kind],
+			Tag:  fields[kind+1],
+		}
+
+		if event.Kind == banditSelection && kind+2 < len(fields) {
+			event.Policy = fields[kind+2]
+		}
+
+		if event.Kind == banditSelection && kind+3 < len(fields) {
+			weight, err := strconv.Atoi(fields[kind+3])
+			if err != nil || weight < 1 {
+				return []LogEvent{}, fmt.Errorf("line %d: bad weight %s", lines, fields[kind+3])
+			}
+
+			event.Weight = weight
+		}
+
+		if event.Kind == banditReward {
+			if kind+2 >= len(fields) {
+				return []LogEvent{}, fmt.Errorf("line %d: reward missing", lines)
+			}
+
+			reward, err := strconv.ParseFloat(fields[kind+2], 64)
+			if err != nil {
+				return []LogEvent{}, fmt.Errorf("line %d: bad reward: %s", lines, err.Error())
+			}
+
+			event.Reward = reward
+		}
+
+		events = append(events, event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return []LogEvent{}, fmt.Errorf("could not read log: %s", err.Error())
+	}
+
+	return events, nil
+}
+
+// Replay rebuilds the counters of experiment `e` as they were at time `at`,
+// using all logged events up to and including `at`. Events of other
+// experiments are ignored.
+func Replay(events []LogEvent, e *Experiment, at time.Time) (*Counters, error) {
+	if len(e.Variations) == 0 {
+		return nil, fmt.Errorf("experiment %s has no variations", e.Name)
+	}
+
+	c := newState(e.Arms())
+	for _, event := range events {
+		if event.Time.After(at) {
+			continue
+		}
+
+		v, ok := replayVariation(e, event.Tag)
+		if !ok {
+			continue
+		}
+
+		arm := e.Arm(v.Ordinal) - 1
+		switch event.Kind {
+		case banditSelection:
+			c.counts[arm] += event.weight()
+		case banditReward:
+			// the selection may have been logged before the replayed period.
+			if c.counts[arm] == 0 {
+				c.counts[arm] = 1
+			}
+
+			c.Update(arm+1, event.Reward)
+		}
+	}
+
+	return c, nil
+}
+
+// replayVariation finds the variation given a logged tag, which may or may
+// not include the pinning timestamp.
+func replayVariation(e *Experiment, tag string) (Variation, bool) {
+	if v, err := e.GetTaggedVariation(tag); err == nil {
+		return v, true
+	}
+
+	if untimed, _, err := TimestampedTagToTag(tag); err == nil {
+		if v, err := e.GetTaggedVariation(untimed); err == nil {
+			return v, true
+		}
+	}
+
+	return Variation{}, false
+}
+
+// SelectAt answers which variation strategy `s` would have selected for
+// experiment `e` at time `at`. The strategy is initialized with the replayed
+// state, so pass a fresh strategy rather than the one serving traffic.
+func SelectAt(events []LogEvent, e *Experiment, s Strategy, at time.Time) (Variation, error) {
+	c, err := Replay(events, e, at)
+	if err != nil {
+		return Variation{}, err
+	}
+
+	if err := s.Init(c); err != nil {
+		return Variation{}, fmt.Errorf("could not init strategy: %s", err.Error())
+	}
+
+	arm := s.SelectArm()
+	if arm < 1 || arm > e.Arms() {
+		return Variation{}, fmt.Errorf("selected impossible arm %d", arm)
+	}
+
+	return e.GetVariation(e.pick(arm))
+}
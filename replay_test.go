@@ -0,0 +1,61 @@
+package bandit
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	log := strings.Join([]string{
+		"2013/09/15 15:13:04 1379257984 BanditSelection shape-20130822:1",
+		"2013/09/15 15:13:04 1379257985 BanditSelection shape-20130822:2",
+		"2013/09/15 15:13:04 1379257986 BanditReward shape-20130822:2:1379257985 1.000000",
+		"2013/09/15 15:13:04 1379257987 BanditSelection plants-20121111:1",
+		"2013/09/15 15:13:04 1379257988 BanditEvent anomaly shape-20130822 1 burst",
+		"2013/09/15 15:13:04 1379258000 BanditSelection shape-20130822:1",
+		"2013/09/15 15:13:04 1379258001 BanditReward shape-20130822:1 1.000000",
+	}, "\n")
+
+	events, err := ParseLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("could not parse log: %s", err.Error())
+	}
+
+	if got, expected := len(events), 6; got != expected {
+		t.Fatalf("expected %d events, got %d", expected, got)
+	}
+
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	c, err := Replay(events, e, time.Unix(1379257990, 0))
+	if err != nil {
+		t.Fatalf("could not replay: %s", err.Error())
+	}
+
+	if got := c.counts; got[0] != 1 || got[1] != 1 {
+		t.Fatalf("expected a single pull per arm, got %v", got)
+	}
+
+	if got := c.values; got[0] != 0 || got[1] != 1 {
+		t.Fatalf("expected values [0 1], got %v", got)
+	}
+
+	strategy, err := NewEpsilonGreedy(len(e.Variations), 0)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	v, err := SelectAt(events, e, strategy, time.Unix(1379257990, 0))
+	if err != nil {
+		t.Fatalf("could not select: %s", err.Error())
+	}
+
+	if expected := "shape-20130822:2"; v.Tag != expected {
+		t.Fatalf("expected %s at time T, got %s", expected, v.Tag)
+	}
+}
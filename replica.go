@@ -0,0 +1,165 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamDeltas streams state changes of the primary's experiments `es` to a
+// read only replica. Each delta is a line
+//
+// <experiment> <arm> <count> <value>
+//
+// with a 1 indexed arm. A batch of deltas is terminated by a blank line. The
+// first batch contains all arms; after that, only arms which changed since
+// the previous batch are sent, every `interval`. Returns when writing fails.
+// Over HTTP, the stream is a long lived response, which replicas read with
+// Replicate.
+func StreamDeltas(es *Experiments, w io.Writer, interval time.Duration) error {
+	sent := make(map[string]*Counters)
+	for {
+		if err := writeDeltas(es, w, sent); err != nil {
+			return err
+		}
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// writeDeltas writes a single batch of deltas and remembers what was sent.
+func writeDeltas(es *Experiments, w io.Writer, sent map[string]*Counters) error {
+	all, names := es.All(), []string{}
+	for name := range all {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		current := all[name].Strategy.Snapshot()
+		previous, ok := sent[name]
+		for arm := 0; arm < current.arms; arm++ {
+			if ok && previous.arms == current.arms &&
+				previous.counts[arm] == current.counts[arm] &&
+				previous.values[arm] == current.values[arm] {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "%s %d %d %v\n",
+				name, arm+1, current.counts[arm], current.values[arm]); err != nil {
+				return fmt.Errorf("could not write delta: %s", err.Error())
+			}
+		}
+
+		sent[name] = current
+	}
+
+	if _, err := fmt.Fprintln(w); err != nil {
+		return fmt.Errorf("could not write delta: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ApplyDeltas reads deltas written by StreamDeltas from `r` and initializes
+// the matching strategies of the replica's experiments `es` after each batch.
+// Deltas of unknown experiments are ignored. Returns at the end of the stream.
+func ApplyDeltas(es *Experiments, r io.Reader) error {
+	state := make(map[string]*Counters)
+	changed := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			for name := range changed {
+				e, ok := es.Get(name)
+				if !ok {
+					continue
+				}
+
+				if err := e.Strategy.Init(state[name].Snapshot()); err != nil {
+					log.Printf("could not apply deltas to %s: %s", name, err.Error())
+				}
+			}
+
+			changed = make(map[string]bool)
+			continue
+		}
+
+		if len(fields) != 4 {
+			return fmt.Errorf("delta does not have 4 fields: '%s'", scanner.Text())
+		}
+
+		name := fields[0]
+		e, ok := es.Get(name)
+		if !ok {
+			continue
+		}
+
+		arm, err := strconv.Atoi(fields[1])
+		if err != nil || arm < 1 || arm > e.Arms() {
+			return fmt.Errorf("invalid arm in delta: '%s'", scanner.Text())
+		}
+
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return fmt.Errorf("invalid count in delta: %s", err.Error())
+		}
+
+		value, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return fmt.Errorf("invalid value in delta: %s", err.Error())
+		}
+
+		// an apply on either side may change the number of arms. the primary
+		// then sends all arms again.
+		if c, ok := state[name]; !ok || c.arms != e.Arms() {
+			state[name] = newState(e.Arms())
+		}
+
+		state[name].counts[arm-1] = count
+		state[name].values[arm-1] = value
+		changed[name] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read deltas: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Replicate keeps the replica's experiments `es` in sync with the primary
+// behind `o`, reconnecting after `retry` whenever the stream ends. Never
+// returns.
+func Replicate(es *Experiments, o Opener, retry time.Duration) {
+	for {
+		stream, err := o.Open()
+		if err != nil {
+			log.Printf("could not connect to primary: %s", err.Error())
+		} else {
+			if err := ApplyDeltas(es, stream); err != nil {
+				log.Printf("replication failed: %s", err.Error())
+			}
+
+			stream.Close()
+		}
+
+		time.Sleep(retry)
+	}
+}
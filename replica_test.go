@@ -0,0 +1,115 @@
+package bandit
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReplicateDeltas(t *testing.T) {
+	primary, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	replica, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	strategy := primary.index["shape-20130822"].Strategy
+	arm := strategy.SelectArm()
+	strategy.Update(arm, 1.0)
+
+	sent := make(map[string]*Counters)
+	w := new(bytes.Buffer)
+	if err := writeDeltas(primary, w, sent); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := strings.Count(w.String(), "\n"); got != 3 {
+		t.Fatalf("expected a full batch of 2 arms, got '%s'", w.String())
+	}
+
+	// only changed arms are sent
+	arm = strategy.SelectArm()
+	strategy.Update(arm, 0.0)
+	if err := writeDeltas(primary, w, sent); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got := strings.Count(w.String(), "\n"); got != 5 {
+		t.Fatalf("expected a single delta, got '%s'", w.String())
+	}
+
+	if err := ApplyDeltas(replica, w); err != nil {
+		t.Fatalf("could not apply deltas: %s", err.Error())
+	}
+
+	expected := strategy.Snapshot()
+	got := replica.index["shape-20130822"].Strategy.Snapshot()
+	for i := range expected.counts {
+		if got.counts[i] != expected.counts[i] || got.values[i] != expected.values[i] {
+			t.Fatalf("expected replica %v %v, got %v %v",
+				expected.counts, expected.values, got.counts, got.values)
+		}
+	}
+}
+
+func TestReplicateResizedDeltas(t *testing.T) {
+	config := []byte(`[{"experiment_name": "shape-20130822", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "variations": [
+		{"ordinal": 1, "url": "http://localhost:8080/widget?shape=circle"}]}]`)
+
+	replica, err := NewExperiments(NewBytesOpener(config))
+	if err != nil {
+		t.Fatalf("could not read config: %s", err.Error())
+	}
+
+	// square is added on the replica between the two batches
+	r := &chunkedReader{chunks: []string{
+		"shape-20130822 1 1 1\n\n",
+		"shape-20130822 1 2 0.5\nshape-20130822 2 3 1\n\n",
+	}, between: func() {
+		next, err := NewExperiments(NewFileOpener("experiments.json"))
+		if err != nil {
+			t.Fatalf("while reading experiment fixture: %s", err.Error())
+		}
+
+		if _, err := replica.Apply(next, RestoreReset); err != nil {
+			t.Fatalf("could not apply: %s", err.Error())
+		}
+	}}
+
+	if err := ApplyDeltas(replica, r); err != nil {
+		t.Fatalf("could not apply deltas: %s", err.Error())
+	}
+
+	got := replica.index["shape-20130822"].Strategy.Snapshot()
+	if got.counts[0] != 2 || got.counts[1] != 3 {
+		t.Fatalf("expected replica counts [2 3], got %v", got.counts)
+	}
+}
+
+// chunkedReader returns one chunk per read, and calls `between` before each
+// chunk but the first.
+type chunkedReader struct {
+	chunks  []string
+	read    int
+	between func()
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if r.read == len(r.chunks) {
+		return 0, io.EOF
+	}
+
+	if r.read > 0 {
+		r.between()
+	}
+
+	n := copy(p, r.chunks[r.read])
+	r.read++
+	return n, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Archiver is implemented by strategies which can reset atomically while
+// serving and return the state they had at the moment of the reset. All
+// strategies in this package do.
+type Archiver interface {
+	ResetWithArchive() *Counters
+}
+
+// ResetWithArchive resets the strategy of the experiment under live traffic
+// and returns the replaced state, which callers may store for later analysis.
+// Each concurrent selection and reward applies either to the replaced state or
+// to the fresh one, never to both. Rewards buffered by wrapping strategies are
+// dropped. An EventReset is emitted to `n`, if not nil.
+func (e *Experiment) ResetWithArchive(n Notifier) *Counters {
+	archive := resetWithArchive(e.Strategy)
+	if n != nil {
+		pulls := 0
+		for _, count := range archive.counts {
+			pulls += count
+		}
+
+		n.Notify(Event{
+			Time:       time.Now(),
+			Kind:       EventReset,
+			Experiment: e.Name,
+			Message:    fmt.Sprintf("archived %d pulls at revision %d", pulls, archive.revision),
+		})
+	}
+
+	return archive
+}
+
+// resetWithArchive resets `s` and returns its state from just before. If `s`
+// is not an Archiver, rewards applied between snapshot and reset are missing
+// from the archive.
+func resetWithArchive(s Strategy) *Counters {
+	if a, ok := s.(Archiver); ok {
+		return a.ResetWithArchive()
+	}
+
+	archive := s.Snapshot()
+	s.Reset()
+	return archive
+}
+
+// ResetWithArchive resets the counts and values and returns the replaced ones.
+// Priorities, costs, tie breaking and discount are kept.
+func (c *Counters) ResetWithArchive() *Counters {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.reset()
+}
+
+// reset swaps in fresh counts and values without locking and returns the
+// replaced state. The replaced slices are no longer referenced by `c`, so they
+// are handed out without copying.
+func (c *Counters) reset() *Counters {
+	archive := newState(c.arms)
+	archive.counts, archive.values = c.counts, c.values
+	archive.revision = c.revision
+	if c.priorities != nil {
+		archive.priorities = append([]float64{}, c.priorities...)
+	}
+
+	if c.costs != nil {
+		archive.costs = append([]float64{}, c.costs...)
+	}
+
+	c.counts = make([]int, c.arms)
+	c.values = make([]float64, c.arms)
+	c.revision++
+
+	return archive
+}
+
+// ResetWithArchive resets the counters and drops all reward windows at once.
+func (u *windowedUCB1) ResetWithArchive() *Counters {
+	u.Lock()
+	defer u.Unlock()
+
+	u.rewards = newWindows(u.arms, u.window)
+	return u.reset()
+}
+
+// ResetWithArchive restarts all control charts and delegates to the wrapped
+// strategy.
+func (a *anomalyDetector) ResetWithArchive() *Counters {
+	a.Lock()
+	defer a.Unlock()
+
+	a.charts = make([]ewmaChart, len(a.charts))
+	return resetWithArchive(a.strategy)
+}
+
+// ResetWithArchive drops buffered rewards and delegates to the wrapped
+// strategy. It waits for a running flush, so that a flush never applies to
+// both the replaced and the fresh state.
+func (b *batchedStrategy) ResetWithArchive() *Counters {
+	b.Lock()
+	defer b.Unlock()
+
+	b.pending = make(map[int][]float64)
+	b.buffered = 0
+	return resetWithArchive(b.strategy)
+}
+
+// ResetWithArchive drops prefetched selections and delegates to the wrapped
+// strategy.
+func (p *Prefetcher) ResetWithArchive() *Counters {
+	p.Lock()
+	defer p.Unlock()
+
+	p.queue = p.queue[:0]
+	return resetWithArchive(p.strategy)
+}
@@ -0,0 +1,72 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestResetWithArchiveUnderTraffic(t *testing.T) {
+	s, err := NewEpsilonGreedy(2, 0.5)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	e := &Experiment{Name: "shape-20130822", Strategy: s}
+	var events []Event
+	n := NotifierFunc(func(ev Event) { events = append(events, ev) })
+
+	workers, selections := 4, 1000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < selections; i++ {
+				s.Update(s.SelectArm(), 1)
+			}
+		}()
+	}
+
+	archive := e.ResetWithArchive(n)
+	wg.Wait()
+
+	pulls := 0
+	for _, c := range [][]int{archive.counts, s.Snapshot().counts} {
+		for _, count := range c {
+			pulls += count
+		}
+	}
+
+	if pulls != workers*selections {
+		t.Fatalf("expected %d pulls across archive and fresh state, got %d", workers*selections, pulls)
+	}
+
+	if len(events) != 1 || events[0].Kind != EventReset || events[0].Experiment != e.Name {
+		t.Fatalf("expected a reset event, got %v", events)
+	}
+}
+
+func TestResetWithArchiveBatched(t *testing.T) {
+	inner := NewUCB1(2)
+	b, err := NewBatched(inner, 10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	inner.SelectArm()
+	inner.Update(1, 1)
+	b.Update(2, 1)
+
+	archive := (&Experiment{Strategy: b}).ResetWithArchive(nil)
+	if archive.counts[0] != 1 || archive.values[0] != 1 {
+		t.Fatalf("expected archived reward on arm 1, got %v %v", archive.counts, archive.values)
+	}
+
+	b.(*batchedStrategy).Flush()
+	if fresh := inner.Snapshot(); fresh.values[1] != 0 || fresh.counts[0] != 0 {
+		t.Fatalf("expected buffered reward to be dropped, got %v %v", fresh.counts, fresh.values)
+	}
+}
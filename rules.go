@@ -0,0 +1,369 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ruleInterval is how often experiments evaluate their rules.
+const ruleInterval = 10 * time.Second
+
+// ruleBuckets is the number of buckets the shortest window of a metric is
+// split into. Observations are summed per bucket, so memory does not grow
+// with traffic and windows are exact to a bucket.
+const ruleBuckets = 60
+
+const (
+	// EventRule is emitted when a rule fires.
+	EventRule = "rule"
+
+	// ActionDisable stops serving the arm.
+	ActionDisable = "disable"
+
+	// ActionNotify reports the arm to the notifier.
+	ActionNotify = "notify"
+)
+
+// Rule is a declarative condition on the windowed mean of a metric per arm,
+// along with the actions taken when an arm meets it. Rules are written as
+//
+// <metric> <|> <threshold>[%] over <window> then <action>[ and <action>]
+//
+// for example "error_rate > 2% over 10m then disable and notify".
+type Rule struct {
+	Metric    string
+	Above     bool // fire above the threshold. otherwise below it.
+	Threshold float64
+	Window    time.Duration
+	Actions   []string
+}
+
+// ParseRule parses a rule in the form documented on Rule.
+func ParseRule(s string) (Rule, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 7 || fields[3] != "over" || fields[5] != "then" {
+		return Rule{}, fmt.Errorf("rule '%s' not in form '<metric> <op> <threshold> over <window> then <action>'", s)
+	}
+
+	r := Rule{Metric: fields[0]}
+	switch fields[1] {
+	case ">":
+		r.Above = true
+	case "<":
+		r.Above = false
+	default:
+		return Rule{}, fmt.Errorf("unknown operator '%s' in rule '%s'", fields[1], s)
+	}
+
+	threshold, scale := fields[2], 1.0
+	if strings.HasSuffix(threshold, "%") {
+		threshold, scale = strings.TrimSuffix(threshold, "%"), 0.01
+	}
+
+	t, err := strconv.ParseFloat(threshold, 64)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid threshold in rule '%s': %s", s, err.Error())
+	}
+
+	r.Threshold = t * scale
+
+	window, err := time.ParseDuration(fields[4])
+	if err != nil || !(window > 0) {
+		return Rule{}, fmt.Errorf("invalid window in rule '%s'", s)
+	}
+
+	r.Window = window
+
+	for i, action := range fields[6:] {
+		if i%2 == 1 {
+			if action != "and" {
+				return Rule{}, fmt.Errorf("expected 'and' between actions in rule '%s'", s)
+			}
+
+			continue
+		}
+
+		switch action {
+		case ActionDisable, ActionNotify:
+			r.Actions = append(r.Actions, action)
+		default:
+			return Rule{}, fmt.Errorf("unknown action '%s' in rule '%s'", action, s)
+		}
+	}
+
+	if len(fields[6:])%2 == 0 {
+		return Rule{}, fmt.Errorf("missing action after 'and' in rule '%s'", s)
+	}
+
+	return r, nil
+}
+
+// String formats the rule as it would be declared.
+func (r Rule) String() string {
+	op := "<"
+	if r.Above {
+		op = ">"
+	}
+
+	return fmt.Sprintf("%s %s %v over %s then %s",
+		r.Metric, op, r.Threshold, r.Window, strings.Join(r.Actions, " and "))
+}
+
+// met returns true if `mean` meets the rule's condition.
+func (r Rule) met(mean float64) bool {
+	if r.Above {
+		return mean > r.Threshold
+	}
+
+	return mean < r.Threshold
+}
+
+// has returns true if the rule takes `action`.
+func (r Rule) has(action string) bool {
+	for _, a := range r.Actions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewRules returns an engine evaluating the experiment's rules against
+// observed metrics. Events are sent to `n`, which may be nil. Experiments
+// with rules start their own engine, see Experiment.Observe.
+func NewRules(e *Experiment, n Notifier) *Rules {
+	metrics := make(map[string]*ruleMetric)
+	for _, rule := range e.Rules {
+		m, ok := metrics[rule.Metric]
+		if !ok {
+			m = &ruleMetric{width: rule.Window, buckets: make([][]ruleBucket, len(e.Variations))}
+			metrics[rule.Metric] = m
+		}
+
+		if rule.Window < m.width {
+			m.width = rule.Window
+		}
+
+		if rule.Window > m.window {
+			m.window = rule.Window
+		}
+	}
+
+	for _, m := range metrics {
+		m.width /= ruleBuckets
+	}
+
+	return &Rules{
+		experiment: e,
+		notifier:   n,
+		metrics:    metrics,
+		firing:     make([][]bool, len(e.Rules)),
+		done:       make(chan bool),
+	}
+}
+
+// Rules evaluates the experiment's rules. A rule fires once when an arm starts
+// meeting its condition, and may fire again after the arm recovered. Arms
+// without observations inside a rule's window never meet it.
+type Rules struct {
+	sync.Mutex
+	experiment *Experiment
+	notifier   Notifier
+	metrics    map[string]*ruleMetric // metric -> per arm observations
+	firing     [][]bool               // rule -> per arm firing
+	done       chan bool
+}
+
+// ruleMetric sums the observations of a metric per arm in buckets of equal
+// width, covering the longest window of the rules on the metric.
+type ruleMetric struct {
+	width   time.Duration
+	window  time.Duration
+	buckets [][]ruleBucket // per arm, oldest first
+}
+
+// ruleBucket sums the observations made from `start` for the width of the
+// bucket.
+type ruleBucket struct {
+	start time.Time
+	sum   float64
+	n     int
+}
+
+// observe adds `value` to the bucket of 0 indexed `arm` at time `at`.
+// Observations arriving out of order are added to the bucket they belong to.
+func (m *ruleMetric) observe(arm int, value float64, at time.Time) {
+	start, buckets := at.Truncate(m.width), m.buckets[arm]
+	i := len(buckets)
+	for i > 0 && buckets[i-1].start.After(start) {
+		i--
+	}
+
+	if i > 0 && buckets[i-1].start.Equal(start) {
+		buckets[i-1].sum += value
+		buckets[i-1].n++
+		return
+	}
+
+	buckets = append(buckets, ruleBucket{})
+	copy(buckets[i+1:], buckets[i:])
+	buckets[i] = ruleBucket{start: start, sum: value, n: 1}
+	m.buckets[arm] = buckets
+}
+
+// mean returns the mean of the observations of 0 indexed `arm` in buckets
+// starting inside `window` before `now`, and their number.
+func (m *ruleMetric) mean(arm int, window time.Duration, now time.Time) (float64, int) {
+	sum, n := 0.0, 0
+	for _, b := range m.buckets[arm] {
+		if now.Sub(b.start) <= window {
+			sum, n = sum+b.sum, n+b.n
+		}
+	}
+
+	if n == 0 {
+		return 0, 0
+	}
+
+	return sum / float64(n), n
+}
+
+// Observe records `value` of `metric` for 1 indexed `arm` at time `at`.
+// Metrics without rules are dropped.
+func (r *Rules) Observe(arm int, metric string, value float64, at time.Time) {
+	r.Lock()
+	defer r.Unlock()
+
+	if m, ok := r.metrics[metric]; ok {
+		m.observe(arm-1, value, at)
+	}
+}
+
+// Evaluate checks all rules at time `now`, takes the actions of rules which
+// started firing and returns the resulting events.
+func (r *Rules) Evaluate(now time.Time) []Event {
+	r.Lock()
+	r.expire(now)
+
+	var fired, notify []Event
+	var disable []int
+	for i, rule := range r.experiment.Rules {
+		if r.firing[i] == nil {
+			r.firing[i] = make([]bool, len(r.experiment.Variations))
+		}
+
+		m := r.metrics[rule.Metric]
+		for arm := range m.buckets {
+			mean, n := m.mean(arm, rule.Window, now)
+			met := n > 0 && rule.met(mean)
+			if met && !r.firing[i][arm] {
+				e := Event{
+					Time:       now,
+					Kind:       EventRule,
+					Experiment: r.experiment.Name,
+					Arm:        arm + 1,
+					Message:    fmt.Sprintf("%s: %f", rule, mean),
+				}
+
+				fired = append(fired, e)
+				if rule.has(ActionNotify) {
+					notify = append(notify, e)
+				}
+
+				if rule.has(ActionDisable) {
+					disable = append(disable, arm+1)
+				}
+			}
+
+			r.firing[i][arm] = met
+		}
+	}
+	r.Unlock()
+
+	for _, arm := range disable {
+		r.experiment.Disable(arm)
+	}
+
+	if r.notifier != nil {
+		for _, e := range notify {
+			r.notifier.Notify(e)
+		}
+	}
+
+	return fired
+}
+
+// Run evaluates the rules every `interval` until the engine is closed.
+func (r *Rules) Run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case now := <-t.C:
+			r.Evaluate(now)
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// Close stops Run.
+func (r *Rules) Close() error {
+	close(r.done)
+	return nil
+}
+
+// expire drops buckets starting before the longest window of their metric.
+func (r *Rules) expire(now time.Time) {
+	for _, m := range r.metrics {
+		for arm, buckets := range m.buckets {
+			i := 0
+			for i < len(buckets) && now.Sub(buckets[i].start) > m.window {
+				i++
+			}
+
+			m.buckets[arm] = buckets[i:]
+		}
+	}
+}
+
+// startRules starts an engine for the experiment's rules and channels for its
+// guardrails, if it has any. Events are logged. The engine is stopped when the
+// experiment is closed.
+func (e *Experiment) startRules() {
+	if len(e.Guardrails) > 0 {
+		e.channels = NewChannels(e, NewLogNotifier())
+	}
+
+	if len(e.Rules) == 0 {
+		return
+	}
+
+	e.rules = NewRules(e, NewLogNotifier())
+	go e.rules.Run(ruleInterval)
+}
+
+// Observe records `value` of a secondary `metric`, such as an error rate, for
+// the variation with the given ordinal, and feeds it to the experiment's
+// guardrails and rules. Metrics of experiments without either are dropped.
+func (e *Experiment) Observe(ordinal int, metric string, value float64) {
+	if ordinal < 1 || ordinal > len(e.Variations) {
+		return
+	}
+
+	if e.channels != nil {
+		e.channels.Observe(ordinal, metric, value)
+	}
+
+	if e.rules != nil {
+		e.rules.Observe(ordinal, metric, value, time.Now())
+	}
+}
@@ -0,0 +1,129 @@
+package bandit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	r, err := ParseRule("error_rate > 2% over 10m then disable and notify")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if r.Metric != "error_rate" || !r.Above || r.Threshold != 0.02 ||
+		r.Window != 10*time.Minute || len(r.Actions) != 2 {
+		t.Fatalf("unexpected rule %v", r)
+	}
+
+	for _, invalid := range []string{
+		"error_rate > 2%",
+		"error_rate = 2% over 10m then notify",
+		"error_rate > 2% over 10m then explode",
+		"error_rate > 2% over 10m then notify and",
+		"error_rate > 2% over forever then notify",
+	} {
+		if _, err := ParseRule(invalid); err == nil {
+			t.Fatalf("expected error parsing '%s'", invalid)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	rule, err := ParseRule("error_rate > 2% over 10m then disable and notify")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.Rules = []Rule{rule}
+
+	var events []Event
+	r := NewRules(e, NotifierFunc(func(e Event) { events = append(events, e) }))
+	start := time.Unix(1379257984, 0)
+
+	// errors on arm 2 long ago are outside of the window
+	r.Observe(2, "error_rate", 1, start)
+	for i := 0; i < 100; i++ {
+		r.Observe(1, "error_rate", 0, start.Add(time.Hour))
+		r.Observe(2, "error_rate", 0, start.Add(time.Hour))
+	}
+
+	if fired := r.Evaluate(start.Add(time.Hour)); len(fired) != 0 {
+		t.Fatalf("expected no rule to fire, got %v", fired)
+	}
+
+	// observations are summed per bucket, expired buckets are dropped
+	if buckets := r.metrics["error_rate"].buckets[1]; len(buckets) != 1 || buckets[0].n != 100 {
+		t.Fatalf("expected a single bucket of 100 observations, got %v", buckets)
+	}
+
+	// metrics without rules are not kept
+	r.Observe(1, "latency", 1, start.Add(time.Hour))
+	if _, ok := r.metrics["latency"]; ok {
+		t.Fatalf("expected metric without rules to be dropped")
+	}
+
+	for i := 0; i < 10; i++ {
+		r.Observe(2, "error_rate", 1, start.Add(time.Hour+time.Minute))
+	}
+
+	r.Evaluate(start.Add(time.Hour + time.Minute))
+	r.Evaluate(start.Add(time.Hour + 2*time.Minute))
+
+	if len(events) != 1 || events[0].Arm != 2 || events[0].Kind != EventRule {
+		t.Fatalf("expected a single rule event for arm 2, got %v", events)
+	}
+
+	if !e.Disabled(2) || e.Disabled(1) {
+		t.Fatalf("expected only arm 2 to be disabled")
+	}
+}
+
+func TestExperimentRules(t *testing.T) {
+	config := []byte(`[{"experiment_name": "shape-20130822", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1,
+		"rules": ["error_rate > 2% over 10m then disable"], "variations": [
+		{"ordinal": 1, "url": "http://localhost/circle"},
+		{"ordinal": 2, "url": "http://localhost/square"}]}]`)
+
+	es, err := NewExperiments(NewBytesOpener(config))
+	if err != nil {
+		t.Fatalf("while reading config: %s", err.Error())
+	}
+
+	e, _ := es.Get("shape-20130822")
+	if e.rules == nil {
+		t.Fatalf("expected experiment with rules to start an engine")
+	}
+
+	e.Observe(2, "error_rate", 1)
+	e.Observe(3, "error_rate", 1)
+	e.rules.Evaluate(time.Now())
+	if !e.Disabled(2) || e.Disabled(1) {
+		t.Fatalf("expected observed errors to disable arm 2")
+	}
+
+	// applying a new config stops the engine of the replaced experiment
+	next, _ := NewExperiments(NewBytesOpener(config))
+	if _, err := es.Apply(next, RestoreFail); err != nil {
+		t.Fatalf("could not apply: %s", err.Error())
+	}
+
+	select {
+	case <-e.rules.done:
+	default:
+		t.Fatalf("expected replaced engine to be stopped")
+	}
+
+	applied, _ := es.Get("shape-20130822")
+	if applied.rules == nil || applied.rules == e.rules {
+		t.Fatalf("expected applied experiment to start its own engine")
+	}
+
+	es.Close()
+}
@@ -0,0 +1,103 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// sampleSize is the default number of raw rewards kept per variation.
+const sampleSize = 100
+
+// NewSamples returns empty reservoirs of at most `size` rewards for each of
+// `arms` 1 indexed arms.
+func NewSamples(arms, size int) *Samples {
+	return &Samples{
+		size:       size,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		reservoirs: make([]reservoir, arms),
+	}
+}
+
+// Samples keeps a weighted random sample of raw rewards per arm, for
+// distributional diagnostics such as QQ plots or inspecting outliers. Memory
+// is bounded by the reservoir size, however many rewards are observed.
+//
+// Sampling is A-Res by Efraimidis and Spirakis: each reward gets the key
+// u^(1/weight) for uniform u, and the rewards with the largest keys are kept.
+// With equal weights this is a uniform sample.
+type Samples struct {
+	sync.Mutex
+	size       int
+	rand       *rand.Rand
+	reservoirs []reservoir
+}
+
+// Add offers `reward` of 1 indexed `arm` with a weight in (0, ∞).
+func (s *Samples) Add(arm int, reward, weight float64) {
+	if !(weight > 0) {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	key := math.Pow(s.rand.Float64(), 1/weight)
+	r := &s.reservoirs[arm-1]
+	if len(*r) < s.size {
+		heap.Push(r, keyed{key, reward})
+		return
+	}
+
+	if s.size > 0 && key > (*r)[0].key {
+		(*r)[0] = keyed{key, reward}
+		heap.Fix(r, 0)
+	}
+}
+
+// Rewards returns a copy of the sampled rewards of 1 indexed `arm`, in no
+// particular order.
+func (s *Samples) Rewards(arm int) []float64 {
+	s.Lock()
+	defer s.Unlock()
+
+	rewards := make([]float64, len(s.reservoirs[arm-1]))
+	for i, k := range s.reservoirs[arm-1] {
+		rewards[i] = k.reward
+	}
+
+	return rewards
+}
+
+// reset drops all samples.
+func (s *Samples) reset() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.reservoirs = make([]reservoir, len(s.reservoirs))
+}
+
+// keyed is a sampled reward with its A-Res key.
+type keyed struct {
+	key    float64
+	reward float64
+}
+
+// reservoir is a min heap of keyed rewards, so the smallest key is replaced.
+type reservoir []keyed
+
+func (r reservoir) Len() int            { return len(r) }
+func (r reservoir) Less(i, j int) bool  { return r[i].key < r[j].key }
+func (r reservoir) Swap(i, j int)       { r[i], r[j] = r[j], r[i] }
+func (r *reservoir) Push(x interface{}) { *r = append(*r, x.(keyed)) }
+func (r *reservoir) Pop() interface{} {
+	old := *r
+	x := old[len(old)-1]
+	*r = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,66 @@
+package bandit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSamples(t *testing.T) {
+	s := NewSamples(2, 100)
+	for i := 0; i < 10000; i++ {
+		s.Add(1, float64(i%10), 1)
+	}
+
+	rewards := s.Rewards(1)
+	if len(rewards) != 100 {
+		t.Fatalf("expected 100 samples, got %d", len(rewards))
+	}
+
+	mean := 0.0
+	for _, r := range rewards {
+		mean += r / 100
+	}
+
+	if math.Abs(mean-4.5) > 1 {
+		t.Fatalf("expected uniform sample with mean near 4.5, got %f", mean)
+	}
+
+	if got := s.Rewards(2); len(got) != 0 {
+		t.Fatalf("expected no samples of arm 2, got %v", got)
+	}
+}
+
+func TestWeightedSamples(t *testing.T) {
+	s := NewSamples(1, 100)
+	for i := 0; i < 10000; i++ {
+		// one in ten rewards is 1, but weighted 9 times heavier
+		if i%10 == 0 {
+			s.Add(1, 1, 9)
+		} else {
+			s.Add(1, 0, 1)
+		}
+	}
+
+	ones := 0
+	for _, r := range s.Rewards(1) {
+		if r == 1 {
+			ones++
+		}
+	}
+
+	if ones < 30 || ones > 70 {
+		t.Fatalf("expected about half of the samples to be heavy rewards, got %d", ones)
+	}
+}
+
+func TestStatsSamples(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.Update(2, 0.5)
+	if got := e.Stats().Arms[1].Samples; len(got) != 1 || got[0] != 0.5 {
+		t.Fatalf("expected sampled reward, got %v", got)
+	}
+}
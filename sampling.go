@@ -0,0 +1,97 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"sync"
+	"sync/atomic"
+)
+
+// NewDecisionSampler constructs a sampler which logs all exploration decisions
+// and one in `n` exploitation decisions of each experiment and arm, so that
+// logging stays bounded at high throughput. A decision exploits if it selects
+// an arm with the highest current estimate.
+func NewDecisionSampler(n int) (*DecisionSampler, error) {
+	if n < 1 {
+		return &DecisionSampler{}, fmt.Errorf("n not in [1, ∞)")
+	}
+
+	return &DecisionSampler{
+		n:       n,
+		pending: make(map[string][]int),
+	}, nil
+}
+
+// DecisionSampler counts exploitation decisions per experiment and arm, and
+// logs every n-th with weight n. The weights of logged lines add up to the
+// exact number of decisions, except for fewer than n decisions per arm which
+// are still pending.
+type DecisionSampler struct {
+	sync.Mutex
+	n         int
+	pending   map[string][]int // unlogged exploitation decisions per arm
+	decisions int64
+	logged    int64
+}
+
+// Sample returns the weight of the selection line to log for selecting `v`
+// from `e`, or 0 if it should not be logged.
+func (d *DecisionSampler) Sample(e *Experiment, v Variation) int {
+	atomic.AddInt64(&d.decisions, 1)
+	arm := e.Arm(v.Ordinal)
+	if d.n == 1 || exploring(e.Strategy, arm) {
+		atomic.AddInt64(&d.logged, 1)
+		return 1
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	pending, ok := d.pending[e.Name]
+	if !ok || len(pending) < arm {
+		pending = append(pending, make([]int, arm-len(pending))...)
+		d.pending[e.Name] = pending
+	}
+
+	pending[arm-1]++
+	if pending[arm-1] < d.n {
+		return 0
+	}
+
+	pending[arm-1] = 0
+	atomic.AddInt64(&d.logged, 1)
+	return d.n
+}
+
+// Counts returns the number of decisions sampled and the number of them
+// which were logged.
+func (d *DecisionSampler) Counts() (decisions, logged int64) {
+	return atomic.LoadInt64(&d.decisions), atomic.LoadInt64(&d.logged)
+}
+
+// leader is implemented by strategies which can tell whether an arm has the
+// highest estimate without taking a snapshot. See Counters.leading.
+type leader interface {
+	leading(arm int) bool
+}
+
+// exploring is true if 1 indexed `arm` does not have the highest estimate of
+// strategy `s`, including priorities and costs.
+func exploring(s Strategy, arm int) bool {
+	if l, ok := s.(leader); ok {
+		return !l.leading(arm - 1)
+	}
+
+	c := s.Snapshot()
+	_, best := bmath.Max(c.estimates())
+	for _, i := range best {
+		if i == arm-1 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,82 @@
+package bandit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecisionSampler(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	c := NewCounters(2)
+	c.counts, c.values = []int{10, 10}, []float64{0.1, 0.5}
+	if err := e.Strategy.Init(&c); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	d, err := NewDecisionSampler(4)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	exploit, explore := e.Variations[1], e.Variations[0]
+	var weights []int
+	for i := 0; i < 10; i++ {
+		weights = append(weights, d.Sample(e, exploit))
+	}
+
+	if expected := []int{0, 0, 0, 4, 0, 0, 0, 4, 0, 0}; !reflect.DeepEqual(weights, expected) {
+		t.Fatalf("expected exploitation weights %v, got %v", expected, weights)
+	}
+
+	if weight := d.Sample(e, explore); weight != 1 {
+		t.Fatalf("expected exploration to be logged with weight 1, got %d", weight)
+	}
+
+	if decisions, logged := d.Counts(); decisions != 11 || logged != 3 {
+		t.Fatalf("expected 3 of 11 decisions logged, got %d of %d", logged, decisions)
+	}
+
+	if _, err := NewDecisionSampler(0); err == nil {
+		t.Fatalf("expected n of 0 to fail")
+	}
+}
+
+func TestWeightedSelectionLine(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	lines := []string{
+		WeightedSelectionLine(*e, e.Variations[1], 4),
+		SelectionLine(*e, e.Variations[0]),
+	}
+
+	events, err := ParseLog(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatalf("could not parse weighted lines: %s", err.Error())
+	}
+
+	if events[0].Weight != 4 || events[1].Weight != 0 {
+		t.Fatalf("expected weights 4 and 0, got %d and %d", events[0].Weight, events[1].Weight)
+	}
+
+	c, err := Replay(events, e, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if c.counts[0] != 1 || c.counts[1] != 4 {
+		t.Fatalf("expected weighted counts [1 4], got %v", c.counts)
+	}
+
+	if _, err := ParseLog(strings.NewReader(lines[1] + " x")); err == nil {
+		t.Fatalf("expected bad weight to fail")
+	}
+}
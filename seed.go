@@ -0,0 +1,117 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"math"
+)
+
+// ChangeStateSeeded is reported for each new arm whose state was seeded from
+// a similar existing arm, see Seed.
+const ChangeStateSeeded = "state-seeded"
+
+// Seed initializes the strategy from `previous`, the running version of the
+// same experiment. Arms present in both versions keep their state. A new arm
+// starts at the mean reward of its most similar existing arm, with at most
+// `pulls` pulls: the arm declared with "seed-from", or else the nearest arm
+// by "features". New arms without a similar arm start at zero.
+func (e *Experiment) Seed(previous *Experiment, pulls int) error {
+	if e.members != nil || previous.members != nil {
+		return fmt.Errorf("could not seed %s: grouped variations are not supported", e.Name)
+	}
+
+	old := previous.Strategy.Snapshot()
+	stored := State{Counts: old.counts, Values: old.values}
+	for _, v := range previous.Variations {
+		stored.Tags = append(stored.Tags, v.Tag)
+	}
+
+	c, _ := e.seed(stored, pulls)
+	if err := e.Strategy.Init(c); err != nil {
+		return fmt.Errorf("could not seed %s: %s", e.Name, err.Error())
+	}
+
+	return nil
+}
+
+// seed returns the counters seeded from the `stored` state of the previous
+// version, see Seed, and the changes to it. Stored arms are matched by tag.
+func (e *Experiment) seed(stored State, pulls int) (*Counters, []Change) {
+	c := newState(len(e.Variations))
+
+	from := make(map[string]int)
+	for arm, tag := range stored.Tags {
+		if arm < len(stored.Counts) && arm < len(stored.Values) {
+			from[tag] = arm
+		}
+	}
+
+	// stored arm of existing arms, by ordinal in e
+	existing := make(map[int]int)
+	for _, v := range e.Variations {
+		if arm, ok := from[v.Tag]; ok {
+			existing[v.Ordinal] = arm + 1
+			c.counts[v.Ordinal-1] = stored.Counts[arm]
+			c.values[v.Ordinal-1] = stored.Values[arm]
+		}
+	}
+
+	var changes []Change
+	for _, tag := range stored.Tags {
+		if _, err := e.GetTaggedVariation(tag); err != nil {
+			changes = append(changes, Change{e.Name, ChangeStateLost, tag})
+		}
+	}
+
+	for _, v := range e.Variations {
+		if _, ok := existing[v.Ordinal]; ok {
+			continue
+		}
+
+		similar, ok := e.similar(v.Ordinal, existing)
+		if !ok {
+			continue
+		}
+
+		arm := existing[similar] - 1
+		c.counts[v.Ordinal-1] = int(math.Min(float64(stored.Counts[arm]), float64(pulls)))
+		c.values[v.Ordinal-1] = stored.Values[arm]
+		changes = append(changes, Change{e.Name, ChangeStateSeeded, fmt.Sprintf("%s: from %s", v.Tag, stored.Tags[arm])})
+	}
+
+	return c, changes
+}
+
+// similar returns the ordinal of the existing arm most similar to `ordinal`.
+func (e *Experiment) similar(ordinal int, existing map[int]int) (int, bool) {
+	if seed, ok := e.Seeds[ordinal]; ok {
+		_, ok := existing[seed]
+		return seed, ok
+	}
+
+	features, ok := e.Features[ordinal]
+	if !ok {
+		return 0, false
+	}
+
+	nearest, min := 0, math.Inf(1)
+	for candidate := range existing {
+		other, ok := e.Features[candidate]
+		if !ok || len(other) != len(features) {
+			continue
+		}
+
+		distance := 0.0
+		for i := range features {
+			distance += (features[i] - other[i]) * (features[i] - other[i])
+		}
+
+		if distance < min || (distance == min && candidate < nearest) {
+			nearest, min = candidate, distance
+		}
+	}
+
+	return nearest, nearest != 0
+}
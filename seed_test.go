@@ -0,0 +1,99 @@
+package bandit
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSeed(t *testing.T) {
+	previous, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	c := NewCounters(2)
+	c.counts[0], c.counts[1] = 100, 300
+	c.values[0], c.values[1] = 0.1, 0.4
+	if err := previous.Strategy.Init(&c); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	strategy, err := NewSoftmax(4, 0.1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e := &Experiment{
+		Name:     previous.Name,
+		Strategy: strategy,
+		Variations: append(append(Variations{}, previous.Variations...),
+			Variation{Ordinal: 3, Tag: "shape-20130822:3"},
+			Variation{Ordinal: 4, Tag: "shape-20130822:4"},
+		),
+		Seeds: map[int]int{3: 1},
+		Features: map[int][]float64{
+			1: []float64{0, 0},
+			2: []float64{1, 1},
+			4: []float64{0.9, 1},
+		},
+	}
+
+	if err := e.Seed(previous, 10); err != nil {
+		t.Fatalf("could not seed: %s", err.Error())
+	}
+
+	got := e.Strategy.Snapshot()
+	expectedCounts := []int{100, 300, 10, 10}
+	expectedValues := []float64{0.1, 0.4, 0.1, 0.4}
+	for i := range expectedCounts {
+		if got.counts[i] != expectedCounts[i] || got.values[i] != expectedValues[i] {
+			t.Fatalf("expected %v %v, got %v %v", expectedCounts, expectedValues, got.counts, got.values)
+		}
+	}
+}
+
+func TestSeedOnImport(t *testing.T) {
+	config := `[{"experiment_name": "shape-20130822", "strategy": "epsilonGreedy",
+		"parameters": [0.1], "preferred": 1, "seed-pulls": 10, "variations": [
+		{"ordinal": 1, "url": "http://localhost/circle"},
+		{"ordinal": 2, "url": "http://localhost/square"}%s]}]`
+
+	es, err := NewExperiments(NewBytesOpener([]byte(fmt.Sprintf(config, ""))))
+	if err != nil {
+		t.Fatalf("while reading config: %s", err.Error())
+	}
+
+	running, _ := es.Get("shape-20130822")
+	c := NewCounters(2)
+	c.counts[0], c.counts[1] = 100, 300
+	c.values[0], c.values[1] = 0.1, 0.4
+	if err := running.Strategy.Init(&c); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// a new triangle is seeded from the square, even though it fails the policy
+	triangle := `, {"ordinal": 3, "url": "http://localhost/triangle", "seed-from": 2}`
+	next, err := NewExperiments(NewBytesOpener([]byte(fmt.Sprintf(config, triangle))))
+	if err != nil {
+		t.Fatalf("while reading config: %s", err.Error())
+	}
+
+	report, err := es.Import(next, RestoreFail, false)
+	if err != nil {
+		t.Fatalf("could not import: %s", err.Error())
+	}
+
+	if len(report.Changes) != 2 || report.Changes[1].Kind != ChangeStateSeeded {
+		t.Fatalf("expected a variation to be added and seeded, got %v", report.Changes)
+	}
+
+	e, _ := es.Get("shape-20130822")
+	got := e.Strategy.Snapshot()
+	expectedCounts := []int{100, 300, 10}
+	expectedValues := []float64{0.1, 0.4, 0.4}
+	for i := range expectedCounts {
+		if got.counts[i] != expectedCounts[i] || got.values[i] != expectedValues[i] {
+			t.Fatalf("expected %v %v, got %v %v", expectedCounts, expectedValues, got.counts, got.values)
+		}
+	}
+}
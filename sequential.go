@@ -0,0 +1,114 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+	"sync"
+)
+
+// NewSequentialTest returns a monitor comparing every arm against the 1
+// indexed `control` arm with a mixture sequential probability ratio test.
+// `τ` is the standard deviation of the normal mixing distribution over the
+// effect size, in reward units.
+func NewSequentialTest(arms, control int, τ float64) (*SequentialTest, error) {
+	if control < 1 || control > arms {
+		return &SequentialTest{}, fmt.Errorf("control %d not in [1,%d]", control, arms)
+	}
+
+	if !(τ > 0) {
+		return &SequentialTest{}, fmt.Errorf("τ not in (0, ∞)")
+	}
+
+	pValues := make([]float64, arms)
+	for i := range pValues {
+		pValues[i] = 1
+	}
+
+	return &SequentialTest{
+		control: control - 1,
+		tau2:    τ * τ,
+		moments: make([]moments, arms),
+		pValues: pValues,
+	}, nil
+}
+
+// SequentialTest computes always valid p-values from streaming rewards.
+// Analysts may look at them after every observation without inflating false
+// positives.
+type SequentialTest struct {
+	sync.Mutex
+	control int       // 0 indexed control arm
+	tau2    float64   // variance of the mixing distribution
+	moments []moments // per arm reward moments
+	pValues []float64 // running always valid p-values
+}
+
+// moments are running mean and sum of squared deviations (Welford).
+type moments struct {
+	n    int
+	mean float64
+	m2   float64
+}
+
+// add adds x to the moments.
+func (m *moments) add(x float64) {
+	m.n++
+	delta := x - m.mean
+	m.mean += delta / float64(m.n)
+	m.m2 += delta * (x - m.mean)
+}
+
+// variance is the unbiased sample variance.
+func (m *moments) variance() float64 {
+	if m.n < 2 {
+		return 0
+	}
+
+	return m.m2 / float64(m.n-1)
+}
+
+// Observe adds a reward of 1 indexed `arm` and updates the p-values.
+func (s *SequentialTest) Observe(arm int, reward float64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.moments[arm-1].add(reward)
+	if arm-1 == s.control {
+		for i := range s.moments {
+			s.update(i)
+		}
+
+		return
+	}
+
+	s.update(arm - 1)
+}
+
+// update recomputes the p-value of 0 indexed arm `i` against the control.
+func (s *SequentialTest) update(i int) {
+	t, c := s.moments[i], s.moments[s.control]
+	if i == s.control || t.n < 2 || c.n < 2 {
+		return
+	}
+
+	v := t.variance()/float64(t.n) + c.variance()/float64(c.n)
+	if v == 0 {
+		return
+	}
+
+	λ := bmath.MSPRT(t.mean-c.mean, v, s.tau2)
+	s.pValues[i] = math.Min(s.pValues[i], 1/λ)
+}
+
+// PValues returns always valid p-values of each arm against the control. The
+// control's own p-value is always 1.
+func (s *SequentialTest) PValues() []float64 {
+	s.Lock()
+	defer s.Unlock()
+
+	return append([]float64{}, s.pValues...)
+}
@@ -0,0 +1,43 @@
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"testing"
+)
+
+func TestSequentialTest(t *testing.T) {
+	s, err := NewSequentialTest(3, 1, 0.1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	control, same, better := bmath.BernRand(0.1), bmath.BernRand(0.1), bmath.BernRand(0.3)
+	for i := 0; i < 5000; i++ {
+		s.Observe(1, control())
+		s.Observe(2, same())
+		s.Observe(3, better())
+	}
+
+	p := s.PValues()
+	if p[0] != 1 {
+		t.Fatalf("expected control p-value of 1, got %f", p[0])
+	}
+
+	if p[1] < 0.001 {
+		t.Fatalf("expected equal arm not to be significant, got p = %f", p[1])
+	}
+
+	if p[2] > 0.001 {
+		t.Fatalf("expected better arm to be significant, got p = %f", p[2])
+	}
+}
+
+func TestSequentialTestParameters(t *testing.T) {
+	if _, err := NewSequentialTest(2, 3, 0.1); err == nil {
+		t.Fatalf("expected error on control out of range")
+	}
+
+	if _, err := NewSequentialTest(2, 1, 0); err == nil {
+		t.Fatalf("expected error on τ = 0")
+	}
+}
@@ -3,7 +3,11 @@
 
 package sim
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
 
 // Strategy can select arm or update information
 type Strategy interface {
@@ -17,6 +21,34 @@ type Arm func() float64
 
 // MonteCarlo runs a monte carlo experiment with the given strategy and arms.
 func MonteCarlo(sims, trials int, arms []Arm, b Strategy) (Simulation, error) {
+	return MonteCarloFeedback(sims, trials, arms, b, Feedback{})
+}
+
+// Feedback models how rewards reach the strategy. Delay draws the number of
+// trials a reward takes to arrive, and may be nil for immediate rewards. Loss
+// is the probability in [0, 1] that a reward never arrives.
+type Feedback struct {
+	Delay func() float64
+	Loss  float64
+	Seed  int64
+}
+
+// pending is a reward on its way to the strategy.
+type pending struct {
+	due    int
+	arm    int
+	reward float64
+}
+
+// MonteCarloFeedback runs a monte carlo experiment where rewards reach the
+// strategy as modelled by `f`. The simulation records rewards at the time of
+// selection, so that summaries reflect the true performance of the strategy.
+func MonteCarloFeedback(sims, trials int, arms []Arm, b Strategy, f Feedback) (Simulation, error) {
+	if f.Loss < 0 || f.Loss > 1 {
+		return Simulation{}, fmt.Errorf("loss %f not in [0, 1]", f.Loss)
+	}
+
+	r := rand.New(rand.NewSource(f.Seed))
 	s := Simulation{
 		Sims:       sims,
 		Trials:     trials,
@@ -31,10 +63,35 @@ func MonteCarlo(sims, trials int, arms []Arm, b Strategy) (Simulation, error) {
 		s.Description = fmt.Sprintf("%b", b)
 		b.Reset()
 
+		var inflight []pending
 		for trial := 0; trial < trials; trial++ {
+			// deliver rewards which have arrived by now
+			waiting := inflight[:0]
+			for _, p := range inflight {
+				if p.due <= trial {
+					b.Update(p.arm, p.reward)
+				} else {
+					waiting = append(waiting, p)
+				}
+			}
+
+			inflight = waiting
+
 			selected := b.SelectArm()
 			reward := arms[selected-1]()
-			b.Update(selected, reward)
+			switch {
+			case f.Loss > 0 && r.Float64() < f.Loss:
+				s.Lost++
+			case f.Delay == nil:
+				b.Update(selected, reward)
+			default:
+				delay := int(math.Max(0, math.Floor(f.Delay()+0.5)))
+				if delay == 0 {
+					b.Update(selected, reward)
+				} else {
+					inflight = append(inflight, pending{trial + delay, selected, reward})
+				}
+			}
 
 			// record this trial into column i
 			i := sim*trials + trial
@@ -64,6 +121,7 @@ type Simulation struct {
 	Selected    []int
 	Reward      []float64
 	Cumulative  []float64
+	Lost        int // rewards which never reached the strategy
 }
 
 // Summary summarizes a Simulation and returns corresponding plot points.
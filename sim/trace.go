@@ -0,0 +1,56 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// NewTraceArm returns an arm which draws rewards uniformly, with replacement,
+// from `rewards` recorded in production. This bootstrap resampling keeps the
+// shape of the recorded reward distribution, such as long tails of revenue
+// or mostly zero rewards, where parametric arms would smooth it away. Draws
+// are seeded with `seed`.
+func NewTraceArm(rewards []float64, seed int64) (Arm, error) {
+	if len(rewards) == 0 {
+		return func() float64 { return 0 }, fmt.Errorf("trace has no rewards")
+	}
+
+	trace := append([]float64{}, rewards...)
+	r := rand.New(rand.NewSource(seed))
+	return func() float64 {
+		return trace[r.Intn(len(trace))]
+	}, nil
+}
+
+// NewTraceArms returns a trace arm for each trace in `traces`, and the 1
+// indexed arms with the highest mean reward.
+func NewTraceArms(traces [][]float64, seed int64) ([]Arm, []int, error) {
+	var arms []Arm
+	var best []int
+	bestMean := 0.0
+	for i, trace := range traces {
+		arm, err := NewTraceArm(trace, seed+int64(i))
+		if err != nil {
+			return []Arm{}, []int{}, fmt.Errorf("arm %d: %s", i+1, err.Error())
+		}
+
+		arms = append(arms, arm)
+
+		sum := 0.0
+		for _, reward := range trace {
+			sum += reward
+		}
+
+		switch mean := sum / float64(len(trace)); {
+		case len(best) == 0 || mean > bestMean:
+			best, bestMean = []int{i + 1}, mean
+		case mean == bestMean:
+			best = append(best, i+1)
+		}
+	}
+
+	return arms, best, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SlateSelector is implemented by strategies which can fill `k` slots, e.g.
+// the positions of a ranked list on a page, with distinct arms at once. Each
+// slot is rewarded separately by calling Update with the arm shown in it.
+type SlateSelector interface {
+	SelectSlate(k int) []int
+}
+
+// SelectSlate returns `k` distinct 1 indexed arms selected by `s`, best
+// first. Wrapped strategies select slates if the strategy they wrap does.
+func SelectSlate(s Strategy, k int) ([]int, error) {
+	ss, ok := s.(SlateSelector)
+	if _, inner := innermost(s).(SlateSelector); !ok || !inner {
+		return []int{}, fmt.Errorf("%s cannot select slates", s)
+	}
+
+	if arms := s.Snapshot().arms; k < 1 || k > arms {
+		return []int{}, fmt.Errorf("slate of %d not in [1, %d]", k, arms)
+	}
+
+	return ss.SelectSlate(k), nil
+}
+
+// SelectSlate returns the `k` 1 indexed arms with the highest upper confidence
+// bounds, see Chen et al., 2013, "Combinatorial Multi-Armed Bandit".
+func (u *uCB1) SelectSlate(k int) []int {
+	u.Lock()
+	defer u.Unlock()
+
+	return u.slate(u.bounds(), k)
+}
+
+// SelectSlate returns the `k` 1 indexed arms with the highest posterior
+// draws, see Komiyama et al., 2015, "Optimal Regret Analysis of Thompson
+// Sampling in Stochastic Multi-armed Bandit Problem with Multiple Plays".
+func (t *thompson) SelectSlate(k int) []int {
+	t.Lock()
+	defer t.Unlock()
+
+	thetas := make([]float64, t.arms)
+	for i := range thetas {
+		α, β := t.posterior(i, t.alpha)
+		thetas[i] = t.estimate(i, t.betaRand.NextBeta(α, β))
+	}
+
+	return t.slate(thetas, k)
+}
+
+// slate pulls and returns the `k` 1 indexed arms with the highest `scores`,
+// without locking. Equal scores are ordered by the tie breaking policy.
+func (c *Counters) slate(scores []float64, k int) []int {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+
+	if c.ties != TiesOrdinal {
+		c.rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	arms := make([]int, k)
+	for slot, arm := range order[:k] {
+		c.counts[arm]++
+		arms[slot] = arm + 1
+	}
+
+	return arms
+}
@@ -0,0 +1,87 @@
+package bandit
+
+import (
+	"github.com/purzelrakete/bandit/sim"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// seededArms returns bernoulli arms with means `μs`, drawing from a single
+// seeded source so that simulations are deterministic.
+func seededArms(seed int64, μs ...float64) []sim.Arm {
+	r := rand.New(rand.NewSource(seed))
+	arms := make([]sim.Arm, len(μs))
+	for i, μ := range μs {
+		μ := μ
+		arms[i] = func() float64 {
+			if r.Float64() <= μ {
+				return 1
+			}
+
+			return 0
+		}
+	}
+
+	return arms
+}
+
+func TestSelectSlate(t *testing.T) {
+
+	for _, c := range []struct {
+		name   string
+		params []float64
+	}{
+		{"ucb1", []float64{}},
+		{"thompson", []float64{1}},
+	} {
+		arms := seededArms(7, 0.1, 0.3, 0.2, 0.8)
+		s, err := New(len(arms), c.name, c.params)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if _, err := NewSeeded(s, 42); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		rounds, best := 3000, 0
+		for round := 0; round < rounds; round++ {
+			slate, err := SelectSlate(s, 2)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			// each slot is rewarded separately
+			for _, arm := range slate {
+				s.Update(arm, arms[arm-1]())
+			}
+
+			sort.Ints(slate)
+			if round >= rounds-500 && reflect.DeepEqual(slate, []int{2, 4}) {
+				best++
+			}
+		}
+
+		if best < 400 {
+			t.Fatalf("%s: expected slate of arms 2 and 4, got it %d of the last 500 rounds", c.name, best)
+		}
+	}
+}
+
+func TestSelectSlateFails(t *testing.T) {
+	s := NewUCB1(3)
+	if _, err := SelectSlate(s, 4); err == nil {
+		t.Fatalf("expected slate larger than arms to fail")
+	}
+
+	if slate, _ := SelectSlate(s, 3); len(slate) != 3 || slate[0] == slate[1] || slate[1] == slate[2] || slate[0] == slate[2] {
+		t.Fatalf("expected distinct arms, got %v", slate)
+	}
+
+	greedy, _ := NewEpsilonGreedy(3, 0.1)
+	if _, err := SelectSlate(greedy, 2); err == nil {
+		t.Fatalf("expected strategy without slates to fail")
+	}
+}
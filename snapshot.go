@@ -11,13 +11,16 @@ import (
 	"strings"
 )
 
-// GetSnapshot returns Counters given a snapshot filename.
+// GetSnapshot returns Counters given a snapshot filename. Compressed
+// snapshots are decompressed, see Decompress.
 func GetSnapshot(o Opener) (Counters, error) {
-	reader, err := o.Open()
+	reader, err := NewDecompressingOpener(o).Open()
 	if err != nil {
 		return Counters{}, fmt.Errorf("could not open: %s", err.Error())
 	}
 
+	defer reader.Close()
+
 	counters, err := ParseSnapshot(reader)
 	if err != nil {
 		return Counters{}, fmt.Errorf("could not parse snapshot: %s", err.Error())
@@ -46,11 +49,19 @@ func ParseSnapshot(s io.Reader) (Counters, error) {
 	}
 
 	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Counters{}, fmt.Errorf("empty snapshot")
+	}
+
 	arms, err := strconv.ParseInt(fields[0], 10, 16)
 	if err != nil {
 		return Counters{}, fmt.Errorf("arms not an int: %s", err.Error())
 	}
 
+	if arms < 1 {
+		return Counters{}, fmt.Errorf("need at least 1 arm")
+	}
+
 	if int(arms) != len(fields)-1 {
 		return Counters{}, fmt.Errorf("more fields than arms")
 	}
@@ -0,0 +1,386 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+// Package main contains bandit-soak, which drives a running bandit service
+// with selections and simulated rewards, for validating a release under load:
+//
+//	bandit-soak -api http://localhost:8080 -experiment shape-20130822 \
+//	  -truths 0.1,0.3 -duration 5m -workers 32 -delay exp:2s
+//
+// Each selection is rewarded with probability `-reward-ratio`. The reward is
+// drawn from a Bernoulli with the truth of the selected ordinal and posted
+// after a delay drawn from `-delay`. Throughput and latency percentiles are
+// reported at the end. The run fails unless the ordinal with the highest
+// truth received `-min-share` of the selections in the second half of the
+// run.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/purzelrakete/bandit"
+	bmath "github.com/purzelrakete/bandit/math"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	soakAPI        = flag.String("api", "http://localhost:8080", "url of the bandit service")
+	soakExperiment = flag.String("experiment", "shape-20130822", "experiment to select from")
+	soakRewardPath = flag.String("reward-path", "/feedback", "path rewards are posted to with tag and reward form values")
+	soakTruths     = flag.String("truths", "0.1,0.3", "comma separated Bernoulli reward means, by 1 indexed ordinal")
+	soakRatio      = flag.Float64("reward-ratio", 1, "share of selections which are rewarded")
+	soakDelay      = flag.String("delay", "none", "reward delay distribution ∈ {none,fixed:d,exp:mean,normal:mean}")
+	soakWorkers    = flag.Int("workers", 8, "concurrent selecting clients")
+	soakDuration   = flag.Duration("duration", time.Minute, "duration of the soak")
+	soakTimeout    = flag.Duration("timeout", 5*time.Second, "timeout of each request")
+	soakMinShare   = flag.Float64("min-share", 0.8, "share of selections of the best ordinal in the second half of the run")
+)
+
+func main() {
+	flag.Parse()
+	means, err := parseTruths(*soakTruths)
+	if err != nil {
+		log.Fatalf("could not parse truths: %s", err.Error())
+	}
+
+	delay, err := parseDelay(*soakDelay)
+	if err != nil {
+		log.Fatalf("could not parse delay: %s", err.Error())
+	}
+
+	s := &soak{
+		client:   &http.Client{Timeout: *soakTimeout},
+		means:    means,
+		rewarded: bmath.BernRand(*soakRatio),
+		delay:    delay,
+		half:     time.Now().Add(*soakDuration / 2),
+		tallies:  make([]int, len(means)),
+	}
+
+	for _, μ := range means {
+		s.truths = append(s.truths, bmath.BernRand(μ))
+	}
+
+	start := time.Now()
+	deadline := start.Add(*soakDuration)
+	var workers sync.WaitGroup
+	for i := 0; i < *soakWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for time.Now().Before(deadline) {
+				s.run()
+			}
+		}()
+	}
+
+	workers.Wait()
+	elapsed := time.Since(start)
+	s.rewards.Wait()
+
+	s.report(os.Stdout, elapsed)
+	if err := s.converged(*soakMinShare); err != nil {
+		log.Fatalf("did not converge: %s", err.Error())
+	}
+}
+
+// soak records the selections and latencies of all workers. Random draws
+// are made under the lock, since the generators are not safe for concurrent
+// use.
+type soak struct {
+	sync.Mutex
+	client   *http.Client
+	means    []float64
+	truths   []func() float64
+	rewarded func() float64
+	delay    func() time.Duration
+	half     time.Time
+	rewards  sync.WaitGroup
+
+	selects   []time.Duration
+	updates   []time.Duration
+	failures  int
+	tallies   []int // selections by ordinal in the second half
+	unknown   int   // selections of ordinals without truth in the second half
+	lastError error
+}
+
+// run makes a single selection and schedules its reward.
+func (s *soak) run() {
+	start := time.Now()
+	var selection struct {
+		Tag string `json:"tag"`
+	}
+
+	path := *soakAPI + "/experiments/" + url.QueryEscape(*soakExperiment)
+	if err := s.get(path, &selection); err != nil {
+		s.fail(err)
+		return
+	}
+
+	s.observe(&s.selects, time.Since(start))
+	ordinal, err := ordinalOf(selection.Tag)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+
+	reward, delay, ok := s.tally(ordinal, start)
+	if !ok {
+		return
+	}
+
+	s.rewards.Add(1)
+	time.AfterFunc(delay, func() {
+		defer s.rewards.Done()
+
+		params := url.Values{
+			"tag":    {selection.Tag},
+			"reward": {strconv.FormatFloat(reward, 'f', -1, 64)},
+		}
+
+		start := time.Now()
+		if err := s.post(*soakAPI+*soakRewardPath, params); err != nil {
+			s.fail(err)
+			return
+		}
+
+		s.observe(&s.updates, time.Since(start))
+	})
+}
+
+// get requests `url` and decodes a json response into `v`.
+func (s *soak) get(url string, v interface{}) error {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return err
+	}
+
+	return decode(resp, v)
+}
+
+// post sends the form `values` to `url`.
+func (s *soak) post(url string, values url.Values) error {
+	resp, err := s.client.PostForm(url, values)
+	if err != nil {
+		return err
+	}
+
+	return decode(resp, nil)
+}
+
+// decode closes `resp` after decoding its json body into `v`, unless it is
+// nil. Responses other than 200 OK fail.
+func decode(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	if v == nil {
+		_, err := ioutil.ReadAll(resp.Body)
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// observe records a latency.
+func (s *soak) observe(latencies *[]time.Duration, d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	*latencies = append(*latencies, d)
+}
+
+// fail counts a failed request.
+func (s *soak) fail(err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.failures++
+	s.lastError = err
+}
+
+// tally counts selections of the second half of the run, and draws the
+// reward and delay of the selection. Returns false if it is not rewarded.
+func (s *soak) tally(ordinal int, at time.Time) (float64, time.Duration, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	known := ordinal <= len(s.truths)
+	if !at.Before(s.half) {
+		if known {
+			s.tallies[ordinal-1]++
+		} else {
+			s.unknown++
+		}
+	}
+
+	if !known || s.rewarded() == 0 {
+		return 0, 0, false
+	}
+
+	return s.truths[ordinal-1](), s.delay(), true
+}
+
+// report writes throughput, failures and latency percentiles to `w`.
+func (s *soak) report(w io.Writer, elapsed time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	fmt.Fprintf(w, "duration\t%s\n", elapsed)
+	fmt.Fprintf(w, "selections\t%d\t%.1f/s\n", len(s.selects), float64(len(s.selects))/elapsed.Seconds())
+	fmt.Fprintf(w, "rewards\t%d\t%.1f/s\n", len(s.updates), float64(len(s.updates))/elapsed.Seconds())
+	fmt.Fprintf(w, "failures\t%d\n", s.failures)
+	if s.lastError != nil {
+		fmt.Fprintf(w, "last failure\t%s\n", s.lastError.Error())
+	}
+
+	for _, l := range []struct {
+		name      string
+		latencies []time.Duration
+	}{
+		{"select", s.selects},
+		{"reward", s.updates},
+	} {
+		fmt.Fprintf(w, "%s latency", l.name)
+		for _, q := range []float64{0.5, 0.9, 0.99, 0.999} {
+			fmt.Fprintf(w, "\tp%g=%s", q*100, percentile(l.latencies, q))
+		}
+
+		fmt.Fprintf(w, "\tmax=%s\n", percentile(l.latencies, 1))
+	}
+
+	for i, count := range s.tallies {
+		fmt.Fprintf(w, "ordinal %d\t%d\n", i+1, count)
+	}
+}
+
+// converged checks that the ordinal with the highest truth received at least
+// `share` of the selections in the second half of the run.
+func (s *soak) converged(share float64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	_, best := bmath.Max(s.means)
+	stats := bandit.Stats{Experiment: *soakExperiment}
+	for i, count := range s.tallies {
+		stats.Arms = append(stats.Arms, bandit.ArmStats{Ordinal: i + 1, Count: count})
+	}
+
+	if s.unknown > 0 {
+		stats.Arms = append(stats.Arms, bandit.ArmStats{Count: s.unknown})
+	}
+
+	ordinal, ok := bandit.ShareConvergence(1, share)(stats)
+	if !ok || ordinal != best[0]+1 {
+		return fmt.Errorf("expected ordinal %d with %.2f of selections, got %v", best[0]+1, share, s.tallies)
+	}
+
+	return nil
+}
+
+// percentile returns the `q` quantile of `latencies`, sorting them in place.
+func percentile(latencies []time.Duration, q float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	i := int(q*float64(len(latencies))+0.5) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(latencies) {
+		i = len(latencies) - 1
+	}
+
+	return latencies[i]
+}
+
+// ordinalOf returns the ordinal of a timestamped tag like
+// shape-20130822:2:1377172800.
+func ordinalOf(timestampedTag string) (int, error) {
+	tag, _, err := bandit.TimestampedTagToTag(timestampedTag)
+	if err != nil {
+		return 0, err
+	}
+
+	i := strings.LastIndex(tag, ":")
+	ordinal, err := strconv.Atoi(tag[i+1:])
+	if err != nil || ordinal < 1 {
+		return 0, fmt.Errorf("no ordinal in tag %s", tag)
+	}
+
+	return ordinal, nil
+}
+
+// parseTruths parses comma separated Bernoulli means.
+func parseTruths(s string) ([]float64, error) {
+	var means []float64
+	for _, field := range strings.Split(s, ",") {
+		μ, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || μ < 0 || μ > 1 {
+			return []float64{}, fmt.Errorf("truth %s not in [0, 1]", field)
+		}
+
+		means = append(means, μ)
+	}
+
+	return means, nil
+}
+
+// parseDelay parses a delay distribution like exp:2s. Normal delays have a
+// standard deviation of a quarter of the mean and are cut off at 0.
+func parseDelay(s string) (func() time.Duration, error) {
+	if s == "none" {
+		return func() time.Duration { return 0 }, nil
+	}
+
+	fields := strings.SplitN(s, ":", 2)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected kind:duration, got %s", s)
+	}
+
+	d, err := time.ParseDuration(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	mean := d.Seconds()
+	var rand func() float64
+	switch fields[0] {
+	case "fixed":
+		rand = bmath.DiracRand(mean)
+	case "exp":
+		if mean <= 0 {
+			return nil, fmt.Errorf("exp mean not in (0, ∞)")
+		}
+
+		rand = bmath.ExpRand(1 / mean)
+	case "normal":
+		rand = bmath.NormRand(mean, mean/4)
+	default:
+		return nil, fmt.Errorf("unknown delay distribution %s", fields[0])
+	}
+
+	return func() time.Duration {
+		if x := rand(); x > 0 {
+			return time.Duration(x * float64(time.Second))
+		}
+
+		return 0
+	}, nil
+}
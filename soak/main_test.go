@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDelay(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"none":       0,
+		"fixed:2s":   2 * time.Second,
+		"fixed:-1s":  0,
+		"fixed:0s":   0,
+		"normal:0ms": 0,
+	} {
+		delay, err := parseDelay(s)
+		if err != nil {
+			t.Fatalf("could not parse %s: %s", s, err.Error())
+		}
+
+		if got := delay(); got != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, s, got)
+		}
+	}
+
+	delay, err := parseDelay("exp:2s")
+	if err != nil {
+		t.Fatalf("could not parse exp:2s: %s", err.Error())
+	}
+
+	for i := 0; i < 100; i++ {
+		if got := delay(); got < 0 {
+			t.Fatalf("expected exponential delay in [0, ∞), got %s", got)
+		}
+	}
+
+	for _, invalid := range []string{"", "exp", "exp:0s", "exp:soon", "poisson:1s"} {
+		if _, err := parseDelay(invalid); err == nil {
+			t.Fatalf("expected error parsing '%s'", invalid)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Fatalf("expected 0 without latencies, got %s", got)
+	}
+
+	var latencies []time.Duration
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	for q, expected := range map[float64]time.Duration{
+		0:     time.Millisecond,
+		0.5:   50 * time.Millisecond,
+		0.99:  99 * time.Millisecond,
+		0.999: 100 * time.Millisecond,
+		1:     100 * time.Millisecond,
+	} {
+		if got := percentile(latencies, q); got != expected {
+			t.Fatalf("expected p%g of %s, got %s", q*100, expected, got)
+		}
+	}
+}
+
+func TestOrdinalOf(t *testing.T) {
+	ordinal, err := ordinalOf("shape-20130822:2:1377172800")
+	if err != nil {
+		t.Fatalf("could not get ordinal: %s", err.Error())
+	}
+
+	if ordinal != 2 {
+		t.Fatalf("expected ordinal 2, got %d", ordinal)
+	}
+
+	for _, invalid := range []string{"shape-20130822", "shape-20130822:0:1377172800", "shape:two:1377172800"} {
+		if _, err := ordinalOf(invalid); err == nil {
+			t.Fatalf("expected error for tag '%s'", invalid)
+		}
+	}
+}
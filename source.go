@@ -0,0 +1,129 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+)
+
+// Randomizer is implemented by strategies which draw random numbers. They are
+// seeded with the time by default. All strategies in this package are
+// Randomizers.
+type Randomizer interface {
+	UseSource(src rand.Source) error
+}
+
+// useSource sets the random source of `s`, which must be a Randomizer.
+func useSource(s Strategy, src rand.Source) error {
+	r, ok := s.(Randomizer)
+	if !ok {
+		return fmt.Errorf("%s does not support random sources", s)
+	}
+
+	return r.UseSource(src)
+}
+
+// NewSeeded configures strategy `s` to draw from a source seeded with `seed`,
+// so that its selections are reproducible, e.g. in tests and simulations, and
+// returns it.
+func NewSeeded(s Strategy, seed int64) (Strategy, error) {
+	if err := useSource(s, rand.NewSource(seed)); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// Partition splits a master seed into independent random streams, one per
+// name, e.g. per experiment. The seed of each stream is mixed from the master
+// seed and the name with splitmix64, so streams do not depend on the order in
+// which they are requested, and simulations and tests of many experiments in
+// one process are reproducible as a whole.
+type Partition struct {
+	seed uint64
+}
+
+// NewPartition returns the partition of the master seed `seed`.
+func NewPartition(seed int64) Partition {
+	return Partition{seed: splitMix64(uint64(seed))}
+}
+
+// Source returns a new source of the stream `name`. Sources of the same name
+// produce the same numbers.
+func (p Partition) Source(name string) rand.Source {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return rand.NewSource(int64(splitMix64(p.seed ^ h.Sum64())))
+}
+
+// splitMix64 is the output function of the splitmix64 generator, which maps
+// similar inputs such as consecutive seeds to unrelated outputs.
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// Seed configures the strategy of each experiment to draw from the stream of
+// its name in `p`. Strategies of clones and restarts are seeded from config
+// again, see "random-seed".
+func (e *Experiments) Seed(p Partition) error {
+	all, names := e.All(), []string{}
+	for name := range all {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if err := useSource(all[name].Strategy, p.Source(name)); err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// UseSource replaces the random source. Sources are not safe for concurrent
+// use, so `src` must not be shared with other strategies. It is kept through
+// Init and Reset.
+func (c *Counters) UseSource(src rand.Source) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.rand = rand.New(src)
+	return nil
+}
+
+// UseSource replaces the random source of posterior draws and ties.
+func (t *thompson) UseSource(src rand.Source) error {
+	t.Lock()
+	defer t.Unlock()
+
+	t.rand = rand.New(src)
+	t.betaRand = bmath.NewBetaRandFrom(t.rand)
+	return nil
+}
+
+// UseSource replaces the random source of the duels.
+func (r *rUCB) UseSource(src rand.Source) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.rand = rand.New(src)
+	return nil
+}
+
+// UseSource replaces the random source breaking ties between arms.
+func (m *linearModels) UseSource(src rand.Source) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.rand = rand.New(src)
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"testing"
+)
+
+func TestNewSeeded(t *testing.T) {
+	strategies := map[string][]float64{
+		"epsilonGreedy":         {0.5},
+		"softmax":               {0.1},
+		"gradient":              {0.1},
+		"ucb1":                  {},
+		"klUcb":                 {},
+		"windowedUcb1":          {10},
+		"thompson":              {1},
+		"gaussianThompson":      {},
+		"bootstrapThompson":     {10},
+		"bayesUcb":              {1},
+		"successiveElimination": {0.1, 0.1},
+		"budgetedUcb":           {1000, 1, 2, 3},
+	}
+
+	for name, params := range strategies {
+		var runs [2][]int
+		for run := range runs {
+			s, err := New(3, name, params)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			if _, err := NewSeeded(s, 42); err != nil {
+				t.Fatalf("could not seed %s: %s", name, err.Error())
+			}
+
+			// the source is kept when restoring state
+			c := NewCounters(3)
+			if err := s.Init(&c); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			for i := 0; i < 200; i++ {
+				arm := s.SelectArm()
+				s.Update(arm, float64(i%arm)/2)
+				runs[run] = append(runs[run], arm)
+			}
+		}
+
+		for i := range runs[0] {
+			if runs[0][i] != runs[1][i] {
+				t.Fatalf("expected seeded %s to select the same arms, got %v and %v", name, runs[0], runs[1])
+			}
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	p := NewPartition(42)
+	if p.Source("a").Int63() != p.Source("a").Int63() {
+		t.Fatalf("expected streams of the same name to be equal")
+	}
+
+	if p.Source("a").Int63() == p.Source("b").Int63() {
+		t.Fatalf("expected streams of different names to differ")
+	}
+
+	if p.Source("a").Int63() == NewPartition(43).Source("a").Int63() {
+		t.Fatalf("expected streams of different master seeds to differ")
+	}
+
+	// selections do not depend on the order experiments are served in
+	var runs [2][]int
+	for run := range runs {
+		es, err := NewExperiments(NewFileOpener("experiments.json"))
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if err := es.Seed(p); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		var names []string
+		for name := range es.All() {
+			names = append(names, name)
+		}
+
+		for i := 0; i < 100; i++ {
+			for _, name := range names {
+				e := es.index[name]
+				v := e.Select()
+				e.Update(v.Ordinal, float64(i%v.Ordinal))
+				if name == "shape-20130822" {
+					runs[run] = append(runs[run], v.Ordinal)
+				}
+			}
+		}
+	}
+
+	for i := range runs[0] {
+		if runs[0][i] != runs[1][i] {
+			t.Fatalf("expected partitioned experiments to select the same variations, got %v and %v", runs[0], runs[1])
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Stats summarizes the current state of an experiment, as served by the stats
+// API.
+type Stats struct {
+	Experiment string                  `json:"experiment"`
+	Strategy   string                  `json:"strategy"`
+	Policy     string                  `json:"policy"`  // fingerprint of the serving policy, see Fingerprint
+	Metrics    map[string]Metric       `json:"metrics"` // by json name of the arm stat
+	Arms       []ArmStats              `json:"arms"`
+	History    []Point                 `json:"history,omitempty"`
+	Latency    map[string]LatencyStats `json:"latency,omitempty"` // by "select" and "update"
+}
+
+// Directions in which a metric gets better.
+const (
+	DirectionHigher = "higher"
+	DirectionLower  = "lower"
+	DirectionNone   = "none"
+)
+
+// Metric describes an arm stat, so that dashboards can render stats without
+// knowing them in advance.
+type Metric struct {
+	Unit       string `json:"unit"`
+	Direction  string `json:"direction"` // DirectionHigher, DirectionLower or DirectionNone
+	Definition string `json:"definition"`
+}
+
+// metrics describes the arm stats of an experiment rewarded in `unit`.
+func metrics(unit string) map[string]Metric {
+	if unit == "" {
+		unit = "reward"
+	}
+
+	return map[string]Metric{
+		"priority":        {"multiplier", DirectionNone, "business priority multiplying the value when selecting. 1 is neutral."},
+		"cost":            {unit, DirectionLower, "serving cost subtracted from the value when selecting"},
+		"count":           {"selections", DirectionNone, "times the arm was selected, or rewarded if never selected"},
+		"value":           {unit + " per selection", DirectionHigher, "average reward per selection as estimated by the strategy, discounted if configured. not a count of conversions."},
+		"breakdown":       {"selections", DirectionNone, "selections by the dimension passed when selecting"},
+		"samples":         {unit, DirectionHigher, "random sample of raw rewards"},
+		"last_reward":     {"time", DirectionNone, "time of the last reward"},
+		"staleness_hours": {"hours", DirectionLower, "hours the arm has waited for a reward since it was selected"},
+	}
+}
+
+// ArmStats summarizes a single arm. Breakdown holds selections per dimension
+// value, if selections were made with a dimension. Variations in a group
+// report the pooled count and value of the group.
+type ArmStats struct {
+	Ordinal   int            `json:"ordinal"`
+	Tag       string         `json:"tag"`
+	Group     string         `json:"group,omitempty"`
+	Priority  float64        `json:"priority"`
+	Cost      float64        `json:"cost,omitempty"`
+	Count     int            `json:"count"`
+	Value     float64        `json:"value"`
+	Breakdown map[string]int `json:"breakdown,omitempty"`
+	Samples   []float64      `json:"samples,omitempty"` // random sample of raw rewards
+	Rewarded  *time.Time     `json:"last_reward,omitempty"`
+	Staleness float64        `json:"staleness_hours"` // hours waiting for a reward since selected
+}
+
+// Stats returns the current state of the experiment.
+func (e *Experiment) Stats() Stats {
+	c, now := e.Strategy.Snapshot(), time.Now()
+
+	var breakdown map[string][]int
+	if e.Breakdown != nil {
+		breakdown = e.Breakdown.Counts()
+	}
+
+	stats := Stats{
+		Experiment: e.Name,
+		Strategy:   fmt.Sprintf("%s", e.Strategy),
+		Policy:     Fingerprint(e.Strategy),
+		Metrics:    metrics(e.RewardUnit),
+	}
+
+	priorities := e.Priorities()
+	for i, v := range e.Variations {
+		arm := ArmStats{
+			Ordinal:  v.Ordinal,
+			Tag:      v.Tag,
+			Group:    v.Group,
+			Priority: priorities[i],
+			Cost:     v.Cost,
+		}
+
+		if a := e.Arm(v.Ordinal) - 1; a < c.arms {
+			arm.Count = c.counts[a]
+			arm.Value = c.values[a]
+		}
+
+		for value, counts := range breakdown {
+			if counts[i] > 0 {
+				if arm.Breakdown == nil {
+					arm.Breakdown = make(map[string]int)
+				}
+
+				arm.Breakdown[value] = counts[i]
+			}
+		}
+
+		if e.Samples != nil {
+			arm.Samples = e.Samples.Rewards(v.Ordinal)
+		}
+
+		if e.Freshness != nil {
+			if rewarded, ok := e.Freshness.LastRewarded(v.Ordinal); ok {
+				arm.Rewarded = &rewarded
+			}
+
+			arm.Staleness = e.Freshness.Staleness(v.Ordinal, now).Hours()
+		}
+
+		stats.Arms = append(stats.Arms, arm)
+	}
+
+	if e.History != nil {
+		stats.History = e.History.Points()
+	}
+
+	if e.Latencies != nil {
+		stats.Latency = map[string]LatencyStats{
+			"select": e.Latencies.Select.Stats(),
+			"update": e.Latencies.Update.Stats(),
+		}
+	}
+
+	return stats
+}
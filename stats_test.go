@@ -0,0 +1,73 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStatsMetrics(t *testing.T) {
+	f, err := ioutil.TempFile("", "bandit-stats")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	defer os.Remove(f.Name())
+	f.WriteString(`[{
+		"experiment_name": "shape-20130822",
+		"strategy": "epsilonGreedy",
+		"parameters": [0.1],
+		"reward-unit": "EUR",
+		"preferred": 1,
+		"variations": [
+			{ "ordinal": 1, "url": "http://localhost/circle" },
+			{ "ordinal": 2, "url": "http://localhost/square" }
+		]
+	}]`)
+	f.Close()
+
+	e, err := NewExperiment(NewFileOpener(f.Name()), "shape-20130822")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	e.Update(1, 2.5)
+	stats := e.Stats()
+	if stats.Policy != Fingerprint(e.Strategy) {
+		t.Fatalf("expected policy %s, got %s", Fingerprint(e.Strategy), stats.Policy)
+	}
+
+	if value := stats.Metrics["value"]; value.Unit != "EUR per selection" || value.Direction != DirectionHigher {
+		t.Fatalf("expected value in EUR per selection, got %v", value)
+	}
+
+	// every arm stat but the variation's identity is described
+	now := time.Now()
+	data, err := json.Marshal(ArmStats{
+		Priority:  1,
+		Cost:      1,
+		Count:     1,
+		Breakdown: map[string]int{"DE": 1},
+		Samples:   []float64{1},
+		Rewarded:  &now,
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for field := range fields {
+		if _, ok := stats.Metrics[field]; !ok && field != "ordinal" && field != "tag" && field != "group" {
+			t.Fatalf("expected metric for %s", field)
+		}
+	}
+}
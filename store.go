@@ -0,0 +1,210 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrNotFound is returned by stores for unknown keys.
+var ErrNotFound = errors.New("not found")
+
+// Store persists serialized strategy state keyed by experiment name.
+type Store interface {
+	Get(key string) ([]byte, error)
+	Put(key string, value []byte) error
+}
+
+// Batcher is implemented by stores which can put several keys atomically.
+type Batcher interface {
+	PutAll(values map[string][]byte) error
+}
+
+// countersJSON is the serialized form of Counters.
+type countersJSON struct {
+	Counts []int     `json:"counts"`
+	Values []float64 `json:"values"`
+}
+
+// MarshalJSON encodes pulls and mean rewards per arm.
+func (c *Counters) MarshalJSON() ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	return json.Marshal(countersJSON{
+		Counts: c.counts,
+		Values: c.values,
+	})
+}
+
+// UnmarshalJSON decodes counters written by MarshalJSON.
+func (c *Counters) UnmarshalJSON(data []byte) error {
+	var decoded countersJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if len(decoded.Counts) != len(decoded.Values) {
+		return fmt.Errorf("%d counts but %d values", len(decoded.Counts), len(decoded.Values))
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	// live counters keep their arms. only zero counters take the decoded arms.
+	if c.arms != 0 && len(decoded.Counts) != c.arms {
+		return fmt.Errorf("cannot unmarshal %d arms into %d arms", len(decoded.Counts), c.arms)
+	}
+
+	// keep the random source of a live strategy, see UseSource
+	if c.rand == nil {
+		c.rand = NewCounters(0).rand
+	}
+
+	c.arms = len(decoded.Counts)
+	c.counts = decoded.Counts
+	c.values = decoded.Values
+	c.revision++
+
+	return nil
+}
+
+// MarshalStrategy encodes the counters of `s` as json, like MarshalJSON of
+// Counters, so that its knowledge can be kept across restarts outside of a
+// Store. Unlike json.Marshal, it reads the state of wrapped strategies.
+func MarshalStrategy(s Strategy) ([]byte, error) {
+	c := s.Snapshot()
+	return json.Marshal(&c)
+}
+
+// UnmarshalStrategy restores counters written by MarshalStrategy into `s`,
+// which must have the same number of arms.
+func UnmarshalStrategy(s Strategy, data []byte) error {
+	var c Counters
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("could not unmarshal state: %s", err.Error())
+	}
+
+	return s.Init(&c)
+}
+
+// stateful is implemented by strategies which learn more than the counters,
+// such as the preferences of the gradient strategy. Their state is stored
+// next to the counters, see State.
+type stateful interface {
+	marshalState() ([]byte, error)
+	unmarshalState(data []byte) error
+}
+
+// strategyState is the stored state of a stateful strategy, with the
+// strategy it belongs to.
+type strategyState struct {
+	Strategy string          `json:"strategy"`
+	State    json.RawMessage `json:"state"`
+}
+
+// marshalState returns the state of `s` beyond its counters, or nil if there
+// is none.
+func marshalState(s Strategy) ([]byte, error) {
+	st, ok := s.(stateful)
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := st.marshalState()
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	return json.Marshal(strategyState{Strategy: fmt.Sprint(s), State: data})
+}
+
+// unmarshalState restores state written by marshalState into `s`, after its
+// counters were initialized. State of a strategy configured differently than
+// `s` is ignored, so that `s` keeps the state it rebuilt from the counters.
+func unmarshalState(s Strategy, data []byte) error {
+	st, ok := s.(stateful)
+	if !ok || len(data) == 0 {
+		return nil
+	}
+
+	var stored strategyState
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("could not unmarshal strategy state: %s", err.Error())
+	}
+
+	if stored.Strategy != fmt.Sprint(s) {
+		return nil
+	}
+
+	return st.unmarshalState(stored.State)
+}
+
+// State is the serialized state of an experiment. Tags and urls identify
+// the variation of each arm, so that restored state can be checked against the
+// current variations. Annotations are kept with the state.
+type State struct {
+	Tags        []string     `json:"tags,omitempty"`
+	URLs        []string     `json:"urls,omitempty"`
+	Counts      []int        `json:"counts"`
+	Values      []float64    `json:"values"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Revision    uint64       `json:"revision,omitempty"`
+	Strategy    []byte       `json:"strategy,omitempty"` // see stateful. nil for strategies without.
+}
+
+// state returns the current state of the experiment.
+func (e *Experiment) state() State {
+	c := e.Strategy.Snapshot()
+	state := State{
+		Counts:      c.counts,
+		Values:      c.values,
+		Annotations: e.Annotations(),
+		Revision:    c.revision,
+	}
+
+	for _, v := range e.armVariations() {
+		state.Tags = append(state.Tags, v.Tag)
+		state.URLs = append(state.URLs, v.URL)
+	}
+
+	// the counters are enough to go on with, see Init of each strategy
+	strategy, err := marshalState(e.Strategy)
+	if err != nil {
+		log.Printf("could not marshal strategy state of %s: %s", e.Name, err.Error())
+	}
+
+	state.Strategy = strategy
+	return state
+}
+
+// SaveExperiments writes the state of all experiments to `s`, keyed by
+// experiment name. Stores implementing Batcher save all experiments in a
+// single transaction.
+func SaveExperiments(s Store, es *Experiments) error {
+	values := make(map[string][]byte)
+	for name, e := range es.All() {
+		data, err := codec(s).Marshal(e.state())
+		if err != nil {
+			return fmt.Errorf("could not marshal %s: %s", name, err.Error())
+		}
+
+		values[name] = data
+	}
+
+	if b, ok := s.(Batcher); ok {
+		return b.PutAll(values)
+	}
+
+	for name, data := range values {
+		if err := s.Put(name, data); err != nil {
+			return fmt.Errorf("could not save %s: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalStrategy(t *testing.T) {
+	builders := map[string]func() Strategy{
+		"ucb1": func() Strategy { return NewUCB1(2) },
+		"floored": func() Strategy {
+			s, _ := NewFloored(NewUCB1(2), 2, 0.1)
+			return s
+		},
+		"atomic": func() Strategy {
+			s, _ := NewAtomicEpsilonGreedy(2, 0.1)
+			return s
+		},
+	}
+
+	for name, build := range builders {
+		s := build()
+		for i := 0; i < 20; i++ {
+			arm := s.SelectArm()
+			s.Update(arm, float64(arm-1))
+		}
+
+		data, err := MarshalStrategy(s)
+		if err != nil {
+			t.Fatalf("could not marshal %s: %s", name, err.Error())
+		}
+
+		restored := build()
+		if err := UnmarshalStrategy(restored, data); err != nil {
+			t.Fatalf("could not unmarshal %s: %s", name, err.Error())
+		}
+
+		want, got := s.Snapshot(), restored.Snapshot()
+		for i := range want.counts {
+			if got.counts[i] != want.counts[i] || got.values[i] != want.values[i] {
+				t.Fatalf("expected %s to restore %v %v, got %v %v", name, want.counts, want.values, got.counts, got.values)
+			}
+		}
+	}
+
+	if err := UnmarshalStrategy(NewUCB1(3), []byte(`{"counts": [1, 2], "values": [0, 1]}`)); err == nil {
+		t.Fatalf("expected state of 2 arms not to restore 3 arms")
+	}
+
+	// json of wrappers is the state of the wrapped strategy
+	floored, _ := NewFloored(NewUCB1(2), 2, 0.1)
+	inner := floored.(*flooredStrategy).strategy
+	inner.Update(2, 1)
+	if data, err := json.Marshal(floored); err != nil || string(data) != `{"counts":[0,1],"values":[0,1]}` {
+		t.Fatalf("expected json of the wrapped strategy, got %s %v", data, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"counts": [1, 2, 3], "values": [0, 1, 0]}`), floored); err == nil {
+		t.Fatalf("expected state of 3 arms not to restore a wrapped strategy of 2 arms")
+	}
+
+	c := NewCounters(2)
+	if err := c.UnmarshalJSON([]byte(`{"counts": [1, 2, 3], "values": [0, 1, 0]}`)); err == nil || c.arms != 2 {
+		t.Fatalf("expected counters of 2 arms not to unmarshal 3 arms")
+	}
+
+	if err := c.UnmarshalJSON([]byte(`{"counts": [1, 2], "values": [0, 1]}`)); err != nil || c.revision != 1 {
+		t.Fatalf("expected unmarshaling to change the revision, got %d", c.revision)
+	}
+}
+
+func TestStrategyState(t *testing.T) {
+	strategies := map[string]string{
+		"gradient":          "[0.1]",
+		"ucbTuned":          "[]",
+		"bootstrapThompson": "[20]",
+		"windowedUcb1":      "[10]",
+	}
+
+	for name, parameters := range strategies {
+		config := []byte(`[{"experiment_name": "shape-20130822", "strategy": "` + name + `",
+			"parameters": ` + parameters + `, "preferred": 1, "batch-updates": {"size": 1}, "variations": [
+			{"ordinal": 1, "url": "http://localhost/circle"},
+			{"ordinal": 2, "url": "http://localhost/square"}]}]`)
+
+		es, err := NewExperiments(NewBytesOpener(config))
+		if err != nil {
+			t.Fatalf("could not read %s: %s", name, err.Error())
+		}
+
+		e, _ := es.Get("shape-20130822")
+		for i := 0; i < 50; i++ {
+			arm := e.Strategy.SelectArm()
+			e.Strategy.Update(arm, float64(i%3)/2)
+		}
+
+		store := mapStore{}
+		if err := SaveExperiments(store, es); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		restored, _ := NewExperiments(NewBytesOpener(config))
+		if _, err := RestoreExperiments(store, restored, RestoreFail); err != nil {
+			t.Fatalf("could not restore %s: %s", name, err.Error())
+		}
+
+		r, _ := restored.Get("shape-20130822")
+		want, _ := marshalState(e.Strategy)
+		got, _ := marshalState(r.Strategy)
+		if want == nil || string(got) != string(want) {
+			t.Fatalf("expected %s state %s, got %s", name, want, got)
+		}
+	}
+}
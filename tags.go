@@ -0,0 +1,78 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CheckTags refuses experiments which reuse a tag of the stored state in `s`
+// for a different url. Logged selections and rewards refer to variations by
+// tag, so silently repurposing a tag invalidates historical data. To migrate
+// a tag on purpose, set the variation's Replaces to the url it previously
+// served.
+func CheckTags(s Store, es *Experiments) error {
+	all, names := es.All(), []string{}
+	for name := range all {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var reused []string
+	for _, name := range names {
+		data, err := s.Get(name)
+		if err == ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not get %s: %s", name, err.Error())
+		}
+
+		var stored State
+		if err := codec(s).Unmarshal(data, &stored); err != nil {
+			return fmt.Errorf("could not unmarshal %s: %s", name, err.Error())
+		}
+
+		e := all[name]
+		for i, tag := range stored.Tags {
+			if i >= len(stored.URLs) {
+				break
+			}
+
+			v, err := e.GetTaggedVariation(tag)
+			if err != nil || v.URL == stored.URLs[i] || v.Replaces == stored.URLs[i] {
+				continue
+			}
+
+			reused = append(reused, fmt.Sprintf("%s: %s -> %s", tag, stored.URLs[i], v.URL))
+		}
+	}
+
+	if len(reused) > 0 {
+		return fmt.Errorf("tags reused for different urls: %s", strings.Join(reused, ", "))
+	}
+
+	return nil
+}
+
+// CheckChanges refuses changes which reuse a tag for a different url, see
+// CheckTags.
+func CheckChanges(changes []Change) error {
+	var reused []string
+	for _, change := range changes {
+		if change.Kind == ChangeTagReused {
+			reused = append(reused, change.Detail)
+		}
+	}
+
+	if len(reused) > 0 {
+		return fmt.Errorf("tags reused for different urls: %s", strings.Join(reused, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestCheckTags(t *testing.T) {
+	es, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	e := es.index["shape-20130822"]
+	s := mapStore{}
+	if err := SaveExperiments(s, es); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if err := CheckTags(s, es); err != nil {
+		t.Fatalf("expected unchanged tags to pass, got %s", err.Error())
+	}
+
+	old := e.Variations[1].URL
+	e.Variations[1].URL = "http://localhost:8080/widget?shape=triangle"
+	if err := CheckTags(s, es); err == nil {
+		t.Fatalf("expected reused tag to be refused")
+	}
+
+	e.Variations[1].Replaces = old
+	if err := CheckTags(s, es); err != nil {
+		t.Fatalf("expected migrated tag to pass, got %s", err.Error())
+	}
+}
+
+func TestCheckChanges(t *testing.T) {
+	running, err := NewExperiments(NewFileOpener("experiments.json"))
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	shape := *running.index["shape-20130822"]
+	shape.Variations = append(Variations{}, shape.Variations...)
+	shape.Variations[1].URL = "http://localhost:8080/widget?shape=triangle"
+	next := Experiments{index: map[string]*Experiment{"shape-20130822": &shape}}
+
+	changes := Diff(running, &next)
+	if err := CheckChanges(changes); err == nil {
+		t.Fatalf("expected reused tag to be refused, got %v", changes)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+)
+
+// Ways of choosing between equally good arms.
+const (
+	TiesRandom  = "random"
+	TiesOrdinal = "ordinal"
+)
+
+// TieBreaker is implemented by strategies with configurable tie breaking. All
+// strategies in this package break ties uniformly at random by default.
+// Softmax selects proportionally and has no ties.
+type TieBreaker interface {
+	BreakTies(mode string) error
+}
+
+// breakTies sets the tie breaking of `s`, which must be a TieBreaker.
+func breakTies(s Strategy, mode string) error {
+	t, ok := s.(TieBreaker)
+	if !ok {
+		return fmt.Errorf("%s does not support tie breaking", s)
+	}
+
+	return t.BreakTies(mode)
+}
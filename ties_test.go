@@ -0,0 +1,43 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestBreakTies(t *testing.T) {
+	for _, name := range []string{"epsilonGreedy", "ucb1", "thompson"} {
+		params := map[string][]float64{
+			"epsilonGreedy": {0},
+			"ucb1":          {},
+			"thompson":      {1},
+		}[name]
+
+		firsts := make(map[int]int)
+		for i := 0; i < 300; i++ {
+			s, err := New(3, name, params)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			firsts[s.SelectArm()]++
+		}
+
+		if firsts[1] > 150 || firsts[3] < 50 {
+			t.Fatalf("expected %s to break ties uniformly, got %v", name, firsts)
+		}
+
+		s, _ := New(3, name, params)
+		if err := breakTies(s, TiesOrdinal); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		// thompson samples, so there are no ties to break
+		if got := s.SelectArm(); got != 1 && name != "thompson" {
+			t.Fatalf("expected %s to select the lowest ordinal, got %d", name, got)
+		}
+	}
+
+	if err := breakTies(NewUCB1(2), "alphabetical"); err == nil {
+		t.Fatalf("expected error on unknown tie breaking")
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+)
+
+// Traces returns the rewards of each variation of experiment `e` as recorded
+// in `events`, indexed by ordinal - 1, for simulating with trace arms, see
+// sim.NewTraceArm. Selections which were never rewarded are rewards of 0, as
+// in Replay. Fails if a variation was never selected nor rewarded.
+func Traces(events []LogEvent, e *Experiment) ([][]float64, error) {
+	traces := make([][]float64, len(e.Variations))
+	selections := make([]int, len(e.Variations))
+	for _, event := range events {
+		v, ok := replayVariation(e, event.Tag)
+		if !ok {
+			continue
+		}
+
+		switch event.Kind {
+		case banditSelection:
+			selections[v.Ordinal-1] += event.weight()
+		case banditReward:
+			traces[v.Ordinal-1] = append(traces[v.Ordinal-1], event.Reward)
+		}
+	}
+
+	for i, trace := range traces {
+		for unrewarded := selections[i] - len(trace); unrewarded > 0; unrewarded-- {
+			traces[i] = append(traces[i], 0)
+		}
+
+		if len(traces[i]) == 0 {
+			return [][]float64{}, fmt.Errorf("no trace of %s", e.Variations[i].Tag)
+		}
+	}
+
+	return traces, nil
+}
@@ -0,0 +1,57 @@
+package bandit
+
+import (
+	"github.com/purzelrakete/bandit/sim"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTraces(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	circle, square := e.Variations[0], e.Variations[1]
+	lines := []string{
+		SelectionLine(*e, circle),
+		SelectionLine(*e, circle),
+		RewardLine(*e, circle, 12.5),
+		WeightedSelectionLine(*e, square, 3),
+		RewardLine(*e, square, 1),
+	}
+
+	events, err := ParseLog(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	traces, err := Traces(events, e)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if expected := [][]float64{{12.5, 0}, {1, 0, 0}}; !reflect.DeepEqual(traces, expected) {
+		t.Fatalf("expected traces %v, got %v", expected, traces)
+	}
+
+	arms, best, err := sim.NewTraceArms(traces, 1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if !reflect.DeepEqual(best, []int{1}) {
+		t.Fatalf("expected circle to be best, got %v", best)
+	}
+
+	for i := 0; i < 100; i++ {
+		if reward := arms[0](); reward != 12.5 && reward != 0 {
+			t.Fatalf("expected recorded rewards only, got %f", reward)
+		}
+	}
+
+	if _, err := Traces(events[:3], e); err == nil {
+		t.Fatalf("expected untraced square to fail")
+	}
+}
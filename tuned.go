@@ -0,0 +1,186 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+)
+
+// NewUCBTuned constructs a UCB-Tuned strategy, see Auer et al., 2002,
+// "Finite-time Analysis of the Multiarmed Bandit Problem". It narrows the UCB1
+// confidence bound of arms whose rewards vary little, so that low variance
+// arms are explored less.
+func NewUCBTuned(arms int) Strategy {
+	return &uCBTuned{
+		Counters: NewCounters(arms),
+		squares:  make([]float64, arms),
+	}
+}
+
+// uCBTuned keeps the running average of squared rewards next to the counter
+// values. The bound of an arm pulled n times in t pulls is
+//
+//	sqrt(log(t) / n · min(1/4, V)), V = variance + sqrt(2 · log(t) / n)
+//
+// where 1/4 is the largest variance of rewards in [0, 1].
+type uCBTuned struct {
+	Counters
+	squares []float64 // running average of squared rewards per arm
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (u *uCBTuned) SelectArm() int {
+	u.Lock()
+	defer u.Unlock()
+
+	var arm int
+	if u.ties == TiesOrdinal {
+		_, best := bmath.Max(u.bounds())
+		arm = best[0]
+	} else {
+		arm = bmath.ArgMax(u.bounds(), u.rand)
+	}
+
+	u.counts[arm]++
+	return arm + 1
+}
+
+// bounds returns the upper confidence bound of each arm. Arms which were
+// never pulled are best.
+func (u *uCBTuned) bounds() []float64 {
+	total := 0
+	for _, count := range u.counts {
+		total += count
+	}
+
+	logTotal := math.Log(float64(total))
+	ucbs := append([]float64{}, u.estimates()...)
+	for i, count := range u.counts {
+		if count == 0 {
+			ucbs[i] = math.Inf(1)
+			continue
+		}
+
+		n := float64(count)
+		variance := math.Max(u.squares[i]-u.values[i]*u.values[i], 0)
+		variance += math.Sqrt(2 * logTotal / n)
+		ucbs[i] += math.Sqrt(logTotal / n * math.Min(0.25, variance))
+	}
+
+	return ucbs
+}
+
+// Update the running averages of rewards and squared rewards of the 1 indexed
+// arm.
+func (u *uCBTuned) Update(arm int, reward float64) {
+	u.Lock()
+	defer u.Unlock()
+
+	u.update(arm, reward)
+}
+
+// UpdateBatch applies rewards to the 1 indexed arm in order.
+func (u *uCBTuned) UpdateBatch(arm int, rewards []float64) {
+	u.Lock()
+	defer u.Unlock()
+
+	for _, reward := range rewards {
+		u.update(arm, reward)
+	}
+}
+
+// update both running averages without locking. Squares are discounted like
+// values, see Discount.
+func (u *uCBTuned) update(arm int, reward float64) {
+	u.Counters.update(arm, reward)
+
+	count, square := u.counts[arm-1], reward*reward
+	if u.gamma > 0 && count > 1 {
+		u.squares[arm-1] = u.gamma*u.squares[arm-1] + (1-u.gamma)*square
+	} else {
+		u.squares[arm-1] = (u.squares[arm-1]*float64(count-1) + square) / float64(count)
+	}
+}
+
+// Init the strategy to a new counter state. Snapshots do not carry squared
+// rewards, so each arm assumes the largest variance of rewards in [0, 1]
+// with its mean, that of Bernoulli rewards, until it is rewarded again.
+func (u *uCBTuned) Init(snapshot *Counters) error {
+	if err := u.Counters.Init(snapshot); err != nil {
+		return err
+	}
+
+	u.Lock()
+	defer u.Unlock()
+
+	u.squares = append([]float64{}, u.values...)
+	return nil
+}
+
+// marshalState encodes the running averages of squared rewards.
+func (u *uCBTuned) marshalState() ([]byte, error) {
+	u.Lock()
+	defer u.Unlock()
+
+	return json.Marshal(u.squares)
+}
+
+// unmarshalState restores the squared rewards, replacing the Bernoulli
+// variances assumed by Init.
+func (u *uCBTuned) unmarshalState(data []byte) error {
+	var squares []float64
+	if err := json.Unmarshal(data, &squares); err != nil {
+		return err
+	}
+
+	if len(squares) != u.arms {
+		return fmt.Errorf("%d squared rewards for %d arms", len(squares), u.arms)
+	}
+
+	u.Lock()
+	defer u.Unlock()
+
+	u.squares = squares
+	return nil
+}
+
+// Reset the strategy to initial state.
+func (u *uCBTuned) Reset() {
+	u.ResetWithArchive()
+}
+
+// ResetWithArchive resets the counters and squared rewards at once.
+func (u *uCBTuned) ResetWithArchive() *Counters {
+	u.Lock()
+	defer u.Unlock()
+
+	u.squares = make([]float64, u.arms)
+	return u.reset()
+}
+
+// Distribution returns the probability of selecting each arm.
+func (u *uCBTuned) Distribution() []float64 {
+	u.Lock()
+	defer u.Unlock()
+
+	_, best := bmath.Max(u.bounds())
+	if u.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	distribution := make([]float64, u.arms)
+	for _, arm := range best {
+		distribution[arm] = 1 / float64(len(best))
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (u *uCBTuned) String() string {
+	return fmt.Sprintf("UCBTuned")
+}
@@ -0,0 +1,62 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	bmath "github.com/purzelrakete/bandit/math"
+	"github.com/purzelrakete/bandit/sim"
+	"testing"
+)
+
+func TestUCBTuned(t *testing.T) {
+	sims := 500
+	trials := 1000
+	bestArmIndex := 4 // Bernoulli(bestArm)
+	arms := []sim.Arm{
+		bmath.BernRand(0.1),
+		bmath.BernRand(0.3),
+		bmath.BernRand(0.2),
+		bmath.BernRand(0.8),
+	}
+
+	strategy, err := New(len(arms), "ucbTuned", []float64{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s, err := sim.MonteCarlo(sims, trials, arms, strategy)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	accuracies := sim.Accuracy([]int{bestArmIndex})(&s)
+	if got := accuracies[len(accuracies)-1]; got < 0.9 {
+		t.Fatalf("accuracy is only %f. %d sims, %d trials", got, sims, trials)
+	}
+}
+
+func TestUCBTunedNarrowsLowVariance(t *testing.T) {
+	u := NewUCBTuned(2).(*uCBTuned)
+
+	// both arms have mean 0.5, but only arm 2 varies
+	for i := 0; i < 1000; i++ {
+		u.counts[0]++
+		u.Update(1, 0.5)
+		u.counts[1]++
+		u.Update(2, float64(i%2))
+	}
+
+	bounds := u.bounds()
+	if !(bounds[0] < bounds[1]) {
+		t.Fatalf("expected constant arm to have the narrower bound, got %v", bounds)
+	}
+
+	if err := u.Init(&Counters{arms: 2, counts: []int{10, 10}, values: []float64{0.5, 0.5}}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if bounds := u.bounds(); bounds[0] != bounds[1] {
+		t.Fatalf("expected equal bounds after init, got %v", bounds)
+	}
+}
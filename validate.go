@@ -0,0 +1,69 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
+)
+
+// maxConfigSize bounds experiment configurations, which may come from semi
+// trusted tools.
+const maxConfigSize = 16 << 20
+
+// maxRetentionDays bounds retention-days, so that it fits a time.Duration.
+const maxRetentionDays = 100 * 365
+
+// readConfig reads at most maxConfigSize bytes from `r`.
+func readConfig(r io.Reader) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxConfigSize+1))
+	if err != nil {
+		return []byte{}, err
+	}
+
+	if len(data) > maxConfigSize {
+		return []byte{}, fmt.Errorf("larger than %d bytes", maxConfigSize)
+	}
+
+	return data, nil
+}
+
+// validateConfig checks an experiment's name and the ordinals of its
+// variations, which must be contiguous and start at 1.
+func validateConfig(name string, ordinals []int) error {
+	if name == "" {
+		return fmt.Errorf("experiment without experiment_name")
+	}
+
+	if len(ordinals) == 0 {
+		return fmt.Errorf("%s has no variations", name)
+	}
+
+	seen := make([]bool, len(ordinals))
+	for _, ordinal := range ordinals {
+		if ordinal < 1 || ordinal > len(ordinals) {
+			return fmt.Errorf("%s: ordinal %d not in [1,%d]", name, ordinal, len(ordinals))
+		}
+
+		if seen[ordinal-1] {
+			return fmt.Errorf("%s: duplicate ordinal %d", name, ordinal)
+		}
+
+		seen[ordinal-1] = true
+	}
+
+	return nil
+}
+
+// validateText checks that `s` is valid utf-8. encoding/json replaces invalid
+// utf-8, but decrypted values are arbitrary bytes.
+func validateText(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("%q is not valid utf-8", s)
+	}
+
+	return nil
+}
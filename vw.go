@@ -0,0 +1,186 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Vowpal Wabbit contextual bandit formats, named after their VW flags.
+const (
+	VWCB    = "cb"     // one line per example: <action>:<cost>:<probability> | ...
+	VWCBADF = "cb_adf" // one line per action, the chosen one labelled 0:<cost>:<probability>
+)
+
+// vwExample is a single labelled selection.
+type vwExample struct {
+	ordinal     int
+	cost        float64
+	probability float64
+}
+
+// WriteVW converts the selections and rewards of experiment `e` to Vowpal
+// Wabbit's contextual bandit `format`. Every selection is an example. Rewards
+// are matched to the oldest unrewarded selection of the same variation, and
+// the cost is the negative reward; unrewarded selections cost 0. Logs do not
+// record propensities, so the probability is the share of selections of the
+// variation up to the selection. Events of other experiments are ignored.
+func WriteVW(w io.Writer, events []LogEvent, e *Experiment, format string) error {
+	if format != VWCB && format != VWCBADF {
+		return fmt.Errorf("unknown vw format '%s'", format)
+	}
+
+	var examples []*vwExample
+	pending := make(map[int][]*vwExample)
+	selections, total := make(map[int]int), 0
+	for _, event := range events {
+		v, ok := replayVariation(e, event.Tag)
+		if !ok {
+			continue
+		}
+
+		switch event.Kind {
+		case banditSelection:
+			selections[v.Ordinal] += event.weight()
+			total += event.weight()
+
+			example := &vwExample{
+				ordinal:     v.Ordinal,
+				probability: float64(selections[v.Ordinal]) / float64(total),
+			}
+
+			examples = append(examples, example)
+			pending[v.Ordinal] = append(pending[v.Ordinal], example)
+		case banditReward:
+			// the selection may have been logged before the exported period.
+			if len(pending[v.Ordinal]) == 0 {
+				continue
+			}
+
+			pending[v.Ordinal][0].cost = 0 - event.Reward
+			pending[v.Ordinal] = pending[v.Ordinal][1:]
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, example := range examples {
+		label := fmt.Sprintf("%v:%v", example.cost, example.probability)
+		if format == VWCB {
+			fmt.Fprintf(bw, "%d:%s | experiment=%s\n", example.ordinal, label, e.Name)
+			continue
+		}
+
+		fmt.Fprintf(bw, "shared | experiment=%s\n", e.Name)
+		for _, v := range e.Variations {
+			if v.Ordinal == example.ordinal {
+				fmt.Fprintf(bw, "0:%s | variation=%s\n", label, v.Tag)
+			} else {
+				fmt.Fprintf(bw, "| variation=%s\n", v.Tag)
+			}
+		}
+
+		fmt.Fprintln(bw)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("could not write vw examples: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ReadVW converts Vowpal Wabbit contextual bandit examples in `format` to a
+// selection and a reward event each, tagged with variations of experiment
+// `e`. The reward is the negative cost. In VWCB, actions are ordinals; in
+// VWCBADF, the n-th action line of an example is ordinal n. Features are not
+// read. Events have no time.
+func ReadVW(r io.Reader, e *Experiment, format string) ([]LogEvent, error) {
+	if format != VWCB && format != VWCBADF {
+		return []LogEvent{}, fmt.Errorf("unknown vw format '%s'", format)
+	}
+
+	var events []LogEvent
+	add := func(line, ordinal int, cost float64) error {
+		v, err := e.GetVariation(ordinal)
+		if err != nil {
+			return fmt.Errorf("line %d: %s", line, err.Error())
+		}
+
+		events = append(events,
+			LogEvent{Kind: banditSelection, Tag: v.Tag},
+			LogEvent{Kind: banditReward, Tag: v.Tag, Reward: 0 - cost})
+
+		return nil
+	}
+
+	line, action, chosen, cost := 0, 0, 0, 0.0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		// end of a multiline example
+		if text == "" {
+			if format == VWCBADF && chosen > 0 {
+				if err := add(line, chosen, cost); err != nil {
+					return []LogEvent{}, err
+				}
+			}
+
+			action, chosen = 0, 0
+			continue
+		}
+
+		if format == VWCBADF && strings.HasPrefix(text, "shared") {
+			continue
+		}
+
+		action++
+		label := strings.TrimSpace(strings.SplitN(text, "|", 2)[0])
+		if label == "" {
+			continue
+		}
+
+		parts := strings.Split(label, ":")
+		if len(parts) != 3 {
+			return []LogEvent{}, fmt.Errorf("line %d: label not in form <action>:<cost>:<probability>", line)
+		}
+
+		a, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return []LogEvent{}, fmt.Errorf("line %d: bad action: %s", line, err.Error())
+		}
+
+		c, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return []LogEvent{}, fmt.Errorf("line %d: bad cost: %s", line, err.Error())
+		}
+
+		if format == VWCB {
+			if err := add(line, a, c); err != nil {
+				return []LogEvent{}, err
+			}
+
+			continue
+		}
+
+		chosen, cost = action, c
+	}
+
+	if err := scanner.Err(); err != nil {
+		return []LogEvent{}, fmt.Errorf("could not read vw examples: %s", err.Error())
+	}
+
+	if format == VWCBADF && chosen > 0 {
+		if err := add(line, chosen, cost); err != nil {
+			return []LogEvent{}, err
+		}
+	}
+
+	return events, nil
+}
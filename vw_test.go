@@ -0,0 +1,64 @@
+package bandit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVW(t *testing.T) {
+	e, err := NewExperiment(NewFileOpener("experiments.json"), "shape-20130822")
+	if err != nil {
+		t.Fatalf("while reading experiment fixture: %s", err.Error())
+	}
+
+	log := strings.Join([]string{
+		"1379257984 BanditSelection shape-20130822:1",
+		"1379257985 BanditSelection shape-20130822:2",
+		"1379257986 BanditReward shape-20130822:2:1379257985 1.000000",
+		"1379257987 BanditSelection plants-20121111:1",
+	}, "\n")
+
+	events, err := ParseLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("could not parse log: %s", err.Error())
+	}
+
+	var cb bytes.Buffer
+	if err := WriteVW(&cb, events, e, VWCB); err != nil {
+		t.Fatalf("could not write cb: %s", err.Error())
+	}
+
+	expected := "1:0:1 | experiment=shape-20130822\n2:-1:0.5 | experiment=shape-20130822\n"
+	if got := cb.String(); got != expected {
+		t.Fatalf("expected cb\n%s\ngot\n%s", expected, got)
+	}
+
+	var adf bytes.Buffer
+	if err := WriteVW(&adf, events, e, VWCBADF); err != nil {
+		t.Fatalf("could not write cb_adf: %s", err.Error())
+	}
+
+	if got := strings.Count(adf.String(), "shared |"); got != 2 {
+		t.Fatalf("expected 2 adf examples, got %d", got)
+	}
+
+	for format, examples := range map[string]string{VWCB: cb.String(), VWCBADF: adf.String()} {
+		imported, err := ReadVW(strings.NewReader(examples), e, format)
+		if err != nil {
+			t.Fatalf("could not read %s: %s", format, err.Error())
+		}
+
+		if got := len(imported); got != 4 {
+			t.Fatalf("expected 4 events from %s, got %d", format, got)
+		}
+
+		if imported[2].Tag != "shape-20130822:2" || imported[3].Reward != 1 {
+			t.Fatalf("unexpected events from %s: %v", format, imported)
+		}
+	}
+
+	if _, err := ReadVW(strings.NewReader("3:0:1 | a"), e, VWCB); err == nil {
+		t.Fatalf("expected error on unknown action")
+	}
+}
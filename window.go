@@ -0,0 +1,236 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"encoding/json"
+	"fmt"
+	bmath "github.com/purzelrakete/bandit/math"
+	"math"
+)
+
+// NewWindowedUCB1 constructs a UCB1 strategy for drifting rewards. Means and
+// confidence bounds only consider the last `window` rewards of each arm, so
+// stale observations are forgotten.
+func NewWindowedUCB1(arms, window int) (Strategy, error) {
+	if window < 1 {
+		return &windowedUCB1{}, fmt.Errorf("window not in [1, ∞)")
+	}
+
+	return &windowedUCB1{
+		Counters: NewCounters(arms),
+		window:   window,
+		rewards:  newWindows(arms, window),
+	}, nil
+}
+
+// windowedUCB1 keeps the mean of each arm's window in the counter values. Pull
+// counts are capped at the window when computing bounds.
+type windowedUCB1 struct {
+	Counters
+	window  int
+	rewards []rewardWindow
+}
+
+// rewardWindow is a ring buffer of the most recent rewards of an arm.
+type rewardWindow struct {
+	rewards []float64
+	next    int
+	sum     float64
+}
+
+// newWindows returns empty windows for `arms` arms.
+func newWindows(arms, window int) []rewardWindow {
+	windows := make([]rewardWindow, arms)
+	for i := range windows {
+		windows[i].rewards = make([]float64, 0, window)
+	}
+
+	return windows
+}
+
+// add replaces the oldest reward once the window is full, and returns the
+// mean of the window.
+func (w *rewardWindow) add(reward float64) float64 {
+	if len(w.rewards) < cap(w.rewards) {
+		w.rewards = append(w.rewards, reward)
+	} else {
+		w.sum -= w.rewards[w.next]
+		w.rewards[w.next] = reward
+		w.next = (w.next + 1) % len(w.rewards)
+	}
+
+	w.sum += reward
+	return w.sum / float64(len(w.rewards))
+}
+
+// SelectArm returns 1 indexed arm to be tried next.
+func (u *windowedUCB1) SelectArm() int {
+	u.Lock()
+	defer u.Unlock()
+
+	ucbs := u.bounds()
+	max, arm, ties := math.Inf(-1), 0, 0
+	for i, ucb := range ucbs {
+		if ucb > max {
+			max, arm, ties = ucb, i, 1
+		} else if ucb == max && u.ties != TiesOrdinal {
+			ties++
+			if u.rand.Intn(ties) == 0 {
+				arm = i
+			}
+		}
+	}
+
+	u.counts[arm]++
+	return arm + 1
+}
+
+// bounds returns the upper confidence bound of each arm. Arms which were
+// never pulled are best.
+func (u *windowedUCB1) bounds() []float64 {
+	total := 0
+	for _, count := range u.counts {
+		total += u.pulls(count)
+	}
+
+	logTotal := 2 * math.Log(float64(total))
+	ucbs := append([]float64{}, u.estimates()...)
+	for i := range ucbs {
+		if u.counts[i] == 0 {
+			ucbs[i] = math.Inf(1)
+		} else {
+			ucbs[i] += math.Sqrt(logTotal / float64(u.pulls(u.counts[i])))
+		}
+	}
+
+	return ucbs
+}
+
+// pulls caps `count` at the window.
+func (u *windowedUCB1) pulls(count int) int {
+	if count > u.window {
+		return u.window
+	}
+
+	return count
+}
+
+// Update adds a reward to the window of the 1 indexed arm.
+func (u *windowedUCB1) Update(arm int, reward float64) {
+	u.Lock()
+	defer u.Unlock()
+
+	u.update(arm, reward)
+}
+
+// UpdateBatch adds rewards to the window of the 1 indexed arm in order.
+func (u *windowedUCB1) UpdateBatch(arm int, rewards []float64) {
+	u.Lock()
+	defer u.Unlock()
+
+	for _, reward := range rewards {
+		u.update(arm, reward)
+	}
+}
+
+// update adds a reward without locking.
+func (u *windowedUCB1) update(arm int, reward float64) {
+	if u.counts[arm-1] == 0 {
+		u.counts[arm-1] = 1
+	}
+
+	u.values[arm-1] = u.rewards[arm-1].add(reward)
+	u.revision++
+}
+
+// Init the strategy to a new counter state. Snapshots do not carry windows,
+// so snapshot values are used until arms are rewarded again.
+func (u *windowedUCB1) Init(snapshot *Counters) error {
+	if err := u.Counters.Init(snapshot); err != nil {
+		return err
+	}
+
+	u.Lock()
+	defer u.Unlock()
+
+	u.rewards = newWindows(u.arms, u.window)
+	return nil
+}
+
+// marshalState encodes the rewards in each window, oldest first.
+func (u *windowedUCB1) marshalState() ([]byte, error) {
+	u.Lock()
+	defer u.Unlock()
+
+	windows := make([][]float64, u.arms)
+	for i, w := range u.rewards {
+		windows[i] = append(append([]float64{}, w.rewards[w.next:]...), w.rewards[:w.next]...)
+	}
+
+	return json.Marshal(windows)
+}
+
+// unmarshalState restores the windows, so that old rewards drop out of the
+// means again.
+func (u *windowedUCB1) unmarshalState(data []byte) error {
+	var windows [][]float64
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return err
+	}
+
+	if len(windows) != u.arms {
+		return fmt.Errorf("%d windows for %d arms", len(windows), u.arms)
+	}
+
+	u.Lock()
+	defer u.Unlock()
+
+	rewards := newWindows(u.arms, u.window)
+	for i, window := range windows {
+		if len(window) > u.window {
+			window = window[len(window)-u.window:]
+		}
+
+		for _, reward := range window {
+			rewards[i].add(reward)
+		}
+	}
+
+	u.rewards = rewards
+	return nil
+}
+
+// Reset the strategy to initial state.
+func (u *windowedUCB1) Reset() {
+	u.ResetWithArchive()
+}
+
+// Discount fails, since the window already forgets old rewards.
+func (u *windowedUCB1) Discount(γ float64) error {
+	return fmt.Errorf("%s forgets by window and cannot be discounted", u)
+}
+
+// Distribution returns the probability of selecting each arm.
+func (u *windowedUCB1) Distribution() []float64 {
+	u.Lock()
+	defer u.Unlock()
+
+	_, best := bmath.Max(u.bounds())
+	if u.ties == TiesOrdinal {
+		best = best[:1]
+	}
+
+	distribution := make([]float64, u.arms)
+	for _, arm := range best {
+		distribution[arm] = 1 / float64(len(best))
+	}
+
+	return distribution
+}
+
+// String returns information on this Strategy
+func (u *windowedUCB1) String() string {
+	return fmt.Sprintf("WindowedUCB1(window=%d)", u.window)
+}
@@ -0,0 +1,55 @@
+package bandit
+
+import (
+	"testing"
+)
+
+func TestWindowedUCB1(t *testing.T) {
+	s, err := NewWindowedUCB1(2, 3)
+	if err != nil {
+		t.Fatalf("while creating strategy: %s", err.Error())
+	}
+
+	// arm 1 used to be best, then drifts below arm 2
+	for _, reward := range []float64{1, 1, 1, 0, 0, 0} {
+		s.SelectArm()
+		s.Update(1, reward)
+	}
+
+	s.SelectArm()
+	s.Update(2, 0.5)
+
+	c := s.Snapshot()
+	if got := c.values[0]; got != 0 {
+		t.Fatalf("expected window to forget old rewards, got mean %f", got)
+	}
+
+	if got := s.SelectArm(); got != 2 {
+		t.Fatalf("expected drifted arm 2 to be selected, got %d", got)
+	}
+
+	s.Reset()
+	if got := s.Snapshot().values[0]; got != 0 {
+		t.Fatalf("expected reset to clear values, got %f", got)
+	}
+
+	s.Update(1, 1)
+	if got := s.Snapshot().values[0]; got != 1 {
+		t.Fatalf("expected reset to clear windows, got mean %f", got)
+	}
+}
+
+func TestRewardWindow(t *testing.T) {
+	w := newWindows(1, 2)[0]
+	for i, expected := range []float64{1, 1.5, 2.5, 3.5} {
+		if got := w.add(float64(i + 1)); got != expected {
+			t.Fatalf("expected mean %f after %d rewards, got %f", expected, i+1, got)
+		}
+	}
+}
+
+func TestWindowedUCB1Invalid(t *testing.T) {
+	if _, err := New(2, "windowedUcb1", []float64{0}); err == nil {
+		t.Fatalf("expected empty window to fail")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2013 SoundCloud, Rany Keddo. All rights reserved.  Use of this
+// source code is governed by a license that can be found in the LICENSE file.
+
+package bandit
+
+import (
+	"hash"
+	"math/rand"
+	"sync"
+)
+
+// wrapper delegates everything to the wrapped strategy. Strategies wrapping
+// another one embed it and only implement what they change, so that a
+// capability added to the strategies of this package reaches them through
+// all wrappers at once. Its lock belongs to the embedding strategy.
+type wrapper struct {
+	sync.Mutex
+	strategy Strategy
+	named    nameCache // String of the wrapper, see Fingerprint
+}
+
+// unwrapper is implemented by strategies wrapping another one.
+type unwrapper interface {
+	unwrap() Strategy
+}
+
+// innermost returns the strategy at the bottom of all wrappers of `s`.
+func innermost(s Strategy) Strategy {
+	for {
+		u, ok := s.(unwrapper)
+		if !ok {
+			return s
+		}
+
+		s = u.unwrap()
+	}
+}
+
+// unwrap returns the wrapped strategy.
+func (w *wrapper) unwrap() Strategy {
+	return w.strategy
+}
+
+// SelectArm delegates to the wrapped strategy.
+func (w *wrapper) SelectArm() int {
+	return w.strategy.SelectArm()
+}
+
+// SelectAffordableArm delegates to the wrapped strategy, which may refuse.
+func (w *wrapper) SelectAffordableArm() (int, bool) {
+	return selectAffordableArm(w.strategy)
+}
+
+// SelectArms delegates to the wrapped strategy.
+func (w *wrapper) SelectArms(arms []int) {
+	SelectArms(w.strategy, arms)
+}
+
+// SelectSlate delegates to the wrapped strategy. It is empty if the wrapped
+// strategy cannot select slates, which SelectSlate checks beforehand.
+func (w *wrapper) SelectSlate(k int) []int {
+	slate, _ := SelectSlate(w.strategy, k)
+	return slate
+}
+
+// Update delegates to the wrapped strategy.
+func (w *wrapper) Update(arm int, reward float64) {
+	w.strategy.Update(arm, reward)
+}
+
+// UpdateBatch delegates to the wrapped strategy.
+func (w *wrapper) UpdateBatch(arm int, rewards []float64) {
+	UpdateBatch(w.strategy, arm, rewards)
+}
+
+// Init delegates to the wrapped strategy. It waits for a running reset, so
+// that the replaced state is never archived halfway.
+func (w *wrapper) Init(c *Counters) error {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.strategy.Init(c)
+}
+
+// Snapshot delegates to the wrapped strategy.
+func (w *wrapper) Snapshot() *Counters {
+	return w.strategy.Snapshot()
+}
+
+// Reset delegates to the wrapped strategy. Wrappers implementing
+// ResetWithArchive implement Reset as well, since this one calls the
+// ResetWithArchive of the wrapper.
+func (w *wrapper) Reset() {
+	w.ResetWithArchive()
+}
+
+// ResetWithArchive delegates to the wrapped strategy.
+func (w *wrapper) ResetWithArchive() *Counters {
+	w.Lock()
+	defer w.Unlock()
+
+	return resetWithArchive(w.strategy)
+}
+
+// Distribution delegates to the wrapped strategy.
+func (w *wrapper) Distribution() []float64 {
+	return distribution(w.strategy)
+}
+
+// BreakTies delegates to the wrapped strategy.
+func (w *wrapper) BreakTies(mode string) error {
+	return breakTies(w.strategy, mode)
+}
+
+// Discount delegates to the wrapped strategy.
+func (w *wrapper) Discount(γ float64) error {
+	return discount(w.strategy, γ)
+}
+
+// UseSource delegates to the wrapped strategy.
+func (w *wrapper) UseSource(src rand.Source) error {
+	return useSource(w.strategy, src)
+}
+
+// Prioritize delegates to the wrapped strategy.
+func (w *wrapper) Prioritize(priorities []float64) error {
+	return prioritize(w.strategy, priorities)
+}
+
+// Charge delegates to the wrapped strategy.
+func (w *wrapper) Charge(costs []float64) error {
+	return charge(w.strategy, costs)
+}
+
+// release delegates to the wrapped strategy.
+func (w *wrapper) release(arms []int) {
+	release(w.strategy, arms)
+}
+
+// pull delegates to the wrapped strategy.
+func (w *wrapper) pull(arm int) {
+	pull(w.strategy, arm)
+}
+
+// pullAffordable delegates to the wrapped strategy.
+func (w *wrapper) pullAffordable(arm int) bool {
+	return pullAffordable(w.strategy, arm)
+}
+
+// Flush delegates to the wrapped strategy.
+func (w *wrapper) Flush() {
+	flush(w.strategy)
+}
+
+// Close delegates to the wrapped strategy.
+func (w *wrapper) Close() error {
+	return closeStrategy(w.strategy)
+}
+
+// policy delegates to the wrapped strategy.
+func (w *wrapper) policy(h hash.Hash32) uint64 {
+	return policy(h, w.strategy)
+}
+
+// names returns the name cache of the wrapper.
+func (w *wrapper) names() *nameCache {
+	return &w.named
+}
+
+// MarshalJSON encodes the state of the wrapped strategy, see MarshalStrategy.
+func (w *wrapper) MarshalJSON() ([]byte, error) {
+	return MarshalStrategy(w.strategy)
+}
+
+// UnmarshalJSON restores the state of the wrapped strategy, see
+// UnmarshalStrategy.
+func (w *wrapper) UnmarshalJSON(data []byte) error {
+	return UnmarshalStrategy(w.strategy, data)
+}
+
+// marshalState delegates to the wrapped strategy.
+func (w *wrapper) marshalState() ([]byte, error) {
+	return marshalState(w.strategy)
+}
+
+// unmarshalState delegates to the wrapped strategy.
+func (w *wrapper) unmarshalState(data []byte) error {
+	return unmarshalState(w.strategy, data)
+}
+
+// leading delegates to the wrapped strategy.
+func (w *wrapper) leading(arm int) bool {
+	return !exploring(w.strategy, arm+1)
+}
@@ -0,0 +1,92 @@
+package bandit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWrappersDelegate(t *testing.T) {
+	wrappers := map[string]func(s Strategy) (Strategy, error){
+		"anomaly": func(s Strategy) (Strategy, error) {
+			return NewAnomalyDetector(s, "shape", 4, AnomalyConfig{Lambda: 0.1, Limit: 3}, nil)
+		},
+		"batched": func(s Strategy) (Strategy, error) {
+			return NewBatched(s, 1, 0)
+		},
+		"floored": func(s Strategy) (Strategy, error) {
+			return NewFloored(s, 4, 0.05)
+		},
+		"prefetcher": func(s Strategy) (Strategy, error) {
+			return NewPrefetcher(s), nil
+		},
+		"readthrough": func(s Strategy) (Strategy, error) {
+			return NewReadThrough(s, mapStore{}, "shape", nil, time.Hour)
+		},
+	}
+
+	for name, wrap := range wrappers {
+		inner := NewUCB1(4)
+		s, err := wrap(inner)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err.Error())
+		}
+
+		if _, ok := s.(BatchSelector); !ok {
+			t.Fatalf("%s: expected a batch selector", name)
+		}
+
+		arms := make([]int, 10)
+		SelectArms(s, arms)
+		for _, arm := range arms {
+			if arm < 1 || arm > 4 {
+				t.Fatalf("%s: arm %d not in [1, 4]", name, arm)
+			}
+		}
+
+		slate, err := SelectSlate(s, 3)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err.Error())
+		}
+
+		if len(slate) != 3 {
+			t.Fatalf("%s: expected a slate of 3, got %v", name, slate)
+		}
+
+		// every selection is counted by the wrapped strategy
+		pulls := 0
+		for _, count := range inner.Snapshot().counts {
+			pulls += count
+		}
+
+		if pulls != 13 {
+			t.Fatalf("%s: expected 13 pulls, got %d", name, pulls)
+		}
+
+		data, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err.Error())
+		}
+
+		expected, err := MarshalStrategy(inner)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if string(data) != string(expected) {
+			t.Fatalf("%s: expected %s, got %s", name, expected, data)
+		}
+
+		closeStrategy(s)
+	}
+
+	// wrappers select slates only if the wrapped strategy does
+	greedy, err := NewEpsilonGreedy(4, 0.1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if _, err := SelectSlate(NewPrefetcher(greedy), 2); err == nil {
+		t.Fatalf("expected slate of epsilon greedy to fail")
+	}
+}